@@ -0,0 +1,83 @@
+package commands
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"text/tabwriter"
+
+	"github.com/edwinjordan/wmsTest_Golang/domain"
+	"github.com/edwinjordan/wmsTest_Golang/internal/logging"
+	"github.com/edwinjordan/wmsTest_Golang/repository"
+	"github.com/edwinjordan/wmsTest_Golang/service"
+)
+
+// runReconcile prints every stored quantity that does not match the movement ledger. With --fix it
+// also writes the correcting adjustment movements, recorded under the user given by --user.
+func runReconcile(db *sql.DB, args []string) error {
+	ctx := context.Background()
+
+	flags := flag.NewFlagSet("reconcile", flag.ContinueOnError)
+	fix := flags.Bool("fix", false, "write adjustment movements so the ledger matches the stored quantities")
+	userID := flags.Int("user", 0, "ID of the user the adjustment movements are recorded under (required with --fix)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *fix && *userID <= 0 {
+		return errors.New("--user is required with --fix")
+	}
+
+	repos := repository.NewRepositories(db)
+	stockService := service.NewStockService(repos.StockMovement, repos.Product, repos.ProductUnit, repos.Location, repos.Warehouse, repos.StockLevel, repos.StockSnapshot, repos.SerialNumber, repos.Reservation, repos.CostLayer, repository.NewUnitOfWork(db))
+
+	report, err := stockService.ReconcileStock(ctx, *fix, *userID)
+	if err != nil {
+		return err
+	}
+
+	printReconciliation(report)
+
+	if report.Fixed {
+		logging.LogInfo(ctx, "Successfully reconciled stock", slog.Int("adjustments", len(report.Adjustments)), slog.Int("products", len(report.Products)))
+	}
+	return nil
+}
+
+func printReconciliation(report *domain.StockReconciliation) {
+	if len(report.Locations) == 0 && len(report.Products) == 0 {
+		fmt.Println("Stored quantities match the movement ledger")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	if len(report.Locations) > 0 {
+		fmt.Fprintf(w, "Stock level discrepancies: %d\n", len(report.Locations))
+		fmt.Fprintln(w, "SKU\tLOCATION\tLOT\tSTORED\tLEDGER\tDIFFERENCE")
+		for _, d := range report.Locations {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%d\t%+d\n", d.SKU, *d.LocationCode, d.LotNumber, d.StoredQuantity, d.LedgerQuantity, d.Difference())
+		}
+		fmt.Fprintln(w)
+	}
+
+	if len(report.Products) > 0 {
+		fmt.Fprintf(w, "Product quantity discrepancies: %d\n", len(report.Products))
+		fmt.Fprintln(w, "SKU\tSTORED\tLEDGER\tDIFFERENCE")
+		for _, d := range report.Products {
+			fmt.Fprintf(w, "%s\t%d\t%d\t%+d\n", d.SKU, d.StoredQuantity, d.LedgerQuantity, d.Difference())
+		}
+		fmt.Fprintln(w)
+	}
+
+	w.Flush()
+
+	if report.Fixed {
+		fmt.Printf("Wrote %d adjustment movement(s) and reset product quantities to the ledger\n", len(report.Adjustments))
+	} else {
+		fmt.Println("Run with --fix --user=<id> to write correcting adjustment movements")
+	}
+}
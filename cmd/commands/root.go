@@ -33,6 +33,14 @@ func Execute(command string, args []string) error {
 		if err := runSeeder(db, target); err != nil {
 			return fmt.Errorf("seeding failed: %w", err)
 		}
+	case "snapshot":
+		if err := runSnapshot(db, args); err != nil {
+			return fmt.Errorf("snapshot failed: %w", err)
+		}
+	case "reconcile":
+		if err := runReconcile(db, args); err != nil {
+			return fmt.Errorf("reconcile failed: %w", err)
+		}
 	default:
 		return errors.New("unknown command: " + command)
 	}
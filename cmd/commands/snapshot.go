@@ -0,0 +1,45 @@
+package commands
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/edwinjordan/wmsTest_Golang/domain"
+	"github.com/edwinjordan/wmsTest_Golang/internal/logging"
+	"github.com/edwinjordan/wmsTest_Golang/repository"
+	"github.com/edwinjordan/wmsTest_Golang/service"
+)
+
+// runSnapshot stores a stock snapshot, by default at the start of the current day (UTC), so it can
+// be scheduled daily. An existing snapshot at the same time is left as it is.
+func runSnapshot(db *sql.DB, args []string) error {
+	ctx := context.Background()
+
+	at := time.Now().UTC().Truncate(24 * time.Hour)
+	if len(args) > 0 {
+		parsed, err := time.Parse(time.RFC3339, args[0])
+		if err != nil {
+			return fmt.Errorf("snapshot time must be an RFC 3339 timestamp: %w", err)
+		}
+		at = parsed
+	}
+
+	repos := repository.NewRepositories(db)
+	stockService := service.NewStockService(repos.StockMovement, repos.Product, repos.ProductUnit, repos.Location, repos.Warehouse, repos.StockLevel, repos.StockSnapshot, repos.SerialNumber, repos.Reservation, repos.CostLayer, repository.NewUnitOfWork(db))
+
+	snapshot, err := stockService.TakeStockSnapshot(ctx, at)
+	if errors.Is(err, domain.ErrDuplicateEntry) {
+		logging.LogInfo(ctx, "Stock snapshot already exists", slog.Time("taken_at", at))
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	logging.LogInfo(ctx, "Successfully took stock snapshot", slog.Int("id", snapshot.ID), slog.Time("taken_at", snapshot.TakenAt))
+	return nil
+}
@@ -11,6 +11,9 @@ var (
 	ErrDuplicateEntry     = errors.New("duplicate entry")
 	ErrInsufficientStock  = errors.New("insufficient stock")
 	ErrExceedsCapacity    = errors.New("exceeds location capacity")
+	ErrTemperatureRange   = errors.New("location temperature is outside the product's storage range")
+	ErrInvalidStatus      = errors.New("invalid status for this operation")
+	ErrForbidden          = errors.New("permission denied")
 	ErrInternalServer     = errors.New("internal server error")
 )
 
@@ -0,0 +1,85 @@
+package domain
+
+import "time"
+
+// CostMethod represents how issued stock of a product is valued
+type CostMethod string
+
+const (
+	CostFIFO    CostMethod = "FIFO"    // Oldest cost layer is issued first
+	CostAverage CostMethod = "AVERAGE" // Moving average of the stock at the location
+)
+
+// IsValid reports whether the cost method is one of the known methods
+func (m CostMethod) IsValid() bool {
+	return m == CostFIFO || m == CostAverage
+}
+
+// CostLayer represents a quantity of a product received at a location at one unit cost
+type CostLayer struct {
+	ID                int       `json:"id"`
+	ProductID         int       `json:"product_id"`
+	LocationID        int       `json:"location_id"`
+	MovementID        *int      `json:"movement_id,omitempty"` // Empty for opening balances
+	UnitCost          float64   `json:"unit_cost"`
+	Quantity          int       `json:"quantity"`
+	RemainingQuantity int       `json:"remaining_quantity"`
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+// StockMovementCost represents the quantity a movement added to or took from one cost layer
+type StockMovementCost struct {
+	CostLayerID int     `json:"cost_layer_id"`
+	Quantity    int     `json:"quantity"`
+	UnitCost    float64 `json:"unit_cost"`
+}
+
+// ValuationGroupBy represents the dimension an inventory valuation is broken down by
+type ValuationGroupBy string
+
+const (
+	ValuationByProduct  ValuationGroupBy = "PRODUCT"
+	ValuationByCategory ValuationGroupBy = "CATEGORY"
+	ValuationByLocation ValuationGroupBy = "LOCATION"
+)
+
+// IsValid reports whether the grouping is one of the known groupings
+func (g ValuationGroupBy) IsValid() bool {
+	switch g {
+	case ValuationByProduct, ValuationByCategory, ValuationByLocation:
+		return true
+	}
+	return false
+}
+
+// ValuationFilter represents filters for the inventory valuation report
+type ValuationFilter struct {
+	GroupBy    ValuationGroupBy `json:"group_by"`
+	ProductID  *int             `json:"product_id,omitempty"`
+	LocationID *int             `json:"location_id,omitempty"`
+	Category   *string          `json:"category,omitempty"`
+	DateFrom   *time.Time       `json:"date_from,omitempty"` // Period for the cost of goods issued
+	DateTo     *time.Time       `json:"date_to,omitempty"`
+}
+
+// ValuationLine represents the stock value of one product, category or location
+type ValuationLine struct {
+	ID                *int    `json:"id,omitempty"` // Product or location ID, empty for categories
+	Key               string  `json:"key"`          // SKU, category or warehouse and location code (MAIN/A-01-01)
+	Name              string  `json:"name"`
+	Quantity          int     `json:"quantity"`
+	Value             float64 `json:"value"`
+	AverageUnitCost   float64 `json:"average_unit_cost"`
+	CostOfGoodsIssued float64 `json:"cost_of_goods_issued"` // Cost of OUT movements in the period
+}
+
+// ValuationReport represents the value of the stock on hand
+type ValuationReport struct {
+	GroupBy                ValuationGroupBy `json:"group_by"`
+	DateFrom               *time.Time       `json:"date_from,omitempty"`
+	DateTo                 *time.Time       `json:"date_to,omitempty"`
+	TotalQuantity          int              `json:"total_quantity"`
+	TotalValue             float64          `json:"total_value"`
+	TotalCostOfGoodsIssued float64          `json:"total_cost_of_goods_issued"`
+	Lines                  []*ValuationLine `json:"lines"`
+}
@@ -0,0 +1,65 @@
+package domain
+
+import "time"
+
+// CycleCountStatus represents the state of a count session
+type CycleCountStatus string
+
+const (
+	CycleCountOpen      CycleCountStatus = "OPEN"
+	CycleCountPosted    CycleCountStatus = "POSTED"
+	CycleCountCancelled CycleCountStatus = "CANCELLED"
+)
+
+// CycleCount represents a physical count of a set of locations
+type CycleCount struct {
+	ID          int              `json:"id"`
+	Status      CycleCountStatus `json:"status"`
+	Notes       string           `json:"notes"`
+	CreatedBy   int              `json:"created_by"`
+	PostedBy    *int             `json:"posted_by,omitempty"`
+	LocationIDs []int            `json:"location_ids"`
+	CreatedAt   time.Time        `json:"created_at"`
+	UpdatedAt   time.Time        `json:"updated_at"`
+	PostedAt    *time.Time       `json:"posted_at,omitempty"`
+
+	// Populated relations
+	Lines []*CycleCountLine `json:"lines,omitempty"`
+}
+
+// CycleCountLine represents the expected and counted quantity of a product at a location
+type CycleCountLine struct {
+	ID                   int        `json:"id"`
+	CycleCountID         int        `json:"cycle_count_id"`
+	ProductID            int        `json:"product_id"`
+	LocationID           int        `json:"location_id"`
+	ExpectedQuantity     int        `json:"expected_quantity"`           // On-hand when the count was opened
+	CountedQuantity      *int       `json:"counted_quantity"`            // Nil until the line is counted
+	Variance             *int       `json:"variance"`                    // Counted minus expected
+	AdjustedQuantity     *int       `json:"adjusted_quantity,omitempty"` // Change posted against on-hand at posting time
+	AdjustmentMovementID *int       `json:"adjustment_movement_id,omitempty"`
+	CountedBy            *int       `json:"counted_by,omitempty"`
+	CountedAt            *time.Time `json:"counted_at,omitempty"`
+
+	// Populated relations
+	Product  *Product  `json:"product,omitempty"`
+	Location *Location `json:"location,omitempty"`
+}
+
+// CreateCycleCountRequest represents the request to open a count for a set of locations
+type CreateCycleCountRequest struct {
+	LocationIDs []int  `json:"location_ids" validate:"required,min=1"`
+	Notes       string `json:"notes"`
+}
+
+// CycleCountEntry represents a single counted quantity
+type CycleCountEntry struct {
+	ProductID       int `json:"product_id" validate:"required"`
+	LocationID      int `json:"location_id" validate:"required"`
+	CountedQuantity int `json:"counted_quantity" validate:"min=0"`
+}
+
+// SubmitCycleCountRequest represents the counted quantities submitted for a count
+type SubmitCycleCountRequest struct {
+	Lines []CycleCountEntry `json:"lines" validate:"required,min=1"`
+}
@@ -0,0 +1,25 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// Idempotency errors
+var (
+	ErrIdempotencyKeyReused  = errors.New("idempotency key has already been used for a different request")
+	ErrIdempotencyInProgress = errors.New("a request with this idempotency key is still being processed")
+	ErrIdempotencyClaimLost  = errors.New("idempotency key has been claimed by another request")
+)
+
+// IdempotencyRecord represents the stored outcome of a request sent with an Idempotency-Key header
+type IdempotencyRecord struct {
+	UserID       int        `json:"user_id"`
+	Key          string     `json:"key"`
+	RequestHash  string     `json:"request_hash"`
+	ClaimToken   string     `json:"-"`                     // Identifies the request holding the key
+	StatusCode   *int       `json:"status_code,omitempty"` // Nil while the original request is in flight
+	ResponseBody []byte     `json:"-"`
+	CreatedAt    time.Time  `json:"created_at"`
+	CompletedAt  *time.Time `json:"completed_at,omitempty"`
+}
@@ -0,0 +1,72 @@
+package domain
+
+import "time"
+
+// InventoryStatus tells whether stock on hand may be used. Only AVAILABLE stock can be reserved,
+// allocated or shipped; the other statuses hold stock in place without moving it.
+type InventoryStatus string
+
+const (
+	InventoryAvailable  InventoryStatus = "AVAILABLE"
+	InventoryQuarantine InventoryStatus = "QUARANTINE" // Waiting for QA, e.g. returned goods
+	InventoryDamaged    InventoryStatus = "DAMAGED"
+	InventoryOnHold     InventoryStatus = "ON_HOLD" // e.g. a recall
+)
+
+// IsValid reports whether the status is one of the known statuses
+func (s InventoryStatus) IsValid() bool {
+	switch s {
+	case InventoryAvailable, InventoryQuarantine, InventoryDamaged, InventoryOnHold:
+		return true
+	}
+	return false
+}
+
+// InventoryStatusChange records stock of a product lot at a location moved from one status to another
+type InventoryStatusChange struct {
+	ID         int             `json:"id"`
+	ProductID  int             `json:"product_id"`
+	LocationID int             `json:"location_id"`
+	LotNumber  string          `json:"lot_number"`
+	FromStatus InventoryStatus `json:"from_status"`
+	ToStatus   InventoryStatus `json:"to_status"`
+	Quantity   int             `json:"quantity"`
+	Reference  string          `json:"reference"`
+	Notes      string          `json:"notes"`
+	UserID     int             `json:"user_id"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+// ChangeInventoryStatusRequest represents the request to change the status of the stock of a
+// product, a location or a lot. At least one of ProductID and LocationID is required, and a lot
+// number needs a product.
+type ChangeInventoryStatusRequest struct {
+	ProductID  *int            `json:"product_id"`
+	LocationID *int            `json:"location_id"`
+	LotNumber  *string         `json:"lot_number" validate:"omitempty,max=50"`
+	FromStatus InventoryStatus `json:"from_status"` // Defaults to AVAILABLE
+	ToStatus   InventoryStatus `json:"to_status" validate:"required"`
+	Quantity   *int            `json:"quantity"` // Defaults to all matching stock; taken in FEFO order
+
+	// SerialNumbers names the units of a serialized product to change; required to change only
+	// part of the stock of a serialized product
+	SerialNumbers []string `json:"serial_numbers"`
+	Reference     string   `json:"reference" validate:"max=100"`
+	Notes         string   `json:"notes"`
+}
+
+// InventoryStatusChangeResult represents the balances changed by a status change request
+type InventoryStatusChangeResult struct {
+	Quantity int                      `json:"quantity"` // Total changed, in base units
+	Changes  []*InventoryStatusChange `json:"changes"`  // One per product, location and lot
+}
+
+// InventoryStatusChangeFilter represents filters for listing status changes
+type InventoryStatusChangeFilter struct {
+	ProductID  *int             `json:"product_id,omitempty"`
+	LocationID *int             `json:"location_id,omitempty"`
+	LotNumber  *string          `json:"lot_number,omitempty"`
+	Status     *InventoryStatus `json:"status,omitempty"` // Changes from or to this status
+	Limit      int              `json:"limit"`
+	Offset     int              `json:"offset"`
+}
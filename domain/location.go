@@ -1,11 +1,16 @@
 package domain
 
-import "time"
+import (
+	"fmt"
+	"math"
+	"time"
+)
 
-// Location represents a storage location in the warehouse
+// Location represents a storage location in a warehouse
 type Location struct {
 	ID          int       `json:"id"`
-	Code        string    `json:"code"` // e.g., "A-01-01" (Zone-Rack-Shelf)
+	WarehouseID int       `json:"warehouse_id"`
+	Code        string    `json:"code"` // e.g., "A-01-01" (Zone-Rack-Shelf), unique per warehouse
 	Name        string    `json:"name"`
 	Zone        string    `json:"zone"`        // e.g., "A", "B", "C"
 	Aisle       string    `json:"aisle"`       // e.g., "01", "02"
@@ -13,6 +18,8 @@ type Location struct {
 	Shelf       string    `json:"shelf"`       // e.g., "01", "02", "03"
 	Capacity    int       `json:"capacity"`    // Maximum quantity that can be stored
 	Temperature *float64  `json:"temperature"` // Optional temperature requirement
+	MaxWeight   *float64  `json:"max_weight"`  // Maximum weight in kg, nil when unlimited
+	MaxVolume   *float64  `json:"max_volume"`  // Maximum volume in m³, nil when unlimited
 	IsActive    bool      `json:"is_active"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
@@ -20,6 +27,7 @@ type Location struct {
 
 // CreateLocationRequest represents the request to create a new location
 type CreateLocationRequest struct {
+	WarehouseID *int     `json:"warehouse_id,omitempty"` // Defaults to the MAIN warehouse
 	Code        string   `json:"code" validate:"required,max=20"`
 	Name        string   `json:"name" validate:"required,max=255"`
 	Zone        string   `json:"zone" validate:"required,max=10"`
@@ -28,6 +36,8 @@ type CreateLocationRequest struct {
 	Shelf       string   `json:"shelf" validate:"required,max=10"`
 	Capacity    int      `json:"capacity" validate:"min=1"`
 	Temperature *float64 `json:"temperature,omitempty"`
+	MaxWeight   *float64 `json:"max_weight,omitempty"` // kg
+	MaxVolume   *float64 `json:"max_volume,omitempty"` // m³
 }
 
 // UpdateLocationRequest represents the request to update a location
@@ -40,5 +50,54 @@ type UpdateLocationRequest struct {
 	Shelf       *string  `json:"shelf,omitempty"`
 	Capacity    *int     `json:"capacity,omitempty"`
 	Temperature *float64 `json:"temperature,omitempty"`
+	MaxWeight   *float64 `json:"max_weight,omitempty"` // kg
+	MaxVolume   *float64 `json:"max_volume,omitempty"` // m³
 	IsActive    *bool    `json:"is_active,omitempty"`
 }
+
+// LocationLoad represents what is currently stored at a location
+type LocationLoad struct {
+	Quantity int     `json:"quantity"`
+	Weight   float64 `json:"weight"` // kg
+	Volume   float64 `json:"volume"` // m³
+}
+
+// capacityTolerance absorbs rounding in weight and volume sums
+const capacityTolerance = 1e-9
+
+// CheckCapacity reports whether quantity more units of a product fit on top of the current load,
+// by unit count, weight and volume. Products without weight or dimensions only count as units.
+func (l *Location) CheckCapacity(load *LocationLoad, product *Product, quantity int) error {
+	if load.Quantity+quantity > l.Capacity {
+		return fmt.Errorf("%w: %d units would exceed the limit of %d units at %s",
+			ErrExceedsCapacity, load.Quantity+quantity, l.Capacity, l.Code)
+	}
+	if l.MaxWeight != nil {
+		weight := load.Weight + float64(quantity)*product.Weight
+		if weight > *l.MaxWeight+capacityTolerance {
+			return fmt.Errorf("%w: %.2f kg would exceed the limit of %.2f kg at %s",
+				ErrExceedsCapacity, weight, *l.MaxWeight, l.Code)
+		}
+	}
+	if l.MaxVolume != nil {
+		volume := load.Volume + float64(quantity)*product.Volume()
+		if volume > *l.MaxVolume+capacityTolerance {
+			return fmt.Errorf("%w: %.3f m³ would exceed the limit of %.3f m³ at %s",
+				ErrExceedsCapacity, volume, *l.MaxVolume, l.Code)
+		}
+	}
+	return nil
+}
+
+// FreeUnits returns how many more units of a product fit on top of the current load, by unit
+// count, weight and volume
+func (l *Location) FreeUnits(load *LocationLoad, product *Product) int {
+	free := l.Capacity - load.Quantity
+	if l.MaxWeight != nil && product.Weight > 0 {
+		free = min(free, int(math.Floor((*l.MaxWeight-load.Weight)/product.Weight+capacityTolerance)))
+	}
+	if volume := product.Volume(); l.MaxVolume != nil && volume > 0 {
+		free = min(free, int(math.Floor((*l.MaxVolume-load.Volume)/volume+capacityTolerance)))
+	}
+	return max(free, 0)
+}
@@ -0,0 +1,12 @@
+package domain
+
+import "time"
+
+// Lot represents a production lot or batch of a product
+type Lot struct {
+	ID         int        `json:"id"`
+	ProductID  int        `json:"product_id"`
+	LotNumber  string     `json:"lot_number"`
+	ExpiryDate *time.Time `json:"expiry_date,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
@@ -0,0 +1,168 @@
+package domain
+
+import (
+	"cmp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PickListLineRequest represents a product and quantity to ship
+type PickListLineRequest struct {
+	ProductID int    `json:"product_id" validate:"required"`
+	Quantity  int    `json:"quantity" validate:"required,gt=0"`
+	UOM       string `json:"uom,omitempty"` // Unit of Quantity, defaults to the base unit
+}
+
+// GeneratePickListRequest represents the request to generate a pick list
+type GeneratePickListRequest struct {
+	Reference   string                 `json:"reference,omitempty"`    // e.g., "SO-2024-015"
+	WarehouseID *int                   `json:"warehouse_id,omitempty"` // Defaults to the MAIN warehouse
+	Lines       []*PickListLineRequest `json:"lines" validate:"required,min=1"`
+}
+
+// PickStop represents one pick at a location, in walking order
+type PickStop struct {
+	Sequence    int        `json:"sequence"`
+	LineNumber  int        `json:"line_number,omitempty"` // 1-based index of the requested line, unset on consolidated stops
+	Location    *Location  `json:"location"`
+	ProductID   int        `json:"product_id"`
+	SKU         string     `json:"sku"`
+	ProductName string     `json:"product_name"`
+	LotNumber   string     `json:"lot_number,omitempty"`
+	ExpiryDate  *time.Time `json:"expiry_date,omitempty"`
+	Quantity    int        `json:"quantity"` // In base units
+	Unit        string     `json:"unit"`
+
+	// Orders splits a consolidated stop across the sales order lines it is picked for
+	Orders []*PickStopOrder `json:"orders,omitempty"`
+}
+
+// PickStopOrder represents the part of a consolidated stop picked for one sales order line
+type PickStopOrder struct {
+	SalesOrderID int    `json:"sales_order_id"`
+	SONumber     string `json:"so_number"`
+	LineNumber   int    `json:"line_number"`
+	Quantity     int    `json:"quantity"`
+}
+
+// PickShortage represents a requested line that could not be allocated in full
+type PickShortage struct {
+	LineNumber        int    `json:"line_number"`
+	ProductID         int    `json:"product_id"`
+	SKU               string `json:"sku"`
+	RequestedQuantity int    `json:"requested_quantity"` // In base units
+	AllocatedQuantity int    `json:"allocated_quantity"`
+	ShortQuantity     int    `json:"short_quantity"`
+}
+
+// PickList represents the allocated stops for a set of lines, ordered along the walking path
+type PickList struct {
+	Reference     string          `json:"reference,omitempty"`
+	WarehouseID   int             `json:"warehouse_id"`
+	GeneratedAt   time.Time       `json:"generated_at"`
+	Complete      bool            `json:"complete"` // Every line is allocated in full
+	TotalQuantity int             `json:"total_quantity"`
+	Stops         []*PickStop     `json:"stops"`
+	Shortages     []*PickShortage `json:"shortages"`
+}
+
+// SequencePickStops orders stops along a serpentine path and numbers them from 1. Warehouses are
+// picked one after another, and within a warehouse zones and aisles are walked in ascending order;
+// racks run up one aisle and back down the next, so the picker never walks an aisle twice. Shelves
+// are picked bottom to top within a rack.
+func SequencePickStops(stops []*PickStop) {
+	// Aisles with stops, in walking order, decide the direction of each aisle
+	type aisleKey struct {
+		warehouseID int
+		zone, aisle string
+	}
+	keyOf := func(location *Location) aisleKey {
+		return aisleKey{location.WarehouseID, location.Zone, location.Aisle}
+	}
+	var aisles []aisleKey
+	seen := make(map[aisleKey]bool)
+	for _, stop := range stops {
+		key := keyOf(stop.Location)
+		if !seen[key] {
+			seen[key] = true
+			aisles = append(aisles, key)
+		}
+	}
+	sort.Slice(aisles, func(i, j int) bool {
+		if aisles[i].warehouseID != aisles[j].warehouseID {
+			return aisles[i].warehouseID < aisles[j].warehouseID
+		}
+		if c := compareLocationPart(aisles[i].zone, aisles[j].zone); c != 0 {
+			return c < 0
+		}
+		return compareLocationPart(aisles[i].aisle, aisles[j].aisle) < 0
+	})
+	// The first aisle of every warehouse is walked up
+	visit := make(map[aisleKey]int, len(aisles))
+	descending := make(map[aisleKey]bool, len(aisles))
+	inWarehouse := 0
+	for i, key := range aisles {
+		if i > 0 && aisles[i-1].warehouseID != key.warehouseID {
+			inWarehouse = 0
+		}
+		visit[key] = i
+		descending[key] = inWarehouse%2 == 1
+		inWarehouse++
+	}
+
+	sort.SliceStable(stops, func(i, j int) bool {
+		a, b := stops[i], stops[j]
+		aKey := keyOf(a.Location)
+		ai, bi := visit[aKey], visit[keyOf(b.Location)]
+		if ai != bi {
+			return ai < bi
+		}
+		if c := compareLocationPart(a.Location.Rack, b.Location.Rack); c != 0 {
+			if descending[aKey] {
+				return c > 0
+			}
+			return c < 0
+		}
+		if c := compareLocationPart(a.Location.Shelf, b.Location.Shelf); c != 0 {
+			return c < 0
+		}
+		if a.Location.Code != b.Location.Code {
+			return a.Location.Code < b.Location.Code
+		}
+		if a.LineNumber != b.LineNumber {
+			return a.LineNumber < b.LineNumber
+		}
+		return a.LotNumber < b.LotNumber
+	})
+
+	for i, stop := range stops {
+		stop.Sequence = i + 1
+	}
+}
+
+// ComparePickPath compares two locations by their position on the walking path, ignoring the
+// direction of the aisle
+func ComparePickPath(a, b *Location) int {
+	if c := cmp.Compare(a.WarehouseID, b.WarehouseID); c != 0 {
+		return c
+	}
+	for _, parts := range [][2]string{{a.Zone, b.Zone}, {a.Aisle, b.Aisle}, {a.Rack, b.Rack}, {a.Shelf, b.Shelf}, {a.Code, b.Code}} {
+		if c := compareLocationPart(parts[0], parts[1]); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// compareLocationPart compares zone, aisle, rack or shelf labels, numerically when both are
+// numbers so that "2" comes before "10"
+func compareLocationPart(a, b string) int {
+	an, aErr := strconv.Atoi(a)
+	bn, bErr := strconv.Atoi(b)
+	if aErr == nil && bErr == nil {
+		return cmp.Compare(an, bn)
+	}
+	return strings.Compare(a, b)
+}
@@ -0,0 +1,136 @@
+package domain
+
+import (
+	"fmt"
+	"testing"
+)
+
+func pickLocation(warehouseID int, zone, aisle, rack, shelf string) *Location {
+	return &Location{
+		WarehouseID: warehouseID,
+		Code:        fmt.Sprintf("%s-%s-%s-%s", zone, aisle, rack, shelf),
+		Zone:        zone,
+		Aisle:       aisle,
+		Rack:        rack,
+		Shelf:       shelf,
+	}
+}
+
+func TestSequencePickStops(t *testing.T) {
+	tests := []struct {
+		name      string
+		locations []*Location
+		want      []string // Warehouse ID and location code in walking order
+	}{
+		{
+			name: "racks run up one aisle and down the next",
+			locations: []*Location{
+				pickLocation(1, "A", "02", "01", "01"),
+				pickLocation(1, "A", "01", "02", "01"),
+				pickLocation(1, "A", "02", "02", "01"),
+				pickLocation(1, "A", "01", "01", "01"),
+			},
+			want: []string{"1 A-01-01-01", "1 A-01-02-01", "1 A-02-02-01", "1 A-02-01-01"},
+		},
+		{
+			name: "numeric labels sort by value",
+			locations: []*Location{
+				pickLocation(1, "A", "10", "1", "1"),
+				pickLocation(1, "A", "2", "1", "1"),
+			},
+			want: []string{"1 A-2-1-1", "1 A-10-1-1"},
+		},
+		{
+			name: "shelves bottom to top within a rack",
+			locations: []*Location{
+				pickLocation(1, "B", "01", "01", "03"),
+				pickLocation(1, "B", "01", "01", "01"),
+				pickLocation(1, "A", "01", "01", "02"),
+			},
+			want: []string{"1 A-01-01-02", "1 B-01-01-01", "1 B-01-01-03"},
+		},
+		{
+			name: "warehouses sharing zone and aisle labels are walked one after another",
+			locations: []*Location{
+				pickLocation(2, "A", "01", "02", "01"),
+				pickLocation(1, "A", "01", "02", "01"),
+				pickLocation(2, "A", "01", "01", "01"),
+				pickLocation(1, "A", "02", "01", "01"),
+				pickLocation(1, "A", "01", "01", "01"),
+				pickLocation(1, "A", "02", "02", "01"),
+			},
+			want: []string{
+				"1 A-01-01-01", "1 A-01-02-01", "1 A-02-02-01", "1 A-02-01-01",
+				"2 A-01-01-01", "2 A-01-02-01",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stops := make([]*PickStop, len(tt.locations))
+			for i, location := range tt.locations {
+				stops[i] = &PickStop{Location: location}
+			}
+
+			SequencePickStops(stops)
+
+			if len(stops) != len(tt.want) {
+				t.Fatalf("got %d stops, want %d", len(stops), len(tt.want))
+			}
+			for i, stop := range stops {
+				got := fmt.Sprintf("%d %s", stop.Location.WarehouseID, stop.Location.Code)
+				if got != tt.want[i] {
+					t.Errorf("stop %d = %s, want %s", i+1, got, tt.want[i])
+				}
+				if stop.Sequence != i+1 {
+					t.Errorf("stop %d has sequence %d", i+1, stop.Sequence)
+				}
+			}
+		})
+	}
+}
+
+func TestSequencePickStopsSameLocation(t *testing.T) {
+	location := pickLocation(1, "A", "01", "01", "01")
+	stops := []*PickStop{
+		{Location: location, LineNumber: 2, LotNumber: "LOT-A"},
+		{Location: location, LineNumber: 1, LotNumber: "LOT-B"},
+		{Location: location, LineNumber: 1, LotNumber: "LOT-A"},
+	}
+
+	SequencePickStops(stops)
+
+	want := []string{"1 LOT-A", "1 LOT-B", "2 LOT-A"}
+	for i, stop := range stops {
+		if got := fmt.Sprintf("%d %s", stop.LineNumber, stop.LotNumber); got != want[i] {
+			t.Errorf("stop %d = %s, want %s", i+1, got, want[i])
+		}
+	}
+}
+
+func TestComparePickPath(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b *Location
+		want int
+	}{
+		{"same location", pickLocation(1, "A", "01", "01", "01"), pickLocation(1, "A", "01", "01", "01"), 0},
+		{"warehouse before zone", pickLocation(2, "A", "01", "01", "01"), pickLocation(1, "B", "01", "01", "01"), 1},
+		{"zone", pickLocation(1, "A", "09", "01", "01"), pickLocation(1, "B", "01", "01", "01"), -1},
+		{"numeric aisle", pickLocation(1, "A", "2", "1", "1"), pickLocation(1, "A", "10", "1", "1"), -1},
+		{"rack", pickLocation(1, "A", "01", "03", "01"), pickLocation(1, "A", "01", "02", "09"), 1},
+		{"shelf", pickLocation(1, "A", "01", "01", "01"), pickLocation(1, "A", "01", "01", "02"), -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ComparePickPath(tt.a, tt.b); got != tt.want {
+				t.Errorf("ComparePickPath() = %d, want %d", got, tt.want)
+			}
+			if got := ComparePickPath(tt.b, tt.a); got != -tt.want {
+				t.Errorf("ComparePickPath() reversed = %d, want %d", got, -tt.want)
+			}
+		})
+	}
+}
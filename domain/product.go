@@ -1,44 +1,136 @@
 package domain
 
-import "time"
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
 
 // Product represents a product in the warehouse
 type Product struct {
-	ID          int       `json:"id"`
-	SKU         string    `json:"sku"`
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	Price       float64   `json:"price"`
-	Weight      float64   `json:"weight"`     // in kg
-	Dimensions  string    `json:"dimensions"` // "LxWxH" in cm
-	Category    string    `json:"category"`
-	Quantity    int       `json:"quantity"`
-	IsActive    bool      `json:"is_active"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID             int        `json:"id"`
+	SKU            string     `json:"sku"`
+	Name           string     `json:"name"`
+	Description    string     `json:"description"`
+	Price          float64    `json:"price"`
+	Weight         float64    `json:"weight"`     // in kg
+	Dimensions     string     `json:"dimensions"` // "LxWxH" in cm
+	Length         float64    `json:"length"`     // in cm, parsed from Dimensions
+	Width          float64    `json:"width"`      // in cm, parsed from Dimensions
+	Height         float64    `json:"height"`     // in cm, parsed from Dimensions
+	Category       string     `json:"category"`
+	Quantity       int        `json:"quantity"`
+	IsSerialized   bool       `json:"is_serialized"`             // Every unit is tracked by serial number
+	CostMethod     CostMethod `json:"cost_method"`               // FIFO or AVERAGE
+	BaseUnit       string     `json:"base_unit"`                 // Unit every stored quantity is counted in
+	MinTemperature *float64   `json:"min_temperature,omitempty"` // Storage range in °C, nil when there is no lower limit
+	MaxTemperature *float64   `json:"max_temperature,omitempty"` // Storage range in °C, nil when there is no upper limit
+	IsActive       bool       `json:"is_active"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+
+	// Populated relations
+	Units []*ProductUnit `json:"units,omitempty"` // Pack sizes besides the base unit
 }
 
 // CreateProductRequest represents the request to create a new product
 type CreateProductRequest struct {
-	SKU         string  `json:"sku" validate:"required,max=50"`
-	Name        string  `json:"name" validate:"required,max=255"`
-	Description string  `json:"description"`
-	Price       float64 `json:"price" validate:"min=0"`
-	Weight      float64 `json:"weight" validate:"min=0"`
-	Dimensions  string  `json:"dimensions"`
-	Category    string  `json:"category" validate:"required,max=100"`
-	Quantity    int     `json:"quantity" validate:"required,min=0"`
+	SKU            string         `json:"sku" validate:"required,max=50"`
+	Name           string         `json:"name" validate:"required,max=255"`
+	Description    string         `json:"description"`
+	Price          float64        `json:"price" validate:"min=0"`
+	Weight         float64        `json:"weight" validate:"min=0"`
+	Dimensions     string         `json:"dimensions"`
+	Category       string         `json:"category" validate:"required,max=100"`
+	IsSerialized   bool           `json:"is_serialized"`
+	CostMethod     CostMethod     `json:"cost_method"` // Defaults to FIFO
+	BaseUnit       string         `json:"base_unit"`   // Defaults to EA
+	Units          []*ProductUnit `json:"units"`
+	MinTemperature *float64       `json:"min_temperature"` // °C, optional
+	MaxTemperature *float64       `json:"max_temperature"` // °C, optional
 }
 
 // UpdateProductRequest represents the request to update a product
 type UpdateProductRequest struct {
-	SKU         *string  `json:"sku,omitempty"`
-	Name        *string  `json:"name,omitempty"`
-	Description *string  `json:"description,omitempty"`
-	Price       *float64 `json:"price,omitempty"`
-	Weight      *float64 `json:"weight,omitempty"`
-	Dimensions  *string  `json:"dimensions,omitempty"`
-	Category    *string  `json:"category,omitempty"`
-	IsActive    *bool    `json:"is_active,omitempty"`
-	Quantity    *int     `json:"quantity,omitempty"`
+	SKU            *string     `json:"sku,omitempty"`
+	Name           *string     `json:"name,omitempty"`
+	Description    *string     `json:"description,omitempty"`
+	Price          *float64    `json:"price,omitempty"`
+	Weight         *float64    `json:"weight,omitempty"`
+	Dimensions     *string     `json:"dimensions,omitempty"`
+	Category       *string     `json:"category,omitempty"`
+	IsActive       *bool       `json:"is_active,omitempty"`
+	IsSerialized   *bool       `json:"is_serialized,omitempty"`
+	CostMethod     *CostMethod `json:"cost_method,omitempty"`
+	BaseUnit       *string     `json:"base_unit,omitempty"`
+	MinTemperature *float64    `json:"min_temperature,omitempty"` // °C
+	MaxTemperature *float64    `json:"max_temperature,omitempty"` // °C
+	// Units replaces every pack size of the product when given; an empty list removes them all
+	Units []*ProductUnit `json:"units,omitempty"`
+}
+
+// Volume returns the space one unit of the product takes up, in m³
+func (p *Product) Volume() float64 {
+	return p.Length * p.Width * p.Height / 1e6
+}
+
+// ParseDimensions reads a "LxWxH" dimensions text in cm, e.g. "10x20x5" or "10.5 x 20 x 5".
+// An empty text means the dimensions are unknown and parses as zero.
+func ParseDimensions(dimensions string) (length, width, height float64, err error) {
+	if strings.TrimSpace(dimensions) == "" {
+		return 0, 0, 0, nil
+	}
+
+	parts := strings.Split(strings.ToLower(dimensions), "x")
+	if len(parts) != 3 {
+		return 0, 0, 0, fmt.Errorf("%w: dimensions must be given as LxWxH in cm", ErrInvalidInput)
+	}
+
+	values := make([]float64, 3)
+	for i, part := range parts {
+		value, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil || value < 0 {
+			return 0, 0, 0, fmt.Errorf("%w: dimensions must be given as LxWxH in cm", ErrInvalidInput)
+		}
+		values[i] = value
+	}
+
+	return values[0], values[1], values[2], nil
+}
+
+// HasTemperatureRequirement reports whether the product must be kept within a temperature range
+func (p *Product) HasTemperatureRequirement() bool {
+	return p.MinTemperature != nil || p.MaxTemperature != nil
+}
+
+// CanBeStoredAt reports whether the temperature of a location is within the storage range of the
+// product. A location without a temperature is not controlled and only takes products without a range.
+func (p *Product) CanBeStoredAt(location *Location) bool {
+	if !p.HasTemperatureRequirement() {
+		return true
+	}
+	if location.Temperature == nil {
+		return false
+	}
+	if p.MinTemperature != nil && *location.Temperature < *p.MinTemperature {
+		return false
+	}
+	if p.MaxTemperature != nil && *location.Temperature > *p.MaxTemperature {
+		return false
+	}
+	return true
+}
+
+// TemperatureRange describes the storage range of the product, e.g. "2.0°C to 8.0°C"
+func (p *Product) TemperatureRange() string {
+	switch {
+	case p.MinTemperature != nil && p.MaxTemperature != nil:
+		return fmt.Sprintf("%.1f°C to %.1f°C", *p.MinTemperature, *p.MaxTemperature)
+	case p.MinTemperature != nil:
+		return fmt.Sprintf("at least %.1f°C", *p.MinTemperature)
+	case p.MaxTemperature != nil:
+		return fmt.Sprintf("at most %.1f°C", *p.MaxTemperature)
+	}
+	return "any temperature"
 }
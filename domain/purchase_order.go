@@ -0,0 +1,179 @@
+package domain
+
+import (
+	"math"
+	"time"
+)
+
+// Supplier represents a vendor that goods are purchased from
+type Supplier struct {
+	ID          int       `json:"id"`
+	Code        string    `json:"code"`
+	Name        string    `json:"name"`
+	ContactName string    `json:"contact_name"`
+	Email       string    `json:"email"`
+	Phone       string    `json:"phone"`
+	IsActive    bool      `json:"is_active"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// CreateSupplierRequest represents the request to create a new supplier
+type CreateSupplierRequest struct {
+	Code        string `json:"code" validate:"required,max=50"`
+	Name        string `json:"name" validate:"required,max=255"`
+	ContactName string `json:"contact_name" validate:"max=255"`
+	Email       string `json:"email" validate:"max=255"`
+	Phone       string `json:"phone" validate:"max=50"`
+}
+
+// UpdateSupplierRequest represents the request to update a supplier
+type UpdateSupplierRequest struct {
+	Name        *string `json:"name,omitempty" validate:"omitempty,max=255"`
+	ContactName *string `json:"contact_name,omitempty" validate:"omitempty,max=255"`
+	Email       *string `json:"email,omitempty" validate:"omitempty,max=255"`
+	Phone       *string `json:"phone,omitempty" validate:"omitempty,max=50"`
+	IsActive    *bool   `json:"is_active,omitempty"`
+}
+
+// PurchaseOrderStatus represents the state of a purchase order
+type PurchaseOrderStatus string
+
+const (
+	PurchaseOrderOpen              PurchaseOrderStatus = "OPEN"
+	PurchaseOrderPartiallyReceived PurchaseOrderStatus = "PARTIALLY_RECEIVED"
+	PurchaseOrderClosed            PurchaseOrderStatus = "CLOSED"
+	PurchaseOrderCancelled         PurchaseOrderStatus = "CANCELLED"
+)
+
+// IsReceivable reports whether goods can still be received against the order
+func (s PurchaseOrderStatus) IsReceivable() bool {
+	return s == PurchaseOrderOpen || s == PurchaseOrderPartiallyReceived
+}
+
+// ReceiptStatus describes how much of a purchase order line has been received
+type ReceiptStatus string
+
+const (
+	ReceiptPending  ReceiptStatus = "PENDING"  // Nothing received yet
+	ReceiptPartial  ReceiptStatus = "PARTIAL"  // Less than ordered received so far
+	ReceiptComplete ReceiptStatus = "COMPLETE" // Exactly the ordered quantity received
+	ReceiptOver     ReceiptStatus = "OVER"     // More than ordered received
+	ReceiptShort    ReceiptStatus = "SHORT"    // Order closed with less than ordered received
+)
+
+// PurchaseOrder represents an order placed with a supplier
+type PurchaseOrder struct {
+	ID         int                 `json:"id"`
+	PONumber   string              `json:"po_number"`
+	SupplierID int                 `json:"supplier_id"`
+	Status     PurchaseOrderStatus `json:"status"`
+	// ExpectedDate is the day the goods are due
+	ExpectedDate *time.Time `json:"expected_date,omitempty"`
+	// OverReceiptTolerance is the percentage a line may be received above its ordered quantity
+	OverReceiptTolerance float64    `json:"over_receipt_tolerance"`
+	Notes                string     `json:"notes"`
+	CreatedBy            int        `json:"created_by"`
+	CreatedAt            time.Time  `json:"created_at"`
+	UpdatedAt            time.Time  `json:"updated_at"`
+	ClosedAt             *time.Time `json:"closed_at,omitempty"`
+
+	// Populated relations
+	Supplier *Supplier            `json:"supplier,omitempty"`
+	Lines    []*PurchaseOrderLine `json:"lines,omitempty"`
+}
+
+// PurchaseOrderLine represents the expected quantity of a product on a purchase order
+type PurchaseOrderLine struct {
+	ID               int      `json:"id"`
+	PurchaseOrderID  int      `json:"purchase_order_id"`
+	LineNumber       int      `json:"line_number"`
+	ProductID        int      `json:"product_id"`
+	OrderedQuantity  int      `json:"ordered_quantity"` // In base units
+	ReceivedQuantity int      `json:"received_quantity"`
+	UnitCost         *float64 `json:"unit_cost,omitempty"` // Per base unit, used as the cost of receipts
+
+	// Derived from the quantities and the order status
+	ReceiptStatus       ReceiptStatus `json:"receipt_status"`
+	OutstandingQuantity int           `json:"outstanding_quantity"`
+	OverQuantity        int           `json:"over_quantity"`
+
+	// Populated relations
+	Product *Product `json:"product,omitempty"`
+}
+
+// MaxReceivable returns the most a line may be received in total under the order's tolerance
+func (l *PurchaseOrderLine) MaxReceivable(tolerance float64) int {
+	return int(math.Floor(float64(l.OrderedQuantity) * (1 + tolerance/100)))
+}
+
+// SetReceiptStatus fills in the receipt status, outstanding and over quantity of a line. Lines of a
+// closed order that were not received in full are short.
+func (l *PurchaseOrderLine) SetReceiptStatus(orderStatus PurchaseOrderStatus) {
+	l.OutstandingQuantity = max(l.OrderedQuantity-l.ReceivedQuantity, 0)
+	l.OverQuantity = max(l.ReceivedQuantity-l.OrderedQuantity, 0)
+
+	switch {
+	case l.ReceivedQuantity > l.OrderedQuantity:
+		l.ReceiptStatus = ReceiptOver
+	case l.ReceivedQuantity == l.OrderedQuantity:
+		l.ReceiptStatus = ReceiptComplete
+	case orderStatus == PurchaseOrderClosed:
+		l.ReceiptStatus = ReceiptShort
+	case l.ReceivedQuantity > 0:
+		l.ReceiptStatus = ReceiptPartial
+	default:
+		l.ReceiptStatus = ReceiptPending
+	}
+}
+
+// CreatePurchaseOrderLineRequest represents an ordered product
+type CreatePurchaseOrderLineRequest struct {
+	ProductID int      `json:"product_id" validate:"required"`
+	Quantity  int      `json:"quantity" validate:"required,min=1"`
+	UOM       string   `json:"uom"`       // Unit of Quantity and UnitCost, defaults to the base unit
+	UnitCost  *float64 `json:"unit_cost"` // Defaults to the average cost at receipt
+}
+
+// CreatePurchaseOrderRequest represents the request to create a purchase order
+type CreatePurchaseOrderRequest struct {
+	PONumber             string                            `json:"po_number" validate:"required,max=100"`
+	SupplierID           int                               `json:"supplier_id" validate:"required"`
+	ExpectedDate         string                            `json:"expected_date"` // YYYY-MM-DD
+	OverReceiptTolerance float64                           `json:"over_receipt_tolerance" validate:"min=0"`
+	Notes                string                            `json:"notes"`
+	Lines                []*CreatePurchaseOrderLineRequest `json:"lines" validate:"required,min=1"`
+}
+
+// ReceivePurchaseOrderLineRequest represents goods received against one purchase order line
+type ReceivePurchaseOrderLineRequest struct {
+	LineID        int      `json:"line_id" validate:"required"`
+	LocationID    int      `json:"location_id"` // Defaults to the location of the receipt
+	Quantity      int      `json:"quantity" validate:"required,min=1"`
+	UOM           string   `json:"uom"` // Unit of Quantity, defaults to the base unit
+	LotNumber     string   `json:"lot_number" validate:"max=50"`
+	ExpiryDate    string   `json:"expiry_date"`    // YYYY-MM-DD
+	SerialNumbers []string `json:"serial_numbers"` // Required for serialized products, one per base unit
+}
+
+// ReceivePurchaseOrderRequest represents a delivery received against a purchase order
+type ReceivePurchaseOrderRequest struct {
+	LocationID int                                `json:"location_id"`
+	Reference  string                             `json:"reference" validate:"max=100"` // e.g., the delivery note, defaults to the PO number
+	Notes      string                             `json:"notes"`
+	Lines      []*ReceivePurchaseOrderLineRequest `json:"lines" validate:"required,min=1"`
+}
+
+// PurchaseOrderReceipt represents the outcome of receiving goods against a purchase order
+type PurchaseOrderReceipt struct {
+	PurchaseOrder *PurchaseOrder   `json:"purchase_order"`
+	Movements     []*StockMovement `json:"movements"`
+}
+
+// PurchaseOrderFilter represents filters for listing purchase orders
+type PurchaseOrderFilter struct {
+	SupplierID *int                 `json:"supplier_id,omitempty"`
+	Status     *PurchaseOrderStatus `json:"status,omitempty"`
+	Limit      int                  `json:"limit"`
+	Offset     int                  `json:"offset"`
+}
@@ -0,0 +1,32 @@
+package domain
+
+// PutawayRequest represents the request for putaway location suggestions
+type PutawayRequest struct {
+	ProductID   int    `json:"product_id"`
+	Quantity    int    `json:"quantity"`
+	UOM         string `json:"uom,omitempty"`          // Unit of Quantity, defaults to the base unit
+	Zone        string `json:"zone,omitempty"`         // Preferred zone
+	WarehouseID *int   `json:"warehouse_id,omitempty"` // Defaults to the MAIN warehouse
+	Limit       int    `json:"limit,omitempty"`
+}
+
+// PutawaySuggestion represents a candidate location for putting away stock of a product
+type PutawaySuggestion struct {
+	Rank              int       `json:"rank"`
+	Location          *Location `json:"location"`
+	Score             float64   `json:"score"`
+	AcceptsQuantity   int       `json:"accepts_quantity"` // Base units that still fit, up to the requested quantity
+	FitsAll           bool      `json:"fits_all"`
+	OnHandQuantity    int       `json:"on_hand_quantity"` // Stock of the same product already stored here
+	RemainingCapacity int       `json:"remaining_capacity"`
+	RemainingWeight   *float64  `json:"remaining_weight,omitempty"`
+	RemainingVolume   *float64  `json:"remaining_volume,omitempty"`
+	Reasons           []string  `json:"reasons"`
+}
+
+// PutawayPlan represents the ranked candidate locations for a quantity of a product
+type PutawayPlan struct {
+	Product     *Product             `json:"product"`
+	Quantity    int                  `json:"quantity"` // In base units
+	Suggestions []*PutawaySuggestion `json:"suggestions"`
+}
@@ -0,0 +1,26 @@
+package domain
+
+// StockDiscrepancy represents a stored quantity that does not match the movement ledger.
+// Location discrepancies compare a stock level, product discrepancies compare products.quantity.
+type StockDiscrepancy struct {
+	ProductID      int     `json:"product_id"`
+	SKU            string  `json:"sku"`
+	LocationID     *int    `json:"location_id,omitempty"`
+	LocationCode   *string `json:"location_code,omitempty"`
+	LotNumber      string  `json:"lot_number,omitempty"`
+	StoredQuantity int     `json:"stored_quantity"`
+	LedgerQuantity int     `json:"ledger_quantity"`
+}
+
+// Difference is the quantity the ledger is missing compared to the stored quantity
+func (d *StockDiscrepancy) Difference() int {
+	return d.StoredQuantity - d.LedgerQuantity
+}
+
+// StockReconciliation represents the result of comparing stored quantities with the movement ledger
+type StockReconciliation struct {
+	Locations   []*StockDiscrepancy `json:"locations"`
+	Products    []*StockDiscrepancy `json:"products"`
+	Fixed       bool                `json:"fixed"`
+	Adjustments []*StockMovement    `json:"adjustments,omitempty"` // Ledger corrections written in fix mode
+}
@@ -0,0 +1,93 @@
+package domain
+
+import "time"
+
+// ReorderRule represents the replenishment settings of a product, across all locations or at one location
+type ReorderRule struct {
+	ID              int       `json:"id"`
+	ProductID       int       `json:"product_id"`
+	LocationID      *int      `json:"location_id,omitempty"` // Empty for a rule covering all locations
+	ReorderPoint    int       `json:"reorder_point"`         // Stock at or below this level should be reordered
+	SafetyStock     int       `json:"safety_stock"`          // Stock at or below this level is critical
+	ReorderQuantity int       `json:"reorder_quantity"`      // Quantity to order when the reorder point is reached
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// AlertLevel returns how far the given on-hand quantity has fallen, or an empty level when it is above the reorder point
+func (r *ReorderRule) AlertLevel(quantity int) AlertLevel {
+	switch {
+	case quantity <= r.SafetyStock:
+		return AlertCritical
+	case quantity <= r.ReorderPoint:
+		return AlertLow
+	}
+	return ""
+}
+
+// SetReorderRuleRequest represents the request to create or replace the reorder rule of a product
+type SetReorderRuleRequest struct {
+	LocationID      *int `json:"location_id"` // Leave empty for a rule covering all locations
+	ReorderPoint    int  `json:"reorder_point" validate:"min=0"`
+	SafetyStock     int  `json:"safety_stock" validate:"min=0,ltefield=ReorderPoint"`
+	ReorderQuantity int  `json:"reorder_quantity" validate:"required,min=1"`
+}
+
+// AlertLevel represents how urgently a product needs to be reordered
+type AlertLevel string
+
+const (
+	AlertLow      AlertLevel = "LOW"      // At or below the reorder point
+	AlertCritical AlertLevel = "CRITICAL" // At or below the safety stock
+)
+
+// StockAlertStatus represents the state of a low-stock alert
+type StockAlertStatus string
+
+const (
+	StockAlertOpen         StockAlertStatus = "OPEN"
+	StockAlertAcknowledged StockAlertStatus = "ACKNOWLEDGED"
+	StockAlertResolved     StockAlertStatus = "RESOLVED" // Stock rose above the reorder point again
+)
+
+// StockAlert represents a product that fell to or below its reorder point
+type StockAlert struct {
+	ID             int              `json:"id"`
+	RuleID         int              `json:"rule_id"`
+	ProductID      int              `json:"product_id"`
+	LocationID     *int             `json:"location_id,omitempty"`
+	Level          AlertLevel       `json:"level"`
+	Status         StockAlertStatus `json:"status"`
+	Quantity       int              `json:"quantity"` // On-hand quantity at the last evaluation
+	ReorderPoint   int              `json:"reorder_point"`
+	SafetyStock    int              `json:"safety_stock"`
+	AcknowledgedBy *int             `json:"acknowledged_by,omitempty"`
+	AcknowledgedAt *time.Time       `json:"acknowledged_at,omitempty"`
+	ResolvedAt     *time.Time       `json:"resolved_at,omitempty"`
+	CreatedAt      time.Time        `json:"created_at"`
+	UpdatedAt      time.Time        `json:"updated_at"`
+}
+
+// StockAlertFilter represents filters for listing stock alerts
+type StockAlertFilter struct {
+	ProductID  *int              `json:"product_id,omitempty"`
+	LocationID *int              `json:"location_id,omitempty"`
+	Status     *StockAlertStatus `json:"status,omitempty"`
+	Limit      int               `json:"limit"`
+	Offset     int               `json:"offset"`
+}
+
+// LowStockItem represents a reorder rule whose product is at or below its reorder point
+type LowStockItem struct {
+	RuleID          int        `json:"rule_id"`
+	ProductID       int        `json:"product_id"`
+	SKU             string     `json:"sku"`
+	ProductName     string     `json:"product_name"`
+	LocationID      *int       `json:"location_id,omitempty"`   // Empty for a rule covering all locations
+	LocationCode    *string    `json:"location_code,omitempty"` // Empty for a rule covering all locations
+	OnHandQuantity  int        `json:"on_hand_quantity"`
+	ReorderPoint    int        `json:"reorder_point"`
+	SafetyStock     int        `json:"safety_stock"`
+	ReorderQuantity int        `json:"reorder_quantity"`
+	Level           AlertLevel `json:"level"`
+}
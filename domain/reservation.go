@@ -0,0 +1,77 @@
+package domain
+
+import "time"
+
+// ReservationStatus represents the state of a stock reservation
+type ReservationStatus string
+
+const (
+	ReservationActive   ReservationStatus = "ACTIVE"
+	ReservationReleased ReservationStatus = "RELEASED"
+	ReservationConsumed ReservationStatus = "CONSUMED"
+	ReservationExpired  ReservationStatus = "EXPIRED"
+)
+
+// Reservation represents stock at a location that is promised but not yet shipped
+type Reservation struct {
+	ID               int               `json:"id"`
+	ProductID        int               `json:"product_id"`
+	LocationID       int               `json:"location_id"`
+	Quantity         int               `json:"quantity"`
+	ConsumedQuantity int               `json:"consumed_quantity"`
+	Status           ReservationStatus `json:"status"`
+	Reference        string            `json:"reference"`
+	Notes            string            `json:"notes"`
+	ExpiresAt        *time.Time        `json:"expires_at,omitempty"`
+	CreatedBy        int               `json:"created_by"`
+	CreatedAt        time.Time         `json:"created_at"`
+	UpdatedAt        time.Time         `json:"updated_at"`
+	ClosedAt         *time.Time        `json:"closed_at,omitempty"`
+
+	// Populated relations
+	Product  *Product  `json:"product,omitempty"`
+	Location *Location `json:"location,omitempty"`
+}
+
+// OpenQuantity returns the part of the reservation that has not been consumed yet
+func (r *Reservation) OpenQuantity() int {
+	return r.Quantity - r.ConsumedQuantity
+}
+
+// StockAvailability represents on-hand, reserved and available quantity of a product at a location
+type StockAvailability struct {
+	ProductID         int `json:"product_id"`
+	LocationID        int `json:"location_id"`
+	OnHandQuantity    int `json:"on_hand_quantity"`
+	HeldQuantity      int `json:"held_quantity"` // On-hand that is not AVAILABLE
+	ReservedQuantity  int `json:"reserved_quantity"`
+	AvailableQuantity int `json:"available_quantity"`
+}
+
+// CreateReservationRequest represents the request to reserve stock at a location
+type CreateReservationRequest struct {
+	ProductID  int        `json:"product_id" validate:"required"`
+	LocationID int        `json:"location_id" validate:"required"`
+	Quantity   int        `json:"quantity" validate:"required,min=1"`
+	Reference  string     `json:"reference" validate:"max=100"`
+	Notes      string     `json:"notes"`
+	ExpiresAt  *time.Time `json:"expires_at"` // Optional, RFC 3339
+}
+
+// ConsumeReservationRequest represents the request to ship reserved stock
+type ConsumeReservationRequest struct {
+	Quantity      int      `json:"quantity"` // Defaults to the whole open quantity
+	Reference     string   `json:"reference" validate:"max=100"`
+	Notes         string   `json:"notes"`
+	LotNumber     string   `json:"lot_number" validate:"max=50"`
+	SerialNumbers []string `json:"serial_numbers"`
+}
+
+// ReservationFilter represents filters for listing reservations
+type ReservationFilter struct {
+	ProductID  *int               `json:"product_id,omitempty"`
+	LocationID *int               `json:"location_id,omitempty"`
+	Status     *ReservationStatus `json:"status,omitempty"`
+	Limit      int                `json:"limit"`
+	Offset     int                `json:"offset"`
+}
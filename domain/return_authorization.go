@@ -0,0 +1,149 @@
+package domain
+
+import "time"
+
+// ReturnAuthorizationStatus represents the state of a return authorization
+type ReturnAuthorizationStatus string
+
+const (
+	ReturnAuthorizationOpen              ReturnAuthorizationStatus = "OPEN"
+	ReturnAuthorizationPartiallyReceived ReturnAuthorizationStatus = "PARTIALLY_RECEIVED"
+	ReturnAuthorizationClosed            ReturnAuthorizationStatus = "CLOSED"
+	ReturnAuthorizationCancelled         ReturnAuthorizationStatus = "CANCELLED"
+)
+
+// IsReceivable reports whether returned goods can still be received against the authorization
+func (s ReturnAuthorizationStatus) IsReceivable() bool {
+	return s == ReturnAuthorizationOpen || s == ReturnAuthorizationPartiallyReceived
+}
+
+// ReturnReason explains why the customer sent goods back
+type ReturnReason string
+
+const (
+	ReturnDefective      ReturnReason = "DEFECTIVE"
+	ReturnDamaged        ReturnReason = "DAMAGED"
+	ReturnWrongItem      ReturnReason = "WRONG_ITEM"
+	ReturnNotAsDescribed ReturnReason = "NOT_AS_DESCRIBED"
+	ReturnNoLongerNeeded ReturnReason = "NO_LONGER_NEEDED"
+	ReturnOther          ReturnReason = "OTHER"
+)
+
+// IsValid reports whether the return reason is one of the known reasons
+func (r ReturnReason) IsValid() bool {
+	switch r {
+	case ReturnDefective, ReturnDamaged, ReturnWrongItem, ReturnNotAsDescribed, ReturnNoLongerNeeded, ReturnOther:
+		return true
+	}
+	return false
+}
+
+// ReturnGrade is the decision taken on returned goods when they are received
+type ReturnGrade string
+
+const (
+	ReturnRestock    ReturnGrade = "RESTOCK"    // Back into sellable stock
+	ReturnQuarantine ReturnGrade = "QUARANTINE" // Held for inspection
+	ReturnScrap      ReturnGrade = "SCRAP"      // Received and written off straight away
+)
+
+// IsValid reports whether the grade is one of the known grades
+func (g ReturnGrade) IsValid() bool {
+	switch g {
+	case ReturnRestock, ReturnQuarantine, ReturnScrap:
+		return true
+	}
+	return false
+}
+
+// ReturnAuthorization represents goods a customer is allowed to send back
+type ReturnAuthorization struct {
+	ID           int                       `json:"id"`
+	RMANumber    string                    `json:"rma_number"`
+	SalesOrderID *int                      `json:"sales_order_id,omitempty"` // The shipment being returned, if known
+	Reference    string                    `json:"reference"`                // e.g., the customer's invoice when there is no sales order
+	CustomerName string                    `json:"customer_name"`
+	Status       ReturnAuthorizationStatus `json:"status"`
+	Notes        string                    `json:"notes"`
+	CreatedBy    int                       `json:"created_by"`
+	CreatedAt    time.Time                 `json:"created_at"`
+	UpdatedAt    time.Time                 `json:"updated_at"`
+	ClosedAt     *time.Time                `json:"closed_at,omitempty"`
+
+	// Populated relations
+	Lines []*ReturnAuthorizationLine `json:"lines,omitempty"`
+}
+
+// ReturnAuthorizationLine represents the quantity of a product authorized for return
+type ReturnAuthorizationLine struct {
+	ID                    int          `json:"id"`
+	ReturnAuthorizationID int          `json:"return_authorization_id"`
+	LineNumber            int          `json:"line_number"`
+	ProductID             int          `json:"product_id"`
+	SalesOrderLineID      *int         `json:"sales_order_line_id,omitempty"`
+	ReturnReason          ReturnReason `json:"return_reason"`
+	AuthorizedQuantity    int          `json:"authorized_quantity"` // In base units
+	RestockedQuantity     int          `json:"restocked_quantity"`
+	QuarantinedQuantity   int          `json:"quarantined_quantity"`
+	ScrappedQuantity      int          `json:"scrapped_quantity"`
+
+	// Populated relations
+	Product *Product `json:"product,omitempty"`
+}
+
+// ReceivedQuantity returns the quantity received against the line across all grades
+func (l *ReturnAuthorizationLine) ReceivedQuantity() int {
+	return l.RestockedQuantity + l.QuarantinedQuantity + l.ScrappedQuantity
+}
+
+// CreateReturnAuthorizationLineRequest represents a product authorized for return
+type CreateReturnAuthorizationLineRequest struct {
+	SalesOrderLineID *int         `json:"sales_order_line_id"` // Required when the RMA is against a sales order
+	ProductID        int          `json:"product_id"`          // Required when the RMA is against a reference
+	Quantity         int          `json:"quantity" validate:"required,min=1"`
+	UOM              string       `json:"uom"` // Unit of Quantity, defaults to the base unit
+	ReturnReason     ReturnReason `json:"return_reason" validate:"required"`
+}
+
+// CreateReturnAuthorizationRequest represents the request to authorize a customer return
+type CreateReturnAuthorizationRequest struct {
+	RMANumber    string                                  `json:"rma_number" validate:"required,max=100"`
+	SalesOrderID *int                                    `json:"sales_order_id"` // A shipped sales order, or
+	Reference    string                                  `json:"reference" validate:"max=100"`
+	CustomerName string                                  `json:"customer_name" validate:"max=255"` // Defaults to the customer of the sales order
+	Notes        string                                  `json:"notes"`
+	Lines        []*CreateReturnAuthorizationLineRequest `json:"lines" validate:"required,min=1"`
+}
+
+// ReceiveReturnLineRequest represents returned goods received and graded against one RMA line
+type ReceiveReturnLineRequest struct {
+	LineID        int         `json:"line_id" validate:"required"`
+	Quantity      int         `json:"quantity" validate:"required,min=1"`
+	UOM           string      `json:"uom"` // Unit of Quantity, defaults to the base unit
+	Grade         ReturnGrade `json:"grade" validate:"required"`
+	LocationID    int         `json:"location_id"` // Defaults to the location of the receipt
+	LotNumber     string      `json:"lot_number" validate:"max=50"`
+	ExpiryDate    string      `json:"expiry_date"`    // YYYY-MM-DD
+	SerialNumbers []string    `json:"serial_numbers"` // Required for serialized products, one per base unit
+}
+
+// ReceiveReturnRequest represents returned goods received against an RMA
+type ReceiveReturnRequest struct {
+	LocationID int                         `json:"location_id"`
+	Notes      string                      `json:"notes"`
+	Lines      []*ReceiveReturnLineRequest `json:"lines" validate:"required,min=1"`
+}
+
+// ReturnReceipt represents the outcome of receiving returned goods
+type ReturnReceipt struct {
+	ReturnAuthorization *ReturnAuthorization `json:"return_authorization"`
+	Movements           []*StockMovement     `json:"movements"`
+}
+
+// ReturnAuthorizationFilter represents filters for listing return authorizations
+type ReturnAuthorizationFilter struct {
+	SalesOrderID *int                       `json:"sales_order_id,omitempty"`
+	Status       *ReturnAuthorizationStatus `json:"status,omitempty"`
+	Limit        int                        `json:"limit"`
+	Offset       int                        `json:"offset"`
+}
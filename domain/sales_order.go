@@ -0,0 +1,126 @@
+package domain
+
+import "time"
+
+// SalesOrderStatus represents the state of a sales order
+type SalesOrderStatus string
+
+const (
+	SalesOrderNew       SalesOrderStatus = "NEW"
+	SalesOrderAllocated SalesOrderStatus = "ALLOCATED" // Stock is reserved at the locations to pick from
+	SalesOrderPicked    SalesOrderStatus = "PICKED"
+	SalesOrderPacked    SalesOrderStatus = "PACKED"
+	SalesOrderShipped   SalesOrderStatus = "SHIPPED"
+	SalesOrderCancelled SalesOrderStatus = "CANCELLED"
+)
+
+// SalesOrder represents an outbound customer order
+type SalesOrder struct {
+	ID             int              `json:"id"`
+	SONumber       string           `json:"so_number"`
+	WarehouseID    int              `json:"warehouse_id"` // Stock is allocated from this warehouse only
+	CustomerName   string           `json:"customer_name"`
+	ShipTo         string           `json:"ship_to"`
+	Status         SalesOrderStatus `json:"status"`
+	Priority       int              `json:"priority"` // Higher is more urgent
+	Carrier        string           `json:"carrier"`
+	CarrierCutoff  *time.Time       `json:"carrier_cutoff,omitempty"`
+	TrackingNumber string           `json:"tracking_number"`
+	PackageCount   *int             `json:"package_count,omitempty"`
+	Notes          string           `json:"notes"`
+	WaveID         *int             `json:"wave_id,omitempty"`
+	CreatedBy      int              `json:"created_by"`
+	CreatedAt      time.Time        `json:"created_at"`
+	UpdatedAt      time.Time        `json:"updated_at"`
+	AllocatedAt    *time.Time       `json:"allocated_at,omitempty"`
+	PickedAt       *time.Time       `json:"picked_at,omitempty"`
+	PackedAt       *time.Time       `json:"packed_at,omitempty"`
+	ShippedAt      *time.Time       `json:"shipped_at,omitempty"`
+	CancelledAt    *time.Time       `json:"cancelled_at,omitempty"`
+
+	// Populated relations
+	Lines       []*SalesOrderLine       `json:"lines,omitempty"`
+	Allocations []*SalesOrderAllocation `json:"allocations,omitempty"`
+}
+
+// SalesOrderLine represents the ordered quantity of a product on a sales order
+type SalesOrderLine struct {
+	ID              int `json:"id"`
+	SalesOrderID    int `json:"sales_order_id"`
+	LineNumber      int `json:"line_number"`
+	ProductID       int `json:"product_id"`
+	OrderedQuantity int `json:"ordered_quantity"` // In base units
+	ShippedQuantity int `json:"shipped_quantity"`
+
+	// Populated relations
+	Product *Product `json:"product,omitempty"`
+}
+
+// SalesOrderAllocation represents stock of a line reserved at a location and lot
+type SalesOrderAllocation struct {
+	ID               int       `json:"id"`
+	SalesOrderID     int       `json:"sales_order_id"`
+	SalesOrderLineID int       `json:"sales_order_line_id"`
+	ReservationID    int       `json:"reservation_id"`
+	LocationID       int       `json:"location_id"`
+	LotNumber        string    `json:"lot_number"`
+	Quantity         int       `json:"quantity"`
+	CreatedAt        time.Time `json:"created_at"`
+
+	// Populated relations
+	Location *Location `json:"location,omitempty"`
+}
+
+// CreateSalesOrderLineRequest represents an ordered product
+type CreateSalesOrderLineRequest struct {
+	ProductID int    `json:"product_id" validate:"required"`
+	Quantity  int    `json:"quantity" validate:"required,min=1"`
+	UOM       string `json:"uom"` // Unit of Quantity, defaults to the base unit
+}
+
+// CreateSalesOrderRequest represents the request to create a sales order
+type CreateSalesOrderRequest struct {
+	SONumber      string                         `json:"so_number" validate:"required,max=100"`
+	WarehouseID   *int                           `json:"warehouse_id"` // Defaults to the MAIN warehouse
+	CustomerName  string                         `json:"customer_name" validate:"required,max=255"`
+	ShipTo        string                         `json:"ship_to"`
+	Priority      int                            `json:"priority"` // Higher is more urgent, defaults to 0
+	Carrier       string                         `json:"carrier" validate:"max=100"`
+	CarrierCutoff *time.Time                     `json:"carrier_cutoff"` // Optional, RFC 3339
+	Notes         string                         `json:"notes"`
+	Lines         []*CreateSalesOrderLineRequest `json:"lines" validate:"required,min=1"`
+}
+
+// PackSalesOrderRequest represents the request to mark a picked order as packed
+type PackSalesOrderRequest struct {
+	PackageCount *int `json:"package_count"`
+}
+
+// ShipSalesOrderLineRequest names the serial numbers shipped for a line of a serialized product
+type ShipSalesOrderLineRequest struct {
+	LineID        int      `json:"line_id" validate:"required"`
+	SerialNumbers []string `json:"serial_numbers"`
+}
+
+// ShipSalesOrderRequest represents the request to ship a packed order
+type ShipSalesOrderRequest struct {
+	Carrier        string                       `json:"carrier" validate:"max=100"` // Defaults to the carrier on the order
+	TrackingNumber string                       `json:"tracking_number" validate:"max=100"`
+	Notes          string                       `json:"notes"`
+	Lines          []*ShipSalesOrderLineRequest `json:"lines"` // Only needed for serialized products
+}
+
+// SalesOrderShipment represents the outcome of shipping a sales order
+type SalesOrderShipment struct {
+	SalesOrder *SalesOrder      `json:"sales_order"`
+	Movements  []*StockMovement `json:"movements"`
+}
+
+// SalesOrderFilter represents filters for listing sales orders
+type SalesOrderFilter struct {
+	Status      *SalesOrderStatus `json:"status,omitempty"`
+	WaveID      *int              `json:"wave_id,omitempty"`
+	WarehouseID *int              `json:"warehouse_id,omitempty"`
+	Limit       int               `json:"limit"`
+	Offset      int               `json:"offset"`
+}
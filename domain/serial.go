@@ -0,0 +1,25 @@
+package domain
+
+import "time"
+
+// SerialNumber represents a single unit of a serialized product
+type SerialNumber struct {
+	ID           int             `json:"id"`
+	ProductID    int             `json:"product_id"`
+	SerialNumber string          `json:"serial_number"`
+	LocationID   *int            `json:"location_id"` // Nil when the unit is not on hand
+	LotNumber    string          `json:"lot_number"`
+	Status       InventoryStatus `json:"status"` // Status of the balance the unit is counted in
+	CreatedAt    time.Time       `json:"created_at"`
+	UpdatedAt    time.Time       `json:"updated_at"`
+
+	// Populated relations
+	Product  *Product  `json:"product,omitempty"`
+	Location *Location `json:"location,omitempty"`
+}
+
+// SerialHistory represents a serial number together with every movement that moved it
+type SerialHistory struct {
+	Serial    *SerialNumber    `json:"serial"`
+	Movements []*StockMovement `json:"movements"`
+}
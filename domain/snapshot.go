@@ -0,0 +1,35 @@
+package domain
+
+import "time"
+
+// StockSnapshot represents the on-hand quantities rebuilt from the movement ledger at one point in time
+type StockSnapshot struct {
+	ID        int       `json:"id"`
+	TakenAt   time.Time `json:"taken_at"` // Covers every movement created up to and including this time
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// StockAsOfFilter represents filters for point-in-time stock queries
+type StockAsOfFilter struct {
+	At         time.Time `json:"at"`
+	ProductID  *int      `json:"product_id,omitempty"`
+	LocationID *int      `json:"location_id,omitempty"`
+}
+
+// StockAsOfLine represents the quantity of a product at a location at a point in time
+type StockAsOfLine struct {
+	ProductID    int    `json:"product_id"`
+	SKU          string `json:"sku"`
+	ProductName  string `json:"product_name"`
+	LocationID   int    `json:"location_id"`
+	LocationCode string `json:"location_code"`
+	Quantity     int    `json:"quantity"`
+}
+
+// StockAsOf represents the stock on hand at a point in time
+type StockAsOf struct {
+	At            time.Time        `json:"at"`
+	Snapshot      *StockSnapshot   `json:"snapshot,omitempty"` // Snapshot the ledger was replayed from, if any
+	TotalQuantity int              `json:"total_quantity"`
+	Items         []*StockAsOfLine `json:"items"`
+}
@@ -6,8 +6,58 @@ import "time"
 type StockMovementType string
 
 const (
-	StockIN  StockMovementType = "IN"
-	StockOUT StockMovementType = "OUT"
+	StockIN       StockMovementType = "IN"
+	StockOUT      StockMovementType = "OUT"
+	StockTRANSFER StockMovementType = "TRANSFER"
+	StockADJUST   StockMovementType = "ADJUST"
+	StockREVERSAL StockMovementType = "REVERSAL"
+)
+
+// ReasonCode explains why stock was adjusted
+type ReasonCode string
+
+const (
+	ReasonDamage          ReasonCode = "DAMAGE"
+	ReasonShrinkage       ReasonCode = "SHRINKAGE"
+	ReasonExpired         ReasonCode = "EXPIRED"
+	ReasonFound           ReasonCode = "FOUND"
+	ReasonCountCorrection ReasonCode = "COUNT_CORRECTION"
+	ReasonOther           ReasonCode = "OTHER"
+	// ReasonReconciliation marks ledger corrections written by the reconcile command
+	ReasonReconciliation ReasonCode = "RECONCILIATION"
+	// ReasonScrap writes off returned goods graded as scrap, or stock scrapped by hand
+	ReasonScrap ReasonCode = "SCRAP"
+)
+
+// AdjustmentReasonCodes are the reason codes a manual adjustment may carry; RECONCILIATION is
+// reserved for the reconcile command
+var AdjustmentReasonCodes = []ReasonCode{
+	ReasonDamage, ReasonShrinkage, ReasonExpired, ReasonFound, ReasonCountCorrection, ReasonScrap, ReasonOther,
+}
+
+// IsAdjustmentReason reports whether the reason code may be used on a manual adjustment
+func (r ReasonCode) IsAdjustmentReason() bool {
+	for _, code := range AdjustmentReasonCodes {
+		if r == code {
+			return true
+		}
+	}
+	return false
+}
+
+// IsValid reports whether the reason code is one of the known codes
+func (r ReasonCode) IsValid() bool {
+	switch r {
+	case ReasonDamage, ReasonShrinkage, ReasonExpired, ReasonFound, ReasonCountCorrection, ReasonOther, ReasonReconciliation, ReasonScrap:
+		return true
+	}
+	return false
+}
+
+// Stock directions recorded on every movement
+const (
+	DirectionIncrease = 1  // Quantity is added to the location
+	DirectionDecrease = -1 // Quantity is removed from the location
 )
 
 // StockMovement represents a stock movement transaction
@@ -16,38 +66,228 @@ type StockMovement struct {
 	ProductID  int               `json:"product_id"`
 	LocationID int               `json:"location_id"`
 	UserID     int               `json:"user_id"`
-	Type       StockMovementType `json:"type"`      // IN or OUT
-	Quantity   int               `json:"quantity"`  // Always positive, type determines direction
-	Reference  string            `json:"reference"` // Reference number (PO, SO, etc.)
+	Type       StockMovementType `json:"type"`                  // IN, OUT, TRANSFER, ADJUST or REVERSAL
+	Direction  int               `json:"direction"`             // DirectionIncrease or DirectionDecrease
+	Quantity   int               `json:"quantity"`              // Always positive in base units, direction determines the sign
+	Reference  string            `json:"reference"`             // Reference number (PO, SO, etc.)
+	ReasonCode ReasonCode        `json:"reason_code,omitempty"` // Required for ADJUST movements
 	Notes      string            `json:"notes"`
 	CreatedAt  time.Time         `json:"created_at"`
 
+	// InventoryStatus is the status of the stock added or taken away, AVAILABLE when empty
+	InventoryStatus InventoryStatus `json:"inventory_status"`
+
+	// RelatedMovementID links the two legs of a transfer
+	RelatedMovementID *int `json:"related_movement_id,omitempty"`
+	// ReversalOfID points a REVERSAL movement at the movement it compensates
+	ReversalOfID *int `json:"reversal_of_id,omitempty"`
+
+	// UOM and UOMQuantity record the pack size the movement was requested in, when it was not the base unit
+	UOM         string `json:"uom,omitempty"`
+	UOMQuantity *int   `json:"uom_quantity,omitempty"`
+
+	// ReservationID points an OUT movement at the reservation it fulfils
+	ReservationID *int `json:"reservation_id,omitempty"`
+	// PurchaseOrderLineID points an IN movement at the purchase order line it was received against
+	PurchaseOrderLineID *int `json:"purchase_order_line_id,omitempty"`
+	// SalesOrderLineID points an OUT movement at the sales order line it shipped
+	SalesOrderLineID *int `json:"sales_order_line_id,omitempty"`
+	// ReturnLineID points the movements of a customer return at the return authorization line,
+	// with the reason the goods came back and the grade they were given on receipt
+	ReturnLineID *int         `json:"return_line_id,omitempty"`
+	ReturnReason ReturnReason `json:"return_reason,omitempty"`
+	ReturnGrade  ReturnGrade  `json:"return_grade,omitempty"`
+
+	// Lots moved by this movement; their quantities add up to Quantity
+	Lots []*StockMovementLot `json:"lots,omitempty"`
+
+	// Serial numbers moved by this movement; required for serialized products
+	SerialNumbers []string `json:"serial_numbers,omitempty"`
+
+	// UnitCost is the cost per unit received, or the average cost per unit issued
+	UnitCost *float64 `json:"unit_cost,omitempty"`
+	// TotalCost is the value added to or, for decreases, the cost of goods taken from the location
+	TotalCost *float64 `json:"total_cost,omitempty"`
+	// Costs lists the cost layers added or consumed by this movement
+	Costs []*StockMovementCost `json:"costs,omitempty"`
+
 	// Populated relations
 	Product  *Product  `json:"product,omitempty"`
 	Location *Location `json:"location,omitempty"`
 	User     *User     `json:"user,omitempty"`
 }
 
-// Stock represents current stock level at a location
+// StockMovementLot represents the quantity of a single lot moved by a movement.
+// Stock that is not lot-tracked uses an empty lot number.
+type StockMovementLot struct {
+	LotNumber  string     `json:"lot_number"`
+	ExpiryDate *time.Time `json:"expiry_date,omitempty"`
+	Quantity   int        `json:"quantity"`
+}
+
+// SignedQuantity returns the change the movement makes to the stock at its location
+func (m *StockMovement) SignedQuantity() int {
+	return m.Quantity * m.Direction
+}
+
+// DirectionForType returns the direction implied by IN and OUT movements
+func DirectionForType(movementType StockMovementType) int {
+	if movementType == StockOUT {
+		return DirectionDecrease
+	}
+	return DirectionIncrease
+}
+
+// StockLevel represents current stock level at a location
+type StockLevel struct {
+	ID         int             `json:"id"`
+	ProductID  int             `json:"product_id"`
+	LocationID int             `json:"location_id"`
+	LotNumber  string          `json:"lot_number"`
+	Status     InventoryStatus `json:"status"`
+	ExpiryDate *time.Time      `json:"expiry_date,omitempty"`
+	Quantity   int             `json:"quantity"`
+	CreatedAt  time.Time       `json:"created_at"`
+	UpdatedAt  time.Time       `json:"updated_at"`
+
+	// Populated relations
+	Product  *Product  `json:"product,omitempty"`
+	Location *Location `json:"location,omitempty"`
+}
+
+// StockSummary represents the stock of a product across all locations, or the locations of one warehouse
+type StockSummary struct {
+	Product           *Product             `json:"product"`
+	TotalQuantity     int                  `json:"total_quantity"` // On-hand
+	HeldQuantity      int                  `json:"held_quantity"`  // On-hand in quarantine, damaged or on hold
+	ReservedQuantity  int                  `json:"reserved_quantity"`
+	AvailableQuantity int                  `json:"available_quantity"`
+	Locations         []*StockLevel        `json:"locations"`
+	Availability      []*StockAvailability `json:"availability"` // Per location
+	Warehouses        []*WarehouseStock    `json:"warehouses"`   // Per warehouse
+	Units             []*UnitQuantity      `json:"units"`        // Totals in the base unit and each pack size
+}
+
+// LocationStock represents the products physically stored at a location
+type LocationStock struct {
+	Location          *Location            `json:"location"`
+	TotalQuantity     int                  `json:"total_quantity"` // On-hand
+	HeldQuantity      int                  `json:"held_quantity"`  // On-hand in quarantine, damaged or on hold
+	ReservedQuantity  int                  `json:"reserved_quantity"`
+	AvailableQuantity int                  `json:"available_quantity"`
+	RemainingCapacity int                  `json:"remaining_capacity"`         // Units
+	RemainingWeight   *float64             `json:"remaining_weight,omitempty"` // kg, when the location has a weight limit
+	RemainingVolume   *float64             `json:"remaining_volume,omitempty"` // m³, when the location has a volume limit
+	Items             []*StockLevel        `json:"items"`
+	Availability      []*StockAvailability `json:"availability"` // Per product
+}
 
 // CreateStockMovementRequest represents the request to create a stock movement
 type CreateStockMovementRequest struct {
-	ProductID  int               `json:"product_id" validate:"required"`
-	LocationID int               `json:"location_id" validate:"required"`
-	Type       StockMovementType `json:"type" validate:"required,oneof=IN OUT"`
-	Quantity   int               `json:"quantity" validate:"required,min=1"`
-	Reference  string            `json:"reference" validate:"max=100"`
-	Notes      string            `json:"notes"`
+	ProductID     int               `json:"product_id" validate:"required"`
+	LocationID    int               `json:"location_id" validate:"required"`
+	Type          StockMovementType `json:"type" validate:"required,oneof=IN OUT"`
+	Quantity      int               `json:"quantity" validate:"required,min=1"`
+	UOM           string            `json:"uom"` // Unit of Quantity and UnitCost, defaults to the base unit
+	Reference     string            `json:"reference" validate:"max=100"`
+	Notes         string            `json:"notes"`
+	LotNumber     string            `json:"lot_number" validate:"max=50"` // IN: lot received; OUT: lot to pick, FEFO when empty
+	ExpiryDate    string            `json:"expiry_date"`                  // YYYY-MM-DD, IN only
+	SerialNumbers []string          `json:"serial_numbers"`               // Required for serialized products, one per base unit
+	UnitCost      *float64          `json:"unit_cost"`                    // IN only, defaults to the current average cost
+	// InventoryStatus of the stock received or taken, defaults to AVAILABLE. Taking stock of any
+	// other status OUT needs the OVERRIDE_INVENTORY_STATUS permission.
+	InventoryStatus InventoryStatus `json:"inventory_status"`
+}
+
+// CreateStockTransferRequest represents the request to move stock between two locations
+type CreateStockTransferRequest struct {
+	ProductID      int      `json:"product_id" validate:"required"`
+	FromLocationID int      `json:"from_location_id" validate:"required"`
+	ToLocationID   int      `json:"to_location_id" validate:"required,nefield=FromLocationID"`
+	Quantity       int      `json:"quantity" validate:"required,min=1"`
+	UOM            string   `json:"uom"` // Unit of Quantity, defaults to the base unit
+	Reference      string   `json:"reference" validate:"max=100"`
+	Notes          string   `json:"notes"`
+	LotNumber      string   `json:"lot_number" validate:"max=50"` // Lot to move, FEFO when empty
+	SerialNumbers  []string `json:"serial_numbers"`               // Required for serialized products, one per base unit
+	// InventoryStatus of the stock moved, defaults to AVAILABLE; the stock keeps its status at the destination
+	InventoryStatus InventoryStatus `json:"inventory_status"`
+}
+
+// CreateStockAdjustmentRequest represents the request to correct stock at a location
+type CreateStockAdjustmentRequest struct {
+	ProductID     int        `json:"product_id" validate:"required"`
+	LocationID    int        `json:"location_id" validate:"required"`
+	Quantity      int        `json:"quantity" validate:"required,ne=0"` // Positive adds stock, negative removes it
+	UOM           string     `json:"uom"`                               // Unit of Quantity, defaults to the base unit
+	ReasonCode    ReasonCode `json:"reason_code" validate:"required"`
+	Reference     string     `json:"reference" validate:"max=100"`
+	Notes         string     `json:"notes"`
+	LotNumber     string     `json:"lot_number" validate:"max=50"` // Lot to adjust, FEFO when empty on decreases
+	ExpiryDate    string     `json:"expiry_date"`                  // YYYY-MM-DD, increases only
+	SerialNumbers []string   `json:"serial_numbers"`               // Required for serialized products, one per base unit
+	// InventoryStatus of the stock adjusted, defaults to AVAILABLE
+	InventoryStatus InventoryStatus `json:"inventory_status"`
+}
+
+// ReverseStockMovementRequest represents the request to reverse a posted movement
+type ReverseStockMovementRequest struct {
+	Notes string `json:"notes"`
+}
+
+// StockTransfer represents the paired movements written by a transfer
+type StockTransfer struct {
+	Outbound *StockMovement `json:"outbound"` // Leg leaving the source location
+	Inbound  *StockMovement `json:"inbound"`  // Leg arriving at the destination location
+}
+
+// BatchMode controls how a stock movement batch handles failing lines
+type BatchMode string
+
+const (
+	BatchAllOrNothing BatchMode = "ALL_OR_NOTHING" // Any failing line rolls back the whole batch
+	BatchBestEffort   BatchMode = "BEST_EFFORT"    // Every line is posted on its own
+)
+
+// CreateStockMovementBatchRequest represents the request to post many stock movements at once
+type CreateStockMovementBatchRequest struct {
+	Mode      BatchMode                    `json:"mode"` // Defaults to ALL_OR_NOTHING
+	Movements []CreateStockMovementRequest `json:"movements" validate:"required,min=1"`
+}
+
+// StockMovementBatchLine represents the outcome of one line of a batch
+type StockMovementBatchLine struct {
+	Line     int            `json:"line"` // 1-based position in the request
+	Success  bool           `json:"success"`
+	Movement *StockMovement `json:"movement,omitempty"`
+	Error    string         `json:"error,omitempty"`
+	Err      error          `json:"-"`
+}
+
+// StockMovementBatchResult represents the outcome of a batch
+type StockMovementBatchResult struct {
+	Mode      BatchMode                 `json:"mode"`
+	Succeeded int                       `json:"succeeded"`
+	Failed    int                       `json:"failed"`
+	Lines     []*StockMovementBatchLine `json:"lines"`
 }
 
 // StockMovementFilter represents filters for stock movement queries
 type StockMovementFilter struct {
-	ProductID  *int               `json:"product_id,omitempty"`
-	LocationID *int               `json:"location_id,omitempty"`
-	UserID     *int               `json:"user_id,omitempty"`
-	Type       *StockMovementType `json:"type,omitempty"`
-	DateFrom   *time.Time         `json:"date_from,omitempty"`
-	DateTo     *time.Time         `json:"date_to,omitempty"`
-	Limit      int                `json:"limit"`
-	Offset     int                `json:"offset"`
+	ProductID             *int               `json:"product_id,omitempty"`
+	LocationID            *int               `json:"location_id,omitempty"`
+	WarehouseID           *int               `json:"warehouse_id,omitempty"`
+	UserID                *int               `json:"user_id,omitempty"`
+	Type                  *StockMovementType `json:"type,omitempty"`
+	ReasonCode            *ReasonCode        `json:"reason_code,omitempty"`
+	LotNumber             *string            `json:"lot_number,omitempty"`
+	SerialNumber          *string            `json:"serial_number,omitempty"`
+	PurchaseOrderID       *int               `json:"purchase_order_id,omitempty"`
+	SalesOrderID          *int               `json:"sales_order_id,omitempty"`
+	ReturnAuthorizationID *int               `json:"return_authorization_id,omitempty"`
+	DateFrom              *time.Time         `json:"date_from,omitempty"`
+	DateTo                *time.Time         `json:"date_to,omitempty"`
+	Limit                 int                `json:"limit"`
+	Offset                int                `json:"offset"`
 }
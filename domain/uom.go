@@ -0,0 +1,45 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultBaseUnit is the base unit of products that do not name one
+const DefaultBaseUnit = "EA"
+
+// ProductUnit represents a pack size of a product, such as a case or a pallet
+type ProductUnit struct {
+	Code   string `json:"code"`
+	Factor int    `json:"factor"` // Base units in one of this unit
+}
+
+// UnitQuantity represents the stock of a product expressed in one of its units
+type UnitQuantity struct {
+	UOM               string  `json:"uom"`
+	Factor            int     `json:"factor"`
+	TotalQuantity     float64 `json:"total_quantity"` // On-hand
+	HeldQuantity      float64 `json:"held_quantity"`
+	ReservedQuantity  float64 `json:"reserved_quantity"`
+	AvailableQuantity float64 `json:"available_quantity"`
+}
+
+// NormalizeUOM returns the canonical form of a unit code
+func NormalizeUOM(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// UnitFactor returns the number of base units in one of the given unit. An empty code means the
+// base unit. Units must be populated.
+func (p *Product) UnitFactor(code string) (int, error) {
+	code = NormalizeUOM(code)
+	if code == "" || code == p.BaseUnit {
+		return 1, nil
+	}
+	for _, unit := range p.Units {
+		if unit.Code == code {
+			return unit.Factor, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: unit %q is not defined for product %s", ErrInvalidInput, code, p.SKU)
+}
@@ -4,14 +4,35 @@ import "time"
 
 // User represents a user in the system
 type User struct {
-	ID        int       `json:"id"`
-	Username  string    `json:"username"`
-	Email     string    `json:"email"`
-	Password  string    `json:"-"` // Hidden from JSON responses
-	APIKey    string    `json:"api_key,omitempty"`
-	IsActive  bool      `json:"is_active"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID       int    `json:"id"`
+	Username string `json:"username"`
+	Email    string `json:"email"`
+	Password string `json:"-"` // Hidden from JSON responses
+	APIKey   string `json:"api_key,omitempty"`
+	IsActive bool   `json:"is_active"`
+	// Permissions granted on top of the access every authenticated user has
+	Permissions []string  `json:"permissions"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Permission is a right that has to be granted to a user explicitly
+type Permission string
+
+const (
+	// PermissionOverrideInventoryStatus allows OUT movements to take stock that is not available,
+	// and status changes to release it
+	PermissionOverrideInventoryStatus Permission = "OVERRIDE_INVENTORY_STATUS"
+)
+
+// HasPermission reports whether the user has been granted the permission
+func (u *User) HasPermission(permission Permission) bool {
+	for _, granted := range u.Permissions {
+		if Permission(granted) == permission {
+			return true
+		}
+	}
+	return false
 }
 
 // CreateUserRequest represents the request to create a new user
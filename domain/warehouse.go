@@ -0,0 +1,61 @@
+package domain
+
+import "time"
+
+// DefaultWarehouseCode is the warehouse that existing locations were moved into, used when a
+// request does not name a warehouse
+const DefaultWarehouseCode = "MAIN"
+
+// Warehouse represents a building that holds storage locations
+type Warehouse struct {
+	ID        int       `json:"id"`
+	Code      string    `json:"code"` // e.g., "JKT-01"
+	Name      string    `json:"name"`
+	Address   string    `json:"address"`
+	IsActive  bool      `json:"is_active"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// CreateWarehouseRequest represents the request to create a new warehouse
+type CreateWarehouseRequest struct {
+	Code    string `json:"code" validate:"required,max=20"`
+	Name    string `json:"name" validate:"required,max=255"`
+	Address string `json:"address"`
+}
+
+// UpdateWarehouseRequest represents the request to update a warehouse
+type UpdateWarehouseRequest struct {
+	Name     *string `json:"name,omitempty" validate:"omitempty,max=255"`
+	Address  *string `json:"address,omitempty"`
+	IsActive *bool   `json:"is_active,omitempty"` // A warehouse with active locations cannot be deactivated
+}
+
+// WarehouseStock represents the stock totals of a product in one warehouse
+type WarehouseStock struct {
+	WarehouseID       int    `json:"warehouse_id"`
+	WarehouseCode     string `json:"warehouse_code"`
+	TotalQuantity     int    `json:"total_quantity"` // On-hand
+	HeldQuantity      int    `json:"held_quantity"`  // On-hand in quarantine, damaged or on hold
+	ReservedQuantity  int    `json:"reserved_quantity"`
+	AvailableQuantity int    `json:"available_quantity"`
+}
+
+// WarehouseProductStock represents the stock totals of one product across a warehouse's locations
+type WarehouseProductStock struct {
+	Product           *Product `json:"product"`
+	TotalQuantity     int      `json:"total_quantity"` // On-hand
+	HeldQuantity      int      `json:"held_quantity"`
+	ReservedQuantity  int      `json:"reserved_quantity"`
+	AvailableQuantity int      `json:"available_quantity"`
+}
+
+// WarehouseStockSummary represents the stock held in a warehouse, in total and per product
+type WarehouseStockSummary struct {
+	Warehouse         *Warehouse               `json:"warehouse"`
+	TotalQuantity     int                      `json:"total_quantity"` // On-hand
+	HeldQuantity      int                      `json:"held_quantity"`
+	ReservedQuantity  int                      `json:"reserved_quantity"`
+	AvailableQuantity int                      `json:"available_quantity"`
+	Products          []*WarehouseProductStock `json:"products"`
+}
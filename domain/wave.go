@@ -0,0 +1,80 @@
+package domain
+
+import "time"
+
+// WaveStatus represents the state of a wave
+type WaveStatus string
+
+const (
+	WavePlanned  WaveStatus = "PLANNED"  // Orders are allocated, not yet on the floor
+	WaveReleased WaveStatus = "RELEASED" // Handed to pickers
+	WaveClosed   WaveStatus = "CLOSED"
+)
+
+// WaveGrouping is the order attribute a wave is built around
+type WaveGrouping string
+
+const (
+	WaveByCarrierCutoff WaveGrouping = "CARRIER_CUTOFF" // Same carrier and cutoff time
+	WaveByZone          WaveGrouping = "ZONE"           // Same zone holding most of the order's units
+	WaveByPriority      WaveGrouping = "PRIORITY"       // Same priority
+)
+
+// IsValid reports whether g is a known grouping
+func (g WaveGrouping) IsValid() bool {
+	switch g {
+	case WaveByCarrierCutoff, WaveByZone, WaveByPriority:
+		return true
+	}
+	return false
+}
+
+// Wave represents a group of sales orders picked together
+type Wave struct {
+	ID          int          `json:"id"`
+	WarehouseID int          `json:"warehouse_id"`
+	Status      WaveStatus   `json:"status"`
+	GroupBy     WaveGrouping `json:"group_by"`
+	GroupKey    string       `json:"group_key"` // e.g., "JNE 2024-01-15 16:00", "A" or "5"
+	Notes       string       `json:"notes"`
+	CreatedBy   int          `json:"created_by"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+	ReleasedAt  *time.Time   `json:"released_at,omitempty"`
+	ClosedAt    *time.Time   `json:"closed_at,omitempty"`
+
+	// Populated relations
+	Orders []*SalesOrder `json:"orders,omitempty"`
+}
+
+// BuildWavesRequest represents the request to group new sales orders into waves
+type BuildWavesRequest struct {
+	GroupBy      WaveGrouping `json:"group_by" validate:"required"`
+	WarehouseID  *int         `json:"warehouse_id"`  // Only orders shipping from this warehouse
+	Carrier      string       `json:"carrier"`       // Only orders for this carrier
+	CutoffBefore *time.Time   `json:"cutoff_before"` // Only orders with a carrier cutoff at or before this time, RFC 3339
+	MinPriority  *int         `json:"min_priority"`  // Only orders of at least this priority
+	MaxOrders    int          `json:"max_orders"`    // Per wave, defaults to 20
+	Notes        string       `json:"notes"`
+}
+
+// WaveSkippedOrder represents an order that could not be added to a wave
+type WaveSkippedOrder struct {
+	SalesOrderID int    `json:"sales_order_id"`
+	SONumber     string `json:"so_number"`
+	Reason       string `json:"reason"`
+}
+
+// WaveBuild represents the outcome of building waves
+type WaveBuild struct {
+	Waves   []*Wave             `json:"waves"`
+	Skipped []*WaveSkippedOrder `json:"skipped"` // Orders that could not be allocated in full
+}
+
+// WaveFilter represents filters for listing waves
+type WaveFilter struct {
+	Status      *WaveStatus `json:"status,omitempty"`
+	WarehouseID *int        `json:"warehouse_id,omitempty"`
+	Limit       int         `json:"limit"`
+	Offset      int         `json:"offset"`
+}
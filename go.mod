@@ -3,7 +3,6 @@ module github.com/edwinjordan/wmsTest_Golang
 go 1.25.0
 
 require (
-	github.com/edwinjordan/wmsTest_Golang v0.0.0-20250928023913-c1606335290f
 	github.com/golang-jwt/jwt/v5 v5.3.0
 	github.com/google/uuid v1.6.0
 	github.com/gorilla/mux v1.8.1
@@ -0,0 +1,250 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/edwinjordan/wmsTest_Golang/domain"
+	"github.com/edwinjordan/wmsTest_Golang/middleware"
+	"github.com/edwinjordan/wmsTest_Golang/service"
+	"github.com/gorilla/mux"
+)
+
+type CycleCountHandler struct {
+	cycleCountService service.CycleCountService
+}
+
+func NewCycleCountHandler(cycleCountService service.CycleCountService) *CycleCountHandler {
+	return &CycleCountHandler{
+		cycleCountService: cycleCountService,
+	}
+}
+
+func (h *CycleCountHandler) OpenCycleCount(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		h.respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	var req domain.CreateCycleCountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	// Basic validation
+	if len(req.LocationIDs) == 0 {
+		h.respondWithError(w, http.StatusBadRequest, "At least one location ID is required")
+		return
+	}
+
+	count, err := h.cycleCountService.OpenCycleCount(r.Context(), &req, user.ID)
+	if err != nil {
+		h.respondWithCycleCountError(w, err, "Failed to open cycle count")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusCreated, count)
+}
+
+func (h *CycleCountHandler) ListCycleCounts(w http.ResponseWriter, r *http.Request) {
+	// Parse query parameters
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+
+	if limit <= 0 {
+		limit = 20
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	var status *domain.CycleCountStatus
+	if statusParam := r.URL.Query().Get("status"); statusParam != "" {
+		statusVal := domain.CycleCountStatus(statusParam)
+		status = &statusVal
+	}
+
+	counts, total, err := h.cycleCountService.ListCycleCounts(r.Context(), status, limit, offset)
+	if err != nil {
+		h.respondWithError(w, http.StatusInternalServerError, "Failed to list cycle counts")
+		return
+	}
+
+	// Calculate pagination
+	totalPages := (total + limit - 1) / limit
+	page := (offset / limit) + 1
+
+	response := map[string]interface{}{
+		"cycle_counts": counts,
+		"meta": domain.Meta{
+			Page:       page,
+			Limit:      limit,
+			Total:      total,
+			TotalPages: totalPages,
+		},
+	}
+
+	h.respondWithJSON(w, http.StatusOK, response)
+}
+
+// GetCycleCount returns the count with its lines, so the variances can be reviewed before posting
+func (h *CycleCountHandler) GetCycleCount(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.parseID(w, r)
+	if !ok {
+		return
+	}
+
+	count, err := h.cycleCountService.GetCycleCount(r.Context(), id)
+	if err != nil {
+		h.respondWithCycleCountError(w, err, "Failed to get cycle count")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, count)
+}
+
+func (h *CycleCountHandler) SubmitCounts(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		h.respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	id, ok := h.parseID(w, r)
+	if !ok {
+		return
+	}
+
+	var req domain.SubmitCycleCountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	// Basic validation
+	if len(req.Lines) == 0 {
+		h.respondWithError(w, http.StatusBadRequest, "At least one counted line is required")
+		return
+	}
+	for _, line := range req.Lines {
+		if line.ProductID <= 0 || line.LocationID <= 0 {
+			h.respondWithError(w, http.StatusBadRequest, "Valid product and location IDs are required")
+			return
+		}
+		if line.CountedQuantity < 0 {
+			h.respondWithError(w, http.StatusBadRequest, "Counted quantity must not be negative")
+			return
+		}
+	}
+
+	count, err := h.cycleCountService.SubmitCounts(r.Context(), id, &req, user.ID)
+	if err != nil {
+		h.respondWithCycleCountError(w, err, "Failed to submit counts")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, count)
+}
+
+func (h *CycleCountHandler) PostCycleCount(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		h.respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	id, ok := h.parseID(w, r)
+	if !ok {
+		return
+	}
+
+	count, err := h.cycleCountService.PostCycleCount(r.Context(), id, user.ID)
+	if err != nil {
+		h.respondWithCycleCountError(w, err, "Failed to post cycle count")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, count)
+}
+
+func (h *CycleCountHandler) CancelCycleCount(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.parseID(w, r)
+	if !ok {
+		return
+	}
+
+	count, err := h.cycleCountService.CancelCycleCount(r.Context(), id)
+	if err != nil {
+		h.respondWithCycleCountError(w, err, "Failed to cancel cycle count")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, count)
+}
+
+func (h *CycleCountHandler) parseID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid cycle count ID")
+		return 0, false
+	}
+	return id, true
+}
+
+func (h *CycleCountHandler) respondWithCycleCountError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		h.respondWithError(w, http.StatusNotFound, "Cycle count, product or location not found")
+	case errors.Is(err, domain.ErrInvalidInput):
+		h.respondWithError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, domain.ErrInvalidStatus):
+		h.respondWithError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, domain.ErrInsufficientStock):
+		h.respondWithError(w, http.StatusConflict, "Insufficient stock for this operation")
+	default:
+		h.respondWithError(w, http.StatusInternalServerError, fallback)
+	}
+}
+
+func (h *CycleCountHandler) respondWithError(w http.ResponseWriter, code int, message string) {
+	response := domain.APIResponse{
+		Success: false,
+		Error: &domain.APIError{
+			Code:    code,
+			Message: message,
+		},
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *CycleCountHandler) respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
+	response := domain.APIResponse{
+		Success: true,
+		Data:    payload,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(response)
+}
+
+// SetupRoutes sets up cycle count routes
+func (h *CycleCountHandler) SetupRoutes(router *mux.Router, authMiddleware *middleware.AuthMiddleware) {
+	counts := router.PathPrefix("/cycle-counts").Subrouter()
+	counts.Use(authMiddleware.FlexibleAuth) // All cycle count endpoints require authentication
+
+	counts.HandleFunc("", h.OpenCycleCount).Methods("POST")
+	counts.HandleFunc("", h.ListCycleCounts).Methods("GET")
+	counts.HandleFunc("/{id:[0-9]+}", h.GetCycleCount).Methods("GET")
+	counts.HandleFunc("/{id:[0-9]+}/counts", h.SubmitCounts).Methods("POST")
+	counts.HandleFunc("/{id:[0-9]+}/post", h.PostCycleCount).Methods("POST")
+	counts.HandleFunc("/{id:[0-9]+}/cancel", h.CancelCycleCount).Methods("POST")
+}
@@ -0,0 +1,166 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/edwinjordan/wmsTest_Golang/domain"
+	"github.com/edwinjordan/wmsTest_Golang/middleware"
+	"github.com/edwinjordan/wmsTest_Golang/service"
+	"github.com/gorilla/mux"
+)
+
+type InventoryStatusHandler struct {
+	inventoryStatusService service.InventoryStatusService
+}
+
+func NewInventoryStatusHandler(inventoryStatusService service.InventoryStatusService) *InventoryStatusHandler {
+	return &InventoryStatusHandler{
+		inventoryStatusService: inventoryStatusService,
+	}
+}
+
+// ChangeInventoryStatus puts stock of a product, location or lot on hold, or releases it
+func (h *InventoryStatusHandler) ChangeInventoryStatus(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		h.respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	var req domain.ChangeInventoryStatusRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	// Basic validation
+	if req.ProductID == nil && req.LocationID == nil {
+		h.respondWithError(w, http.StatusBadRequest, "Product ID or location ID is required")
+		return
+	}
+	if req.ToStatus == "" {
+		h.respondWithError(w, http.StatusBadRequest, "To status is required")
+		return
+	}
+
+	result, err := h.inventoryStatusService.ChangeInventoryStatus(r.Context(), &req, user.ID)
+	if err != nil {
+		h.respondWithInventoryStatusError(w, err, "Failed to change inventory status")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusCreated, result)
+}
+
+func (h *InventoryStatusHandler) ListStatusChanges(w http.ResponseWriter, r *http.Request) {
+	// Parse query parameters
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+
+	if limit <= 0 {
+		limit = 20
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	filter := &domain.InventoryStatusChangeFilter{
+		Limit:  limit,
+		Offset: offset,
+	}
+
+	if productID := r.URL.Query().Get("product_id"); productID != "" {
+		if id, err := strconv.Atoi(productID); err == nil {
+			filter.ProductID = &id
+		}
+	}
+
+	if locationID := r.URL.Query().Get("location_id"); locationID != "" {
+		if id, err := strconv.Atoi(locationID); err == nil {
+			filter.LocationID = &id
+		}
+	}
+
+	if lotNumber := r.URL.Query().Get("lot_number"); lotNumber != "" {
+		filter.LotNumber = &lotNumber
+	}
+
+	if status := r.URL.Query().Get("status"); status != "" {
+		statusVal := domain.InventoryStatus(status)
+		filter.Status = &statusVal
+	}
+
+	changes, total, err := h.inventoryStatusService.ListStatusChanges(r.Context(), filter)
+	if err != nil {
+		h.respondWithError(w, http.StatusInternalServerError, "Failed to list inventory status changes")
+		return
+	}
+
+	// Calculate pagination
+	totalPages := (total + limit - 1) / limit
+	page := (offset / limit) + 1
+
+	response := map[string]interface{}{
+		"status_changes": changes,
+		"meta": domain.Meta{
+			Page:       page,
+			Limit:      limit,
+			Total:      total,
+			TotalPages: totalPages,
+		},
+	}
+
+	h.respondWithJSON(w, http.StatusOK, response)
+}
+
+func (h *InventoryStatusHandler) respondWithInventoryStatusError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		h.respondWithError(w, http.StatusNotFound, "Product or location not found")
+	case errors.Is(err, domain.ErrInsufficientStock):
+		h.respondWithError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, domain.ErrInvalidInput):
+		h.respondWithError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, domain.ErrForbidden):
+		h.respondWithError(w, http.StatusForbidden, err.Error())
+	default:
+		h.respondWithError(w, http.StatusInternalServerError, fallback)
+	}
+}
+
+func (h *InventoryStatusHandler) respondWithError(w http.ResponseWriter, code int, message string) {
+	response := domain.APIResponse{
+		Success: false,
+		Error: &domain.APIError{
+			Code:    code,
+			Message: message,
+		},
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *InventoryStatusHandler) respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
+	response := domain.APIResponse{
+		Success: true,
+		Data:    payload,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(response)
+}
+
+// SetupRoutes sets up inventory status routes
+func (h *InventoryStatusHandler) SetupRoutes(router *mux.Router, authMiddleware *middleware.AuthMiddleware) {
+	statuses := router.PathPrefix("/inventory-status").Subrouter()
+	statuses.Use(authMiddleware.FlexibleAuth) // All inventory status endpoints require authentication
+
+	statuses.HandleFunc("/changes", h.ChangeInventoryStatus).Methods("POST")
+	statuses.HandleFunc("/changes", h.ListStatusChanges).Methods("GET")
+}
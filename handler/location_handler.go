@@ -2,6 +2,7 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -29,6 +30,10 @@ func (h *LocationHandler) CreateLocation(w http.ResponseWriter, r *http.Request)
 	}
 
 	// Basic validation
+	if req.WarehouseID != nil && *req.WarehouseID <= 0 {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid warehouse ID")
+		return
+	}
 	if req.Code == "" || req.Name == "" || req.Zone == "" || req.Aisle == "" || req.Rack == "" || req.Shelf == "" {
 		h.respondWithError(w, http.StatusBadRequest, "Code, name, zone, aisle, rack, and shelf are required")
 		return
@@ -39,10 +44,23 @@ func (h *LocationHandler) CreateLocation(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	if (req.MaxWeight != nil && *req.MaxWeight <= 0) || (req.MaxVolume != nil && *req.MaxVolume <= 0) {
+		h.respondWithError(w, http.StatusBadRequest, "Max weight and max volume must be greater than 0")
+		return
+	}
+
 	location, err := h.locationService.CreateLocation(r.Context(), &req)
 	if err != nil {
 		if err == domain.ErrDuplicateEntry {
-			h.respondWithError(w, http.StatusConflict, "Location with this code already exists")
+			h.respondWithError(w, http.StatusConflict, "Location with this code already exists in the warehouse")
+			return
+		}
+		if errors.Is(err, domain.ErrNotFound) {
+			h.respondWithError(w, http.StatusNotFound, "Warehouse not found")
+			return
+		}
+		if errors.Is(err, domain.ErrInvalidInput) {
+			h.respondWithError(w, http.StatusBadRequest, err.Error())
 			return
 		}
 		h.respondWithError(w, http.StatusInternalServerError, "Failed to create location")
@@ -81,12 +99,27 @@ func (h *LocationHandler) GetLocationByCode(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	location, err := h.locationService.GetLocationByCode(r.Context(), code)
+	// The warehouse is only needed when several warehouses use the code
+	var warehouseID *int
+	if warehouse := r.URL.Query().Get("warehouse_id"); warehouse != "" {
+		id, err := strconv.Atoi(warehouse)
+		if err != nil {
+			h.respondWithError(w, http.StatusBadRequest, "Invalid warehouse ID")
+			return
+		}
+		warehouseID = &id
+	}
+
+	location, err := h.locationService.GetLocationByCode(r.Context(), warehouseID, code)
 	if err != nil {
-		if err == domain.ErrNotFound {
+		if errors.Is(err, domain.ErrNotFound) {
 			h.respondWithError(w, http.StatusNotFound, "Location not found")
 			return
 		}
+		if errors.Is(err, domain.ErrInvalidInput) {
+			h.respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
 		h.respondWithError(w, http.StatusInternalServerError, "Failed to get location")
 		return
 	}
@@ -108,6 +141,11 @@ func (h *LocationHandler) UpdateLocation(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	if (req.MaxWeight != nil && *req.MaxWeight <= 0) || (req.MaxVolume != nil && *req.MaxVolume <= 0) {
+		h.respondWithError(w, http.StatusBadRequest, "Max weight and max volume must be greater than 0")
+		return
+	}
+
 	location, err := h.locationService.UpdateLocation(r.Context(), id, &req)
 	if err != nil {
 		if err == domain.ErrNotFound {
@@ -115,7 +153,7 @@ func (h *LocationHandler) UpdateLocation(w http.ResponseWriter, r *http.Request)
 			return
 		}
 		if err == domain.ErrDuplicateEntry {
-			h.respondWithError(w, http.StatusConflict, "Location with this code already exists")
+			h.respondWithError(w, http.StatusConflict, "Location with this code already exists in the warehouse")
 			return
 		}
 		h.respondWithError(w, http.StatusInternalServerError, "Failed to update location")
@@ -159,14 +197,21 @@ func (h *LocationHandler) ListLocations(w http.ResponseWriter, r *http.Request)
 		offset = 0
 	}
 
+	var warehouseID *int
+	if warehouse := r.URL.Query().Get("warehouse_id"); warehouse != "" {
+		if id, err := strconv.Atoi(warehouse); err == nil {
+			warehouseID = &id
+		}
+	}
+
 	var locations []*domain.Location
 	var total int
 	var err error
 
 	if zone != "" {
-		locations, total, err = h.locationService.ListLocationsByZone(r.Context(), zone, limit, offset)
+		locations, total, err = h.locationService.ListLocationsByZone(r.Context(), warehouseID, zone, limit, offset)
 	} else {
-		locations, total, err = h.locationService.ListLocations(r.Context(), limit, offset)
+		locations, total, err = h.locationService.ListLocations(r.Context(), warehouseID, limit, offset)
 	}
 
 	if err != nil {
@@ -0,0 +1,152 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/edwinjordan/wmsTest_Golang/domain"
+	"github.com/edwinjordan/wmsTest_Golang/middleware"
+	"github.com/edwinjordan/wmsTest_Golang/service"
+	"github.com/gorilla/mux"
+)
+
+type PickListHandler struct {
+	pickListService service.PickListService
+}
+
+func NewPickListHandler(pickListService service.PickListService) *PickListHandler {
+	return &PickListHandler{
+		pickListService: pickListService,
+	}
+}
+
+// GeneratePickList allocates the lines in the body and returns the stops in walking order, as JSON
+// or, with ?format=text, as a printable document
+func (h *PickListHandler) GeneratePickList(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "json" && format != "text" {
+		h.respondWithError(w, http.StatusBadRequest, "Format must be json or text")
+		return
+	}
+
+	var req domain.GeneratePickListRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	// Basic validation
+	if len(req.Lines) == 0 {
+		h.respondWithError(w, http.StatusBadRequest, "At least one line is required")
+		return
+	}
+	if req.WarehouseID != nil && *req.WarehouseID <= 0 {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid warehouse ID")
+		return
+	}
+
+	pickList, err := h.pickListService.GeneratePickList(r.Context(), &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrNotFound):
+			h.respondWithError(w, http.StatusNotFound, "Product or warehouse not found")
+		case errors.Is(err, domain.ErrInvalidInput):
+			h.respondWithError(w, http.StatusBadRequest, err.Error())
+		default:
+			h.respondWithError(w, http.StatusInternalServerError, "Failed to generate pick list")
+		}
+		return
+	}
+
+	if format == "text" {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		writePickList(w, pickList)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, pickList)
+}
+
+// writePickList renders a pick list as a plain text document with a check box per stop
+func writePickList(out io.Writer, pickList *domain.PickList) {
+	fmt.Fprintln(out, "PICK LIST")
+	if pickList.Reference != "" {
+		fmt.Fprintf(out, "Reference: %s\n", pickList.Reference)
+	}
+	fmt.Fprintf(out, "Generated: %s\n", pickList.GeneratedAt.Format("2006-01-02 15:04"))
+	fmt.Fprintf(out, "Stops: %d  Units: %d\n\n", len(pickList.Stops), pickList.TotalQuantity)
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "#\tLOCATION\tZONE\tAISLE\tRACK\tSHELF\tSKU\tPRODUCT\tLOT\tEXPIRY\tQTY\tUNIT\tLINE\tPICKED")
+	for _, stop := range pickList.Stops {
+		expiry := ""
+		if stop.ExpiryDate != nil {
+			expiry = stop.ExpiryDate.Format("2006-01-02")
+		}
+		// Consolidated stops list the order lines they are picked for
+		line := strconv.Itoa(stop.LineNumber)
+		if len(stop.Orders) > 0 {
+			parts := make([]string, len(stop.Orders))
+			for i, order := range stop.Orders {
+				parts[i] = fmt.Sprintf("%s/%d x%d", order.SONumber, order.LineNumber, order.Quantity)
+			}
+			line = strings.Join(parts, ", ")
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%d\t%s\t%s\t[ ]\n",
+			stop.Sequence, stop.Location.Code, stop.Location.Zone, stop.Location.Aisle, stop.Location.Rack, stop.Location.Shelf,
+			stop.SKU, stop.ProductName, stop.LotNumber, expiry, stop.Quantity, stop.Unit, line)
+	}
+	w.Flush()
+
+	if len(pickList.Shortages) > 0 {
+		fmt.Fprintf(out, "\nShortages: %d\n", len(pickList.Shortages))
+		w = tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "LINE\tSKU\tREQUESTED\tALLOCATED\tSHORT")
+		for _, shortage := range pickList.Shortages {
+			fmt.Fprintf(w, "%d\t%s\t%d\t%d\t%d\n", shortage.LineNumber, shortage.SKU, shortage.RequestedQuantity, shortage.AllocatedQuantity, shortage.ShortQuantity)
+		}
+		w.Flush()
+	}
+
+	fmt.Fprintln(out, "\nPicked by: ____________________  Date: ____________")
+}
+
+func (h *PickListHandler) respondWithError(w http.ResponseWriter, code int, message string) {
+	response := domain.APIResponse{
+		Success: false,
+		Error: &domain.APIError{
+			Code:    code,
+			Message: message,
+		},
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *PickListHandler) respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
+	response := domain.APIResponse{
+		Success: true,
+		Data:    payload,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(response)
+}
+
+// SetupRoutes sets up pick list routes
+func (h *PickListHandler) SetupRoutes(router *mux.Router, authMiddleware *middleware.AuthMiddleware) {
+	pickLists := router.PathPrefix("/pick-lists").Subrouter()
+	pickLists.Use(authMiddleware.FlexibleAuth) // All pick list endpoints require authentication
+
+	pickLists.HandleFunc("", h.GeneratePickList).Methods("POST")
+}
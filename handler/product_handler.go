@@ -2,6 +2,7 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -40,6 +41,10 @@ func (h *ProductHandler) CreateProduct(w http.ResponseWriter, r *http.Request) {
 			h.respondWithError(w, http.StatusConflict, "Product with this SKU already exists")
 			return
 		}
+		if errors.Is(err, domain.ErrInvalidInput) {
+			h.respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
 		h.respondWithError(w, http.StatusInternalServerError, "Failed to create product")
 		return
 	}
@@ -113,6 +118,10 @@ func (h *ProductHandler) UpdateProduct(w http.ResponseWriter, r *http.Request) {
 			h.respondWithError(w, http.StatusConflict, "Product with this SKU already exists")
 			return
 		}
+		if errors.Is(err, domain.ErrInvalidInput) {
+			h.respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
 		h.respondWithError(w, http.StatusInternalServerError, "Failed to update product")
 		return
 	}
@@ -0,0 +1,367 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/edwinjordan/wmsTest_Golang/domain"
+	"github.com/edwinjordan/wmsTest_Golang/middleware"
+	"github.com/edwinjordan/wmsTest_Golang/service"
+	"github.com/gorilla/mux"
+)
+
+type PurchaseOrderHandler struct {
+	purchaseOrderService service.PurchaseOrderService
+}
+
+func NewPurchaseOrderHandler(purchaseOrderService service.PurchaseOrderService) *PurchaseOrderHandler {
+	return &PurchaseOrderHandler{
+		purchaseOrderService: purchaseOrderService,
+	}
+}
+
+func (h *PurchaseOrderHandler) CreateSupplier(w http.ResponseWriter, r *http.Request) {
+	var req domain.CreateSupplierRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	// Basic validation
+	if req.Code == "" || req.Name == "" {
+		h.respondWithError(w, http.StatusBadRequest, "Code and name are required")
+		return
+	}
+
+	supplier, err := h.purchaseOrderService.CreateSupplier(r.Context(), &req)
+	if err != nil {
+		h.respondWithPurchaseOrderError(w, err, "Failed to create supplier")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusCreated, supplier)
+}
+
+func (h *PurchaseOrderHandler) GetSupplier(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.parseID(w, r, "Invalid supplier ID")
+	if !ok {
+		return
+	}
+
+	supplier, err := h.purchaseOrderService.GetSupplier(r.Context(), id)
+	if err != nil {
+		h.respondWithPurchaseOrderError(w, err, "Failed to get supplier")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, supplier)
+}
+
+func (h *PurchaseOrderHandler) UpdateSupplier(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.parseID(w, r, "Invalid supplier ID")
+	if !ok {
+		return
+	}
+
+	var req domain.UpdateSupplierRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	supplier, err := h.purchaseOrderService.UpdateSupplier(r.Context(), id, &req)
+	if err != nil {
+		h.respondWithPurchaseOrderError(w, err, "Failed to update supplier")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, supplier)
+}
+
+func (h *PurchaseOrderHandler) ListSuppliers(w http.ResponseWriter, r *http.Request) {
+	limit, offset := h.parsePagination(r)
+
+	suppliers, total, err := h.purchaseOrderService.ListSuppliers(r.Context(), limit, offset)
+	if err != nil {
+		h.respondWithError(w, http.StatusInternalServerError, "Failed to list suppliers")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"suppliers": suppliers,
+		"meta":      h.meta(limit, offset, total),
+	})
+}
+
+func (h *PurchaseOrderHandler) CreatePurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		h.respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	var req domain.CreatePurchaseOrderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	// Basic validation
+	if req.PONumber == "" || req.SupplierID <= 0 {
+		h.respondWithError(w, http.StatusBadRequest, "PO number and supplier ID are required")
+		return
+	}
+	if len(req.Lines) == 0 {
+		h.respondWithError(w, http.StatusBadRequest, "At least one line is required")
+		return
+	}
+
+	order, err := h.purchaseOrderService.CreatePurchaseOrder(r.Context(), &req, user.ID)
+	if err != nil {
+		h.respondWithPurchaseOrderError(w, err, "Failed to create purchase order")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusCreated, order)
+}
+
+func (h *PurchaseOrderHandler) GetPurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.parseID(w, r, "Invalid purchase order ID")
+	if !ok {
+		return
+	}
+
+	order, err := h.purchaseOrderService.GetPurchaseOrder(r.Context(), id)
+	if err != nil {
+		h.respondWithPurchaseOrderError(w, err, "Failed to get purchase order")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, order)
+}
+
+func (h *PurchaseOrderHandler) ListPurchaseOrders(w http.ResponseWriter, r *http.Request) {
+	limit, offset := h.parsePagination(r)
+
+	filter := &domain.PurchaseOrderFilter{
+		Limit:  limit,
+		Offset: offset,
+	}
+
+	if supplierID := r.URL.Query().Get("supplier_id"); supplierID != "" {
+		if id, err := strconv.Atoi(supplierID); err == nil {
+			filter.SupplierID = &id
+		}
+	}
+
+	if status := r.URL.Query().Get("status"); status != "" {
+		statusVal := domain.PurchaseOrderStatus(status)
+		filter.Status = &statusVal
+	}
+
+	orders, total, err := h.purchaseOrderService.ListPurchaseOrders(r.Context(), filter)
+	if err != nil {
+		h.respondWithError(w, http.StatusInternalServerError, "Failed to list purchase orders")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"purchase_orders": orders,
+		"meta":            h.meta(limit, offset, total),
+	})
+}
+
+// ReceivePurchaseOrder posts a delivery against the lines of a purchase order
+func (h *PurchaseOrderHandler) ReceivePurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		h.respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	id, ok := h.parseID(w, r, "Invalid purchase order ID")
+	if !ok {
+		return
+	}
+
+	var req domain.ReceivePurchaseOrderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	// Basic validation
+	if len(req.Lines) == 0 {
+		h.respondWithError(w, http.StatusBadRequest, "At least one line is required")
+		return
+	}
+
+	receipt, err := h.purchaseOrderService.ReceivePurchaseOrder(r.Context(), id, &req, user.ID)
+	if err != nil {
+		var lineErr *service.BatchLineError
+		if errors.As(err, &lineErr) {
+			code, message := h.purchaseOrderErrorMessage(lineErr.Err, "Failed to receive purchase order line")
+			h.respondWithError(w, code, fmt.Sprintf("Line %d: %s", lineErr.Line, message))
+			return
+		}
+		h.respondWithPurchaseOrderError(w, err, "Failed to receive purchase order")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusCreated, receipt)
+}
+
+func (h *PurchaseOrderHandler) ListReceipts(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.parseID(w, r, "Invalid purchase order ID")
+	if !ok {
+		return
+	}
+	limit, offset := h.parsePagination(r)
+
+	movements, total, err := h.purchaseOrderService.ListReceipts(r.Context(), id, limit, offset)
+	if err != nil {
+		h.respondWithPurchaseOrderError(w, err, "Failed to list purchase order receipts")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"movements": movements,
+		"meta":      h.meta(limit, offset, total),
+	})
+}
+
+func (h *PurchaseOrderHandler) ClosePurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.parseID(w, r, "Invalid purchase order ID")
+	if !ok {
+		return
+	}
+
+	order, err := h.purchaseOrderService.ClosePurchaseOrder(r.Context(), id)
+	if err != nil {
+		h.respondWithPurchaseOrderError(w, err, "Failed to close purchase order")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, order)
+}
+
+func (h *PurchaseOrderHandler) CancelPurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.parseID(w, r, "Invalid purchase order ID")
+	if !ok {
+		return
+	}
+
+	order, err := h.purchaseOrderService.CancelPurchaseOrder(r.Context(), id)
+	if err != nil {
+		h.respondWithPurchaseOrderError(w, err, "Failed to cancel purchase order")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, order)
+}
+
+func (h *PurchaseOrderHandler) parseID(w http.ResponseWriter, r *http.Request, message string) (int, bool) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		h.respondWithError(w, http.StatusBadRequest, message)
+		return 0, false
+	}
+	return id, true
+}
+
+func (h *PurchaseOrderHandler) parsePagination(r *http.Request) (int, int) {
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+
+	if limit <= 0 {
+		limit = 20
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	return limit, offset
+}
+
+func (h *PurchaseOrderHandler) meta(limit, offset, total int) domain.Meta {
+	return domain.Meta{
+		Page:       (offset / limit) + 1,
+		Limit:      limit,
+		Total:      total,
+		TotalPages: (total + limit - 1) / limit,
+	}
+}
+
+func (h *PurchaseOrderHandler) respondWithPurchaseOrderError(w http.ResponseWriter, err error, fallback string) {
+	code, message := h.purchaseOrderErrorMessage(err, fallback)
+	h.respondWithError(w, code, message)
+}
+
+func (h *PurchaseOrderHandler) purchaseOrderErrorMessage(err error, fallback string) (int, string) {
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		return http.StatusNotFound, "Supplier, purchase order, product or location not found"
+	case errors.Is(err, domain.ErrDuplicateEntry):
+		return http.StatusConflict, "Supplier code or PO number already exists"
+	case errors.Is(err, domain.ErrExceedsCapacity):
+		return http.StatusBadRequest, err.Error()
+	case errors.Is(err, domain.ErrTemperatureRange):
+		return http.StatusBadRequest, err.Error()
+	case errors.Is(err, domain.ErrInvalidInput):
+		return http.StatusBadRequest, err.Error()
+	case errors.Is(err, domain.ErrInvalidStatus):
+		return http.StatusConflict, err.Error()
+	default:
+		return http.StatusInternalServerError, fallback
+	}
+}
+
+func (h *PurchaseOrderHandler) respondWithError(w http.ResponseWriter, code int, message string) {
+	response := domain.APIResponse{
+		Success: false,
+		Error: &domain.APIError{
+			Code:    code,
+			Message: message,
+		},
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *PurchaseOrderHandler) respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
+	response := domain.APIResponse{
+		Success: true,
+		Data:    payload,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(response)
+}
+
+// SetupRoutes sets up supplier and purchase order routes
+func (h *PurchaseOrderHandler) SetupRoutes(router *mux.Router, authMiddleware *middleware.AuthMiddleware, idempotencyMiddleware *middleware.IdempotencyMiddleware) {
+	suppliers := router.PathPrefix("/suppliers").Subrouter()
+	suppliers.Use(authMiddleware.FlexibleAuth) // All supplier endpoints require authentication
+
+	suppliers.HandleFunc("", h.CreateSupplier).Methods("POST")
+	suppliers.HandleFunc("", h.ListSuppliers).Methods("GET")
+	suppliers.HandleFunc("/{id:[0-9]+}", h.GetSupplier).Methods("GET")
+	suppliers.HandleFunc("/{id:[0-9]+}", h.UpdateSupplier).Methods("PUT")
+
+	orders := router.PathPrefix("/purchase-orders").Subrouter()
+	orders.Use(authMiddleware.FlexibleAuth)
+
+	orders.HandleFunc("", h.CreatePurchaseOrder).Methods("POST")
+	orders.HandleFunc("", h.ListPurchaseOrders).Methods("GET")
+	orders.HandleFunc("/{id:[0-9]+}", h.GetPurchaseOrder).Methods("GET")
+	orders.Handle("/{id:[0-9]+}/receipts", idempotencyMiddleware.Idempotent(http.HandlerFunc(h.ReceivePurchaseOrder))).Methods("POST") // Safe to retry with an Idempotency-Key header
+	orders.HandleFunc("/{id:[0-9]+}/receipts", h.ListReceipts).Methods("GET")
+	orders.HandleFunc("/{id:[0-9]+}/close", h.ClosePurchaseOrder).Methods("POST")
+	orders.HandleFunc("/{id:[0-9]+}/cancel", h.CancelPurchaseOrder).Methods("POST")
+}
@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/edwinjordan/wmsTest_Golang/domain"
+	"github.com/edwinjordan/wmsTest_Golang/middleware"
+	"github.com/edwinjordan/wmsTest_Golang/service"
+	"github.com/gorilla/mux"
+)
+
+type PutawayHandler struct {
+	putawayService service.PutawayService
+}
+
+func NewPutawayHandler(putawayService service.PutawayService) *PutawayHandler {
+	return &PutawayHandler{
+		putawayService: putawayService,
+	}
+}
+
+// SuggestLocations returns the locations ranked for putting away a quantity of a product
+func (h *PutawayHandler) SuggestLocations(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	productID, err := strconv.Atoi(query.Get("product_id"))
+	if err != nil || productID <= 0 {
+		h.respondWithError(w, http.StatusBadRequest, "Valid product ID is required")
+		return
+	}
+	quantity, err := strconv.Atoi(query.Get("quantity"))
+	if err != nil || quantity <= 0 {
+		h.respondWithError(w, http.StatusBadRequest, "Quantity must be greater than 0")
+		return
+	}
+	limit, _ := strconv.Atoi(query.Get("limit"))
+	var warehouseID *int
+	if value := query.Get("warehouse_id"); value != "" {
+		id, err := strconv.Atoi(value)
+		if err != nil || id <= 0 {
+			h.respondWithError(w, http.StatusBadRequest, "Invalid warehouse ID")
+			return
+		}
+		warehouseID = &id
+	}
+
+	req := &domain.PutawayRequest{
+		ProductID:   productID,
+		Quantity:    quantity,
+		UOM:         query.Get("uom"),
+		Zone:        query.Get("zone"),
+		WarehouseID: warehouseID,
+		Limit:       limit,
+	}
+
+	plan, err := h.putawayService.SuggestLocations(r.Context(), req)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrNotFound):
+			h.respondWithError(w, http.StatusNotFound, "Product or warehouse not found")
+		case errors.Is(err, domain.ErrInvalidInput):
+			h.respondWithError(w, http.StatusBadRequest, err.Error())
+		default:
+			h.respondWithError(w, http.StatusInternalServerError, "Failed to suggest putaway locations")
+		}
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, plan)
+}
+
+func (h *PutawayHandler) respondWithError(w http.ResponseWriter, code int, message string) {
+	response := domain.APIResponse{
+		Success: false,
+		Error: &domain.APIError{
+			Code:    code,
+			Message: message,
+		},
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *PutawayHandler) respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
+	response := domain.APIResponse{
+		Success: true,
+		Data:    payload,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(response)
+}
+
+// SetupRoutes sets up putaway routes
+func (h *PutawayHandler) SetupRoutes(router *mux.Router, authMiddleware *middleware.AuthMiddleware) {
+	putaway := router.PathPrefix("/putaway").Subrouter()
+	putaway.Use(authMiddleware.FlexibleAuth) // All putaway endpoints require authentication
+
+	putaway.HandleFunc("/suggestions", h.SuggestLocations).Methods("GET")
+}
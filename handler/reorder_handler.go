@@ -0,0 +1,246 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/edwinjordan/wmsTest_Golang/domain"
+	"github.com/edwinjordan/wmsTest_Golang/middleware"
+	"github.com/edwinjordan/wmsTest_Golang/service"
+	"github.com/gorilla/mux"
+)
+
+type ReorderHandler struct {
+	reorderService service.ReorderService
+}
+
+func NewReorderHandler(reorderService service.ReorderService) *ReorderHandler {
+	return &ReorderHandler{
+		reorderService: reorderService,
+	}
+}
+
+// SetReorderRule creates or replaces the reorder rule of a product for the location in the body
+func (h *ReorderHandler) SetReorderRule(w http.ResponseWriter, r *http.Request) {
+	productID, ok := h.parseID(w, r, "id", "Invalid product ID")
+	if !ok {
+		return
+	}
+
+	var req domain.SetReorderRuleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	// Basic validation
+	if req.LocationID != nil && *req.LocationID <= 0 {
+		h.respondWithError(w, http.StatusBadRequest, "Valid location ID is required")
+		return
+	}
+	if req.ReorderQuantity <= 0 {
+		h.respondWithError(w, http.StatusBadRequest, "Reorder quantity must be greater than 0")
+		return
+	}
+
+	rule, err := h.reorderService.SetReorderRule(r.Context(), productID, &req)
+	if err != nil {
+		h.respondWithReorderError(w, err, "Failed to set reorder rule")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, rule)
+}
+
+func (h *ReorderHandler) ListReorderRules(w http.ResponseWriter, r *http.Request) {
+	productID, ok := h.parseID(w, r, "id", "Invalid product ID")
+	if !ok {
+		return
+	}
+
+	rules, err := h.reorderService.ListReorderRules(r.Context(), productID)
+	if err != nil {
+		h.respondWithReorderError(w, err, "Failed to list reorder rules")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"rules": rules,
+	})
+}
+
+func (h *ReorderHandler) DeleteReorderRule(w http.ResponseWriter, r *http.Request) {
+	productID, ok := h.parseID(w, r, "id", "Invalid product ID")
+	if !ok {
+		return
+	}
+	ruleID, ok := h.parseID(w, r, "ruleId", "Invalid reorder rule ID")
+	if !ok {
+		return
+	}
+
+	if err := h.reorderService.DeleteReorderRule(r.Context(), productID, ruleID); err != nil {
+		h.respondWithReorderError(w, err, "Failed to delete reorder rule")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, map[string]string{"message": "Reorder rule deleted successfully"})
+}
+
+// ListLowStock returns the products at or below their reorder point, critical items first
+func (h *ReorderHandler) ListLowStock(w http.ResponseWriter, r *http.Request) {
+	items, err := h.reorderService.ListLowStock(r.Context())
+	if err != nil {
+		h.respondWithError(w, http.StatusInternalServerError, "Failed to list low stock")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"items": items,
+	})
+}
+
+func (h *ReorderHandler) ListStockAlerts(w http.ResponseWriter, r *http.Request) {
+	// Parse query parameters
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+
+	if limit <= 0 {
+		limit = 20
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	filter := &domain.StockAlertFilter{
+		Limit:  limit,
+		Offset: offset,
+	}
+
+	if productID := r.URL.Query().Get("product_id"); productID != "" {
+		if id, err := strconv.Atoi(productID); err == nil {
+			filter.ProductID = &id
+		}
+	}
+
+	if locationID := r.URL.Query().Get("location_id"); locationID != "" {
+		if id, err := strconv.Atoi(locationID); err == nil {
+			filter.LocationID = &id
+		}
+	}
+
+	if status := r.URL.Query().Get("status"); status != "" {
+		statusVal := domain.StockAlertStatus(status)
+		filter.Status = &statusVal
+	}
+
+	alerts, total, err := h.reorderService.ListStockAlerts(r.Context(), filter)
+	if err != nil {
+		h.respondWithError(w, http.StatusInternalServerError, "Failed to list stock alerts")
+		return
+	}
+
+	// Calculate pagination
+	totalPages := (total + limit - 1) / limit
+	page := (offset / limit) + 1
+
+	response := map[string]interface{}{
+		"alerts": alerts,
+		"meta": domain.Meta{
+			Page:       page,
+			Limit:      limit,
+			Total:      total,
+			TotalPages: totalPages,
+		},
+	}
+
+	h.respondWithJSON(w, http.StatusOK, response)
+}
+
+func (h *ReorderHandler) AcknowledgeStockAlert(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		h.respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	id, ok := h.parseID(w, r, "id", "Invalid stock alert ID")
+	if !ok {
+		return
+	}
+
+	alert, err := h.reorderService.AcknowledgeStockAlert(r.Context(), id, user.ID)
+	if err != nil {
+		h.respondWithReorderError(w, err, "Failed to acknowledge stock alert")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, alert)
+}
+
+func (h *ReorderHandler) parseID(w http.ResponseWriter, r *http.Request, name, message string) (int, bool) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars[name])
+	if err != nil {
+		h.respondWithError(w, http.StatusBadRequest, message)
+		return 0, false
+	}
+	return id, true
+}
+
+func (h *ReorderHandler) respondWithReorderError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		h.respondWithError(w, http.StatusNotFound, "Product, location, reorder rule or stock alert not found")
+	case errors.Is(err, domain.ErrInvalidInput):
+		h.respondWithError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, domain.ErrInvalidStatus):
+		h.respondWithError(w, http.StatusConflict, err.Error())
+	default:
+		h.respondWithError(w, http.StatusInternalServerError, fallback)
+	}
+}
+
+func (h *ReorderHandler) respondWithError(w http.ResponseWriter, code int, message string) {
+	response := domain.APIResponse{
+		Success: false,
+		Error: &domain.APIError{
+			Code:    code,
+			Message: message,
+		},
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *ReorderHandler) respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
+	response := domain.APIResponse{
+		Success: true,
+		Data:    payload,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(response)
+}
+
+// SetupRoutes sets up reorder rule, low-stock and stock alert routes
+func (h *ReorderHandler) SetupRoutes(router *mux.Router, authMiddleware *middleware.AuthMiddleware) {
+	products := router.PathPrefix("/products").Subrouter()
+	products.Use(authMiddleware.FlexibleAuth) // All reorder endpoints require authentication
+
+	products.HandleFunc("/low-stock", h.ListLowStock).Methods("GET")
+	products.HandleFunc("/{id:[0-9]+}/reorder-rules", h.ListReorderRules).Methods("GET")
+	products.HandleFunc("/{id:[0-9]+}/reorder-rules", h.SetReorderRule).Methods("PUT")
+	products.HandleFunc("/{id:[0-9]+}/reorder-rules/{ruleId:[0-9]+}", h.DeleteReorderRule).Methods("DELETE")
+
+	alerts := router.PathPrefix("/stock-alerts").Subrouter()
+	alerts.Use(authMiddleware.FlexibleAuth)
+
+	alerts.HandleFunc("", h.ListStockAlerts).Methods("GET")
+	alerts.HandleFunc("/{id:[0-9]+}/acknowledge", h.AcknowledgeStockAlert).Methods("POST")
+}
@@ -0,0 +1,234 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/edwinjordan/wmsTest_Golang/domain"
+	"github.com/edwinjordan/wmsTest_Golang/middleware"
+	"github.com/edwinjordan/wmsTest_Golang/service"
+	"github.com/gorilla/mux"
+)
+
+type ReservationHandler struct {
+	reservationService service.ReservationService
+}
+
+func NewReservationHandler(reservationService service.ReservationService) *ReservationHandler {
+	return &ReservationHandler{
+		reservationService: reservationService,
+	}
+}
+
+func (h *ReservationHandler) CreateReservation(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		h.respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	var req domain.CreateReservationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	// Basic validation
+	if req.ProductID <= 0 || req.LocationID <= 0 {
+		h.respondWithError(w, http.StatusBadRequest, "Valid product and location IDs are required")
+		return
+	}
+	if req.Quantity <= 0 {
+		h.respondWithError(w, http.StatusBadRequest, "Quantity must be greater than 0")
+		return
+	}
+
+	reservation, err := h.reservationService.CreateReservation(r.Context(), &req, user.ID)
+	if err != nil {
+		h.respondWithReservationError(w, err, "Failed to create reservation")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusCreated, reservation)
+}
+
+func (h *ReservationHandler) ListReservations(w http.ResponseWriter, r *http.Request) {
+	// Parse query parameters
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+
+	if limit <= 0 {
+		limit = 20
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	filter := &domain.ReservationFilter{
+		Limit:  limit,
+		Offset: offset,
+	}
+
+	if productID := r.URL.Query().Get("product_id"); productID != "" {
+		if id, err := strconv.Atoi(productID); err == nil {
+			filter.ProductID = &id
+		}
+	}
+
+	if locationID := r.URL.Query().Get("location_id"); locationID != "" {
+		if id, err := strconv.Atoi(locationID); err == nil {
+			filter.LocationID = &id
+		}
+	}
+
+	if status := r.URL.Query().Get("status"); status != "" {
+		statusVal := domain.ReservationStatus(status)
+		filter.Status = &statusVal
+	}
+
+	reservations, total, err := h.reservationService.ListReservations(r.Context(), filter)
+	if err != nil {
+		h.respondWithError(w, http.StatusInternalServerError, "Failed to list reservations")
+		return
+	}
+
+	// Calculate pagination
+	totalPages := (total + limit - 1) / limit
+	page := (offset / limit) + 1
+
+	response := map[string]interface{}{
+		"reservations": reservations,
+		"meta": domain.Meta{
+			Page:       page,
+			Limit:      limit,
+			Total:      total,
+			TotalPages: totalPages,
+		},
+	}
+
+	h.respondWithJSON(w, http.StatusOK, response)
+}
+
+func (h *ReservationHandler) GetReservation(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.parseID(w, r)
+	if !ok {
+		return
+	}
+
+	reservation, err := h.reservationService.GetReservation(r.Context(), id)
+	if err != nil {
+		h.respondWithReservationError(w, err, "Failed to get reservation")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, reservation)
+}
+
+func (h *ReservationHandler) ReleaseReservation(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.parseID(w, r)
+	if !ok {
+		return
+	}
+
+	reservation, err := h.reservationService.ReleaseReservation(r.Context(), id)
+	if err != nil {
+		h.respondWithReservationError(w, err, "Failed to release reservation")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, reservation)
+}
+
+// ConsumeReservation ships reserved stock. The body is optional; without it the whole open quantity is shipped.
+func (h *ReservationHandler) ConsumeReservation(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		h.respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	id, ok := h.parseID(w, r)
+	if !ok {
+		return
+	}
+
+	var req domain.ConsumeReservationRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			h.respondWithError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+	}
+
+	movement, err := h.reservationService.ConsumeReservation(r.Context(), id, &req, user.ID)
+	if err != nil {
+		h.respondWithReservationError(w, err, "Failed to consume reservation")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusCreated, movement)
+}
+
+func (h *ReservationHandler) parseID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid reservation ID")
+		return 0, false
+	}
+	return id, true
+}
+
+func (h *ReservationHandler) respondWithReservationError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		h.respondWithError(w, http.StatusNotFound, "Reservation, product or location not found")
+	case errors.Is(err, domain.ErrInsufficientStock):
+		h.respondWithError(w, http.StatusBadRequest, "Insufficient available stock for this operation")
+	case errors.Is(err, domain.ErrInvalidInput):
+		h.respondWithError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, domain.ErrInvalidStatus):
+		h.respondWithError(w, http.StatusConflict, err.Error())
+	default:
+		h.respondWithError(w, http.StatusInternalServerError, fallback)
+	}
+}
+
+func (h *ReservationHandler) respondWithError(w http.ResponseWriter, code int, message string) {
+	response := domain.APIResponse{
+		Success: false,
+		Error: &domain.APIError{
+			Code:    code,
+			Message: message,
+		},
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *ReservationHandler) respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
+	response := domain.APIResponse{
+		Success: true,
+		Data:    payload,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(response)
+}
+
+// SetupRoutes sets up reservation routes
+func (h *ReservationHandler) SetupRoutes(router *mux.Router, authMiddleware *middleware.AuthMiddleware) {
+	reservations := router.PathPrefix("/reservations").Subrouter()
+	reservations.Use(authMiddleware.FlexibleAuth) // All reservation endpoints require authentication
+
+	reservations.HandleFunc("", h.CreateReservation).Methods("POST")
+	reservations.HandleFunc("", h.ListReservations).Methods("GET")
+	reservations.HandleFunc("/{id:[0-9]+}", h.GetReservation).Methods("GET")
+	reservations.HandleFunc("/{id:[0-9]+}/release", h.ReleaseReservation).Methods("POST")
+	reservations.HandleFunc("/{id:[0-9]+}/consume", h.ConsumeReservation).Methods("POST")
+}
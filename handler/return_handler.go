@@ -0,0 +1,288 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/edwinjordan/wmsTest_Golang/domain"
+	"github.com/edwinjordan/wmsTest_Golang/middleware"
+	"github.com/edwinjordan/wmsTest_Golang/service"
+	"github.com/gorilla/mux"
+)
+
+type ReturnHandler struct {
+	returnService service.ReturnService
+}
+
+func NewReturnHandler(returnService service.ReturnService) *ReturnHandler {
+	return &ReturnHandler{
+		returnService: returnService,
+	}
+}
+
+func (h *ReturnHandler) CreateReturnAuthorization(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		h.respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	var req domain.CreateReturnAuthorizationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	// Basic validation
+	if req.RMANumber == "" {
+		h.respondWithError(w, http.StatusBadRequest, "RMA number is required")
+		return
+	}
+	if len(req.Lines) == 0 {
+		h.respondWithError(w, http.StatusBadRequest, "At least one line is required")
+		return
+	}
+
+	rma, err := h.returnService.CreateReturnAuthorization(r.Context(), &req, user.ID)
+	if err != nil {
+		h.respondWithReturnError(w, err, "Failed to create return authorization")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusCreated, rma)
+}
+
+func (h *ReturnHandler) GetReturnAuthorization(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.parseID(w, r)
+	if !ok {
+		return
+	}
+
+	rma, err := h.returnService.GetReturnAuthorization(r.Context(), id)
+	if err != nil {
+		h.respondWithReturnError(w, err, "Failed to get return authorization")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, rma)
+}
+
+func (h *ReturnHandler) ListReturnAuthorizations(w http.ResponseWriter, r *http.Request) {
+	limit, offset := h.parsePagination(r)
+
+	filter := &domain.ReturnAuthorizationFilter{
+		Limit:  limit,
+		Offset: offset,
+	}
+
+	if salesOrderID := r.URL.Query().Get("sales_order_id"); salesOrderID != "" {
+		if id, err := strconv.Atoi(salesOrderID); err == nil {
+			filter.SalesOrderID = &id
+		}
+	}
+
+	if status := r.URL.Query().Get("status"); status != "" {
+		statusVal := domain.ReturnAuthorizationStatus(status)
+		filter.Status = &statusVal
+	}
+
+	rmas, total, err := h.returnService.ListReturnAuthorizations(r.Context(), filter)
+	if err != nil {
+		h.respondWithError(w, http.StatusInternalServerError, "Failed to list return authorizations")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"return_authorizations": rmas,
+		"meta":                  h.meta(limit, offset, total),
+	})
+}
+
+// ReceiveReturn books returned goods in against an RMA, graded as restock, quarantine or scrap
+func (h *ReturnHandler) ReceiveReturn(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		h.respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	id, ok := h.parseID(w, r)
+	if !ok {
+		return
+	}
+
+	var req domain.ReceiveReturnRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	// Basic validation
+	if len(req.Lines) == 0 {
+		h.respondWithError(w, http.StatusBadRequest, "At least one line is required")
+		return
+	}
+
+	receipt, err := h.returnService.ReceiveReturn(r.Context(), id, &req, user.ID)
+	if err != nil {
+		var lineErr *service.BatchLineError
+		if errors.As(err, &lineErr) {
+			code, message := h.returnErrorMessage(lineErr.Err, "Failed to receive return line")
+			h.respondWithError(w, code, fmt.Sprintf("Line %d: %s", lineErr.Line, message))
+			return
+		}
+		h.respondWithReturnError(w, err, "Failed to receive return")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusCreated, receipt)
+}
+
+func (h *ReturnHandler) CloseReturnAuthorization(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.parseID(w, r)
+	if !ok {
+		return
+	}
+
+	rma, err := h.returnService.CloseReturnAuthorization(r.Context(), id)
+	if err != nil {
+		h.respondWithReturnError(w, err, "Failed to close return authorization")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, rma)
+}
+
+func (h *ReturnHandler) CancelReturnAuthorization(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.parseID(w, r)
+	if !ok {
+		return
+	}
+
+	rma, err := h.returnService.CancelReturnAuthorization(r.Context(), id)
+	if err != nil {
+		h.respondWithReturnError(w, err, "Failed to cancel return authorization")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, rma)
+}
+
+func (h *ReturnHandler) ListMovements(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.parseID(w, r)
+	if !ok {
+		return
+	}
+	limit, offset := h.parsePagination(r)
+
+	movements, total, err := h.returnService.ListMovements(r.Context(), id, limit, offset)
+	if err != nil {
+		h.respondWithReturnError(w, err, "Failed to list return movements")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"movements": movements,
+		"meta":      h.meta(limit, offset, total),
+	})
+}
+
+func (h *ReturnHandler) parseID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid return authorization ID")
+		return 0, false
+	}
+	return id, true
+}
+
+func (h *ReturnHandler) parsePagination(r *http.Request) (int, int) {
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+
+	if limit <= 0 {
+		limit = 20
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	return limit, offset
+}
+
+func (h *ReturnHandler) meta(limit, offset, total int) domain.Meta {
+	return domain.Meta{
+		Page:       (offset / limit) + 1,
+		Limit:      limit,
+		Total:      total,
+		TotalPages: (total + limit - 1) / limit,
+	}
+}
+
+func (h *ReturnHandler) respondWithReturnError(w http.ResponseWriter, err error, fallback string) {
+	code, message := h.returnErrorMessage(err, fallback)
+	h.respondWithError(w, code, message)
+}
+
+func (h *ReturnHandler) returnErrorMessage(err error, fallback string) (int, string) {
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		return http.StatusNotFound, "Return authorization, sales order, product or location not found"
+	case errors.Is(err, domain.ErrDuplicateEntry):
+		return http.StatusConflict, "RMA number already exists"
+	case errors.Is(err, domain.ErrInsufficientStock):
+		return http.StatusConflict, err.Error()
+	case errors.Is(err, domain.ErrExceedsCapacity):
+		return http.StatusBadRequest, err.Error()
+	case errors.Is(err, domain.ErrTemperatureRange):
+		return http.StatusBadRequest, err.Error()
+	case errors.Is(err, domain.ErrInvalidInput):
+		return http.StatusBadRequest, err.Error()
+	case errors.Is(err, domain.ErrInvalidStatus):
+		return http.StatusConflict, err.Error()
+	default:
+		return http.StatusInternalServerError, fallback
+	}
+}
+
+func (h *ReturnHandler) respondWithError(w http.ResponseWriter, code int, message string) {
+	response := domain.APIResponse{
+		Success: false,
+		Error: &domain.APIError{
+			Code:    code,
+			Message: message,
+		},
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *ReturnHandler) respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
+	response := domain.APIResponse{
+		Success: true,
+		Data:    payload,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(response)
+}
+
+// SetupRoutes sets up return authorization routes
+func (h *ReturnHandler) SetupRoutes(router *mux.Router, authMiddleware *middleware.AuthMiddleware, idempotencyMiddleware *middleware.IdempotencyMiddleware) {
+	rmas := router.PathPrefix("/return-authorizations").Subrouter()
+	rmas.Use(authMiddleware.FlexibleAuth) // All return endpoints require authentication
+
+	rmas.HandleFunc("", h.CreateReturnAuthorization).Methods("POST")
+	rmas.HandleFunc("", h.ListReturnAuthorizations).Methods("GET")
+	rmas.HandleFunc("/{id:[0-9]+}", h.GetReturnAuthorization).Methods("GET")
+	rmas.Handle("/{id:[0-9]+}/receipts", idempotencyMiddleware.Idempotent(http.HandlerFunc(h.ReceiveReturn))).Methods("POST") // Safe to retry with an Idempotency-Key header
+	rmas.HandleFunc("/{id:[0-9]+}/movements", h.ListMovements).Methods("GET")
+	rmas.HandleFunc("/{id:[0-9]+}/close", h.CloseReturnAuthorization).Methods("POST")
+	rmas.HandleFunc("/{id:[0-9]+}/cancel", h.CancelReturnAuthorization).Methods("POST")
+}
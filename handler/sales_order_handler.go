@@ -0,0 +1,353 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/edwinjordan/wmsTest_Golang/domain"
+	"github.com/edwinjordan/wmsTest_Golang/middleware"
+	"github.com/edwinjordan/wmsTest_Golang/service"
+	"github.com/gorilla/mux"
+)
+
+type SalesOrderHandler struct {
+	salesOrderService service.SalesOrderService
+}
+
+func NewSalesOrderHandler(salesOrderService service.SalesOrderService) *SalesOrderHandler {
+	return &SalesOrderHandler{
+		salesOrderService: salesOrderService,
+	}
+}
+
+func (h *SalesOrderHandler) CreateSalesOrder(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		h.respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	var req domain.CreateSalesOrderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	// Basic validation
+	if req.SONumber == "" || req.CustomerName == "" {
+		h.respondWithError(w, http.StatusBadRequest, "SO number and customer name are required")
+		return
+	}
+	if len(req.Lines) == 0 {
+		h.respondWithError(w, http.StatusBadRequest, "At least one line is required")
+		return
+	}
+
+	order, err := h.salesOrderService.CreateSalesOrder(r.Context(), &req, user.ID)
+	if err != nil {
+		h.respondWithSalesOrderError(w, err, "Failed to create sales order")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusCreated, order)
+}
+
+func (h *SalesOrderHandler) GetSalesOrder(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.parseID(w, r)
+	if !ok {
+		return
+	}
+
+	order, err := h.salesOrderService.GetSalesOrder(r.Context(), id)
+	if err != nil {
+		h.respondWithSalesOrderError(w, err, "Failed to get sales order")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, order)
+}
+
+func (h *SalesOrderHandler) ListSalesOrders(w http.ResponseWriter, r *http.Request) {
+	limit, offset := h.parsePagination(r)
+
+	filter := &domain.SalesOrderFilter{
+		Limit:  limit,
+		Offset: offset,
+	}
+
+	if status := r.URL.Query().Get("status"); status != "" {
+		statusVal := domain.SalesOrderStatus(status)
+		filter.Status = &statusVal
+	}
+
+	if waveID := r.URL.Query().Get("wave_id"); waveID != "" {
+		if id, err := strconv.Atoi(waveID); err == nil {
+			filter.WaveID = &id
+		}
+	}
+
+	if warehouseID := r.URL.Query().Get("warehouse_id"); warehouseID != "" {
+		if id, err := strconv.Atoi(warehouseID); err == nil {
+			filter.WarehouseID = &id
+		}
+	}
+
+	orders, total, err := h.salesOrderService.ListSalesOrders(r.Context(), filter)
+	if err != nil {
+		h.respondWithError(w, http.StatusInternalServerError, "Failed to list sales orders")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"sales_orders": orders,
+		"meta":         h.meta(limit, offset, total),
+	})
+}
+
+// AllocateSalesOrder reserves stock for every line of a new order
+func (h *SalesOrderHandler) AllocateSalesOrder(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		h.respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	id, ok := h.parseID(w, r)
+	if !ok {
+		return
+	}
+
+	order, err := h.salesOrderService.AllocateSalesOrder(r.Context(), id, user.ID)
+	if err != nil {
+		h.respondWithSalesOrderError(w, err, "Failed to allocate sales order")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, order)
+}
+
+func (h *SalesOrderHandler) PickSalesOrder(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.parseID(w, r)
+	if !ok {
+		return
+	}
+
+	order, err := h.salesOrderService.PickSalesOrder(r.Context(), id)
+	if err != nil {
+		h.respondWithSalesOrderError(w, err, "Failed to pick sales order")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, order)
+}
+
+func (h *SalesOrderHandler) PackSalesOrder(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.parseID(w, r)
+	if !ok {
+		return
+	}
+
+	// The body is optional
+	var req domain.PackSalesOrderRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			h.respondWithError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+	}
+
+	order, err := h.salesOrderService.PackSalesOrder(r.Context(), id, &req)
+	if err != nil {
+		h.respondWithSalesOrderError(w, err, "Failed to pack sales order")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, order)
+}
+
+// ShipSalesOrder posts the OUT movements of a packed order
+func (h *SalesOrderHandler) ShipSalesOrder(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		h.respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	id, ok := h.parseID(w, r)
+	if !ok {
+		return
+	}
+
+	// The body is optional
+	var req domain.ShipSalesOrderRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			h.respondWithError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+	}
+
+	shipment, err := h.salesOrderService.ShipSalesOrder(r.Context(), id, &req, user.ID)
+	if err != nil {
+		h.respondWithSalesOrderError(w, err, "Failed to ship sales order")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusCreated, shipment)
+}
+
+func (h *SalesOrderHandler) CancelSalesOrder(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.parseID(w, r)
+	if !ok {
+		return
+	}
+
+	order, err := h.salesOrderService.CancelSalesOrder(r.Context(), id)
+	if err != nil {
+		h.respondWithSalesOrderError(w, err, "Failed to cancel sales order")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, order)
+}
+
+// GetPickList returns the allocated stops of an order in walking order, as JSON or printable text
+func (h *SalesOrderHandler) GetPickList(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.parseID(w, r)
+	if !ok {
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "json" && format != "text" {
+		h.respondWithError(w, http.StatusBadRequest, "Format must be json or text")
+		return
+	}
+
+	pickList, err := h.salesOrderService.GetPickList(r.Context(), id)
+	if err != nil {
+		h.respondWithSalesOrderError(w, err, "Failed to get pick list")
+		return
+	}
+
+	if format == "text" {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		writePickList(w, pickList)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, pickList)
+}
+
+func (h *SalesOrderHandler) ListMovements(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.parseID(w, r)
+	if !ok {
+		return
+	}
+	limit, offset := h.parsePagination(r)
+
+	movements, total, err := h.salesOrderService.ListMovements(r.Context(), id, limit, offset)
+	if err != nil {
+		h.respondWithSalesOrderError(w, err, "Failed to list sales order movements")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"movements": movements,
+		"meta":      h.meta(limit, offset, total),
+	})
+}
+
+func (h *SalesOrderHandler) parseID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid sales order ID")
+		return 0, false
+	}
+	return id, true
+}
+
+func (h *SalesOrderHandler) parsePagination(r *http.Request) (int, int) {
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+
+	if limit <= 0 {
+		limit = 20
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	return limit, offset
+}
+
+func (h *SalesOrderHandler) meta(limit, offset, total int) domain.Meta {
+	return domain.Meta{
+		Page:       (offset / limit) + 1,
+		Limit:      limit,
+		Total:      total,
+		TotalPages: (total + limit - 1) / limit,
+	}
+}
+
+func (h *SalesOrderHandler) respondWithSalesOrderError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		h.respondWithError(w, http.StatusNotFound, "Sales order, product, location or warehouse not found")
+	case errors.Is(err, domain.ErrDuplicateEntry):
+		h.respondWithError(w, http.StatusConflict, "SO number already exists")
+	case errors.Is(err, domain.ErrInsufficientStock):
+		h.respondWithError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, domain.ErrInvalidInput):
+		h.respondWithError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, domain.ErrInvalidStatus):
+		h.respondWithError(w, http.StatusConflict, err.Error())
+	default:
+		h.respondWithError(w, http.StatusInternalServerError, fallback)
+	}
+}
+
+func (h *SalesOrderHandler) respondWithError(w http.ResponseWriter, code int, message string) {
+	response := domain.APIResponse{
+		Success: false,
+		Error: &domain.APIError{
+			Code:    code,
+			Message: message,
+		},
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *SalesOrderHandler) respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
+	response := domain.APIResponse{
+		Success: true,
+		Data:    payload,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(response)
+}
+
+// SetupRoutes sets up sales order routes
+func (h *SalesOrderHandler) SetupRoutes(router *mux.Router, authMiddleware *middleware.AuthMiddleware, idempotencyMiddleware *middleware.IdempotencyMiddleware) {
+	orders := router.PathPrefix("/sales-orders").Subrouter()
+	orders.Use(authMiddleware.FlexibleAuth) // All sales order endpoints require authentication
+
+	orders.HandleFunc("", h.CreateSalesOrder).Methods("POST")
+	orders.HandleFunc("", h.ListSalesOrders).Methods("GET")
+	orders.HandleFunc("/{id:[0-9]+}", h.GetSalesOrder).Methods("GET")
+	orders.HandleFunc("/{id:[0-9]+}/allocate", h.AllocateSalesOrder).Methods("POST")
+	orders.HandleFunc("/{id:[0-9]+}/pick", h.PickSalesOrder).Methods("POST")
+	orders.HandleFunc("/{id:[0-9]+}/pack", h.PackSalesOrder).Methods("POST")
+	orders.Handle("/{id:[0-9]+}/ship", idempotencyMiddleware.Idempotent(http.HandlerFunc(h.ShipSalesOrder))).Methods("POST") // Safe to retry with an Idempotency-Key header
+	orders.HandleFunc("/{id:[0-9]+}/cancel", h.CancelSalesOrder).Methods("POST")
+	orders.HandleFunc("/{id:[0-9]+}/pick-list", h.GetPickList).Methods("GET")
+	orders.HandleFunc("/{id:[0-9]+}/movements", h.ListMovements).Methods("GET")
+}
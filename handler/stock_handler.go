@@ -2,9 +2,11 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/edwinjordan/wmsTest_Golang/domain"
@@ -53,36 +55,184 @@ func (h *StockHandler) ProcessStockMovement(w http.ResponseWriter, r *http.Reque
 		h.respondWithError(w, http.StatusBadRequest, "Quantity must be greater than 0")
 		return
 	}
+	if req.UnitCost != nil && *req.UnitCost < 0 {
+		h.respondWithError(w, http.StatusBadRequest, "Unit cost must not be negative")
+		return
+	}
 
 	movement, err := h.stockService.ProcessStockMovement(r.Context(), &req, user.ID)
 	if err != nil {
-		// Debug logging for error handling
-		fmt.Printf("DEBUG HANDLER: Error occurred: %v\n", err)
-		fmt.Printf("DEBUG HANDLER: Error type: %T\n", err)
+		h.respondWithStockError(w, err, "Failed to process stock movement")
+		return
+	}
 
-		if err == domain.ErrNotFound {
-			fmt.Printf("DEBUG HANDLER: Handling ErrNotFound\n")
-			h.respondWithError(w, http.StatusNotFound, "Product or location not found")
+	h.respondWithJSON(w, http.StatusCreated, movement)
+}
+
+// ProcessStockMovementBatch posts several IN/OUT movements in one request. Lines are validated
+// by the service, which reports failures with their 1-based line number.
+func (h *StockHandler) ProcessStockMovementBatch(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		h.respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	var req domain.CreateStockMovementBatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	// Basic validation
+	if len(req.Movements) == 0 {
+		h.respondWithError(w, http.StatusBadRequest, "At least one movement is required")
+		return
+	}
+
+	result, err := h.stockService.ProcessStockMovementBatch(r.Context(), &req, user.ID)
+	if err != nil {
+		var lineErr *service.BatchLineError
+		if errors.As(err, &lineErr) {
+			code, message := h.stockErrorMessage(lineErr.Err, "Failed to process stock movement")
+			h.respondWithError(w, code, fmt.Sprintf("Line %d: %s", lineErr.Line, message))
 			return
 		}
-		if err == domain.ErrInsufficientStock {
-			fmt.Printf("DEBUG HANDLER: Handling ErrInsufficientStock\n")
-			h.respondWithError(w, http.StatusBadRequest, "Insufficient stock for this operation")
-			return
+		h.respondWithStockError(w, err, "Failed to process stock movement batch")
+		return
+	}
+
+	for _, line := range result.Lines {
+		if line.Err != nil {
+			_, line.Error = h.stockErrorMessage(line.Err, "Failed to process stock movement")
 		}
-		if err == domain.ErrExceedsCapacity {
-			fmt.Printf("DEBUG HANDLER: Handling ErrExceedsCapacity\n")
-			h.respondWithError(w, http.StatusBadRequest, "Stock movement exceeds location capacity")
-			return
+	}
+
+	status := http.StatusCreated
+	if result.Failed > 0 {
+		status = http.StatusOK
+	}
+	h.respondWithJSON(w, status, result)
+}
+
+func (h *StockHandler) TransferStock(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		h.respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	var req domain.CreateStockTransferRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	// Basic validation
+	if req.ProductID <= 0 {
+		h.respondWithError(w, http.StatusBadRequest, "Valid product ID is required")
+		return
+	}
+	if req.FromLocationID <= 0 || req.ToLocationID <= 0 {
+		h.respondWithError(w, http.StatusBadRequest, "Valid source and destination location IDs are required")
+		return
+	}
+	if req.FromLocationID == req.ToLocationID {
+		h.respondWithError(w, http.StatusBadRequest, "Source and destination locations must differ")
+		return
+	}
+	if req.Quantity <= 0 {
+		h.respondWithError(w, http.StatusBadRequest, "Quantity must be greater than 0")
+		return
+	}
+
+	transfer, err := h.stockService.TransferStock(r.Context(), &req, user.ID)
+	if err != nil {
+		h.respondWithStockError(w, err, "Failed to process stock transfer")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusCreated, transfer)
+}
+
+func (h *StockHandler) AdjustStock(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		h.respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	var req domain.CreateStockAdjustmentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	// Basic validation
+	if req.ProductID <= 0 {
+		h.respondWithError(w, http.StatusBadRequest, "Valid product ID is required")
+		return
+	}
+	if req.LocationID <= 0 {
+		h.respondWithError(w, http.StatusBadRequest, "Valid location ID is required")
+		return
+	}
+	if req.Quantity == 0 {
+		h.respondWithError(w, http.StatusBadRequest, "Quantity must not be zero")
+		return
+	}
+	if !req.ReasonCode.IsAdjustmentReason() {
+		codes := make([]string, len(domain.AdjustmentReasonCodes))
+		for i, code := range domain.AdjustmentReasonCodes {
+			codes[i] = string(code)
 		}
-		fmt.Printf("DEBUG HANDLER: Handling generic error\n")
-		h.respondWithError(w, http.StatusInternalServerError, "Failed to process stock movement")
+		h.respondWithError(w, http.StatusBadRequest, "Reason code must be one of "+strings.Join(codes, ", "))
+		return
+	}
+
+	movement, err := h.stockService.AdjustStock(r.Context(), &req, user.ID)
+	if err != nil {
+		h.respondWithStockError(w, err, "Failed to process stock adjustment")
 		return
 	}
 
 	h.respondWithJSON(w, http.StatusCreated, movement)
 }
 
+func (h *StockHandler) ReverseStockMovement(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		h.respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid movement ID")
+		return
+	}
+
+	// The body is optional
+	var req domain.ReverseStockMovementRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			h.respondWithError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+	}
+
+	reversals, err := h.stockService.ReverseStockMovement(r.Context(), id, &req, user.ID)
+	if err != nil {
+		h.respondWithStockError(w, err, "Failed to reverse stock movement")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusCreated, map[string]interface{}{
+		"reversals": reversals,
+	})
+}
+
 func (h *StockHandler) GetStockMovements(w http.ResponseWriter, r *http.Request) {
 	// Parse query parameters
 	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
@@ -113,6 +263,12 @@ func (h *StockHandler) GetStockMovements(w http.ResponseWriter, r *http.Request)
 		}
 	}
 
+	if warehouseID := r.URL.Query().Get("warehouse_id"); warehouseID != "" {
+		if id, err := strconv.Atoi(warehouseID); err == nil {
+			filter.WarehouseID = &id
+		}
+	}
+
 	if userID := r.URL.Query().Get("user_id"); userID != "" {
 		if id, err := strconv.Atoi(userID); err == nil {
 			filter.UserID = &id
@@ -120,12 +276,44 @@ func (h *StockHandler) GetStockMovements(w http.ResponseWriter, r *http.Request)
 	}
 
 	if movementType := r.URL.Query().Get("type"); movementType != "" {
-		if movementType == "IN" || movementType == "OUT" {
+		if movementType == "IN" || movementType == "OUT" || movementType == "TRANSFER" || movementType == "ADJUST" || movementType == "REVERSAL" {
 			typeVal := domain.StockMovementType(movementType)
 			filter.Type = &typeVal
 		}
 	}
 
+	if reasonCode := r.URL.Query().Get("reason_code"); reasonCode != "" {
+		if reasonVal := domain.ReasonCode(reasonCode); reasonVal.IsValid() {
+			filter.ReasonCode = &reasonVal
+		}
+	}
+
+	if lotNumber := r.URL.Query().Get("lot_number"); lotNumber != "" {
+		filter.LotNumber = &lotNumber
+	}
+
+	if serialNumber := r.URL.Query().Get("serial_number"); serialNumber != "" {
+		filter.SerialNumber = &serialNumber
+	}
+
+	if purchaseOrderID := r.URL.Query().Get("purchase_order_id"); purchaseOrderID != "" {
+		if id, err := strconv.Atoi(purchaseOrderID); err == nil {
+			filter.PurchaseOrderID = &id
+		}
+	}
+
+	if salesOrderID := r.URL.Query().Get("sales_order_id"); salesOrderID != "" {
+		if id, err := strconv.Atoi(salesOrderID); err == nil {
+			filter.SalesOrderID = &id
+		}
+	}
+
+	if rmaID := r.URL.Query().Get("return_authorization_id"); rmaID != "" {
+		if id, err := strconv.Atoi(rmaID); err == nil {
+			filter.ReturnAuthorizationID = &id
+		}
+	}
+
 	if dateFrom := r.URL.Query().Get("date_from"); dateFrom != "" {
 		if date, err := time.Parse("2006-01-02", dateFrom); err == nil {
 			filter.DateFrom = &date
@@ -182,6 +370,222 @@ func (h *StockHandler) GetStockMovement(w http.ResponseWriter, r *http.Request)
 	h.respondWithJSON(w, http.StatusOK, movement)
 }
 
+func (h *StockHandler) GetStockSummary(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	productID, err := strconv.Atoi(vars["productId"])
+	if err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+
+	var warehouseID *int
+	if warehouse := r.URL.Query().Get("warehouse_id"); warehouse != "" {
+		id, err := strconv.Atoi(warehouse)
+		if err != nil {
+			h.respondWithError(w, http.StatusBadRequest, "Invalid warehouse ID")
+			return
+		}
+		warehouseID = &id
+	}
+
+	summary, err := h.stockService.GetStockSummary(r.Context(), productID, r.URL.Query().Get("uom"), warehouseID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			h.respondWithError(w, http.StatusNotFound, "Product or warehouse not found")
+			return
+		}
+		if errors.Is(err, domain.ErrInvalidInput) {
+			h.respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		h.respondWithError(w, http.StatusInternalServerError, "Failed to get stock summary")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, summary)
+}
+
+func (h *StockHandler) GetStockByLocation(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	locationID, err := strconv.Atoi(vars["locationId"])
+	if err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid location ID")
+		return
+	}
+
+	stock, err := h.stockService.GetStockByLocation(r.Context(), locationID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			h.respondWithError(w, http.StatusNotFound, "Location not found")
+			return
+		}
+		h.respondWithError(w, http.StatusInternalServerError, "Failed to get location stock")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, stock)
+}
+
+// GetStockByWarehouse returns the stock totals of a warehouse, overall and per product
+func (h *StockHandler) GetStockByWarehouse(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	warehouseID, err := strconv.Atoi(vars["warehouseId"])
+	if err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid warehouse ID")
+		return
+	}
+
+	stock, err := h.stockService.GetStockByWarehouse(r.Context(), warehouseID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			h.respondWithError(w, http.StatusNotFound, "Warehouse not found")
+			return
+		}
+		h.respondWithError(w, http.StatusInternalServerError, "Failed to get warehouse stock")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, stock)
+}
+
+// GetSerialHistory returns the current location and full movement history of a serial number
+func (h *StockHandler) GetSerialHistory(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	serialNumber := vars["serialNumber"]
+	if serialNumber == "" {
+		h.respondWithError(w, http.StatusBadRequest, "Serial number is required")
+		return
+	}
+
+	histories, err := h.stockService.GetSerialHistory(r.Context(), serialNumber)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			h.respondWithError(w, http.StatusNotFound, "Serial number not found")
+			return
+		}
+		h.respondWithError(w, http.StatusInternalServerError, "Failed to get serial number history")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"serials": histories,
+	})
+}
+
+// GetStockAsOf returns the on-hand quantities per product and location at a point in time.
+// A bare date means the end of that day (UTC), or now for today.
+func (h *StockHandler) GetStockAsOf(w http.ResponseWriter, r *http.Request) {
+	at := r.URL.Query().Get("at")
+	if at == "" {
+		h.respondWithError(w, http.StatusBadRequest, "Query parameter 'at' is required")
+		return
+	}
+
+	filter := &domain.StockAsOfFilter{}
+	if timestamp, err := time.Parse(time.RFC3339, at); err == nil {
+		filter.At = timestamp
+	} else if date, err := time.Parse("2006-01-02", at); err == nil {
+		filter.At = date.AddDate(0, 0, 1).Add(-time.Microsecond)
+		// Today has not ended yet
+		if now := time.Now(); filter.At.After(now) && !date.After(now) {
+			filter.At = now
+		}
+	} else {
+		h.respondWithError(w, http.StatusBadRequest, "Query parameter 'at' must be an RFC 3339 timestamp or a YYYY-MM-DD date")
+		return
+	}
+
+	if productID := r.URL.Query().Get("product_id"); productID != "" {
+		if id, err := strconv.Atoi(productID); err == nil {
+			filter.ProductID = &id
+		}
+	}
+
+	if locationID := r.URL.Query().Get("location_id"); locationID != "" {
+		if id, err := strconv.Atoi(locationID); err == nil {
+			filter.LocationID = &id
+		}
+	}
+
+	stock, err := h.stockService.GetStockAsOf(r.Context(), filter)
+	if err != nil {
+		h.respondWithStockError(w, err, "Failed to get stock as of the requested time")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, stock)
+}
+
+// GetInventoryValuation reports the value of the stock on hand by product, category or location
+func (h *StockHandler) GetInventoryValuation(w http.ResponseWriter, r *http.Request) {
+	filter := &domain.ValuationFilter{
+		GroupBy: domain.ValuationGroupBy(strings.ToUpper(r.URL.Query().Get("group_by"))),
+	}
+
+	if productID := r.URL.Query().Get("product_id"); productID != "" {
+		if id, err := strconv.Atoi(productID); err == nil {
+			filter.ProductID = &id
+		}
+	}
+
+	if locationID := r.URL.Query().Get("location_id"); locationID != "" {
+		if id, err := strconv.Atoi(locationID); err == nil {
+			filter.LocationID = &id
+		}
+	}
+
+	if category := r.URL.Query().Get("category"); category != "" {
+		filter.Category = &category
+	}
+
+	if dateFrom := r.URL.Query().Get("date_from"); dateFrom != "" {
+		if date, err := time.Parse("2006-01-02", dateFrom); err == nil {
+			filter.DateFrom = &date
+		}
+	}
+
+	if dateTo := r.URL.Query().Get("date_to"); dateTo != "" {
+		if date, err := time.Parse("2006-01-02", dateTo); err == nil {
+			filter.DateTo = &date
+		}
+	}
+
+	report, err := h.stockService.GetInventoryValuation(r.Context(), filter)
+	if err != nil {
+		h.respondWithStockError(w, err, "Failed to get inventory valuation")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, report)
+}
+
+// respondWithStockError maps stock business rule violations to client errors
+func (h *StockHandler) respondWithStockError(w http.ResponseWriter, err error, fallback string) {
+	code, message := h.stockErrorMessage(err, fallback)
+	h.respondWithError(w, code, message)
+}
+
+func (h *StockHandler) stockErrorMessage(err error, fallback string) (int, string) {
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		return http.StatusNotFound, "Product, location or stock movement not found"
+	case errors.Is(err, domain.ErrInsufficientStock):
+		return http.StatusBadRequest, "Insufficient stock for this operation"
+	case errors.Is(err, domain.ErrExceedsCapacity):
+		return http.StatusBadRequest, err.Error()
+	case errors.Is(err, domain.ErrTemperatureRange):
+		return http.StatusBadRequest, err.Error()
+	case errors.Is(err, domain.ErrInvalidInput):
+		return http.StatusBadRequest, err.Error()
+	case errors.Is(err, domain.ErrInvalidStatus):
+		return http.StatusConflict, err.Error()
+	case errors.Is(err, domain.ErrForbidden):
+		return http.StatusForbidden, err.Error()
+	default:
+		return http.StatusInternalServerError, fallback
+	}
+}
+
 func (h *StockHandler) respondWithError(w http.ResponseWriter, code int, message string) {
 	response := domain.APIResponse{
 		Success: false,
@@ -208,16 +612,28 @@ func (h *StockHandler) respondWithJSON(w http.ResponseWriter, code int, payload
 }
 
 // SetupStockRoutes sets up stock routes
-func (h *StockHandler) SetupRoutes(router *mux.Router, authMiddleware *middleware.AuthMiddleware) {
+func (h *StockHandler) SetupRoutes(router *mux.Router, authMiddleware *middleware.AuthMiddleware, idempotencyMiddleware *middleware.IdempotencyMiddleware) {
 	stock := router.PathPrefix("/stock-movements").Subrouter()
 	stock.Use(authMiddleware.FlexibleAuth) // All stock endpoints require authentication
 
 	// Stock movement routes
-	stock.HandleFunc("", h.ProcessStockMovement).Methods("POST")
+	stock.Handle("", idempotencyMiddleware.Idempotent(http.HandlerFunc(h.ProcessStockMovement))).Methods("POST") // Safe to retry with an Idempotency-Key header
 	stock.HandleFunc("", h.GetStockMovements).Methods("GET")
+	stock.Handle("/batch", idempotencyMiddleware.Idempotent(http.HandlerFunc(h.ProcessStockMovementBatch))).Methods("POST")
+	stock.HandleFunc("/transfers", h.TransferStock).Methods("POST")
+	stock.HandleFunc("/adjustments", h.AdjustStock).Methods("POST")
 	stock.HandleFunc("/{id:[0-9]+}", h.GetStockMovement).Methods("GET")
+	stock.HandleFunc("/{id:[0-9]+}/reverse", h.ReverseStockMovement).Methods("POST")
+
+	// Stock summary routes
+	stock.HandleFunc("/products/{productId:[0-9]+}/summary", h.GetStockSummary).Methods("GET")
+	stock.HandleFunc("/locations/{locationId:[0-9]+}", h.GetStockByLocation).Methods("GET")
+	stock.HandleFunc("/warehouses/{warehouseId:[0-9]+}", h.GetStockByWarehouse).Methods("GET")
+	stock.HandleFunc("/as-of", h.GetStockAsOf).Methods("GET")
+
+	// Inventory valuation routes
+	stock.HandleFunc("/valuation", h.GetInventoryValuation).Methods("GET")
 
-	// // Stock summary routes
-	// stock.HandleFunc("/products/{productId:[0-9]+}/summary", h.GetStockSummary).Methods("GET")
-	// stock.HandleFunc("/locations/{locationId:[0-9]+}", h.GetStockByLocation).Methods("GET")
+	// Serial number routes
+	stock.HandleFunc("/serials/{serialNumber}", h.GetSerialHistory).Methods("GET")
 }
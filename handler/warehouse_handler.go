@@ -0,0 +1,171 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/edwinjordan/wmsTest_Golang/domain"
+	"github.com/edwinjordan/wmsTest_Golang/middleware"
+	"github.com/edwinjordan/wmsTest_Golang/service"
+	"github.com/gorilla/mux"
+)
+
+type WarehouseHandler struct {
+	warehouseService service.WarehouseService
+}
+
+func NewWarehouseHandler(warehouseService service.WarehouseService) *WarehouseHandler {
+	return &WarehouseHandler{
+		warehouseService: warehouseService,
+	}
+}
+
+func (h *WarehouseHandler) CreateWarehouse(w http.ResponseWriter, r *http.Request) {
+	var req domain.CreateWarehouseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	// Basic validation
+	if req.Code == "" || req.Name == "" {
+		h.respondWithError(w, http.StatusBadRequest, "Code and name are required")
+		return
+	}
+
+	warehouse, err := h.warehouseService.CreateWarehouse(r.Context(), &req)
+	if err != nil {
+		h.respondWithWarehouseError(w, err, "Failed to create warehouse")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusCreated, warehouse)
+}
+
+func (h *WarehouseHandler) GetWarehouse(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid warehouse ID")
+		return
+	}
+
+	warehouse, err := h.warehouseService.GetWarehouse(r.Context(), id)
+	if err != nil {
+		h.respondWithWarehouseError(w, err, "Failed to get warehouse")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, warehouse)
+}
+
+func (h *WarehouseHandler) UpdateWarehouse(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid warehouse ID")
+		return
+	}
+
+	var req domain.UpdateWarehouseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	warehouse, err := h.warehouseService.UpdateWarehouse(r.Context(), id, &req)
+	if err != nil {
+		h.respondWithWarehouseError(w, err, "Failed to update warehouse")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, warehouse)
+}
+
+func (h *WarehouseHandler) ListWarehouses(w http.ResponseWriter, r *http.Request) {
+	// Parse query parameters
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+
+	if limit <= 0 {
+		limit = 20
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	warehouses, total, err := h.warehouseService.ListWarehouses(r.Context(), limit, offset)
+	if err != nil {
+		h.respondWithError(w, http.StatusInternalServerError, "Failed to list warehouses")
+		return
+	}
+
+	// Calculate pagination
+	totalPages := (total + limit - 1) / limit
+	page := (offset / limit) + 1
+
+	response := map[string]interface{}{
+		"warehouses": warehouses,
+		"meta": domain.Meta{
+			Page:       page,
+			Limit:      limit,
+			Total:      total,
+			TotalPages: totalPages,
+		},
+	}
+
+	h.respondWithJSON(w, http.StatusOK, response)
+}
+
+func (h *WarehouseHandler) respondWithWarehouseError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		h.respondWithError(w, http.StatusNotFound, "Warehouse not found")
+	case errors.Is(err, domain.ErrDuplicateEntry):
+		h.respondWithError(w, http.StatusConflict, "Warehouse with this code already exists")
+	case errors.Is(err, domain.ErrInvalidStatus):
+		h.respondWithError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, domain.ErrInvalidInput):
+		h.respondWithError(w, http.StatusBadRequest, err.Error())
+	default:
+		h.respondWithError(w, http.StatusInternalServerError, fallback)
+	}
+}
+
+func (h *WarehouseHandler) respondWithError(w http.ResponseWriter, code int, message string) {
+	response := domain.APIResponse{
+		Success: false,
+		Error: &domain.APIError{
+			Code:    code,
+			Message: message,
+		},
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *WarehouseHandler) respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
+	response := domain.APIResponse{
+		Success: true,
+		Data:    payload,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(response)
+}
+
+// SetupRoutes sets up warehouse routes
+func (h *WarehouseHandler) SetupRoutes(router *mux.Router, authMiddleware *middleware.AuthMiddleware) {
+	warehouses := router.PathPrefix("/warehouses").Subrouter()
+	warehouses.Use(authMiddleware.FlexibleAuth) // All warehouse endpoints require authentication
+
+	warehouses.HandleFunc("", h.CreateWarehouse).Methods("POST")
+	warehouses.HandleFunc("", h.ListWarehouses).Methods("GET")
+	warehouses.HandleFunc("/{id:[0-9]+}", h.GetWarehouse).Methods("GET")
+	warehouses.HandleFunc("/{id:[0-9]+}", h.UpdateWarehouse).Methods("PUT")
+}
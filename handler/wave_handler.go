@@ -0,0 +1,238 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/edwinjordan/wmsTest_Golang/domain"
+	"github.com/edwinjordan/wmsTest_Golang/middleware"
+	"github.com/edwinjordan/wmsTest_Golang/service"
+	"github.com/gorilla/mux"
+)
+
+type WaveHandler struct {
+	waveService service.WaveService
+}
+
+func NewWaveHandler(waveService service.WaveService) *WaveHandler {
+	return &WaveHandler{
+		waveService: waveService,
+	}
+}
+
+// BuildWaves groups new sales orders into planned waves and allocates their stock
+func (h *WaveHandler) BuildWaves(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		h.respondWithError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	var req domain.BuildWavesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	// Basic validation
+	if req.GroupBy == "" {
+		h.respondWithError(w, http.StatusBadRequest, "Group by is required")
+		return
+	}
+
+	build, err := h.waveService.BuildWaves(r.Context(), &req, user.ID)
+	if err != nil {
+		h.respondWithWaveError(w, err, "Failed to build waves")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusCreated, build)
+}
+
+func (h *WaveHandler) GetWave(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.parseID(w, r)
+	if !ok {
+		return
+	}
+
+	wave, err := h.waveService.GetWave(r.Context(), id)
+	if err != nil {
+		h.respondWithWaveError(w, err, "Failed to get wave")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, wave)
+}
+
+func (h *WaveHandler) ListWaves(w http.ResponseWriter, r *http.Request) {
+	// Parse query parameters
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+
+	if limit <= 0 {
+		limit = 20
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	filter := &domain.WaveFilter{
+		Limit:  limit,
+		Offset: offset,
+	}
+
+	if status := r.URL.Query().Get("status"); status != "" {
+		statusVal := domain.WaveStatus(status)
+		filter.Status = &statusVal
+	}
+
+	if warehouseID := r.URL.Query().Get("warehouse_id"); warehouseID != "" {
+		if id, err := strconv.Atoi(warehouseID); err == nil {
+			filter.WarehouseID = &id
+		}
+	}
+
+	waves, total, err := h.waveService.ListWaves(r.Context(), filter)
+	if err != nil {
+		h.respondWithError(w, http.StatusInternalServerError, "Failed to list waves")
+		return
+	}
+
+	// Calculate pagination
+	totalPages := (total + limit - 1) / limit
+	page := (offset / limit) + 1
+
+	response := map[string]interface{}{
+		"waves": waves,
+		"meta": domain.Meta{
+			Page:       page,
+			Limit:      limit,
+			Total:      total,
+			TotalPages: totalPages,
+		},
+	}
+
+	h.respondWithJSON(w, http.StatusOK, response)
+}
+
+func (h *WaveHandler) ReleaseWave(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.parseID(w, r)
+	if !ok {
+		return
+	}
+
+	wave, err := h.waveService.ReleaseWave(r.Context(), id)
+	if err != nil {
+		h.respondWithWaveError(w, err, "Failed to release wave")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, wave)
+}
+
+func (h *WaveHandler) CloseWave(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.parseID(w, r)
+	if !ok {
+		return
+	}
+
+	wave, err := h.waveService.CloseWave(r.Context(), id)
+	if err != nil {
+		h.respondWithWaveError(w, err, "Failed to close wave")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, wave)
+}
+
+// GetPickList returns the consolidated pick list of a wave, as JSON or printable text
+func (h *WaveHandler) GetPickList(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.parseID(w, r)
+	if !ok {
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "json" && format != "text" {
+		h.respondWithError(w, http.StatusBadRequest, "Format must be json or text")
+		return
+	}
+
+	pickList, err := h.waveService.GetPickList(r.Context(), id)
+	if err != nil {
+		h.respondWithWaveError(w, err, "Failed to get wave pick list")
+		return
+	}
+
+	if format == "text" {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		writePickList(w, pickList)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, pickList)
+}
+
+func (h *WaveHandler) parseID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid wave ID")
+		return 0, false
+	}
+	return id, true
+}
+
+func (h *WaveHandler) respondWithWaveError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		h.respondWithError(w, http.StatusNotFound, "Wave, sales order or warehouse not found")
+	case errors.Is(err, domain.ErrInvalidInput):
+		h.respondWithError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, domain.ErrInvalidStatus):
+		h.respondWithError(w, http.StatusConflict, err.Error())
+	default:
+		h.respondWithError(w, http.StatusInternalServerError, fallback)
+	}
+}
+
+func (h *WaveHandler) respondWithError(w http.ResponseWriter, code int, message string) {
+	response := domain.APIResponse{
+		Success: false,
+		Error: &domain.APIError{
+			Code:    code,
+			Message: message,
+		},
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *WaveHandler) respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
+	response := domain.APIResponse{
+		Success: true,
+		Data:    payload,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(response)
+}
+
+// SetupRoutes sets up wave routes
+func (h *WaveHandler) SetupRoutes(router *mux.Router, authMiddleware *middleware.AuthMiddleware) {
+	waves := router.PathPrefix("/waves").Subrouter()
+	waves.Use(authMiddleware.FlexibleAuth) // All wave endpoints require authentication
+
+	waves.HandleFunc("", h.BuildWaves).Methods("POST")
+	waves.HandleFunc("", h.ListWaves).Methods("GET")
+	waves.HandleFunc("/{id:[0-9]+}", h.GetWave).Methods("GET")
+	waves.HandleFunc("/{id:[0-9]+}/release", h.ReleaseWave).Methods("POST")
+	waves.HandleFunc("/{id:[0-9]+}/close", h.CloseWave).Methods("POST")
+	waves.HandleFunc("/{id:[0-9]+}/pick-list", h.GetPickList).Methods("GET")
+}
@@ -32,12 +32,8 @@ func main() {
 	defer db.Close()
 
 	// Initialize repositories
-	repos := &repository.Repositories{
-		User:          repository.NewUserRepository(db.DB),
-		Product:       repository.NewProductRepository(db.DB),
-		Location:      repository.NewLocationRepository(db.DB),
-		StockMovement: repository.NewStockMovementRepository(db.DB),
-	}
+	repos := repository.NewRepositories(db.DB)
+	uow := repository.NewUnitOfWork(db.DB)
 
 	// Initialize services
 	jwtSecret := os.Getenv("JWT_SECRET")
@@ -47,18 +43,42 @@ func main() {
 	}
 
 	authService := service.NewAuthService(repos.User, jwtSecret)
-	productService := service.NewProductService(repos.Product)
-	locationService := service.NewLocationService(repos.Location)
-	stockService := service.NewStockService(repos.StockMovement, repos.Product, repos.Location)
+	productService := service.NewProductService(repos.Product, repos.ProductUnit, uow)
+	locationService := service.NewLocationService(repos.Location, repos.Warehouse)
+	stockService := service.NewStockService(repos.StockMovement, repos.Product, repos.ProductUnit, repos.Location, repos.Warehouse, repos.StockLevel, repos.StockSnapshot, repos.SerialNumber, repos.Reservation, repos.CostLayer, uow)
+	cycleCountService := service.NewCycleCountService(repos.CycleCount, uow)
+	reservationService := service.NewReservationService(repos.Reservation, uow)
+	idempotencyService := service.NewIdempotencyService(repos.Idempotency)
+	reorderService := service.NewReorderService(repos.ReorderRule, repos.StockAlert, uow)
+	putawayService := service.NewPutawayService(repos.Product, repos.ProductUnit, repos.Location, repos.StockLevel, repos.StockMovement, repos.Warehouse)
+	pickListService := service.NewPickListService(repos.Product, repos.ProductUnit, repos.StockLevel, repos.Reservation, repos.Warehouse)
+	purchaseOrderService := service.NewPurchaseOrderService(repos.Supplier, repos.PurchaseOrder, repos.Product, repos.ProductUnit, repos.StockMovement, uow)
+	salesOrderService := service.NewSalesOrderService(repos.SalesOrder, repos.Product, repos.ProductUnit, repos.StockMovement, repos.Warehouse, uow)
+	waveService := service.NewWaveService(repos.Wave, repos.SalesOrder, uow)
+	returnService := service.NewReturnService(repos.Return, repos.StockMovement, uow)
+	inventoryStatusService := service.NewInventoryStatusService(repos.StatusChange, uow)
+	warehouseService := service.NewWarehouseService(repos.Warehouse, repos.Location)
 
 	// Initialize middleware
 	authMiddleware := middleware.NewAuthMiddleware(authService)
+	idempotencyMiddleware := middleware.NewIdempotencyMiddleware(idempotencyService)
 
 	// Initialize handlers
 	authHandler := handler.NewAuthHandler(authService)
 	productHandler := handler.NewProductHandler(productService)
 	locationHandler := handler.NewLocationHandler(locationService)
 	stockHandler := handler.NewStockHandler(stockService)
+	cycleCountHandler := handler.NewCycleCountHandler(cycleCountService)
+	reservationHandler := handler.NewReservationHandler(reservationService)
+	reorderHandler := handler.NewReorderHandler(reorderService)
+	putawayHandler := handler.NewPutawayHandler(putawayService)
+	pickListHandler := handler.NewPickListHandler(pickListService)
+	purchaseOrderHandler := handler.NewPurchaseOrderHandler(purchaseOrderService)
+	salesOrderHandler := handler.NewSalesOrderHandler(salesOrderService)
+	waveHandler := handler.NewWaveHandler(waveService)
+	returnHandler := handler.NewReturnHandler(returnService)
+	inventoryStatusHandler := handler.NewInventoryStatusHandler(inventoryStatusService)
+	warehouseHandler := handler.NewWarehouseHandler(warehouseService)
 
 	// Setup router
 	router := mux.NewRouter()
@@ -73,7 +93,18 @@ func main() {
 	authHandler.SetupRoutes(api, authMiddleware)
 	productHandler.SetupRoutes(api, authMiddleware)
 	locationHandler.SetupRoutes(api, authMiddleware)
-	stockHandler.SetupRoutes(api, authMiddleware)
+	stockHandler.SetupRoutes(api, authMiddleware, idempotencyMiddleware)
+	cycleCountHandler.SetupRoutes(api, authMiddleware)
+	reservationHandler.SetupRoutes(api, authMiddleware)
+	reorderHandler.SetupRoutes(api, authMiddleware)
+	putawayHandler.SetupRoutes(api, authMiddleware)
+	pickListHandler.SetupRoutes(api, authMiddleware)
+	purchaseOrderHandler.SetupRoutes(api, authMiddleware, idempotencyMiddleware)
+	salesOrderHandler.SetupRoutes(api, authMiddleware, idempotencyMiddleware)
+	waveHandler.SetupRoutes(api, authMiddleware)
+	returnHandler.SetupRoutes(api, authMiddleware, idempotencyMiddleware)
+	inventoryStatusHandler.SetupRoutes(api, authMiddleware)
+	warehouseHandler.SetupRoutes(api, authMiddleware)
 
 	// Health check endpoint (no authentication required)
 	router.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,181 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/edwinjordan/wmsTest_Golang/domain"
+	"github.com/edwinjordan/wmsTest_Golang/service"
+)
+
+const (
+	IdempotencyKeyHeader      = "Idempotency-Key"
+	IdempotencyReplayedHeader = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLength = 255
+
+	// idempotencyCompleteAttempts is how often storing a response is tried before the key is left
+	// in flight
+	idempotencyCompleteAttempts = 3
+)
+
+type IdempotencyMiddleware struct {
+	idempotencyService service.IdempotencyService
+}
+
+func NewIdempotencyMiddleware(idempotencyService service.IdempotencyService) *IdempotencyMiddleware {
+	return &IdempotencyMiddleware{
+		idempotencyService: idempotencyService,
+	}
+}
+
+// Idempotent makes a write endpoint safe to retry. When the request carries an Idempotency-Key
+// header, the first response for that key is stored and replayed for retries with the same body;
+// reusing the key with a different body is rejected. It must run after authentication, as keys
+// are scoped per user.
+func (m *IdempotencyMiddleware) Idempotent(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(IdempotencyKeyHeader)
+		if key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			m.respondWithError(w, http.StatusBadRequest, "Idempotency-Key must be at most 255 characters")
+			return
+		}
+
+		user, ok := GetUserFromContext(r.Context())
+		if !ok {
+			m.respondWithError(w, http.StatusUnauthorized, "User not found in context")
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			m.respondWithError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		claim, err := m.idempotencyService.Begin(r.Context(), user.ID, key, hashRequest(r, body))
+		if err != nil {
+			switch {
+			case errors.Is(err, domain.ErrIdempotencyKeyReused):
+				m.respondWithError(w, http.StatusUnprocessableEntity, err.Error())
+			case errors.Is(err, domain.ErrIdempotencyInProgress):
+				m.respondWithError(w, http.StatusConflict, err.Error())
+			default:
+				m.respondWithError(w, http.StatusInternalServerError, "Failed to process idempotency key")
+			}
+			return
+		}
+
+		// Retry of a completed request: replay the original response
+		if claim.StatusCode != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set(IdempotencyReplayedHeader, "true")
+			w.WriteHeader(*claim.StatusCode)
+			w.Write(claim.ResponseBody)
+			return
+		}
+
+		// A handler that fails leaves the outcome unknown to the client, so the key is released for a
+		// retry, also when next panics
+		handlerFailed := true
+		defer func() {
+			recovered := recover()
+			if handlerFailed {
+				if err := m.idempotencyService.Abandon(context.WithoutCancel(r.Context()), claim); err != nil {
+					println("Failed to release idempotency key:", err.Error())
+				}
+			}
+			if recovered != nil {
+				panic(recovered)
+			}
+		}()
+
+		recorder := &recordingResponseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+
+		if recorder.statusCode >= http.StatusInternalServerError {
+			return
+		}
+		handlerFailed = false
+
+		// The request took effect, so the key must not be released even if storing the response
+		// fails; it then stays in flight until its lease expires
+		m.complete(context.WithoutCancel(r.Context()), claim, recorder.statusCode, recorder.body.Bytes())
+	})
+}
+
+// complete stores the response, retrying a few times as a failed store leaves retries of the
+// request waiting for the lease. A claim that lost the key to another request is not retried.
+func (m *IdempotencyMiddleware) complete(ctx context.Context, claim *domain.IdempotencyRecord, statusCode int, body []byte) {
+	var err error
+	for attempt := 0; attempt < idempotencyCompleteAttempts; attempt++ {
+		err = m.idempotencyService.Complete(ctx, claim, statusCode, body)
+		if err == nil || errors.Is(err, domain.ErrIdempotencyClaimLost) {
+			break
+		}
+	}
+	if err != nil {
+		println("Failed to store idempotent response:", err.Error())
+	}
+}
+
+// hashRequest fingerprints the request target and body. JSON bodies are compared by content,
+// so retries that only differ in formatting or key order count as the same request.
+func hashRequest(r *http.Request, body []byte) string {
+	canonical := body
+	var decoded interface{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&decoded); err == nil {
+		if encoded, err := json.Marshal(decoded); err == nil {
+			canonical = encoded
+		}
+	}
+
+	hash := sha256.New()
+	hash.Write([]byte(r.Method + " " + r.URL.Path + "\n"))
+	hash.Write(canonical)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func (m *IdempotencyMiddleware) respondWithError(w http.ResponseWriter, code int, message string) {
+	response := domain.APIResponse{
+		Success: false,
+		Error: &domain.APIError{
+			Code:    code,
+			Message: message,
+		},
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(response)
+}
+
+// recordingResponseWriter passes the response through while keeping a copy of it
+type recordingResponseWriter struct {
+	http.ResponseWriter
+	statusCode int
+	body       bytes.Buffer
+}
+
+func (rw *recordingResponseWriter) WriteHeader(code int) {
+	rw.statusCode = code
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+func (rw *recordingResponseWriter) Write(b []byte) (int, error) {
+	rw.body.Write(b)
+	return rw.ResponseWriter.Write(b)
+}
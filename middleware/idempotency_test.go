@@ -0,0 +1,164 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/edwinjordan/wmsTest_Golang/domain"
+)
+
+// fakeIdempotencyService records how the middleware settles a key
+type fakeIdempotencyService struct {
+	beginRecord *domain.IdempotencyRecord
+	beginErr    error
+	completeErr error
+
+	begun     int
+	completed int
+	abandoned int
+}
+
+func (s *fakeIdempotencyService) Begin(ctx context.Context, userID int, key, requestHash string) (*domain.IdempotencyRecord, error) {
+	s.begun++
+	if s.beginRecord == nil && s.beginErr == nil {
+		return &domain.IdempotencyRecord{UserID: userID, Key: key, RequestHash: requestHash, ClaimToken: "claim-1"}, nil
+	}
+	return s.beginRecord, s.beginErr
+}
+
+func (s *fakeIdempotencyService) Complete(ctx context.Context, claim *domain.IdempotencyRecord, statusCode int, body []byte) error {
+	s.completed++
+	return s.completeErr
+}
+
+func (s *fakeIdempotencyService) Abandon(ctx context.Context, claim *domain.IdempotencyRecord) error {
+	s.abandoned++
+	return nil
+}
+
+func idempotentRequest(key string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/stock-movements", strings.NewReader(`{"product_id": 1}`))
+	if key != "" {
+		r.Header.Set(IdempotencyKeyHeader, key)
+	}
+	return r.WithContext(context.WithValue(r.Context(), UserContextKey, &domain.User{ID: 7}))
+}
+
+func TestIdempotentSettlesKey(t *testing.T) {
+	stored := http.StatusCreated
+
+	tests := []struct {
+		name          string
+		key           string
+		service       *fakeIdempotencyService
+		handler       http.HandlerFunc
+		wantStatus    int
+		wantCalled    bool
+		wantBegun     int
+		wantCompleted int
+		wantAbandoned int
+	}{
+		{
+			name:       "no key passes through",
+			service:    &fakeIdempotencyService{},
+			handler:    func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusCreated) },
+			wantStatus: http.StatusCreated, wantCalled: true,
+		},
+		{
+			name:       "success is stored",
+			key:        "key-1",
+			service:    &fakeIdempotencyService{},
+			handler:    func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusCreated) },
+			wantStatus: http.StatusCreated, wantCalled: true, wantBegun: 1, wantCompleted: 1,
+		},
+		{
+			name:       "client error is stored",
+			key:        "key-1",
+			service:    &fakeIdempotencyService{},
+			handler:    func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusBadRequest) },
+			wantStatus: http.StatusBadRequest, wantCalled: true, wantBegun: 1, wantCompleted: 1,
+		},
+		{
+			name:       "server error releases the key",
+			key:        "key-1",
+			service:    &fakeIdempotencyService{},
+			handler:    func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusInternalServerError) },
+			wantStatus: http.StatusInternalServerError, wantCalled: true, wantBegun: 1, wantAbandoned: 1,
+		},
+		{
+			name:       "failing to store the response keeps the key",
+			key:        "key-1",
+			service:    &fakeIdempotencyService{completeErr: errors.New("connection reset")},
+			handler:    func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusCreated) },
+			wantStatus: http.StatusCreated, wantCalled: true, wantBegun: 1, wantCompleted: idempotencyCompleteAttempts,
+		},
+		{
+			name:       "claim taken over by another request is not retried",
+			key:        "key-1",
+			service:    &fakeIdempotencyService{completeErr: domain.ErrIdempotencyClaimLost},
+			handler:    func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusCreated) },
+			wantStatus: http.StatusCreated, wantCalled: true, wantBegun: 1, wantCompleted: 1,
+		},
+		{
+			name:       "completed request is replayed",
+			key:        "key-1",
+			service:    &fakeIdempotencyService{beginRecord: &domain.IdempotencyRecord{StatusCode: &stored, ResponseBody: []byte(`{}`)}},
+			handler:    func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusCreated) },
+			wantStatus: http.StatusCreated, wantBegun: 1,
+		},
+		{
+			name:       "request in flight is rejected",
+			key:        "key-1",
+			service:    &fakeIdempotencyService{beginErr: domain.ErrIdempotencyInProgress},
+			handler:    func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusCreated) },
+			wantStatus: http.StatusConflict, wantBegun: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called := false
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				called = true
+				tt.handler(w, r)
+			})
+			recorder := httptest.NewRecorder()
+
+			NewIdempotencyMiddleware(tt.service).Idempotent(next).ServeHTTP(recorder, idempotentRequest(tt.key))
+
+			if recorder.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", recorder.Code, tt.wantStatus)
+			}
+			if called != tt.wantCalled {
+				t.Errorf("handler called = %v, want %v", called, tt.wantCalled)
+			}
+			if tt.service.begun != tt.wantBegun || tt.service.completed != tt.wantCompleted || tt.service.abandoned != tt.wantAbandoned {
+				t.Errorf("begun %d, completed %d, abandoned %d; want %d, %d, %d",
+					tt.service.begun, tt.service.completed, tt.service.abandoned, tt.wantBegun, tt.wantCompleted, tt.wantAbandoned)
+			}
+		})
+	}
+}
+
+func TestIdempotentReleasesKeyOnPanic(t *testing.T) {
+	service := &fakeIdempotencyService{}
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("posting failed")
+	})
+
+	defer func() {
+		if recovered := recover(); recovered != "posting failed" {
+			t.Errorf("recovered %v, want the handler's panic", recovered)
+		}
+		if service.completed != 0 || service.abandoned != 1 {
+			t.Errorf("completed %d, abandoned %d; want 0, 1", service.completed, service.abandoned)
+		}
+	}()
+
+	NewIdempotencyMiddleware(service).Idempotent(next).ServeHTTP(httptest.NewRecorder(), idempotentRequest("key-1"))
+	t.Fatal("panic was not passed on")
+}
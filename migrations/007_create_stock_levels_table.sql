@@ -0,0 +1,30 @@
+-- +goose Up
+-- Create stock_levels table (on-hand quantity per product and location)
+CREATE TABLE stock_levels (
+    id SERIAL PRIMARY KEY,
+    product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    location_id INTEGER NOT NULL REFERENCES locations(id) ON DELETE CASCADE,
+    quantity INTEGER NOT NULL DEFAULT 0 CHECK (quantity >= 0),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    UNIQUE (product_id, location_id)
+);
+
+-- Create indexes for stock_levels table
+CREATE INDEX idx_stock_levels_product_id ON stock_levels(product_id);
+CREATE INDEX idx_stock_levels_location_id ON stock_levels(location_id);
+
+-- Build initial balances from the existing movement history
+INSERT INTO stock_levels (product_id, location_id, quantity)
+SELECT product_id, location_id, SUM(CASE WHEN type = 'IN' THEN quantity ELSE -quantity END)
+FROM stock_movements
+GROUP BY product_id, location_id
+HAVING SUM(CASE WHEN type = 'IN' THEN quantity ELSE -quantity END) > 0;
+
+-- +goose Down
+-- Drop indexes first
+DROP INDEX IF EXISTS idx_stock_levels_location_id;
+DROP INDEX IF EXISTS idx_stock_levels_product_id;
+
+-- Drop stock_levels table
+DROP TABLE IF EXISTS stock_levels;
@@ -0,0 +1,21 @@
+-- +goose Up
+-- Allow TRANSFER movements
+ALTER TABLE stock_movements DROP CONSTRAINT IF EXISTS stock_movements_type_check;
+ALTER TABLE stock_movements ADD CONSTRAINT stock_movements_type_check CHECK (type IN ('IN', 'OUT', 'TRANSFER'));
+
+-- Direction of each movement at its location: 1 adds stock, -1 removes it
+ALTER TABLE stock_movements ADD COLUMN direction SMALLINT NOT NULL DEFAULT 1 CHECK (direction IN (-1, 1));
+UPDATE stock_movements SET direction = -1 WHERE type = 'OUT';
+ALTER TABLE stock_movements ALTER COLUMN direction DROP DEFAULT;
+
+-- Link between the two legs of a transfer
+ALTER TABLE stock_movements ADD COLUMN related_movement_id INTEGER REFERENCES stock_movements(id) ON DELETE SET NULL;
+CREATE INDEX idx_stock_movements_related_movement_id ON stock_movements(related_movement_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_stock_movements_related_movement_id;
+ALTER TABLE stock_movements DROP COLUMN IF EXISTS related_movement_id;
+ALTER TABLE stock_movements DROP COLUMN IF EXISTS direction;
+DELETE FROM stock_movements WHERE type = 'TRANSFER';
+ALTER TABLE stock_movements DROP CONSTRAINT IF EXISTS stock_movements_type_check;
+ALTER TABLE stock_movements ADD CONSTRAINT stock_movements_type_check CHECK (type IN ('IN', 'OUT'));
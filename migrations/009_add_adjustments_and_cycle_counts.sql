@@ -0,0 +1,59 @@
+-- +goose Up
+-- Allow ADJUST movements, which must always carry a reason code
+ALTER TABLE stock_movements DROP CONSTRAINT IF EXISTS stock_movements_type_check;
+ALTER TABLE stock_movements ADD CONSTRAINT stock_movements_type_check CHECK (type IN ('IN', 'OUT', 'TRANSFER', 'ADJUST'));
+ALTER TABLE stock_movements ADD COLUMN reason_code VARCHAR(30) NOT NULL DEFAULT '';
+ALTER TABLE stock_movements ADD CONSTRAINT stock_movements_adjust_reason_check CHECK (type <> 'ADJUST' OR reason_code <> '');
+CREATE INDEX idx_stock_movements_reason_code ON stock_movements(reason_code);
+
+-- Create cycle_counts table
+CREATE TABLE cycle_counts (
+    id SERIAL PRIMARY KEY,
+    status VARCHAR(20) NOT NULL DEFAULT 'OPEN' CHECK (status IN ('OPEN', 'POSTED', 'CANCELLED')),
+    notes TEXT,
+    created_by INTEGER NOT NULL REFERENCES users(id),
+    posted_by INTEGER REFERENCES users(id),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    posted_at TIMESTAMP WITH TIME ZONE
+);
+
+-- Locations covered by a count
+CREATE TABLE cycle_count_locations (
+    cycle_count_id INTEGER NOT NULL REFERENCES cycle_counts(id) ON DELETE CASCADE,
+    location_id INTEGER NOT NULL REFERENCES locations(id) ON DELETE CASCADE,
+    PRIMARY KEY (cycle_count_id, location_id)
+);
+
+-- Expected and counted quantity per product and location
+CREATE TABLE cycle_count_lines (
+    id SERIAL PRIMARY KEY,
+    cycle_count_id INTEGER NOT NULL REFERENCES cycle_counts(id) ON DELETE CASCADE,
+    product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    location_id INTEGER NOT NULL REFERENCES locations(id) ON DELETE CASCADE,
+    expected_quantity INTEGER NOT NULL DEFAULT 0,
+    counted_quantity INTEGER CHECK (counted_quantity >= 0),
+    counted_by INTEGER REFERENCES users(id),
+    counted_at TIMESTAMP WITH TIME ZONE,
+    adjusted_quantity INTEGER,
+    adjustment_movement_id INTEGER REFERENCES stock_movements(id) ON DELETE SET NULL,
+    UNIQUE (cycle_count_id, product_id, location_id)
+);
+
+-- Create indexes for cycle count tables
+CREATE INDEX idx_cycle_counts_status ON cycle_counts(status);
+CREATE INDEX idx_cycle_count_lines_cycle_count_id ON cycle_count_lines(cycle_count_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_cycle_count_lines_cycle_count_id;
+DROP INDEX IF EXISTS idx_cycle_counts_status;
+DROP TABLE IF EXISTS cycle_count_lines;
+DROP TABLE IF EXISTS cycle_count_locations;
+DROP TABLE IF EXISTS cycle_counts;
+
+DROP INDEX IF EXISTS idx_stock_movements_reason_code;
+DELETE FROM stock_movements WHERE type = 'ADJUST';
+ALTER TABLE stock_movements DROP CONSTRAINT IF EXISTS stock_movements_adjust_reason_check;
+ALTER TABLE stock_movements DROP COLUMN IF EXISTS reason_code;
+ALTER TABLE stock_movements DROP CONSTRAINT IF EXISTS stock_movements_type_check;
+ALTER TABLE stock_movements ADD CONSTRAINT stock_movements_type_check CHECK (type IN ('IN', 'OUT', 'TRANSFER'));
@@ -0,0 +1,15 @@
+-- +goose Up
+-- Allow REVERSAL movements, which compensate a previously posted movement
+ALTER TABLE stock_movements DROP CONSTRAINT IF EXISTS stock_movements_type_check;
+ALTER TABLE stock_movements ADD CONSTRAINT stock_movements_type_check CHECK (type IN ('IN', 'OUT', 'TRANSFER', 'ADJUST', 'REVERSAL'));
+ALTER TABLE stock_movements ADD COLUMN reversal_of_id INTEGER REFERENCES stock_movements(id) ON DELETE CASCADE;
+
+-- A movement can only be reversed once
+CREATE UNIQUE INDEX idx_stock_movements_reversal_of_id ON stock_movements(reversal_of_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_stock_movements_reversal_of_id;
+DELETE FROM stock_movements WHERE type = 'REVERSAL';
+ALTER TABLE stock_movements DROP COLUMN IF EXISTS reversal_of_id;
+ALTER TABLE stock_movements DROP CONSTRAINT IF EXISTS stock_movements_type_check;
+ALTER TABLE stock_movements ADD CONSTRAINT stock_movements_type_check CHECK (type IN ('IN', 'OUT', 'TRANSFER', 'ADJUST'));
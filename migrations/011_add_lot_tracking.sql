@@ -0,0 +1,51 @@
+-- +goose Up
+-- Create product_lots table (lot/batch master with expiry)
+CREATE TABLE product_lots (
+    id SERIAL PRIMARY KEY,
+    product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    lot_number VARCHAR(50) NOT NULL,
+    expiry_date DATE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    UNIQUE (product_id, lot_number)
+);
+
+CREATE INDEX idx_product_lots_expiry_date ON product_lots(expiry_date);
+
+-- Balances are kept per lot; stock without a lot uses the empty lot number
+ALTER TABLE stock_levels ADD COLUMN lot_number VARCHAR(50) NOT NULL DEFAULT '';
+ALTER TABLE stock_levels DROP CONSTRAINT IF EXISTS stock_levels_product_id_location_id_key;
+ALTER TABLE stock_levels ADD CONSTRAINT stock_levels_product_id_location_id_lot_number_key UNIQUE (product_id, location_id, lot_number);
+
+-- Quantity of each lot moved by a movement
+CREATE TABLE stock_movement_lots (
+    movement_id INTEGER NOT NULL REFERENCES stock_movements(id) ON DELETE CASCADE,
+    lot_number VARCHAR(50) NOT NULL DEFAULT '',
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    PRIMARY KEY (movement_id, lot_number)
+);
+
+CREATE INDEX idx_stock_movement_lots_lot_number ON stock_movement_lots(lot_number);
+
+-- Existing movements moved stock without a lot
+INSERT INTO stock_movement_lots (movement_id, lot_number, quantity)
+SELECT id, '', quantity FROM stock_movements;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_stock_movement_lots_lot_number;
+DROP TABLE IF EXISTS stock_movement_lots;
+
+-- Fold lot balances back into a single balance per product and location
+CREATE TEMP TABLE stock_levels_merged AS
+SELECT product_id, location_id, SUM(quantity) AS quantity
+FROM stock_levels
+GROUP BY product_id, location_id;
+DELETE FROM stock_levels;
+ALTER TABLE stock_levels DROP CONSTRAINT IF EXISTS stock_levels_product_id_location_id_lot_number_key;
+ALTER TABLE stock_levels DROP COLUMN IF EXISTS lot_number;
+INSERT INTO stock_levels (product_id, location_id, quantity)
+SELECT product_id, location_id, quantity FROM stock_levels_merged;
+DROP TABLE stock_levels_merged;
+ALTER TABLE stock_levels ADD CONSTRAINT stock_levels_product_id_location_id_key UNIQUE (product_id, location_id);
+
+DROP INDEX IF EXISTS idx_product_lots_expiry_date;
+DROP TABLE IF EXISTS product_lots;
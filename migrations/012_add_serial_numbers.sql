@@ -0,0 +1,35 @@
+-- +goose Up
+-- Serialized products track every unit by serial number
+ALTER TABLE products ADD COLUMN is_serialized BOOLEAN NOT NULL DEFAULT false;
+
+-- Create serial_numbers table (current whereabouts of each unit; NULL location means not on hand)
+CREATE TABLE serial_numbers (
+    id SERIAL PRIMARY KEY,
+    product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    serial_number VARCHAR(100) NOT NULL,
+    location_id INTEGER REFERENCES locations(id) ON DELETE RESTRICT,
+    lot_number VARCHAR(50) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    UNIQUE (product_id, serial_number)
+);
+
+CREATE INDEX idx_serial_numbers_serial_number ON serial_numbers(serial_number);
+CREATE INDEX idx_serial_numbers_location_id ON serial_numbers(location_id);
+
+-- Serial numbers moved by each stock movement
+CREATE TABLE stock_movement_serials (
+    movement_id INTEGER NOT NULL REFERENCES stock_movements(id) ON DELETE CASCADE,
+    serial_number_id INTEGER NOT NULL REFERENCES serial_numbers(id) ON DELETE CASCADE,
+    PRIMARY KEY (movement_id, serial_number_id)
+);
+
+CREATE INDEX idx_stock_movement_serials_serial_number_id ON stock_movement_serials(serial_number_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_stock_movement_serials_serial_number_id;
+DROP TABLE IF EXISTS stock_movement_serials;
+DROP INDEX IF EXISTS idx_serial_numbers_location_id;
+DROP INDEX IF EXISTS idx_serial_numbers_serial_number;
+DROP TABLE IF EXISTS serial_numbers;
+ALTER TABLE products DROP COLUMN IF EXISTS is_serialized;
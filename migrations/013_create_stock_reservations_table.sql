@@ -0,0 +1,33 @@
+-- +goose Up
+-- Create stock_reservations table (stock promised to an order but not yet shipped)
+CREATE TABLE stock_reservations (
+    id SERIAL PRIMARY KEY,
+    product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE RESTRICT,
+    location_id INTEGER NOT NULL REFERENCES locations(id) ON DELETE RESTRICT,
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    consumed_quantity INTEGER NOT NULL DEFAULT 0 CHECK (consumed_quantity >= 0 AND consumed_quantity <= quantity),
+    status VARCHAR(20) NOT NULL DEFAULT 'ACTIVE' CHECK (status IN ('ACTIVE', 'RELEASED', 'CONSUMED', 'EXPIRED')),
+    reference VARCHAR(100),
+    notes TEXT,
+    expires_at TIMESTAMP WITH TIME ZONE,
+    created_by INTEGER NOT NULL REFERENCES users(id) ON DELETE RESTRICT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    closed_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX idx_stock_reservations_product_location ON stock_reservations(product_id, location_id) WHERE status = 'ACTIVE';
+CREATE INDEX idx_stock_reservations_status ON stock_reservations(status);
+CREATE INDEX idx_stock_reservations_expires_at ON stock_reservations(expires_at) WHERE status = 'ACTIVE';
+
+-- OUT movements that fulfil a reservation point back at it
+ALTER TABLE stock_movements ADD COLUMN reservation_id INTEGER REFERENCES stock_reservations(id) ON DELETE SET NULL;
+CREATE INDEX idx_stock_movements_reservation_id ON stock_movements(reservation_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_stock_movements_reservation_id;
+ALTER TABLE stock_movements DROP COLUMN IF EXISTS reservation_id;
+DROP INDEX IF EXISTS idx_stock_reservations_expires_at;
+DROP INDEX IF EXISTS idx_stock_reservations_status;
+DROP INDEX IF EXISTS idx_stock_reservations_product_location;
+DROP TABLE IF EXISTS stock_reservations;
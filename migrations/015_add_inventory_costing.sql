@@ -0,0 +1,52 @@
+-- +goose Up
+-- Valuation method per product: FIFO consumes the oldest cost layer first, AVERAGE keeps a single
+-- moving-average layer per location
+ALTER TABLE products ADD COLUMN cost_method VARCHAR(10) NOT NULL DEFAULT 'FIFO' CHECK (cost_method IN ('FIFO', 'AVERAGE'));
+
+-- Unit cost received on increases, cost of goods issued on decreases
+ALTER TABLE stock_movements ADD COLUMN unit_cost DECIMAL(15,4);
+ALTER TABLE stock_movements ADD COLUMN total_cost DECIMAL(18,4);
+
+-- Create cost_layers table (quantity received at one unit cost, still on hand while remaining_quantity > 0)
+CREATE TABLE cost_layers (
+    id SERIAL PRIMARY KEY,
+    product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    location_id INTEGER NOT NULL REFERENCES locations(id) ON DELETE CASCADE,
+    movement_id INTEGER REFERENCES stock_movements(id) ON DELETE SET NULL,
+    unit_cost DECIMAL(15,4) NOT NULL CHECK (unit_cost >= 0),
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    remaining_quantity INTEGER NOT NULL CHECK (remaining_quantity >= 0 AND remaining_quantity <= quantity),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_cost_layers_open ON cost_layers(product_id, location_id, created_at, id) WHERE remaining_quantity > 0;
+CREATE INDEX idx_cost_layers_movement_id ON cost_layers(movement_id);
+
+-- Cost layers added or consumed by each movement
+CREATE TABLE stock_movement_costs (
+    movement_id INTEGER NOT NULL REFERENCES stock_movements(id) ON DELETE CASCADE,
+    cost_layer_id INTEGER NOT NULL REFERENCES cost_layers(id) ON DELETE CASCADE,
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    unit_cost DECIMAL(15,4) NOT NULL,
+    PRIMARY KEY (movement_id, cost_layer_id)
+);
+
+CREATE INDEX idx_stock_movement_costs_cost_layer_id ON stock_movement_costs(cost_layer_id);
+
+-- Stock already on hand opens at the product price, the only cost known so far
+INSERT INTO cost_layers (product_id, location_id, unit_cost, quantity, remaining_quantity)
+SELECT sl.product_id, sl.location_id, p.price, SUM(sl.quantity), SUM(sl.quantity)
+FROM stock_levels sl
+JOIN products p ON sl.product_id = p.id
+GROUP BY sl.product_id, sl.location_id, p.price
+HAVING SUM(sl.quantity) > 0;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_stock_movement_costs_cost_layer_id;
+DROP TABLE IF EXISTS stock_movement_costs;
+DROP INDEX IF EXISTS idx_cost_layers_movement_id;
+DROP INDEX IF EXISTS idx_cost_layers_open;
+DROP TABLE IF EXISTS cost_layers;
+ALTER TABLE stock_movements DROP COLUMN IF EXISTS total_cost;
+ALTER TABLE stock_movements DROP COLUMN IF EXISTS unit_cost;
+ALTER TABLE products DROP COLUMN IF EXISTS cost_method;
@@ -0,0 +1,20 @@
+-- +goose Up
+-- Create stock_snapshots table (on-hand quantities rebuilt from the movement ledger at taken_at)
+CREATE TABLE stock_snapshots (
+    id SERIAL PRIMARY KEY,
+    taken_at TIMESTAMP WITH TIME ZONE NOT NULL UNIQUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+-- Quantity per product and location in each snapshot; zero balances are not stored
+CREATE TABLE stock_snapshot_lines (
+    snapshot_id INTEGER NOT NULL REFERENCES stock_snapshots(id) ON DELETE CASCADE,
+    product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    location_id INTEGER NOT NULL REFERENCES locations(id) ON DELETE CASCADE,
+    quantity INTEGER NOT NULL,
+    PRIMARY KEY (snapshot_id, product_id, location_id)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS stock_snapshot_lines;
+DROP TABLE IF EXISTS stock_snapshots;
@@ -0,0 +1,48 @@
+-- +goose Up
+-- Create reorder_rules table (replenishment settings per product, across all locations or at one location)
+CREATE TABLE reorder_rules (
+    id SERIAL PRIMARY KEY,
+    product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    location_id INTEGER REFERENCES locations(id) ON DELETE CASCADE,
+    reorder_point INTEGER NOT NULL CHECK (reorder_point >= 0),
+    safety_stock INTEGER NOT NULL DEFAULT 0 CHECK (safety_stock >= 0 AND safety_stock <= reorder_point),
+    reorder_quantity INTEGER NOT NULL CHECK (reorder_quantity > 0),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+-- At most one rule per product and location, and one covering all locations
+CREATE UNIQUE INDEX idx_reorder_rules_product_location ON reorder_rules(product_id, location_id) WHERE location_id IS NOT NULL;
+CREATE UNIQUE INDEX idx_reorder_rules_product ON reorder_rules(product_id) WHERE location_id IS NULL;
+
+-- Create stock_alerts table (raised when stock falls to or below a reorder point)
+CREATE TABLE stock_alerts (
+    id SERIAL PRIMARY KEY,
+    rule_id INTEGER NOT NULL REFERENCES reorder_rules(id) ON DELETE CASCADE,
+    product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    location_id INTEGER REFERENCES locations(id) ON DELETE CASCADE,
+    level VARCHAR(10) NOT NULL CHECK (level IN ('LOW', 'CRITICAL')),
+    status VARCHAR(20) NOT NULL DEFAULT 'OPEN' CHECK (status IN ('OPEN', 'ACKNOWLEDGED', 'RESOLVED')),
+    quantity INTEGER NOT NULL,
+    reorder_point INTEGER NOT NULL,
+    safety_stock INTEGER NOT NULL,
+    acknowledged_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    acknowledged_at TIMESTAMP WITH TIME ZONE,
+    resolved_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+-- A rule has at most one alert that is not resolved
+CREATE UNIQUE INDEX idx_stock_alerts_rule_unresolved ON stock_alerts(rule_id) WHERE status <> 'RESOLVED';
+CREATE INDEX idx_stock_alerts_status ON stock_alerts(status);
+CREATE INDEX idx_stock_alerts_product_id ON stock_alerts(product_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_stock_alerts_product_id;
+DROP INDEX IF EXISTS idx_stock_alerts_status;
+DROP INDEX IF EXISTS idx_stock_alerts_rule_unresolved;
+DROP TABLE IF EXISTS stock_alerts;
+DROP INDEX IF EXISTS idx_reorder_rules_product;
+DROP INDEX IF EXISTS idx_reorder_rules_product_location;
+DROP TABLE IF EXISTS reorder_rules;
@@ -0,0 +1,22 @@
+-- +goose Up
+-- Unit every stored quantity of a product is counted in
+ALTER TABLE products ADD COLUMN base_unit VARCHAR(20) NOT NULL DEFAULT 'EA';
+
+-- Create product_units table (pack sizes of a product, e.g. CASE = 12 base units)
+CREATE TABLE product_units (
+    product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    code VARCHAR(20) NOT NULL,
+    factor INTEGER NOT NULL CHECK (factor > 1),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    PRIMARY KEY (product_id, code)
+);
+
+-- Unit and quantity a movement was requested in, when it was not the base unit
+ALTER TABLE stock_movements ADD COLUMN uom VARCHAR(20) NOT NULL DEFAULT '';
+ALTER TABLE stock_movements ADD COLUMN uom_quantity INTEGER CHECK (uom_quantity > 0);
+
+-- +goose Down
+ALTER TABLE stock_movements DROP COLUMN IF EXISTS uom_quantity;
+ALTER TABLE stock_movements DROP COLUMN IF EXISTS uom;
+DROP TABLE IF EXISTS product_units;
+ALTER TABLE products DROP COLUMN IF EXISTS base_unit;
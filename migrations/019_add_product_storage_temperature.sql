@@ -0,0 +1,10 @@
+-- +goose Up
+-- Storage temperature range per product in °C; NULL means no limit on that side
+ALTER TABLE products ADD COLUMN min_temperature DECIMAL(5,2);
+ALTER TABLE products ADD COLUMN max_temperature DECIMAL(5,2);
+ALTER TABLE products ADD CONSTRAINT products_temperature_range_check CHECK (min_temperature <= max_temperature);
+
+-- +goose Down
+ALTER TABLE products DROP CONSTRAINT IF EXISTS products_temperature_range_check;
+ALTER TABLE products DROP COLUMN IF EXISTS max_temperature;
+ALTER TABLE products DROP COLUMN IF EXISTS min_temperature;
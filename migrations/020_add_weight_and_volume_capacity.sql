@@ -0,0 +1,25 @@
+-- +goose Up
+-- Structured product dimensions in cm, parsed from the "LxWxH" dimensions text
+ALTER TABLE products ADD COLUMN length_cm DECIMAL(10,2) NOT NULL DEFAULT 0 CHECK (length_cm >= 0);
+ALTER TABLE products ADD COLUMN width_cm DECIMAL(10,2) NOT NULL DEFAULT 0 CHECK (width_cm >= 0);
+ALTER TABLE products ADD COLUMN height_cm DECIMAL(10,2) NOT NULL DEFAULT 0 CHECK (height_cm >= 0);
+
+UPDATE products
+SET length_cm = parts[1]::DECIMAL, width_cm = parts[2]::DECIMAL, height_cm = parts[3]::DECIMAL
+FROM (
+    SELECT id, regexp_split_to_array(lower(trim(dimensions)), '\s*x\s*') AS parts
+    FROM products
+    WHERE dimensions ~* '^\s*[0-9]+(\.[0-9]+)?\s*x\s*[0-9]+(\.[0-9]+)?\s*x\s*[0-9]+(\.[0-9]+)?\s*$'
+) parsed
+WHERE products.id = parsed.id;
+
+-- Weight (kg) and volume (m³) a location can hold; NULL means no limit
+ALTER TABLE locations ADD COLUMN max_weight DECIMAL(10,2) CHECK (max_weight > 0);
+ALTER TABLE locations ADD COLUMN max_volume DECIMAL(10,3) CHECK (max_volume > 0);
+
+-- +goose Down
+ALTER TABLE locations DROP COLUMN IF EXISTS max_volume;
+ALTER TABLE locations DROP COLUMN IF EXISTS max_weight;
+ALTER TABLE products DROP COLUMN IF EXISTS height_cm;
+ALTER TABLE products DROP COLUMN IF EXISTS width_cm;
+ALTER TABLE products DROP COLUMN IF EXISTS length_cm;
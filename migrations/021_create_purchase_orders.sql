@@ -0,0 +1,58 @@
+-- +goose Up
+-- Create suppliers table
+CREATE TABLE suppliers (
+    id SERIAL PRIMARY KEY,
+    code VARCHAR(50) UNIQUE NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    contact_name VARCHAR(255),
+    email VARCHAR(255),
+    phone VARCHAR(50),
+    is_active BOOLEAN DEFAULT true,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+-- Create purchase_orders table (inbound orders placed with a supplier)
+CREATE TABLE purchase_orders (
+    id SERIAL PRIMARY KEY,
+    po_number VARCHAR(100) UNIQUE NOT NULL,
+    supplier_id INTEGER NOT NULL REFERENCES suppliers(id) ON DELETE RESTRICT,
+    status VARCHAR(20) NOT NULL DEFAULT 'OPEN' CHECK (status IN ('OPEN', 'PARTIALLY_RECEIVED', 'CLOSED', 'CANCELLED')),
+    expected_date DATE,
+    over_receipt_tolerance DECIMAL(5,2) NOT NULL DEFAULT 0 CHECK (over_receipt_tolerance >= 0),
+    notes TEXT,
+    created_by INTEGER NOT NULL REFERENCES users(id) ON DELETE RESTRICT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    closed_at TIMESTAMP WITH TIME ZONE
+);
+
+-- Create purchase_order_lines table (expected quantities in base units)
+CREATE TABLE purchase_order_lines (
+    id SERIAL PRIMARY KEY,
+    purchase_order_id INTEGER NOT NULL REFERENCES purchase_orders(id) ON DELETE CASCADE,
+    line_number INTEGER NOT NULL,
+    product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE RESTRICT,
+    ordered_quantity INTEGER NOT NULL CHECK (ordered_quantity > 0),
+    received_quantity INTEGER NOT NULL DEFAULT 0 CHECK (received_quantity >= 0),
+    unit_cost DECIMAL(15,4) CHECK (unit_cost >= 0),
+    UNIQUE (purchase_order_id, line_number)
+);
+
+-- Link receipts to the purchase order line they were received against
+ALTER TABLE stock_movements ADD COLUMN purchase_order_line_id INTEGER REFERENCES purchase_order_lines(id) ON DELETE RESTRICT;
+
+CREATE INDEX idx_purchase_orders_supplier_id ON purchase_orders(supplier_id);
+CREATE INDEX idx_purchase_orders_status ON purchase_orders(status);
+CREATE INDEX idx_purchase_order_lines_product_id ON purchase_order_lines(product_id);
+CREATE INDEX idx_stock_movements_purchase_order_line_id ON stock_movements(purchase_order_line_id) WHERE purchase_order_line_id IS NOT NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_stock_movements_purchase_order_line_id;
+DROP INDEX IF EXISTS idx_purchase_order_lines_product_id;
+DROP INDEX IF EXISTS idx_purchase_orders_status;
+DROP INDEX IF EXISTS idx_purchase_orders_supplier_id;
+ALTER TABLE stock_movements DROP COLUMN IF EXISTS purchase_order_line_id;
+DROP TABLE IF EXISTS purchase_order_lines;
+DROP TABLE IF EXISTS purchase_orders;
+DROP TABLE IF EXISTS suppliers;
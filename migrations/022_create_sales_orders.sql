@@ -0,0 +1,60 @@
+-- +goose Up
+-- Create sales_orders table (outbound customer orders)
+CREATE TABLE sales_orders (
+    id SERIAL PRIMARY KEY,
+    so_number VARCHAR(100) UNIQUE NOT NULL,
+    customer_name VARCHAR(255) NOT NULL,
+    ship_to TEXT,
+    status VARCHAR(20) NOT NULL DEFAULT 'NEW' CHECK (status IN ('NEW', 'ALLOCATED', 'PICKED', 'PACKED', 'SHIPPED', 'CANCELLED')),
+    carrier VARCHAR(100),
+    tracking_number VARCHAR(100),
+    package_count INTEGER CHECK (package_count > 0),
+    notes TEXT,
+    created_by INTEGER NOT NULL REFERENCES users(id) ON DELETE RESTRICT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    allocated_at TIMESTAMP WITH TIME ZONE,
+    picked_at TIMESTAMP WITH TIME ZONE,
+    packed_at TIMESTAMP WITH TIME ZONE,
+    shipped_at TIMESTAMP WITH TIME ZONE,
+    cancelled_at TIMESTAMP WITH TIME ZONE
+);
+
+-- Create sales_order_lines table (ordered quantities in base units)
+CREATE TABLE sales_order_lines (
+    id SERIAL PRIMARY KEY,
+    sales_order_id INTEGER NOT NULL REFERENCES sales_orders(id) ON DELETE CASCADE,
+    line_number INTEGER NOT NULL,
+    product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE RESTRICT,
+    ordered_quantity INTEGER NOT NULL CHECK (ordered_quantity > 0),
+    shipped_quantity INTEGER NOT NULL DEFAULT 0 CHECK (shipped_quantity >= 0),
+    UNIQUE (sales_order_id, line_number)
+);
+
+-- Create sales_order_allocations table (stock reserved for a line at a location and lot)
+CREATE TABLE sales_order_allocations (
+    id SERIAL PRIMARY KEY,
+    sales_order_id INTEGER NOT NULL REFERENCES sales_orders(id) ON DELETE CASCADE,
+    sales_order_line_id INTEGER NOT NULL REFERENCES sales_order_lines(id) ON DELETE CASCADE,
+    reservation_id INTEGER NOT NULL REFERENCES stock_reservations(id) ON DELETE RESTRICT,
+    location_id INTEGER NOT NULL REFERENCES locations(id) ON DELETE RESTRICT,
+    lot_number VARCHAR(50) NOT NULL DEFAULT '',
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+-- Link shipments to the sales order line they fulfil
+ALTER TABLE stock_movements ADD COLUMN sales_order_line_id INTEGER REFERENCES sales_order_lines(id) ON DELETE RESTRICT;
+
+CREATE INDEX idx_sales_orders_status ON sales_orders(status);
+CREATE INDEX idx_sales_order_allocations_sales_order_id ON sales_order_allocations(sales_order_id);
+CREATE INDEX idx_stock_movements_sales_order_line_id ON stock_movements(sales_order_line_id) WHERE sales_order_line_id IS NOT NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_stock_movements_sales_order_line_id;
+DROP INDEX IF EXISTS idx_sales_order_allocations_sales_order_id;
+DROP INDEX IF EXISTS idx_sales_orders_status;
+ALTER TABLE stock_movements DROP COLUMN IF EXISTS sales_order_line_id;
+DROP TABLE IF EXISTS sales_order_allocations;
+DROP TABLE IF EXISTS sales_order_lines;
+DROP TABLE IF EXISTS sales_orders;
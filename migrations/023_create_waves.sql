@@ -0,0 +1,31 @@
+-- +goose Up
+-- Priority and carrier cutoff decide how outbound orders are grouped into waves
+ALTER TABLE sales_orders ADD COLUMN priority INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE sales_orders ADD COLUMN carrier_cutoff TIMESTAMP WITH TIME ZONE;
+
+-- Create waves table (sales orders released to the floor together)
+CREATE TABLE waves (
+    id SERIAL PRIMARY KEY,
+    status VARCHAR(20) NOT NULL DEFAULT 'PLANNED' CHECK (status IN ('PLANNED', 'RELEASED', 'CLOSED')),
+    group_by VARCHAR(20) NOT NULL CHECK (group_by IN ('CARRIER_CUTOFF', 'ZONE', 'PRIORITY')),
+    group_key VARCHAR(255) NOT NULL,
+    notes TEXT,
+    created_by INTEGER NOT NULL REFERENCES users(id) ON DELETE RESTRICT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    released_at TIMESTAMP WITH TIME ZONE,
+    closed_at TIMESTAMP WITH TIME ZONE
+);
+
+ALTER TABLE sales_orders ADD COLUMN wave_id INTEGER REFERENCES waves(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_waves_status ON waves(status);
+CREATE INDEX idx_sales_orders_wave_id ON sales_orders(wave_id) WHERE wave_id IS NOT NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_sales_orders_wave_id;
+DROP INDEX IF EXISTS idx_waves_status;
+ALTER TABLE sales_orders DROP COLUMN IF EXISTS wave_id;
+DROP TABLE IF EXISTS waves;
+ALTER TABLE sales_orders DROP COLUMN IF EXISTS carrier_cutoff;
+ALTER TABLE sales_orders DROP COLUMN IF EXISTS priority;
@@ -0,0 +1,51 @@
+-- +goose Up
+-- Create return_authorizations table (customer returns, against a shipped sales order or a free reference)
+CREATE TABLE return_authorizations (
+    id SERIAL PRIMARY KEY,
+    rma_number VARCHAR(100) UNIQUE NOT NULL,
+    sales_order_id INTEGER REFERENCES sales_orders(id) ON DELETE RESTRICT,
+    reference VARCHAR(100),
+    customer_name VARCHAR(255) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'OPEN' CHECK (status IN ('OPEN', 'PARTIALLY_RECEIVED', 'CLOSED', 'CANCELLED')),
+    notes TEXT,
+    created_by INTEGER NOT NULL REFERENCES users(id) ON DELETE RESTRICT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    closed_at TIMESTAMP WITH TIME ZONE
+);
+
+-- Create return_authorization_lines table (authorized quantities in base units, received quantities per grade)
+CREATE TABLE return_authorization_lines (
+    id SERIAL PRIMARY KEY,
+    return_authorization_id INTEGER NOT NULL REFERENCES return_authorizations(id) ON DELETE CASCADE,
+    line_number INTEGER NOT NULL,
+    product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE RESTRICT,
+    sales_order_line_id INTEGER REFERENCES sales_order_lines(id) ON DELETE RESTRICT,
+    return_reason VARCHAR(30) NOT NULL CHECK (return_reason IN ('DEFECTIVE', 'DAMAGED', 'WRONG_ITEM', 'NOT_AS_DESCRIBED', 'NO_LONGER_NEEDED', 'OTHER')),
+    authorized_quantity INTEGER NOT NULL CHECK (authorized_quantity > 0),
+    restocked_quantity INTEGER NOT NULL DEFAULT 0 CHECK (restocked_quantity >= 0),
+    quarantined_quantity INTEGER NOT NULL DEFAULT 0 CHECK (quarantined_quantity >= 0),
+    scrapped_quantity INTEGER NOT NULL DEFAULT 0 CHECK (scrapped_quantity >= 0),
+    UNIQUE (return_authorization_id, line_number)
+);
+
+-- Link return movements to the RMA line and record why the goods came back and how they were graded
+ALTER TABLE stock_movements ADD COLUMN return_line_id INTEGER REFERENCES return_authorization_lines(id) ON DELETE RESTRICT;
+ALTER TABLE stock_movements ADD COLUMN return_reason VARCHAR(30) NOT NULL DEFAULT '';
+ALTER TABLE stock_movements ADD COLUMN return_grade VARCHAR(20) NOT NULL DEFAULT '';
+
+CREATE INDEX idx_return_authorizations_sales_order_id ON return_authorizations(sales_order_id) WHERE sales_order_id IS NOT NULL;
+CREATE INDEX idx_return_authorizations_status ON return_authorizations(status);
+CREATE INDEX idx_return_authorization_lines_sales_order_line_id ON return_authorization_lines(sales_order_line_id) WHERE sales_order_line_id IS NOT NULL;
+CREATE INDEX idx_stock_movements_return_line_id ON stock_movements(return_line_id) WHERE return_line_id IS NOT NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_stock_movements_return_line_id;
+DROP INDEX IF EXISTS idx_return_authorization_lines_sales_order_line_id;
+DROP INDEX IF EXISTS idx_return_authorizations_status;
+DROP INDEX IF EXISTS idx_return_authorizations_sales_order_id;
+ALTER TABLE stock_movements DROP COLUMN IF EXISTS return_grade;
+ALTER TABLE stock_movements DROP COLUMN IF EXISTS return_reason;
+ALTER TABLE stock_movements DROP COLUMN IF EXISTS return_line_id;
+DROP TABLE IF EXISTS return_authorization_lines;
+DROP TABLE IF EXISTS return_authorizations;
@@ -0,0 +1,57 @@
+-- +goose Up
+-- Balances are kept per inventory status; only AVAILABLE stock can be reserved, picked or shipped
+ALTER TABLE stock_levels ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'AVAILABLE' CHECK (status IN ('AVAILABLE', 'QUARANTINE', 'DAMAGED', 'ON_HOLD'));
+ALTER TABLE stock_levels DROP CONSTRAINT IF EXISTS stock_levels_product_id_location_id_lot_number_key;
+ALTER TABLE stock_levels ADD CONSTRAINT stock_levels_product_id_location_id_lot_number_status_key UNIQUE (product_id, location_id, lot_number, status);
+CREATE INDEX idx_stock_levels_status ON stock_levels(status) WHERE status <> 'AVAILABLE';
+
+-- The status of the stock a movement added or took away
+ALTER TABLE stock_movements ADD COLUMN inventory_status VARCHAR(20) NOT NULL DEFAULT 'AVAILABLE' CHECK (inventory_status IN ('AVAILABLE', 'QUARANTINE', 'DAMAGED', 'ON_HOLD'));
+
+-- Create inventory_status_changes table (stock put on or taken off hold without moving it)
+CREATE TABLE inventory_status_changes (
+    id SERIAL PRIMARY KEY,
+    product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE RESTRICT,
+    location_id INTEGER NOT NULL REFERENCES locations(id) ON DELETE RESTRICT,
+    lot_number VARCHAR(50) NOT NULL DEFAULT '',
+    from_status VARCHAR(20) NOT NULL CHECK (from_status IN ('AVAILABLE', 'QUARANTINE', 'DAMAGED', 'ON_HOLD')),
+    to_status VARCHAR(20) NOT NULL CHECK (to_status IN ('AVAILABLE', 'QUARANTINE', 'DAMAGED', 'ON_HOLD')),
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    reference VARCHAR(100) NOT NULL DEFAULT '',
+    notes TEXT,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE RESTRICT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    CHECK (from_status <> to_status)
+);
+
+CREATE INDEX idx_inventory_status_changes_product_id ON inventory_status_changes(product_id);
+CREATE INDEX idx_inventory_status_changes_location_id ON inventory_status_changes(location_id);
+CREATE INDEX idx_inventory_status_changes_created_at ON inventory_status_changes(created_at);
+
+-- Permissions granted to a user on top of the usual access, e.g. OVERRIDE_INVENTORY_STATUS
+ALTER TABLE users ADD COLUMN permissions TEXT[] NOT NULL DEFAULT '{}';
+UPDATE users SET permissions = ARRAY['OVERRIDE_INVENTORY_STATUS'] WHERE username IN ('admin', 'manager');
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN IF EXISTS permissions;
+
+DROP INDEX IF EXISTS idx_inventory_status_changes_created_at;
+DROP INDEX IF EXISTS idx_inventory_status_changes_location_id;
+DROP INDEX IF EXISTS idx_inventory_status_changes_product_id;
+DROP TABLE IF EXISTS inventory_status_changes;
+
+ALTER TABLE stock_movements DROP COLUMN IF EXISTS inventory_status;
+
+-- Fold status balances back into a single balance per product, location and lot
+CREATE TEMP TABLE stock_levels_merged AS
+SELECT product_id, location_id, lot_number, SUM(quantity) AS quantity
+FROM stock_levels
+GROUP BY product_id, location_id, lot_number;
+DELETE FROM stock_levels;
+DROP INDEX IF EXISTS idx_stock_levels_status;
+ALTER TABLE stock_levels DROP CONSTRAINT IF EXISTS stock_levels_product_id_location_id_lot_number_status_key;
+ALTER TABLE stock_levels DROP COLUMN IF EXISTS status;
+INSERT INTO stock_levels (product_id, location_id, lot_number, quantity)
+SELECT product_id, location_id, lot_number, quantity FROM stock_levels_merged;
+DROP TABLE stock_levels_merged;
+ALTER TABLE stock_levels ADD CONSTRAINT stock_levels_product_id_location_id_lot_number_key UNIQUE (product_id, location_id, lot_number);
@@ -0,0 +1,34 @@
+-- +goose Up
+-- Create warehouses table (the buildings locations belong to)
+CREATE TABLE warehouses (
+    id SERIAL PRIMARY KEY,
+    code VARCHAR(20) UNIQUE NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    address TEXT,
+    is_active BOOLEAN DEFAULT true NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+-- Existing locations all belong to the one building the schema assumed so far
+INSERT INTO warehouses (code, name) VALUES ('MAIN', 'Main Warehouse');
+
+ALTER TABLE locations ADD COLUMN warehouse_id INTEGER REFERENCES warehouses(id) ON DELETE RESTRICT;
+UPDATE locations SET warehouse_id = (SELECT id FROM warehouses WHERE code = 'MAIN');
+ALTER TABLE locations ALTER COLUMN warehouse_id SET NOT NULL;
+
+-- Location codes are unique per warehouse instead of globally
+ALTER TABLE locations DROP CONSTRAINT IF EXISTS locations_code_key;
+ALTER TABLE locations ADD CONSTRAINT locations_warehouse_id_code_key UNIQUE (warehouse_id, code);
+
+CREATE INDEX idx_warehouses_is_active ON warehouses(is_active);
+CREATE INDEX idx_locations_warehouse_id ON locations(warehouse_id);
+
+-- +goose Down
+-- Fails while two warehouses share a location code
+DROP INDEX IF EXISTS idx_locations_warehouse_id;
+DROP INDEX IF EXISTS idx_warehouses_is_active;
+ALTER TABLE locations DROP CONSTRAINT IF EXISTS locations_warehouse_id_code_key;
+ALTER TABLE locations ADD CONSTRAINT locations_code_key UNIQUE (code);
+ALTER TABLE locations DROP COLUMN IF EXISTS warehouse_id;
+DROP TABLE IF EXISTS warehouses;
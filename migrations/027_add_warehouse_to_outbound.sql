@@ -0,0 +1,18 @@
+-- +goose Up
+-- Sales orders and waves are fulfilled from the locations of a single warehouse
+ALTER TABLE sales_orders ADD COLUMN warehouse_id INTEGER REFERENCES warehouses(id) ON DELETE RESTRICT;
+UPDATE sales_orders SET warehouse_id = (SELECT id FROM warehouses WHERE code = 'MAIN');
+ALTER TABLE sales_orders ALTER COLUMN warehouse_id SET NOT NULL;
+
+ALTER TABLE waves ADD COLUMN warehouse_id INTEGER REFERENCES warehouses(id) ON DELETE RESTRICT;
+UPDATE waves SET warehouse_id = (SELECT id FROM warehouses WHERE code = 'MAIN');
+ALTER TABLE waves ALTER COLUMN warehouse_id SET NOT NULL;
+
+CREATE INDEX idx_sales_orders_warehouse_id ON sales_orders(warehouse_id);
+CREATE INDEX idx_waves_warehouse_id ON waves(warehouse_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_waves_warehouse_id;
+DROP INDEX IF EXISTS idx_sales_orders_warehouse_id;
+ALTER TABLE waves DROP COLUMN IF EXISTS warehouse_id;
+ALTER TABLE sales_orders DROP COLUMN IF EXISTS warehouse_id;
@@ -0,0 +1,30 @@
+-- +goose Up
+-- Serial numbers carry the inventory status of the unit, so held units cannot be picked by serial
+ALTER TABLE serial_numbers ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'AVAILABLE' CHECK (status IN ('AVAILABLE', 'QUARANTINE', 'DAMAGED', 'ON_HOLD'));
+
+-- Which units of a held balance were held is not recorded; assign the held quantity to the units
+-- of the balance in serial number ID order
+WITH held AS (
+    SELECT product_id, location_id, lot_number, status,
+           SUM(quantity) OVER (PARTITION BY product_id, location_id, lot_number ORDER BY status) - quantity AS first_rank,
+           SUM(quantity) OVER (PARTITION BY product_id, location_id, lot_number ORDER BY status) AS last_rank
+    FROM stock_levels
+    WHERE status <> 'AVAILABLE' AND quantity > 0
+), ranked AS (
+    SELECT id, product_id, location_id, lot_number,
+           ROW_NUMBER() OVER (PARTITION BY product_id, location_id, lot_number ORDER BY id) AS unit_rank
+    FROM serial_numbers
+    WHERE location_id IS NOT NULL
+)
+UPDATE serial_numbers sn
+SET status = held.status
+FROM ranked
+JOIN held ON held.product_id = ranked.product_id AND held.location_id = ranked.location_id AND held.lot_number = ranked.lot_number
+    AND ranked.unit_rank > held.first_rank AND ranked.unit_rank <= held.last_rank
+WHERE sn.id = ranked.id;
+
+CREATE INDEX idx_serial_numbers_status ON serial_numbers(status) WHERE status <> 'AVAILABLE';
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_serial_numbers_status;
+ALTER TABLE serial_numbers DROP COLUMN IF EXISTS status;
@@ -0,0 +1,7 @@
+-- +goose Up
+-- Identifies the request holding a key, so a request that outlived its lease cannot settle the key
+-- after another request took it over. Keys claimed before have no token and expire with their lease.
+ALTER TABLE idempotency_keys ADD COLUMN claim_token VARCHAR(36);
+
+-- +goose Down
+ALTER TABLE idempotency_keys DROP COLUMN IF EXISTS claim_token;
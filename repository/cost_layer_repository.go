@@ -0,0 +1,267 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/edwinjordan/wmsTest_Golang/domain"
+)
+
+type costLayerRepository struct {
+	db DBTX
+}
+
+func NewCostLayerRepository(db DBTX) CostLayerRepository {
+	return &costLayerRepository{db: db}
+}
+
+func (r *costLayerRepository) Create(ctx context.Context, layer *domain.CostLayer) error {
+	query := `
+		INSERT INTO cost_layers (product_id, location_id, movement_id, unit_cost, quantity, remaining_quantity, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id`
+
+	layer.CreatedAt = time.Now()
+
+	err := r.db.QueryRowContext(ctx, query,
+		layer.ProductID,
+		layer.LocationID,
+		layer.MovementID,
+		layer.UnitCost,
+		layer.Quantity,
+		layer.RemainingQuantity,
+		layer.CreatedAt,
+	).Scan(&layer.ID)
+
+	if err != nil {
+		return fmt.Errorf("failed to create cost layer: %w", err)
+	}
+
+	return nil
+}
+
+// ListOpen returns the cost layers of a product at a location that still have stock, oldest first
+func (r *costLayerRepository) ListOpen(ctx context.Context, productID, locationID int) ([]*domain.CostLayer, error) {
+	query := `
+		SELECT id, product_id, location_id, movement_id, unit_cost, quantity, remaining_quantity, created_at
+		FROM cost_layers
+		WHERE product_id = $1 AND location_id = $2 AND remaining_quantity > 0
+		ORDER BY created_at, id`
+
+	rows, err := r.db.QueryContext(ctx, query, productID, locationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list cost layers: %w", err)
+	}
+	defer rows.Close()
+
+	var layers []*domain.CostLayer
+	for rows.Next() {
+		layer := &domain.CostLayer{}
+		err := rows.Scan(
+			&layer.ID,
+			&layer.ProductID,
+			&layer.LocationID,
+			&layer.MovementID,
+			&layer.UnitCost,
+			&layer.Quantity,
+			&layer.RemainingQuantity,
+			&layer.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan cost layer: %w", err)
+		}
+		layers = append(layers, layer)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating cost layers: %w", err)
+	}
+
+	return layers, nil
+}
+
+// Update saves the unit cost and quantities of a layer after stock was added to or taken from it
+func (r *costLayerRepository) Update(ctx context.Context, layer *domain.CostLayer) error {
+	query := `
+		UPDATE cost_layers
+		SET unit_cost = $2, quantity = $3, remaining_quantity = $4
+		WHERE id = $1`
+
+	result, err := r.db.ExecContext(ctx, query, layer.ID, layer.UnitCost, layer.Quantity, layer.RemainingQuantity)
+	if err != nil {
+		return fmt.Errorf("failed to update cost layer: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// AddMovementCost records the quantity a movement added to or took from a cost layer
+func (r *costLayerRepository) AddMovementCost(ctx context.Context, movementID int, cost *domain.StockMovementCost) error {
+	query := `
+		INSERT INTO stock_movement_costs (movement_id, cost_layer_id, quantity, unit_cost)
+		VALUES ($1, $2, $3, $4)`
+
+	_, err := r.db.ExecContext(ctx, query, movementID, cost.CostLayerID, cost.Quantity, cost.UnitCost)
+	if err != nil {
+		return fmt.Errorf("failed to record stock movement cost: %w", err)
+	}
+
+	return nil
+}
+
+// GetAverageCost returns the average unit cost of the stock of a product on hand, at one location
+// or across all locations when locationID is nil. It returns nil when there is no such stock.
+func (r *costLayerRepository) GetAverageCost(ctx context.Context, productID int, locationID *int) (*float64, error) {
+	query := `
+		SELECT SUM(remaining_quantity * unit_cost) / NULLIF(SUM(remaining_quantity), 0)
+		FROM cost_layers
+		WHERE product_id = $1 AND remaining_quantity > 0 AND ($2::INTEGER IS NULL OR location_id = $2)`
+
+	var cost *float64
+	err := r.db.QueryRowContext(ctx, query, productID, locationID).Scan(&cost)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get average cost: %w", err)
+	}
+
+	return cost, nil
+}
+
+// ListValuation returns the value of the open cost layers and the cost of goods issued by OUT
+// movements (net of their reversals), grouped as requested and ordered by key. Location codes are
+// only unique within a warehouse, so location keys are prefixed with the warehouse code.
+func (r *costLayerRepository) ListValuation(ctx context.Context, filter *domain.ValuationFilter) ([]*domain.ValuationLine, error) {
+	var columns string
+	switch filter.GroupBy {
+	case domain.ValuationByCategory:
+		columns = "NULL::INTEGER, p.category, p.category"
+	case domain.ValuationByLocation:
+		columns = "l.id, w.code || '/' || l.code, l.name"
+	default:
+		columns = "p.id, p.sku, p.name"
+	}
+
+	var conditions []string
+	var args []interface{}
+	argIndex := 1
+
+	if filter.ProductID != nil {
+		conditions = append(conditions, fmt.Sprintf("p.id = $%d", argIndex))
+		args = append(args, *filter.ProductID)
+		argIndex++
+	}
+
+	if filter.LocationID != nil {
+		conditions = append(conditions, fmt.Sprintf("l.id = $%d", argIndex))
+		args = append(args, *filter.LocationID)
+		argIndex++
+	}
+
+	if filter.Category != nil {
+		conditions = append(conditions, fmt.Sprintf("p.category = $%d", argIndex))
+		args = append(args, *filter.Category)
+		argIndex++
+	}
+
+	stockConditions := append([]string{"cl.remaining_quantity > 0"}, conditions...)
+	stockQuery := fmt.Sprintf(`
+		SELECT %s, SUM(cl.remaining_quantity), SUM(cl.remaining_quantity * cl.unit_cost)
+		FROM cost_layers cl
+		JOIN products p ON cl.product_id = p.id
+		JOIN locations l ON cl.location_id = l.id
+		JOIN warehouses w ON l.warehouse_id = w.id
+		WHERE %s
+		GROUP BY 1, 2, 3
+		ORDER BY 2`, columns, strings.Join(stockConditions, " AND "))
+
+	rows, err := r.db.QueryContext(ctx, stockQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get inventory valuation: %w", err)
+	}
+	defer rows.Close()
+
+	var lines []*domain.ValuationLine
+	byKey := make(map[string]*domain.ValuationLine)
+	for rows.Next() {
+		line := &domain.ValuationLine{}
+		if err := rows.Scan(&line.ID, &line.Key, &line.Name, &line.Quantity, &line.Value); err != nil {
+			return nil, fmt.Errorf("failed to scan valuation line: %w", err)
+		}
+		lines = append(lines, line)
+		byKey[valuationMergeKey(line)] = line
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating valuation lines: %w", err)
+	}
+
+	issuedConditions := append([]string{"sm.total_cost IS NOT NULL", "(sm.type = 'OUT' OR orig.type = 'OUT')"}, conditions...)
+	if filter.DateFrom != nil {
+		issuedConditions = append(issuedConditions, fmt.Sprintf("sm.created_at >= $%d", argIndex))
+		args = append(args, *filter.DateFrom)
+		argIndex++
+	}
+	if filter.DateTo != nil {
+		issuedConditions = append(issuedConditions, fmt.Sprintf("sm.created_at <= $%d", argIndex))
+		args = append(args, *filter.DateTo)
+		argIndex++
+	}
+
+	// OUT movements decrease stock and reversals of them increase it again, so -direction nets the two
+	issuedQuery := fmt.Sprintf(`
+		SELECT %s, SUM(-sm.direction * sm.total_cost)
+		FROM stock_movements sm
+		JOIN products p ON sm.product_id = p.id
+		JOIN locations l ON sm.location_id = l.id
+		JOIN warehouses w ON l.warehouse_id = w.id
+		LEFT JOIN stock_movements orig ON sm.reversal_of_id = orig.id
+		WHERE %s
+		GROUP BY 1, 2, 3
+		ORDER BY 2`, columns, strings.Join(issuedConditions, " AND "))
+
+	issuedRows, err := r.db.QueryContext(ctx, issuedQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cost of goods issued: %w", err)
+	}
+	defer issuedRows.Close()
+
+	for issuedRows.Next() {
+		issued := &domain.ValuationLine{}
+		if err := issuedRows.Scan(&issued.ID, &issued.Key, &issued.Name, &issued.CostOfGoodsIssued); err != nil {
+			return nil, fmt.Errorf("failed to scan cost of goods issued: %w", err)
+		}
+		// Lines with no stock left on hand still report what they issued
+		if line, ok := byKey[valuationMergeKey(issued)]; ok {
+			line.CostOfGoodsIssued = issued.CostOfGoodsIssued
+			continue
+		}
+		lines = append(lines, issued)
+		byKey[valuationMergeKey(issued)] = issued
+	}
+
+	if err = issuedRows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating cost of goods issued: %w", err)
+	}
+
+	return lines, nil
+}
+
+// valuationMergeKey identifies a group across the stock and issued queries: by product or location
+// ID, or by category
+func valuationMergeKey(line *domain.ValuationLine) string {
+	if line.ID != nil {
+		return strconv.Itoa(*line.ID)
+	}
+	return line.Key
+}
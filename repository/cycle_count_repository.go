@@ -0,0 +1,311 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/edwinjordan/wmsTest_Golang/domain"
+	"github.com/lib/pq"
+)
+
+type cycleCountRepository struct {
+	db DBTX
+}
+
+func NewCycleCountRepository(db DBTX) CycleCountRepository {
+	return &cycleCountRepository{db: db}
+}
+
+func (r *cycleCountRepository) Create(ctx context.Context, count *domain.CycleCount) error {
+	query := `
+		INSERT INTO cycle_counts (status, notes, created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id`
+
+	now := time.Now()
+	count.CreatedAt = now
+	count.UpdatedAt = now
+
+	err := r.db.QueryRowContext(ctx, query,
+		count.Status,
+		count.Notes,
+		count.CreatedBy,
+		count.CreatedAt,
+		count.UpdatedAt,
+	).Scan(&count.ID)
+
+	if err != nil {
+		return fmt.Errorf("failed to create cycle count: %w", err)
+	}
+
+	for _, locationID := range count.LocationIDs {
+		_, err := r.db.ExecContext(ctx,
+			`INSERT INTO cycle_count_locations (cycle_count_id, location_id) VALUES ($1, $2)`,
+			count.ID, locationID,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to add cycle count location: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (r *cycleCountRepository) GetByID(ctx context.Context, id int) (*domain.CycleCount, error) {
+	query := `
+		SELECT c.id, c.status, c.notes, c.created_by, c.posted_by, c.created_at, c.updated_at, c.posted_at,
+		       ARRAY(SELECT location_id FROM cycle_count_locations WHERE cycle_count_id = c.id ORDER BY location_id)
+		FROM cycle_counts c
+		WHERE c.id = $1`
+
+	return r.getOne(ctx, query, id)
+}
+
+// GetByIDForUpdate loads a cycle count and locks it until the surrounding transaction ends
+func (r *cycleCountRepository) GetByIDForUpdate(ctx context.Context, id int) (*domain.CycleCount, error) {
+	query := `
+		SELECT c.id, c.status, c.notes, c.created_by, c.posted_by, c.created_at, c.updated_at, c.posted_at,
+		       ARRAY(SELECT location_id FROM cycle_count_locations WHERE cycle_count_id = c.id ORDER BY location_id)
+		FROM cycle_counts c
+		WHERE c.id = $1
+		FOR UPDATE`
+
+	return r.getOne(ctx, query, id)
+}
+
+func (r *cycleCountRepository) getOne(ctx context.Context, query string, id int) (*domain.CycleCount, error) {
+	count := &domain.CycleCount{}
+	var notes sql.NullString
+	var locationIDs pq.Int64Array
+
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
+		&count.ID,
+		&count.Status,
+		&notes,
+		&count.CreatedBy,
+		&count.PostedBy,
+		&count.CreatedAt,
+		&count.UpdatedAt,
+		&count.PostedAt,
+		&locationIDs,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get cycle count by ID: %w", err)
+	}
+
+	count.Notes = notes.String
+	count.LocationIDs = toIntSlice(locationIDs)
+
+	return count, nil
+}
+
+func (r *cycleCountRepository) List(ctx context.Context, status *domain.CycleCountStatus, limit, offset int) ([]*domain.CycleCount, int, error) {
+	// Count total records
+	countQuery := `SELECT COUNT(*) FROM cycle_counts WHERE ($1::varchar IS NULL OR status = $1)`
+	var total int
+	err := r.db.QueryRowContext(ctx, countQuery, status).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count cycle counts: %w", err)
+	}
+
+	// Get paginated records
+	query := `
+		SELECT c.id, c.status, c.notes, c.created_by, c.posted_by, c.created_at, c.updated_at, c.posted_at,
+		       ARRAY(SELECT location_id FROM cycle_count_locations WHERE cycle_count_id = c.id ORDER BY location_id)
+		FROM cycle_counts c
+		WHERE ($1::varchar IS NULL OR c.status = $1)
+		ORDER BY c.created_at DESC
+		LIMIT $2 OFFSET $3`
+
+	rows, err := r.db.QueryContext(ctx, query, status, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list cycle counts: %w", err)
+	}
+	defer rows.Close()
+
+	var counts []*domain.CycleCount
+	for rows.Next() {
+		count := &domain.CycleCount{}
+		var notes sql.NullString
+		var locationIDs pq.Int64Array
+		err := rows.Scan(
+			&count.ID,
+			&count.Status,
+			&notes,
+			&count.CreatedBy,
+			&count.PostedBy,
+			&count.CreatedAt,
+			&count.UpdatedAt,
+			&count.PostedAt,
+			&locationIDs,
+		)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan cycle count: %w", err)
+		}
+		count.Notes = notes.String
+		count.LocationIDs = toIntSlice(locationIDs)
+		counts = append(counts, count)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating cycle counts: %w", err)
+	}
+
+	return counts, total, nil
+}
+
+func (r *cycleCountRepository) UpdateStatus(ctx context.Context, count *domain.CycleCount) error {
+	query := `
+		UPDATE cycle_counts
+		SET status = $2, posted_by = $3, posted_at = $4, updated_at = $5
+		WHERE id = $1`
+
+	count.UpdatedAt = time.Now()
+
+	result, err := r.db.ExecContext(ctx, query,
+		count.ID,
+		count.Status,
+		count.PostedBy,
+		count.PostedAt,
+		count.UpdatedAt,
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to update cycle count status: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *cycleCountRepository) CreateLine(ctx context.Context, line *domain.CycleCountLine) error {
+	query := `
+		INSERT INTO cycle_count_lines (cycle_count_id, product_id, location_id, expected_quantity)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id`
+
+	err := r.db.QueryRowContext(ctx, query,
+		line.CycleCountID,
+		line.ProductID,
+		line.LocationID,
+		line.ExpectedQuantity,
+	).Scan(&line.ID)
+
+	if err != nil {
+		return fmt.Errorf("failed to create cycle count line: %w", err)
+	}
+
+	return nil
+}
+
+// SaveCount records the counted quantity of a line, adding the line when the product
+// was not expected at the location
+func (r *cycleCountRepository) SaveCount(ctx context.Context, line *domain.CycleCountLine) error {
+	query := `
+		INSERT INTO cycle_count_lines (cycle_count_id, product_id, location_id, expected_quantity, counted_quantity, counted_by, counted_at)
+		VALUES ($1, $2, $3, 0, $4, $5, $6)
+		ON CONFLICT (cycle_count_id, product_id, location_id)
+		DO UPDATE SET counted_quantity = EXCLUDED.counted_quantity, counted_by = EXCLUDED.counted_by, counted_at = EXCLUDED.counted_at
+		RETURNING id, expected_quantity`
+
+	err := r.db.QueryRowContext(ctx, query,
+		line.CycleCountID,
+		line.ProductID,
+		line.LocationID,
+		line.CountedQuantity,
+		line.CountedBy,
+		line.CountedAt,
+	).Scan(&line.ID, &line.ExpectedQuantity)
+
+	if err != nil {
+		return fmt.Errorf("failed to save cycle count line: %w", err)
+	}
+
+	return nil
+}
+
+func (r *cycleCountRepository) ListLines(ctx context.Context, cycleCountID int) ([]*domain.CycleCountLine, error) {
+	query := `
+		SELECT ccl.id, ccl.cycle_count_id, ccl.product_id, ccl.location_id, ccl.expected_quantity, ccl.counted_quantity,
+		       ccl.adjusted_quantity, ccl.adjustment_movement_id, ccl.counted_by, ccl.counted_at,
+		       p.id, p.sku, p.name, p.description, p.price, p.weight, p.dimensions, p.category, p.quantity, p.is_serialized, p.cost_method, p.base_unit, p.min_temperature, p.max_temperature, p.length_cm, p.width_cm, p.height_cm, p.is_active, p.created_at, p.updated_at,
+		       l.id, l.warehouse_id, l.code, l.name, l.zone, l.aisle, l.rack, l.shelf, l.capacity, l.temperature, l.max_weight, l.max_volume, l.is_active, l.created_at, l.updated_at
+		FROM cycle_count_lines ccl
+		JOIN products p ON ccl.product_id = p.id
+		JOIN locations l ON ccl.location_id = l.id
+		WHERE ccl.cycle_count_id = $1
+		ORDER BY l.zone, l.aisle, l.rack, l.shelf, p.sku`
+
+	rows, err := r.db.QueryContext(ctx, query, cycleCountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list cycle count lines: %w", err)
+	}
+	defer rows.Close()
+
+	var lines []*domain.CycleCountLine
+	for rows.Next() {
+		line := &domain.CycleCountLine{}
+		product := &domain.Product{}
+		location := &domain.Location{}
+		err := rows.Scan(
+			&line.ID, &line.CycleCountID, &line.ProductID, &line.LocationID, &line.ExpectedQuantity, &line.CountedQuantity,
+			&line.AdjustedQuantity, &line.AdjustmentMovementID, &line.CountedBy, &line.CountedAt,
+			&product.ID, &product.SKU, &product.Name, &product.Description, &product.Price, &product.Weight, &product.Dimensions, &product.Category, &product.Quantity, &product.IsSerialized, &product.CostMethod, &product.BaseUnit, &product.MinTemperature, &product.MaxTemperature, &product.Length, &product.Width, &product.Height, &product.IsActive, &product.CreatedAt, &product.UpdatedAt,
+			&location.ID, &location.WarehouseID, &location.Code, &location.Name, &location.Zone, &location.Aisle, &location.Rack, &location.Shelf, &location.Capacity, &location.Temperature, &location.MaxWeight, &location.MaxVolume, &location.IsActive, &location.CreatedAt, &location.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan cycle count line: %w", err)
+		}
+		line.Product = product
+		line.Location = location
+		lines = append(lines, line)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating cycle count lines: %w", err)
+	}
+
+	return lines, nil
+}
+
+func (r *cycleCountRepository) SetLineAdjustment(ctx context.Context, lineID int, adjustedQuantity int, movementID *int) error {
+	query := `UPDATE cycle_count_lines SET adjusted_quantity = $2, adjustment_movement_id = $3 WHERE id = $1`
+
+	result, err := r.db.ExecContext(ctx, query, lineID, adjustedQuantity, movementID)
+	if err != nil {
+		return fmt.Errorf("failed to update cycle count line: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+func toIntSlice(values pq.Int64Array) []int {
+	result := make([]int, len(values))
+	for i, v := range values {
+		result[i] = int(v)
+	}
+	return result
+}
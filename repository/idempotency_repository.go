@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/edwinjordan/wmsTest_Golang/domain"
+)
+
+type idempotencyRepository struct {
+	db DBTX
+}
+
+func NewIdempotencyRepository(db DBTX) IdempotencyRepository {
+	return &idempotencyRepository{db: db}
+}
+
+// Create stores a new in-flight record. It returns false without error when the key is already taken.
+func (r *idempotencyRepository) Create(ctx context.Context, record *domain.IdempotencyRecord) (bool, error) {
+	query := `
+		INSERT INTO idempotency_keys (user_id, key, request_hash, claim_token, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id, key) DO NOTHING`
+
+	record.CreatedAt = time.Now()
+
+	result, err := r.db.ExecContext(ctx, query,
+		record.UserID,
+		record.Key,
+		record.RequestHash,
+		record.ClaimToken,
+		record.CreatedAt,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to create idempotency key: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected == 1, nil
+}
+
+func (r *idempotencyRepository) Get(ctx context.Context, userID int, key string) (*domain.IdempotencyRecord, error) {
+	query := `
+		SELECT user_id, key, request_hash, status_code, response_body, created_at, completed_at
+		FROM idempotency_keys
+		WHERE user_id = $1 AND key = $2`
+
+	record := &domain.IdempotencyRecord{}
+	var responseBody sql.NullString
+	err := r.db.QueryRowContext(ctx, query, userID, key).Scan(
+		&record.UserID,
+		&record.Key,
+		&record.RequestHash,
+		&record.StatusCode,
+		&responseBody,
+		&record.CreatedAt,
+		&record.CompletedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get idempotency key: %w", err)
+	}
+
+	if responseBody.Valid {
+		record.ResponseBody = []byte(responseBody.String)
+	}
+
+	return record, nil
+}
+
+// Complete stores the response of the original request so retries can replay it. It fails with
+// ErrIdempotencyClaimLost when the key is no longer held by the record's claim.
+func (r *idempotencyRepository) Complete(ctx context.Context, record *domain.IdempotencyRecord) error {
+	query := `
+		UPDATE idempotency_keys
+		SET status_code = $4, response_body = $5, completed_at = $6
+		WHERE user_id = $1 AND key = $2 AND claim_token = $3 AND status_code IS NULL`
+
+	now := time.Now()
+	record.CompletedAt = &now
+
+	result, err := r.db.ExecContext(ctx, query,
+		record.UserID,
+		record.Key,
+		record.ClaimToken,
+		record.StatusCode,
+		string(record.ResponseBody),
+		record.CompletedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to complete idempotency key: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return domain.ErrIdempotencyClaimLost
+	}
+
+	return nil
+}
+
+// Delete releases an in-flight key held by the given claim. It fails with ErrIdempotencyClaimLost
+// when another request holds the key.
+func (r *idempotencyRepository) Delete(ctx context.Context, userID int, key, claimToken string) error {
+	query := `DELETE FROM idempotency_keys WHERE user_id = $1 AND key = $2 AND claim_token = $3 AND status_code IS NULL`
+
+	result, err := r.db.ExecContext(ctx, query, userID, key, claimToken)
+	if err != nil {
+		return fmt.Errorf("failed to delete idempotency key: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return domain.ErrIdempotencyClaimLost
+	}
+
+	return nil
+}
+
+// DeleteStale removes a record that is still in flight and was created before the given time
+func (r *idempotencyRepository) DeleteStale(ctx context.Context, userID int, key string, before time.Time) error {
+	query := `DELETE FROM idempotency_keys WHERE user_id = $1 AND key = $2 AND status_code IS NULL AND created_at < $3`
+
+	if _, err := r.db.ExecContext(ctx, query, userID, key, before); err != nil {
+		return fmt.Errorf("failed to delete stale idempotency key: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteExpired removes every record created before the given time
+func (r *idempotencyRepository) DeleteExpired(ctx context.Context, before time.Time) error {
+	query := `DELETE FROM idempotency_keys WHERE created_at < $1`
+
+	if _, err := r.db.ExecContext(ctx, query, before); err != nil {
+		return fmt.Errorf("failed to delete expired idempotency keys: %w", err)
+	}
+
+	return nil
+}
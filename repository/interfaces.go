@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/edwinjordan/wmsTest_Golang/domain"
 )
@@ -22,38 +23,258 @@ type UserRepository interface {
 type ProductRepository interface {
 	Create(ctx context.Context, product *domain.Product) error
 	GetByID(ctx context.Context, id int) (*domain.Product, error)
+	GetByIDForUpdate(ctx context.Context, id int) (*domain.Product, error)
 	GetBySKU(ctx context.Context, sku string) (*domain.Product, error)
 	Update(ctx context.Context, product *domain.Product) error
 	UpdateQuantity(ctx context.Context, id int, quantity int) error
+	AdjustQuantity(ctx context.Context, id int, delta int) error
 	Delete(ctx context.Context, id int) error
 	List(ctx context.Context, limit, offset int) ([]*domain.Product, int, error)
 	Search(ctx context.Context, query string, limit, offset int) ([]*domain.Product, int, error)
 }
 
+// ProductUnitRepository defines the interface for product pack size operations
+type ProductUnitRepository interface {
+	ListByProduct(ctx context.Context, productID int) ([]*domain.ProductUnit, error)
+	Replace(ctx context.Context, productID int, units []*domain.ProductUnit) error
+}
+
 // LocationRepository defines the interface for location data operations
 type LocationRepository interface {
 	Create(ctx context.Context, location *domain.Location) error
 	GetByID(ctx context.Context, id int) (*domain.Location, error)
-	GetByCode(ctx context.Context, code string) (*domain.Location, error)
+	GetByIDForUpdate(ctx context.Context, id int) (*domain.Location, error)
+	GetByCode(ctx context.Context, warehouseID int, code string) (*domain.Location, error)
 	Update(ctx context.Context, location *domain.Location) error
 	Delete(ctx context.Context, id int) error
-	List(ctx context.Context, limit, offset int) ([]*domain.Location, int, error)
-	ListByZone(ctx context.Context, zone string, limit, offset int) ([]*domain.Location, int, error)
+	List(ctx context.Context, warehouseID *int, limit, offset int) ([]*domain.Location, int, error)
+	ListByZone(ctx context.Context, warehouseID *int, zone string, limit, offset int) ([]*domain.Location, int, error)
+	ListByCode(ctx context.Context, code string) ([]*domain.Location, error)
+	ListActive(ctx context.Context) ([]*domain.Location, error)
+}
+
+// WarehouseRepository defines the interface for warehouse data operations
+type WarehouseRepository interface {
+	Create(ctx context.Context, warehouse *domain.Warehouse) error
+	GetByID(ctx context.Context, id int) (*domain.Warehouse, error)
+	GetByCode(ctx context.Context, code string) (*domain.Warehouse, error)
+	Update(ctx context.Context, warehouse *domain.Warehouse) error
+	List(ctx context.Context, limit, offset int) ([]*domain.Warehouse, int, error)
 }
 
 // StockMovementRepository defines the interface for stock movement data operations
 type StockMovementRepository interface {
 	Create(ctx context.Context, movement *domain.StockMovement) error
+	SetRelatedMovement(ctx context.Context, id int, relatedID int) error
 	GetByID(ctx context.Context, id int) (*domain.StockMovement, error)
+	GetReversal(ctx context.Context, movementID int) (*domain.StockMovement, error)
 	List(ctx context.Context, filter *domain.StockMovementFilter) ([]*domain.StockMovement, int, error)
 	GetByProduct(ctx context.Context, productID int, limit, offset int) ([]*domain.StockMovement, int, error)
 	GetByLocation(ctx context.Context, locationID int, limit, offset int) ([]*domain.StockMovement, int, error)
+	ListLevelDiscrepancies(ctx context.Context, productID *int) ([]*domain.StockDiscrepancy, error)
+	ListProductDiscrepancies(ctx context.Context, productID *int) ([]*domain.StockDiscrepancy, error)
+	CountPutaways(ctx context.Context, productID int, since time.Time) (map[int]int, error)
+	GetShippedUnitCost(ctx context.Context, salesOrderLineID int) (*float64, error)
+}
+
+// StockLevelRepository defines the interface for per-location stock balance operations
+type StockLevelRepository interface {
+	Get(ctx context.Context, productID, locationID int, lotNumber string, status domain.InventoryStatus) (*domain.StockLevel, error)
+	GetQuantity(ctx context.Context, productID, locationID int) (int, error)
+	GetStatusQuantity(ctx context.Context, productID, locationID int, status domain.InventoryStatus) (int, error)
+	AdjustQuantity(ctx context.Context, productID, locationID int, lotNumber string, status domain.InventoryStatus, delta int) error
+	ListLots(ctx context.Context, productID, locationID int, status domain.InventoryStatus) ([]*domain.StockLevel, error)
+	ListByProduct(ctx context.Context, productID int, warehouseID *int) ([]*domain.StockLevel, error)
+	ListByLocation(ctx context.Context, locationID int) ([]*domain.StockLevel, error)
+	ListByWarehouse(ctx context.Context, warehouseID int) ([]*domain.StockLevel, error)
+	GetLocationLoad(ctx context.Context, locationID int) (*domain.LocationLoad, error)
+	ListLocationLoads(ctx context.Context) (map[int]*domain.LocationLoad, error)
+	GetProductTotal(ctx context.Context, productID int) (int, error)
+	ListByStatus(ctx context.Context, productID, locationID *int, lotNumber *string, status domain.InventoryStatus) ([]*domain.StockLevel, error)
+}
+
+// InventoryStatusChangeRepository defines the interface for inventory status change history
+type InventoryStatusChangeRepository interface {
+	Create(ctx context.Context, change *domain.InventoryStatusChange) error
+	List(ctx context.Context, filter *domain.InventoryStatusChangeFilter) ([]*domain.InventoryStatusChange, int, error)
+}
+
+// StockSnapshotRepository defines the interface for point-in-time stock snapshot operations
+type StockSnapshotRepository interface {
+	Create(ctx context.Context, snapshot *domain.StockSnapshot, base *domain.StockSnapshot) error
+	GetLatest(ctx context.Context, at time.Time) (*domain.StockSnapshot, error)
+	ListBalances(ctx context.Context, base *domain.StockSnapshot, filter *domain.StockAsOfFilter) ([]*domain.StockAsOfLine, error)
+}
+
+// LotRepository defines the interface for product lot data operations
+type LotRepository interface {
+	Create(ctx context.Context, lot *domain.Lot) error
+	GetByNumber(ctx context.Context, productID int, lotNumber string) (*domain.Lot, error)
+}
+
+// SerialNumberRepository defines the interface for serial number data operations
+type SerialNumberRepository interface {
+	Create(ctx context.Context, serial *domain.SerialNumber) error
+	GetByNumber(ctx context.Context, productID int, serialNumber string) (*domain.SerialNumber, error)
+	ListByNumber(ctx context.Context, serialNumber string) ([]*domain.SerialNumber, error)
+	UpdateLocation(ctx context.Context, serial *domain.SerialNumber) error
+	UpdateStatus(ctx context.Context, productID, locationID int, lotNumber string, from, to domain.InventoryStatus) error
+	AddMovement(ctx context.Context, movementID int, serialNumberID int) error
+}
+
+// CostLayerRepository defines the interface for inventory cost layer operations
+type CostLayerRepository interface {
+	Create(ctx context.Context, layer *domain.CostLayer) error
+	ListOpen(ctx context.Context, productID, locationID int) ([]*domain.CostLayer, error)
+	Update(ctx context.Context, layer *domain.CostLayer) error
+	AddMovementCost(ctx context.Context, movementID int, cost *domain.StockMovementCost) error
+	GetAverageCost(ctx context.Context, productID int, locationID *int) (*float64, error)
+	ListValuation(ctx context.Context, filter *domain.ValuationFilter) ([]*domain.ValuationLine, error)
+}
+
+// ReorderRuleRepository defines the interface for reorder rule data operations
+type ReorderRuleRepository interface {
+	Create(ctx context.Context, rule *domain.ReorderRule) error
+	Update(ctx context.Context, rule *domain.ReorderRule) error
+	GetByID(ctx context.Context, id int) (*domain.ReorderRule, error)
+	Get(ctx context.Context, productID int, locationID *int) (*domain.ReorderRule, error)
+	ListByProduct(ctx context.Context, productID int) ([]*domain.ReorderRule, error)
+	ListForLocation(ctx context.Context, productID, locationID int) ([]*domain.ReorderRule, error)
+	Delete(ctx context.Context, id int) error
+	ListLowStock(ctx context.Context) ([]*domain.LowStockItem, error)
+}
+
+// StockAlertRepository defines the interface for low-stock alert data operations
+type StockAlertRepository interface {
+	Create(ctx context.Context, alert *domain.StockAlert) error
+	GetByID(ctx context.Context, id int) (*domain.StockAlert, error)
+	GetUnresolvedByRule(ctx context.Context, ruleID int) (*domain.StockAlert, error)
+	Update(ctx context.Context, alert *domain.StockAlert) error
+	List(ctx context.Context, filter *domain.StockAlertFilter) ([]*domain.StockAlert, int, error)
+}
+
+// ReservationRepository defines the interface for stock reservation data operations
+type ReservationRepository interface {
+	Create(ctx context.Context, reservation *domain.Reservation) error
+	GetByID(ctx context.Context, id int) (*domain.Reservation, error)
+	GetByIDForUpdate(ctx context.Context, id int) (*domain.Reservation, error)
+	List(ctx context.Context, filter *domain.ReservationFilter) ([]*domain.Reservation, int, error)
+	Update(ctx context.Context, reservation *domain.Reservation) error
+	ExpireDue(ctx context.Context) error
+	GetReservedQuantity(ctx context.Context, productID, locationID int, excludeID *int) (int, error)
+	GetReservedByProduct(ctx context.Context, productID int, warehouseID *int) (map[int]int, error)
+	GetWarehouseReservedByProduct(ctx context.Context, productID int) (map[int]int, error)
+	GetReservedByLocation(ctx context.Context, locationID int) (map[int]int, error)
+	GetReservedByWarehouse(ctx context.Context, warehouseID int) (map[int]int, error)
+}
+
+// IdempotencyRepository defines the interface for idempotency key data operations
+type IdempotencyRepository interface {
+	Create(ctx context.Context, record *domain.IdempotencyRecord) (bool, error)
+	Get(ctx context.Context, userID int, key string) (*domain.IdempotencyRecord, error)
+	Complete(ctx context.Context, record *domain.IdempotencyRecord) error
+	Delete(ctx context.Context, userID int, key, claimToken string) error
+	DeleteStale(ctx context.Context, userID int, key string, before time.Time) error
+	DeleteExpired(ctx context.Context, before time.Time) error
+}
+
+// CycleCountRepository defines the interface for cycle count data operations
+type CycleCountRepository interface {
+	Create(ctx context.Context, count *domain.CycleCount) error
+	GetByID(ctx context.Context, id int) (*domain.CycleCount, error)
+	GetByIDForUpdate(ctx context.Context, id int) (*domain.CycleCount, error)
+	List(ctx context.Context, status *domain.CycleCountStatus, limit, offset int) ([]*domain.CycleCount, int, error)
+	UpdateStatus(ctx context.Context, count *domain.CycleCount) error
+	CreateLine(ctx context.Context, line *domain.CycleCountLine) error
+	SaveCount(ctx context.Context, line *domain.CycleCountLine) error
+	ListLines(ctx context.Context, cycleCountID int) ([]*domain.CycleCountLine, error)
+	SetLineAdjustment(ctx context.Context, lineID int, adjustedQuantity int, movementID *int) error
+}
+
+// SupplierRepository defines the interface for supplier data operations
+type SupplierRepository interface {
+	Create(ctx context.Context, supplier *domain.Supplier) error
+	GetByID(ctx context.Context, id int) (*domain.Supplier, error)
+	GetByCode(ctx context.Context, code string) (*domain.Supplier, error)
+	Update(ctx context.Context, supplier *domain.Supplier) error
+	List(ctx context.Context, limit, offset int) ([]*domain.Supplier, int, error)
+}
+
+// PurchaseOrderRepository defines the interface for purchase order data operations
+type PurchaseOrderRepository interface {
+	Create(ctx context.Context, order *domain.PurchaseOrder) error
+	GetByID(ctx context.Context, id int) (*domain.PurchaseOrder, error)
+	GetByIDForUpdate(ctx context.Context, id int) (*domain.PurchaseOrder, error)
+	GetByNumber(ctx context.Context, poNumber string) (*domain.PurchaseOrder, error)
+	UpdateStatus(ctx context.Context, order *domain.PurchaseOrder) error
+	List(ctx context.Context, filter *domain.PurchaseOrderFilter) ([]*domain.PurchaseOrder, int, error)
+	CreateLine(ctx context.Context, line *domain.PurchaseOrderLine) error
+	ListLines(ctx context.Context, purchaseOrderID int) ([]*domain.PurchaseOrderLine, error)
+	AddReceivedQuantity(ctx context.Context, lineID int, quantity int) error
+}
+
+// SalesOrderRepository defines the interface for sales order data operations
+type SalesOrderRepository interface {
+	Create(ctx context.Context, order *domain.SalesOrder) error
+	GetByID(ctx context.Context, id int) (*domain.SalesOrder, error)
+	GetByIDForUpdate(ctx context.Context, id int) (*domain.SalesOrder, error)
+	GetByNumber(ctx context.Context, soNumber string) (*domain.SalesOrder, error)
+	Update(ctx context.Context, order *domain.SalesOrder) error
+	List(ctx context.Context, filter *domain.SalesOrderFilter) ([]*domain.SalesOrder, int, error)
+	ListByWave(ctx context.Context, waveID int) ([]*domain.SalesOrder, error)
+	ListWaveCandidatesForUpdate(ctx context.Context, req *domain.BuildWavesRequest) ([]*domain.SalesOrder, error)
+	CreateLine(ctx context.Context, line *domain.SalesOrderLine) error
+	ListLines(ctx context.Context, salesOrderID int) ([]*domain.SalesOrderLine, error)
+	AddShippedQuantity(ctx context.Context, lineID int, quantity int) error
+	CreateAllocation(ctx context.Context, allocation *domain.SalesOrderAllocation) error
+	ListAllocations(ctx context.Context, salesOrderID int) ([]*domain.SalesOrderAllocation, error)
+}
+
+// WaveRepository defines the interface for wave data operations
+type WaveRepository interface {
+	Create(ctx context.Context, wave *domain.Wave) error
+	GetByID(ctx context.Context, id int) (*domain.Wave, error)
+	GetByIDForUpdate(ctx context.Context, id int) (*domain.Wave, error)
+	Update(ctx context.Context, wave *domain.Wave) error
+	List(ctx context.Context, filter *domain.WaveFilter) ([]*domain.Wave, int, error)
+}
+
+// ReturnAuthorizationRepository defines the interface for customer return data operations
+type ReturnAuthorizationRepository interface {
+	Create(ctx context.Context, rma *domain.ReturnAuthorization) error
+	GetByID(ctx context.Context, id int) (*domain.ReturnAuthorization, error)
+	GetByIDForUpdate(ctx context.Context, id int) (*domain.ReturnAuthorization, error)
+	GetByNumber(ctx context.Context, rmaNumber string) (*domain.ReturnAuthorization, error)
+	UpdateStatus(ctx context.Context, rma *domain.ReturnAuthorization) error
+	List(ctx context.Context, filter *domain.ReturnAuthorizationFilter) ([]*domain.ReturnAuthorization, int, error)
+	CreateLine(ctx context.Context, line *domain.ReturnAuthorizationLine) error
+	ListLines(ctx context.Context, returnAuthorizationID int) ([]*domain.ReturnAuthorizationLine, error)
+	AddReceivedQuantity(ctx context.Context, lineID int, grade domain.ReturnGrade, quantity int) error
+	GetReturnedQuantity(ctx context.Context, salesOrderLineID int) (int, error)
 }
 
 // Repositories aggregates all repository interfaces
 type Repositories struct {
 	User          UserRepository
 	Product       ProductRepository
+	ProductUnit   ProductUnitRepository
+	Warehouse     WarehouseRepository
 	Location      LocationRepository
 	StockMovement StockMovementRepository
+	StockLevel    StockLevelRepository
+	StatusChange  InventoryStatusChangeRepository
+	StockSnapshot StockSnapshotRepository
+	Lot           LotRepository
+	SerialNumber  SerialNumberRepository
+	CostLayer     CostLayerRepository
+	ReorderRule   ReorderRuleRepository
+	StockAlert    StockAlertRepository
+	Reservation   ReservationRepository
+	Idempotency   IdempotencyRepository
+	CycleCount    CycleCountRepository
+	Supplier      SupplierRepository
+	PurchaseOrder PurchaseOrderRepository
+	SalesOrder    SalesOrderRepository
+	Wave          WaveRepository
+	Return        ReturnAuthorizationRepository
 }
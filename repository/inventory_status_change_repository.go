@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/edwinjordan/wmsTest_Golang/domain"
+)
+
+type inventoryStatusChangeRepository struct {
+	db DBTX
+}
+
+func NewInventoryStatusChangeRepository(db DBTX) InventoryStatusChangeRepository {
+	return &inventoryStatusChangeRepository{db: db}
+}
+
+func (r *inventoryStatusChangeRepository) Create(ctx context.Context, change *domain.InventoryStatusChange) error {
+	query := `
+		INSERT INTO inventory_status_changes (product_id, location_id, lot_number, from_status, to_status, quantity, reference, notes, user_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id`
+
+	change.CreatedAt = time.Now()
+
+	err := r.db.QueryRowContext(ctx, query,
+		change.ProductID,
+		change.LocationID,
+		change.LotNumber,
+		change.FromStatus,
+		change.ToStatus,
+		change.Quantity,
+		change.Reference,
+		change.Notes,
+		change.UserID,
+		change.CreatedAt,
+	).Scan(&change.ID)
+
+	if err != nil {
+		return fmt.Errorf("failed to create inventory status change: %w", err)
+	}
+
+	return nil
+}
+
+func (r *inventoryStatusChangeRepository) List(ctx context.Context, filter *domain.InventoryStatusChangeFilter) ([]*domain.InventoryStatusChange, int, error) {
+	// Build WHERE conditions
+	var conditions []string
+	var args []interface{}
+	argIndex := 1
+
+	if filter.ProductID != nil {
+		conditions = append(conditions, fmt.Sprintf("product_id = $%d", argIndex))
+		args = append(args, *filter.ProductID)
+		argIndex++
+	}
+
+	if filter.LocationID != nil {
+		conditions = append(conditions, fmt.Sprintf("location_id = $%d", argIndex))
+		args = append(args, *filter.LocationID)
+		argIndex++
+	}
+
+	if filter.LotNumber != nil {
+		conditions = append(conditions, fmt.Sprintf("lot_number = $%d", argIndex))
+		args = append(args, *filter.LotNumber)
+		argIndex++
+	}
+
+	if filter.Status != nil {
+		conditions = append(conditions, fmt.Sprintf("(from_status = $%d OR to_status = $%d)", argIndex, argIndex))
+		args = append(args, *filter.Status)
+		argIndex++
+	}
+
+	whereClause := ""
+	if len(conditions) > 0 {
+		whereClause = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	// Count total records
+	countQuery := fmt.Sprintf(`SELECT COUNT(*) FROM inventory_status_changes %s`, whereClause)
+
+	var total int
+	err := r.db.QueryRowContext(ctx, countQuery, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count inventory status changes: %w", err)
+	}
+
+	// Get paginated records
+	query := fmt.Sprintf(`
+		SELECT id, product_id, location_id, lot_number, from_status, to_status, quantity, reference, notes, user_id, created_at
+		FROM inventory_status_changes
+		%s
+		ORDER BY created_at DESC, id DESC
+		LIMIT $%d OFFSET $%d`, whereClause, argIndex, argIndex+1)
+	args = append(args, filter.Limit, filter.Offset)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list inventory status changes: %w", err)
+	}
+	defer rows.Close()
+
+	var changes []*domain.InventoryStatusChange
+	for rows.Next() {
+		change := &domain.InventoryStatusChange{}
+		var notes sql.NullString
+		err := rows.Scan(
+			&change.ID,
+			&change.ProductID,
+			&change.LocationID,
+			&change.LotNumber,
+			&change.FromStatus,
+			&change.ToStatus,
+			&change.Quantity,
+			&change.Reference,
+			&notes,
+			&change.UserID,
+			&change.CreatedAt,
+		)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan inventory status change: %w", err)
+		}
+		change.Notes = notes.String
+		changes = append(changes, change)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating inventory status changes: %w", err)
+	}
+
+	return changes, total, nil
+}
@@ -10,17 +10,17 @@ import (
 )
 
 type locationRepository struct {
-	db *sql.DB
+	db DBTX
 }
 
-func NewLocationRepository(db *sql.DB) LocationRepository {
+func NewLocationRepository(db DBTX) LocationRepository {
 	return &locationRepository{db: db}
 }
 
 func (r *locationRepository) Create(ctx context.Context, location *domain.Location) error {
 	query := `
-		INSERT INTO locations (code, name, zone, aisle, rack, shelf, capacity, temperature, is_active, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		INSERT INTO locations (warehouse_id, code, name, zone, aisle, rack, shelf, capacity, temperature, max_weight, max_volume, is_active, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
 		RETURNING id`
 
 	now := time.Now()
@@ -29,6 +29,7 @@ func (r *locationRepository) Create(ctx context.Context, location *domain.Locati
 	location.IsActive = true
 
 	err := r.db.QueryRowContext(ctx, query,
+		location.WarehouseID,
 		location.Code,
 		location.Name,
 		location.Zone,
@@ -37,6 +38,8 @@ func (r *locationRepository) Create(ctx context.Context, location *domain.Locati
 		location.Shelf,
 		location.Capacity,
 		location.Temperature,
+		location.MaxWeight,
+		location.MaxVolume,
 		location.IsActive,
 		location.CreatedAt,
 		location.UpdatedAt,
@@ -51,13 +54,14 @@ func (r *locationRepository) Create(ctx context.Context, location *domain.Locati
 
 func (r *locationRepository) GetByID(ctx context.Context, id int) (*domain.Location, error) {
 	query := `
-		SELECT id, code, name, zone, aisle, rack, shelf, capacity, temperature, is_active, created_at, updated_at
+		SELECT id, warehouse_id, code, name, zone, aisle, rack, shelf, capacity, temperature, max_weight, max_volume, is_active, created_at, updated_at
 		FROM locations 
 		WHERE id = $1 AND is_active = true`
 
 	location := &domain.Location{}
 	err := r.db.QueryRowContext(ctx, query, id).Scan(
 		&location.ID,
+		&location.WarehouseID,
 		&location.Code,
 		&location.Name,
 		&location.Zone,
@@ -66,6 +70,8 @@ func (r *locationRepository) GetByID(ctx context.Context, id int) (*domain.Locat
 		&location.Shelf,
 		&location.Capacity,
 		&location.Temperature,
+		&location.MaxWeight,
+		&location.MaxVolume,
 		&location.IsActive,
 		&location.CreatedAt,
 		&location.UpdatedAt,
@@ -81,15 +87,54 @@ func (r *locationRepository) GetByID(ctx context.Context, id int) (*domain.Locat
 	return location, nil
 }
 
-func (r *locationRepository) GetByCode(ctx context.Context, code string) (*domain.Location, error) {
+// GetByIDForUpdate loads an active location and locks its row until the surrounding transaction ends
+func (r *locationRepository) GetByIDForUpdate(ctx context.Context, id int) (*domain.Location, error) {
 	query := `
-		SELECT id, code, name, zone, aisle, rack, shelf, capacity, temperature, is_active, created_at, updated_at
+		SELECT id, warehouse_id, code, name, zone, aisle, rack, shelf, capacity, temperature, max_weight, max_volume, is_active, created_at, updated_at
 		FROM locations 
-		WHERE code = $1 AND is_active = true`
+		WHERE id = $1 AND is_active = true
+		FOR UPDATE`
 
 	location := &domain.Location{}
-	err := r.db.QueryRowContext(ctx, query, code).Scan(
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
+		&location.ID,
+		&location.WarehouseID,
+		&location.Code,
+		&location.Name,
+		&location.Zone,
+		&location.Aisle,
+		&location.Rack,
+		&location.Shelf,
+		&location.Capacity,
+		&location.Temperature,
+		&location.MaxWeight,
+		&location.MaxVolume,
+		&location.IsActive,
+		&location.CreatedAt,
+		&location.UpdatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to lock location: %w", err)
+	}
+
+	return location, nil
+}
+
+// GetByCode returns the active location with the given code in a warehouse
+func (r *locationRepository) GetByCode(ctx context.Context, warehouseID int, code string) (*domain.Location, error) {
+	query := `
+		SELECT id, warehouse_id, code, name, zone, aisle, rack, shelf, capacity, temperature, max_weight, max_volume, is_active, created_at, updated_at
+		FROM locations 
+		WHERE warehouse_id = $1 AND code = $2 AND is_active = true`
+
+	location := &domain.Location{}
+	err := r.db.QueryRowContext(ctx, query, warehouseID, code).Scan(
 		&location.ID,
+		&location.WarehouseID,
 		&location.Code,
 		&location.Name,
 		&location.Zone,
@@ -98,6 +143,8 @@ func (r *locationRepository) GetByCode(ctx context.Context, code string) (*domai
 		&location.Shelf,
 		&location.Capacity,
 		&location.Temperature,
+		&location.MaxWeight,
+		&location.MaxVolume,
 		&location.IsActive,
 		&location.CreatedAt,
 		&location.UpdatedAt,
@@ -117,7 +164,7 @@ func (r *locationRepository) Update(ctx context.Context, location *domain.Locati
 	query := `
 		UPDATE locations 
 		SET code = $2, name = $3, zone = $4, aisle = $5, rack = $6, shelf = $7, 
-		    capacity = $8, temperature = $9, is_active = $10, updated_at = $11
+		    capacity = $8, temperature = $9, max_weight = $10, max_volume = $11, is_active = $12, updated_at = $13
 		WHERE id = $1`
 
 	location.UpdatedAt = time.Now()
@@ -132,6 +179,8 @@ func (r *locationRepository) Update(ctx context.Context, location *domain.Locati
 		location.Shelf,
 		location.Capacity,
 		location.Temperature,
+		location.MaxWeight,
+		location.MaxVolume,
 		location.IsActive,
 		location.UpdatedAt,
 	)
@@ -172,24 +221,25 @@ func (r *locationRepository) Delete(ctx context.Context, id int) error {
 	return nil
 }
 
-func (r *locationRepository) List(ctx context.Context, limit, offset int) ([]*domain.Location, int, error) {
+// List returns the active locations, only those of one warehouse when warehouseID is not nil
+func (r *locationRepository) List(ctx context.Context, warehouseID *int, limit, offset int) ([]*domain.Location, int, error) {
 	// Count total records
-	countQuery := `SELECT COUNT(*) FROM locations WHERE is_active = true`
+	countQuery := `SELECT COUNT(*) FROM locations WHERE is_active = true AND ($1::INTEGER IS NULL OR warehouse_id = $1)`
 	var total int
-	err := r.db.QueryRowContext(ctx, countQuery).Scan(&total)
+	err := r.db.QueryRowContext(ctx, countQuery, warehouseID).Scan(&total)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to count locations: %w", err)
 	}
 
 	// Get paginated records
 	query := `
-		SELECT id, code, name, zone, aisle, rack, shelf, capacity, temperature, is_active, created_at, updated_at
+		SELECT id, warehouse_id, code, name, zone, aisle, rack, shelf, capacity, temperature, max_weight, max_volume, is_active, created_at, updated_at
 		FROM locations 
-		WHERE is_active = true
-		ORDER BY zone, aisle, rack, shelf
-		LIMIT $1 OFFSET $2`
+		WHERE is_active = true AND ($1::INTEGER IS NULL OR warehouse_id = $1)
+		ORDER BY warehouse_id, zone, aisle, rack, shelf
+		LIMIT $2 OFFSET $3`
 
-	rows, err := r.db.QueryContext(ctx, query, limit, offset)
+	rows, err := r.db.QueryContext(ctx, query, warehouseID, limit, offset)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to list locations: %w", err)
 	}
@@ -200,6 +250,7 @@ func (r *locationRepository) List(ctx context.Context, limit, offset int) ([]*do
 		location := &domain.Location{}
 		err := rows.Scan(
 			&location.ID,
+			&location.WarehouseID,
 			&location.Code,
 			&location.Name,
 			&location.Zone,
@@ -208,6 +259,8 @@ func (r *locationRepository) List(ctx context.Context, limit, offset int) ([]*do
 			&location.Shelf,
 			&location.Capacity,
 			&location.Temperature,
+			&location.MaxWeight,
+			&location.MaxVolume,
 			&location.IsActive,
 			&location.CreatedAt,
 			&location.UpdatedAt,
@@ -225,24 +278,25 @@ func (r *locationRepository) List(ctx context.Context, limit, offset int) ([]*do
 	return locations, total, nil
 }
 
-func (r *locationRepository) ListByZone(ctx context.Context, zone string, limit, offset int) ([]*domain.Location, int, error) {
+// ListByZone returns the active locations in a zone, only those of one warehouse when warehouseID is not nil
+func (r *locationRepository) ListByZone(ctx context.Context, warehouseID *int, zone string, limit, offset int) ([]*domain.Location, int, error) {
 	// Count total records
-	countQuery := `SELECT COUNT(*) FROM locations WHERE zone = $1 AND is_active = true`
+	countQuery := `SELECT COUNT(*) FROM locations WHERE zone = $1 AND is_active = true AND ($2::INTEGER IS NULL OR warehouse_id = $2)`
 	var total int
-	err := r.db.QueryRowContext(ctx, countQuery, zone).Scan(&total)
+	err := r.db.QueryRowContext(ctx, countQuery, zone, warehouseID).Scan(&total)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to count locations by zone: %w", err)
 	}
 
 	// Get paginated records
 	query := `
-		SELECT id, code, name, zone, aisle, rack, shelf, capacity, temperature, is_active, created_at, updated_at
+		SELECT id, warehouse_id, code, name, zone, aisle, rack, shelf, capacity, temperature, max_weight, max_volume, is_active, created_at, updated_at
 		FROM locations 
-		WHERE zone = $1 AND is_active = true
-		ORDER BY aisle, rack, shelf
-		LIMIT $2 OFFSET $3`
+		WHERE zone = $1 AND is_active = true AND ($2::INTEGER IS NULL OR warehouse_id = $2)
+		ORDER BY warehouse_id, aisle, rack, shelf
+		LIMIT $3 OFFSET $4`
 
-	rows, err := r.db.QueryContext(ctx, query, zone, limit, offset)
+	rows, err := r.db.QueryContext(ctx, query, zone, warehouseID, limit, offset)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to list locations by zone: %w", err)
 	}
@@ -253,6 +307,7 @@ func (r *locationRepository) ListByZone(ctx context.Context, zone string, limit,
 		location := &domain.Location{}
 		err := rows.Scan(
 			&location.ID,
+			&location.WarehouseID,
 			&location.Code,
 			&location.Name,
 			&location.Zone,
@@ -261,6 +316,8 @@ func (r *locationRepository) ListByZone(ctx context.Context, zone string, limit,
 			&location.Shelf,
 			&location.Capacity,
 			&location.Temperature,
+			&location.MaxWeight,
+			&location.MaxVolume,
 			&location.IsActive,
 			&location.CreatedAt,
 			&location.UpdatedAt,
@@ -277,3 +334,97 @@ func (r *locationRepository) ListByZone(ctx context.Context, zone string, limit,
 
 	return locations, total, nil
 }
+
+// ListByCode returns the active locations with the given code across all warehouses
+func (r *locationRepository) ListByCode(ctx context.Context, code string) ([]*domain.Location, error) {
+	query := `
+		SELECT id, warehouse_id, code, name, zone, aisle, rack, shelf, capacity, temperature, max_weight, max_volume, is_active, created_at, updated_at
+		FROM locations 
+		WHERE code = $1 AND is_active = true
+		ORDER BY warehouse_id`
+
+	rows, err := r.db.QueryContext(ctx, query, code)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list locations by code: %w", err)
+	}
+	defer rows.Close()
+
+	var locations []*domain.Location
+	for rows.Next() {
+		location := &domain.Location{}
+		err := rows.Scan(
+			&location.ID,
+			&location.WarehouseID,
+			&location.Code,
+			&location.Name,
+			&location.Zone,
+			&location.Aisle,
+			&location.Rack,
+			&location.Shelf,
+			&location.Capacity,
+			&location.Temperature,
+			&location.MaxWeight,
+			&location.MaxVolume,
+			&location.IsActive,
+			&location.CreatedAt,
+			&location.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan location: %w", err)
+		}
+		locations = append(locations, location)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating locations by code: %w", err)
+	}
+
+	return locations, nil
+}
+
+// ListActive returns every active location, ordered by zone, aisle, rack and shelf
+func (r *locationRepository) ListActive(ctx context.Context) ([]*domain.Location, error) {
+	query := `
+		SELECT id, warehouse_id, code, name, zone, aisle, rack, shelf, capacity, temperature, max_weight, max_volume, is_active, created_at, updated_at
+		FROM locations 
+		WHERE is_active = true
+		ORDER BY zone, aisle, rack, shelf`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list locations: %w", err)
+	}
+	defer rows.Close()
+
+	var locations []*domain.Location
+	for rows.Next() {
+		location := &domain.Location{}
+		err := rows.Scan(
+			&location.ID,
+			&location.WarehouseID,
+			&location.Code,
+			&location.Name,
+			&location.Zone,
+			&location.Aisle,
+			&location.Rack,
+			&location.Shelf,
+			&location.Capacity,
+			&location.Temperature,
+			&location.MaxWeight,
+			&location.MaxVolume,
+			&location.IsActive,
+			&location.CreatedAt,
+			&location.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan location: %w", err)
+		}
+		locations = append(locations, location)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating locations: %w", err)
+	}
+
+	return locations, nil
+}
@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/edwinjordan/wmsTest_Golang/domain"
+)
+
+type lotRepository struct {
+	db DBTX
+}
+
+func NewLotRepository(db DBTX) LotRepository {
+	return &lotRepository{db: db}
+}
+
+func (r *lotRepository) Create(ctx context.Context, lot *domain.Lot) error {
+	query := `
+		INSERT INTO product_lots (product_id, lot_number, expiry_date, created_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id`
+
+	lot.CreatedAt = time.Now()
+
+	err := r.db.QueryRowContext(ctx, query,
+		lot.ProductID,
+		lot.LotNumber,
+		lot.ExpiryDate,
+		lot.CreatedAt,
+	).Scan(&lot.ID)
+
+	if err != nil {
+		return fmt.Errorf("failed to create lot: %w", err)
+	}
+
+	return nil
+}
+
+func (r *lotRepository) GetByNumber(ctx context.Context, productID int, lotNumber string) (*domain.Lot, error) {
+	query := `
+		SELECT id, product_id, lot_number, expiry_date, created_at
+		FROM product_lots
+		WHERE product_id = $1 AND lot_number = $2`
+
+	lot := &domain.Lot{}
+	err := r.db.QueryRowContext(ctx, query, productID, lotNumber).Scan(
+		&lot.ID,
+		&lot.ProductID,
+		&lot.LotNumber,
+		&lot.ExpiryDate,
+		&lot.CreatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get lot: %w", err)
+	}
+
+	return lot, nil
+}
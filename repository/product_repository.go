@@ -11,17 +11,17 @@ import (
 )
 
 type productRepository struct {
-	db *sql.DB
+	db DBTX
 }
 
-func NewProductRepository(db *sql.DB) ProductRepository {
+func NewProductRepository(db DBTX) ProductRepository {
 	return &productRepository{db: db}
 }
 
 func (r *productRepository) Create(ctx context.Context, product *domain.Product) error {
 	query := `
-		INSERT INTO products (sku, name, description, price, weight, dimensions, category, quantity, is_active, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		INSERT INTO products (sku, name, description, price, weight, dimensions, category, quantity, is_serialized, cost_method, base_unit, min_temperature, max_temperature, length_cm, width_cm, height_cm, is_active, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19)
 		RETURNING id`
 
 	now := time.Now()
@@ -38,6 +38,14 @@ func (r *productRepository) Create(ctx context.Context, product *domain.Product)
 		product.Dimensions,
 		product.Category,
 		product.Quantity,
+		product.IsSerialized,
+		product.CostMethod,
+		product.BaseUnit,
+		product.MinTemperature,
+		product.MaxTemperature,
+		product.Length,
+		product.Width,
+		product.Height,
 		product.IsActive,
 		product.CreatedAt,
 		product.UpdatedAt,
@@ -52,7 +60,7 @@ func (r *productRepository) Create(ctx context.Context, product *domain.Product)
 
 func (r *productRepository) GetByID(ctx context.Context, id int) (*domain.Product, error) {
 	query := `
-		SELECT id, sku, name, description, price, weight, dimensions, category, quantity, is_active, created_at, updated_at
+		SELECT id, sku, name, description, price, weight, dimensions, category, quantity, is_serialized, cost_method, base_unit, min_temperature, max_temperature, length_cm, width_cm, height_cm, is_active, created_at, updated_at
 		FROM products 
 		WHERE id = $1 AND is_active = true`
 
@@ -67,6 +75,14 @@ func (r *productRepository) GetByID(ctx context.Context, id int) (*domain.Produc
 		&product.Dimensions,
 		&product.Category,
 		&product.Quantity,
+		&product.IsSerialized,
+		&product.CostMethod,
+		&product.BaseUnit,
+		&product.MinTemperature,
+		&product.MaxTemperature,
+		&product.Length,
+		&product.Width,
+		&product.Height,
 		&product.IsActive,
 		&product.CreatedAt,
 		&product.UpdatedAt,
@@ -82,9 +98,51 @@ func (r *productRepository) GetByID(ctx context.Context, id int) (*domain.Produc
 	return product, nil
 }
 
+// GetByIDForUpdate loads an active product and locks its row until the surrounding transaction ends
+func (r *productRepository) GetByIDForUpdate(ctx context.Context, id int) (*domain.Product, error) {
+	query := `
+		SELECT id, sku, name, description, price, weight, dimensions, category, quantity, is_serialized, cost_method, base_unit, min_temperature, max_temperature, length_cm, width_cm, height_cm, is_active, created_at, updated_at
+		FROM products 
+		WHERE id = $1 AND is_active = true
+		FOR UPDATE`
+
+	product := &domain.Product{}
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
+		&product.ID,
+		&product.SKU,
+		&product.Name,
+		&product.Description,
+		&product.Price,
+		&product.Weight,
+		&product.Dimensions,
+		&product.Category,
+		&product.Quantity,
+		&product.IsSerialized,
+		&product.CostMethod,
+		&product.BaseUnit,
+		&product.MinTemperature,
+		&product.MaxTemperature,
+		&product.Length,
+		&product.Width,
+		&product.Height,
+		&product.IsActive,
+		&product.CreatedAt,
+		&product.UpdatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to lock product: %w", err)
+	}
+
+	return product, nil
+}
+
 func (r *productRepository) GetBySKU(ctx context.Context, sku string) (*domain.Product, error) {
 	query := `
-		SELECT id, sku, name, description, price, weight, dimensions, category, quantity, is_active, created_at, updated_at
+		SELECT id, sku, name, description, price, weight, dimensions, category, quantity, is_serialized, cost_method, base_unit, min_temperature, max_temperature, length_cm, width_cm, height_cm, is_active, created_at, updated_at
 		FROM products 
 		WHERE sku = $1 AND is_active = true`
 
@@ -99,6 +157,14 @@ func (r *productRepository) GetBySKU(ctx context.Context, sku string) (*domain.P
 		&product.Dimensions,
 		&product.Category,
 		&product.Quantity,
+		&product.IsSerialized,
+		&product.CostMethod,
+		&product.BaseUnit,
+		&product.MinTemperature,
+		&product.MaxTemperature,
+		&product.Length,
+		&product.Width,
+		&product.Height,
 		&product.IsActive,
 		&product.CreatedAt,
 		&product.UpdatedAt,
@@ -114,11 +180,14 @@ func (r *productRepository) GetBySKU(ctx context.Context, sku string) (*domain.P
 	return product, nil
 }
 
+// Update saves the product details. The quantity is left alone: it only changes with stock postings.
 func (r *productRepository) Update(ctx context.Context, product *domain.Product) error {
 	query := `
 		UPDATE products 
 		SET sku = $2, name = $3, description = $4, price = $5, weight = $6, 
-		    dimensions = $7, category = $8, quantity = $9, is_active = $10, updated_at = $11
+		    dimensions = $7, category = $8, is_serialized = $9, cost_method = $10, base_unit = $11,
+		    min_temperature = $12, max_temperature = $13,
+		    length_cm = $14, width_cm = $15, height_cm = $16, is_active = $17, updated_at = $18
 		WHERE id = $1`
 
 	product.UpdatedAt = time.Now()
@@ -132,7 +201,14 @@ func (r *productRepository) Update(ctx context.Context, product *domain.Product)
 		product.Weight,
 		product.Dimensions,
 		product.Category,
-		product.Quantity,
+		product.IsSerialized,
+		product.CostMethod,
+		product.BaseUnit,
+		product.MinTemperature,
+		product.MaxTemperature,
+		product.Length,
+		product.Width,
+		product.Height,
 		product.IsActive,
 		product.UpdatedAt,
 	)
@@ -173,6 +249,27 @@ func (r *productRepository) UpdateQuantity(ctx context.Context, id int, quantity
 	return nil
 }
 
+// AdjustQuantity adds delta (which may be negative) to the product quantity
+func (r *productRepository) AdjustQuantity(ctx context.Context, id int, delta int) error {
+	query := `UPDATE products SET quantity = quantity + $2, updated_at = $3 WHERE id = $1 AND is_active = true`
+
+	result, err := r.db.ExecContext(ctx, query, id, delta, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to adjust product quantity: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
 func (r *productRepository) Delete(ctx context.Context, id int) error {
 	query := `UPDATE products SET is_active = false, updated_at = $2 WHERE id = $1`
 
@@ -204,7 +301,7 @@ func (r *productRepository) List(ctx context.Context, limit, offset int) ([]*dom
 
 	// Get paginated records
 	query := `
-		SELECT id, sku, name, description, price, weight, dimensions, category, quantity, is_active, created_at, updated_at
+		SELECT id, sku, name, description, price, weight, dimensions, category, quantity, is_serialized, cost_method, base_unit, min_temperature, max_temperature, length_cm, width_cm, height_cm, is_active, created_at, updated_at
 		FROM products 
 		WHERE is_active = true
 		ORDER BY created_at DESC
@@ -229,6 +326,14 @@ func (r *productRepository) List(ctx context.Context, limit, offset int) ([]*dom
 			&product.Dimensions,
 			&product.Category,
 			&product.Quantity,
+			&product.IsSerialized,
+			&product.CostMethod,
+			&product.BaseUnit,
+			&product.MinTemperature,
+			&product.MaxTemperature,
+			&product.Length,
+			&product.Width,
+			&product.Height,
 			&product.IsActive,
 			&product.CreatedAt,
 			&product.UpdatedAt,
@@ -264,7 +369,7 @@ func (r *productRepository) Search(ctx context.Context, query string, limit, off
 
 	// Get paginated records
 	searchQuery := `
-		SELECT id, sku, name, description, price, weight, dimensions, category, quantity, is_active, created_at, updated_at
+		SELECT id, sku, name, description, price, weight, dimensions, category, quantity, is_serialized, cost_method, base_unit, min_temperature, max_temperature, length_cm, width_cm, height_cm, is_active, created_at, updated_at
 		FROM products 
 		WHERE is_active = true 
 		AND (LOWER(name) LIKE $1 OR LOWER(sku) LIKE $1 OR LOWER(category) LIKE $1)
@@ -298,6 +403,14 @@ func (r *productRepository) Search(ctx context.Context, query string, limit, off
 			&product.Dimensions,
 			&product.Category,
 			&product.Quantity,
+			&product.IsSerialized,
+			&product.CostMethod,
+			&product.BaseUnit,
+			&product.MinTemperature,
+			&product.MaxTemperature,
+			&product.Length,
+			&product.Width,
+			&product.Height,
 			&product.IsActive,
 			&product.CreatedAt,
 			&product.UpdatedAt,
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/edwinjordan/wmsTest_Golang/domain"
+)
+
+type productUnitRepository struct {
+	db DBTX
+}
+
+func NewProductUnitRepository(db DBTX) ProductUnitRepository {
+	return &productUnitRepository{db: db}
+}
+
+// ListByProduct returns the pack sizes of a product, smallest first
+func (r *productUnitRepository) ListByProduct(ctx context.Context, productID int) ([]*domain.ProductUnit, error) {
+	query := `
+		SELECT code, factor
+		FROM product_units
+		WHERE product_id = $1
+		ORDER BY factor, code`
+
+	rows, err := r.db.QueryContext(ctx, query, productID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list product units: %w", err)
+	}
+	defer rows.Close()
+
+	var units []*domain.ProductUnit
+	for rows.Next() {
+		unit := &domain.ProductUnit{}
+		if err := rows.Scan(&unit.Code, &unit.Factor); err != nil {
+			return nil, fmt.Errorf("failed to scan product unit: %w", err)
+		}
+		units = append(units, unit)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating product units: %w", err)
+	}
+
+	return units, nil
+}
+
+// Replace swaps every pack size of a product for the given ones
+func (r *productUnitRepository) Replace(ctx context.Context, productID int, units []*domain.ProductUnit) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM product_units WHERE product_id = $1`, productID); err != nil {
+		return fmt.Errorf("failed to delete product units: %w", err)
+	}
+
+	now := time.Now()
+	for _, unit := range units {
+		_, err := r.db.ExecContext(ctx,
+			`INSERT INTO product_units (product_id, code, factor, created_at) VALUES ($1, $2, $3, $4)`,
+			productID, unit.Code, unit.Factor, now,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to add product unit: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,281 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/edwinjordan/wmsTest_Golang/domain"
+)
+
+const purchaseOrderColumns = `id, po_number, supplier_id, status, expected_date, over_receipt_tolerance, notes, created_by, created_at, updated_at, closed_at`
+
+type purchaseOrderRepository struct {
+	db DBTX
+}
+
+func NewPurchaseOrderRepository(db DBTX) PurchaseOrderRepository {
+	return &purchaseOrderRepository{db: db}
+}
+
+func (r *purchaseOrderRepository) Create(ctx context.Context, order *domain.PurchaseOrder) error {
+	query := `
+		INSERT INTO purchase_orders (po_number, supplier_id, status, expected_date, over_receipt_tolerance, notes, created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id`
+
+	now := time.Now()
+	order.CreatedAt = now
+	order.UpdatedAt = now
+
+	err := r.db.QueryRowContext(ctx, query,
+		order.PONumber,
+		order.SupplierID,
+		order.Status,
+		order.ExpectedDate,
+		order.OverReceiptTolerance,
+		order.Notes,
+		order.CreatedBy,
+		order.CreatedAt,
+		order.UpdatedAt,
+	).Scan(&order.ID)
+
+	if err != nil {
+		return fmt.Errorf("failed to create purchase order: %w", err)
+	}
+
+	return nil
+}
+
+func (r *purchaseOrderRepository) GetByID(ctx context.Context, id int) (*domain.PurchaseOrder, error) {
+	query := `SELECT ` + purchaseOrderColumns + ` FROM purchase_orders WHERE id = $1`
+
+	return r.getOne(ctx, query, id)
+}
+
+// GetByIDForUpdate loads a purchase order and locks it until the surrounding transaction ends
+func (r *purchaseOrderRepository) GetByIDForUpdate(ctx context.Context, id int) (*domain.PurchaseOrder, error) {
+	query := `SELECT ` + purchaseOrderColumns + ` FROM purchase_orders WHERE id = $1 FOR UPDATE`
+
+	return r.getOne(ctx, query, id)
+}
+
+func (r *purchaseOrderRepository) GetByNumber(ctx context.Context, poNumber string) (*domain.PurchaseOrder, error) {
+	query := `SELECT ` + purchaseOrderColumns + ` FROM purchase_orders WHERE po_number = $1`
+
+	return r.getOne(ctx, query, poNumber)
+}
+
+func (r *purchaseOrderRepository) getOne(ctx context.Context, query string, arg interface{}) (*domain.PurchaseOrder, error) {
+	order := &domain.PurchaseOrder{}
+	var notes sql.NullString
+
+	err := r.db.QueryRowContext(ctx, query, arg).Scan(
+		&order.ID,
+		&order.PONumber,
+		&order.SupplierID,
+		&order.Status,
+		&order.ExpectedDate,
+		&order.OverReceiptTolerance,
+		&notes,
+		&order.CreatedBy,
+		&order.CreatedAt,
+		&order.UpdatedAt,
+		&order.ClosedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get purchase order: %w", err)
+	}
+
+	order.Notes = notes.String
+
+	return order, nil
+}
+
+// UpdateStatus saves the status and closing time of a purchase order
+func (r *purchaseOrderRepository) UpdateStatus(ctx context.Context, order *domain.PurchaseOrder) error {
+	query := `UPDATE purchase_orders SET status = $2, closed_at = $3, updated_at = $4 WHERE id = $1`
+
+	order.UpdatedAt = time.Now()
+
+	result, err := r.db.ExecContext(ctx, query, order.ID, order.Status, order.ClosedAt, order.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to update purchase order: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *purchaseOrderRepository) List(ctx context.Context, filter *domain.PurchaseOrderFilter) ([]*domain.PurchaseOrder, int, error) {
+	// Build WHERE conditions
+	var conditions []string
+	var args []interface{}
+	argIndex := 1
+
+	if filter.SupplierID != nil {
+		conditions = append(conditions, fmt.Sprintf("supplier_id = $%d", argIndex))
+		args = append(args, *filter.SupplierID)
+		argIndex++
+	}
+
+	if filter.Status != nil {
+		conditions = append(conditions, fmt.Sprintf("status = $%d", argIndex))
+		args = append(args, *filter.Status)
+		argIndex++
+	}
+
+	whereClause := ""
+	if len(conditions) > 0 {
+		whereClause = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	// Count total records
+	countQuery := fmt.Sprintf(`SELECT COUNT(*) FROM purchase_orders %s`, whereClause)
+
+	var total int
+	err := r.db.QueryRowContext(ctx, countQuery, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count purchase orders: %w", err)
+	}
+
+	// Get paginated records
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM purchase_orders
+		%s
+		ORDER BY created_at DESC
+		LIMIT $%d OFFSET $%d`, purchaseOrderColumns, whereClause, argIndex, argIndex+1)
+	args = append(args, filter.Limit, filter.Offset)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list purchase orders: %w", err)
+	}
+	defer rows.Close()
+
+	var orders []*domain.PurchaseOrder
+	for rows.Next() {
+		order := &domain.PurchaseOrder{}
+		var notes sql.NullString
+		err := rows.Scan(
+			&order.ID,
+			&order.PONumber,
+			&order.SupplierID,
+			&order.Status,
+			&order.ExpectedDate,
+			&order.OverReceiptTolerance,
+			&notes,
+			&order.CreatedBy,
+			&order.CreatedAt,
+			&order.UpdatedAt,
+			&order.ClosedAt,
+		)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan purchase order: %w", err)
+		}
+		order.Notes = notes.String
+		orders = append(orders, order)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating purchase orders: %w", err)
+	}
+
+	return orders, total, nil
+}
+
+func (r *purchaseOrderRepository) CreateLine(ctx context.Context, line *domain.PurchaseOrderLine) error {
+	query := `
+		INSERT INTO purchase_order_lines (purchase_order_id, line_number, product_id, ordered_quantity, received_quantity, unit_cost)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id`
+
+	err := r.db.QueryRowContext(ctx, query,
+		line.PurchaseOrderID,
+		line.LineNumber,
+		line.ProductID,
+		line.OrderedQuantity,
+		line.ReceivedQuantity,
+		line.UnitCost,
+	).Scan(&line.ID)
+
+	if err != nil {
+		return fmt.Errorf("failed to create purchase order line: %w", err)
+	}
+
+	return nil
+}
+
+// ListLines returns the lines of a purchase order in line number order
+func (r *purchaseOrderRepository) ListLines(ctx context.Context, purchaseOrderID int) ([]*domain.PurchaseOrderLine, error) {
+	query := `
+		SELECT pol.id, pol.purchase_order_id, pol.line_number, pol.product_id, pol.ordered_quantity, pol.received_quantity, pol.unit_cost,
+		       p.id, p.sku, p.name, p.description, p.price, p.weight, p.dimensions, p.category, p.quantity, p.is_serialized, p.cost_method, p.base_unit, p.min_temperature, p.max_temperature, p.length_cm, p.width_cm, p.height_cm, p.is_active, p.created_at, p.updated_at
+		FROM purchase_order_lines pol
+		JOIN products p ON pol.product_id = p.id
+		WHERE pol.purchase_order_id = $1
+		ORDER BY pol.line_number`
+
+	rows, err := r.db.QueryContext(ctx, query, purchaseOrderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list purchase order lines: %w", err)
+	}
+	defer rows.Close()
+
+	var lines []*domain.PurchaseOrderLine
+	for rows.Next() {
+		line := &domain.PurchaseOrderLine{}
+		product := &domain.Product{}
+		err := rows.Scan(
+			&line.ID, &line.PurchaseOrderID, &line.LineNumber, &line.ProductID, &line.OrderedQuantity, &line.ReceivedQuantity, &line.UnitCost,
+			&product.ID, &product.SKU, &product.Name, &product.Description, &product.Price, &product.Weight, &product.Dimensions, &product.Category, &product.Quantity, &product.IsSerialized, &product.CostMethod, &product.BaseUnit, &product.MinTemperature, &product.MaxTemperature, &product.Length, &product.Width, &product.Height, &product.IsActive, &product.CreatedAt, &product.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan purchase order line: %w", err)
+		}
+		line.Product = product
+		lines = append(lines, line)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating purchase order lines: %w", err)
+	}
+
+	return lines, nil
+}
+
+// AddReceivedQuantity adds a receipt to the received quantity of a line
+func (r *purchaseOrderRepository) AddReceivedQuantity(ctx context.Context, lineID int, quantity int) error {
+	query := `UPDATE purchase_order_lines SET received_quantity = received_quantity + $2 WHERE id = $1`
+
+	result, err := r.db.ExecContext(ctx, query, lineID, quantity)
+	if err != nil {
+		return fmt.Errorf("failed to update purchase order line: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,252 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/edwinjordan/wmsTest_Golang/domain"
+)
+
+const reorderRuleColumns = `id, product_id, location_id, reorder_point, safety_stock, reorder_quantity, created_at, updated_at`
+
+type reorderRuleRepository struct {
+	db DBTX
+}
+
+func NewReorderRuleRepository(db DBTX) ReorderRuleRepository {
+	return &reorderRuleRepository{db: db}
+}
+
+func (r *reorderRuleRepository) Create(ctx context.Context, rule *domain.ReorderRule) error {
+	query := `
+		INSERT INTO reorder_rules (product_id, location_id, reorder_point, safety_stock, reorder_quantity, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id`
+
+	now := time.Now()
+	rule.CreatedAt = now
+	rule.UpdatedAt = now
+
+	err := r.db.QueryRowContext(ctx, query,
+		rule.ProductID,
+		rule.LocationID,
+		rule.ReorderPoint,
+		rule.SafetyStock,
+		rule.ReorderQuantity,
+		rule.CreatedAt,
+		rule.UpdatedAt,
+	).Scan(&rule.ID)
+
+	if err != nil {
+		return fmt.Errorf("failed to create reorder rule: %w", err)
+	}
+
+	return nil
+}
+
+// Update saves the reorder point, safety stock and reorder quantity of a rule
+func (r *reorderRuleRepository) Update(ctx context.Context, rule *domain.ReorderRule) error {
+	query := `
+		UPDATE reorder_rules
+		SET reorder_point = $2, safety_stock = $3, reorder_quantity = $4, updated_at = $5
+		WHERE id = $1`
+
+	rule.UpdatedAt = time.Now()
+
+	result, err := r.db.ExecContext(ctx, query,
+		rule.ID,
+		rule.ReorderPoint,
+		rule.SafetyStock,
+		rule.ReorderQuantity,
+		rule.UpdatedAt,
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to update reorder rule: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *reorderRuleRepository) GetByID(ctx context.Context, id int) (*domain.ReorderRule, error) {
+	query := `SELECT ` + reorderRuleColumns + ` FROM reorder_rules WHERE id = $1`
+
+	rule := &domain.ReorderRule{}
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
+		&rule.ID,
+		&rule.ProductID,
+		&rule.LocationID,
+		&rule.ReorderPoint,
+		&rule.SafetyStock,
+		&rule.ReorderQuantity,
+		&rule.CreatedAt,
+		&rule.UpdatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get reorder rule: %w", err)
+	}
+
+	return rule, nil
+}
+
+// Get returns the rule of a product at a location, or the rule covering all locations when locationID is nil
+func (r *reorderRuleRepository) Get(ctx context.Context, productID int, locationID *int) (*domain.ReorderRule, error) {
+	query := `
+		SELECT ` + reorderRuleColumns + `
+		FROM reorder_rules
+		WHERE product_id = $1 AND location_id IS NOT DISTINCT FROM $2`
+
+	rule := &domain.ReorderRule{}
+	err := r.db.QueryRowContext(ctx, query, productID, locationID).Scan(
+		&rule.ID,
+		&rule.ProductID,
+		&rule.LocationID,
+		&rule.ReorderPoint,
+		&rule.SafetyStock,
+		&rule.ReorderQuantity,
+		&rule.CreatedAt,
+		&rule.UpdatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get reorder rule: %w", err)
+	}
+
+	return rule, nil
+}
+
+// ListByProduct returns every rule of a product, the rule covering all locations first
+func (r *reorderRuleRepository) ListByProduct(ctx context.Context, productID int) ([]*domain.ReorderRule, error) {
+	query := `
+		SELECT ` + reorderRuleColumns + `
+		FROM reorder_rules
+		WHERE product_id = $1
+		ORDER BY location_id NULLS FIRST`
+
+	return r.list(ctx, query, productID)
+}
+
+// ListForLocation returns the rules a movement of a product at a location can trigger:
+// the rule at that location and the rule covering all locations
+func (r *reorderRuleRepository) ListForLocation(ctx context.Context, productID, locationID int) ([]*domain.ReorderRule, error) {
+	query := `
+		SELECT ` + reorderRuleColumns + `
+		FROM reorder_rules
+		WHERE product_id = $1 AND (location_id IS NULL OR location_id = $2)
+		ORDER BY location_id NULLS FIRST`
+
+	return r.list(ctx, query, productID, locationID)
+}
+
+func (r *reorderRuleRepository) list(ctx context.Context, query string, args ...interface{}) ([]*domain.ReorderRule, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list reorder rules: %w", err)
+	}
+	defer rows.Close()
+
+	var rules []*domain.ReorderRule
+	for rows.Next() {
+		rule := &domain.ReorderRule{}
+		err := rows.Scan(
+			&rule.ID,
+			&rule.ProductID,
+			&rule.LocationID,
+			&rule.ReorderPoint,
+			&rule.SafetyStock,
+			&rule.ReorderQuantity,
+			&rule.CreatedAt,
+			&rule.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan reorder rule: %w", err)
+		}
+		rules = append(rules, rule)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating reorder rules: %w", err)
+	}
+
+	return rules, nil
+}
+
+func (r *reorderRuleRepository) Delete(ctx context.Context, id int) error {
+	query := `DELETE FROM reorder_rules WHERE id = $1`
+
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete reorder rule: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// ListLowStock returns the rules of active products whose on-hand stock, at the rule's location or
+// across all locations, is at or below the reorder point. Critical items come first.
+func (r *reorderRuleRepository) ListLowStock(ctx context.Context) ([]*domain.LowStockItem, error) {
+	query := `
+		SELECT rr.id, p.id, p.sku, p.name, l.id, l.code, COALESCE(stock.quantity, 0), rr.reorder_point, rr.safety_stock, rr.reorder_quantity
+		FROM reorder_rules rr
+		JOIN products p ON rr.product_id = p.id
+		LEFT JOIN locations l ON rr.location_id = l.id
+		LEFT JOIN LATERAL (
+			SELECT SUM(sl.quantity) AS quantity
+			FROM stock_levels sl
+			WHERE sl.product_id = rr.product_id AND (rr.location_id IS NULL OR sl.location_id = rr.location_id)
+		) stock ON true
+		WHERE p.is_active = true AND COALESCE(stock.quantity, 0) <= rr.reorder_point
+		ORDER BY COALESCE(stock.quantity, 0) <= rr.safety_stock DESC, p.sku, l.code NULLS FIRST`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list low stock: %w", err)
+	}
+	defer rows.Close()
+
+	var items []*domain.LowStockItem
+	for rows.Next() {
+		item := &domain.LowStockItem{}
+		err := rows.Scan(
+			&item.RuleID, &item.ProductID, &item.SKU, &item.ProductName, &item.LocationID, &item.LocationCode,
+			&item.OnHandQuantity, &item.ReorderPoint, &item.SafetyStock, &item.ReorderQuantity,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan low stock item: %w", err)
+		}
+		items = append(items, item)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating low stock: %w", err)
+	}
+
+	return items, nil
+}
@@ -0,0 +1,329 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/edwinjordan/wmsTest_Golang/domain"
+)
+
+// activeReservationCondition matches reservations that still hold stock
+const activeReservationCondition = `status = 'ACTIVE' AND (expires_at IS NULL OR expires_at > NOW())`
+
+type reservationRepository struct {
+	db DBTX
+}
+
+func NewReservationRepository(db DBTX) ReservationRepository {
+	return &reservationRepository{db: db}
+}
+
+func (r *reservationRepository) Create(ctx context.Context, reservation *domain.Reservation) error {
+	query := `
+		INSERT INTO stock_reservations (product_id, location_id, quantity, consumed_quantity, status, reference, notes, expires_at, created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING id`
+
+	now := time.Now()
+	reservation.CreatedAt = now
+	reservation.UpdatedAt = now
+
+	err := r.db.QueryRowContext(ctx, query,
+		reservation.ProductID,
+		reservation.LocationID,
+		reservation.Quantity,
+		reservation.ConsumedQuantity,
+		reservation.Status,
+		reservation.Reference,
+		reservation.Notes,
+		reservation.ExpiresAt,
+		reservation.CreatedBy,
+		reservation.CreatedAt,
+		reservation.UpdatedAt,
+	).Scan(&reservation.ID)
+
+	if err != nil {
+		return fmt.Errorf("failed to create reservation: %w", err)
+	}
+
+	return nil
+}
+
+func (r *reservationRepository) GetByID(ctx context.Context, id int) (*domain.Reservation, error) {
+	query := `
+		SELECT id, product_id, location_id, quantity, consumed_quantity, status, reference, notes, expires_at, created_by, created_at, updated_at, closed_at
+		FROM stock_reservations
+		WHERE id = $1`
+
+	return r.getOne(ctx, query, id)
+}
+
+// GetByIDForUpdate loads a reservation and locks it until the surrounding transaction ends
+func (r *reservationRepository) GetByIDForUpdate(ctx context.Context, id int) (*domain.Reservation, error) {
+	query := `
+		SELECT id, product_id, location_id, quantity, consumed_quantity, status, reference, notes, expires_at, created_by, created_at, updated_at, closed_at
+		FROM stock_reservations
+		WHERE id = $1
+		FOR UPDATE`
+
+	return r.getOne(ctx, query, id)
+}
+
+func (r *reservationRepository) getOne(ctx context.Context, query string, id int) (*domain.Reservation, error) {
+	reservation := &domain.Reservation{}
+	var reference, notes sql.NullString
+
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
+		&reservation.ID,
+		&reservation.ProductID,
+		&reservation.LocationID,
+		&reservation.Quantity,
+		&reservation.ConsumedQuantity,
+		&reservation.Status,
+		&reference,
+		&notes,
+		&reservation.ExpiresAt,
+		&reservation.CreatedBy,
+		&reservation.CreatedAt,
+		&reservation.UpdatedAt,
+		&reservation.ClosedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get reservation by ID: %w", err)
+	}
+
+	reservation.Reference = reference.String
+	reservation.Notes = notes.String
+
+	return reservation, nil
+}
+
+func (r *reservationRepository) List(ctx context.Context, filter *domain.ReservationFilter) ([]*domain.Reservation, int, error) {
+	// Build WHERE conditions
+	var conditions []string
+	var args []interface{}
+	argIndex := 1
+
+	if filter.ProductID != nil {
+		conditions = append(conditions, fmt.Sprintf("product_id = $%d", argIndex))
+		args = append(args, *filter.ProductID)
+		argIndex++
+	}
+
+	if filter.LocationID != nil {
+		conditions = append(conditions, fmt.Sprintf("location_id = $%d", argIndex))
+		args = append(args, *filter.LocationID)
+		argIndex++
+	}
+
+	if filter.Status != nil {
+		conditions = append(conditions, fmt.Sprintf("status = $%d", argIndex))
+		args = append(args, *filter.Status)
+		argIndex++
+	}
+
+	whereClause := ""
+	if len(conditions) > 0 {
+		whereClause = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	// Count total records
+	countQuery := fmt.Sprintf(`SELECT COUNT(*) FROM stock_reservations %s`, whereClause)
+
+	var total int
+	err := r.db.QueryRowContext(ctx, countQuery, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count reservations: %w", err)
+	}
+
+	// Get paginated records
+	query := fmt.Sprintf(`
+		SELECT id, product_id, location_id, quantity, consumed_quantity, status, reference, notes, expires_at, created_by, created_at, updated_at, closed_at
+		FROM stock_reservations
+		%s
+		ORDER BY created_at DESC
+		LIMIT $%d OFFSET $%d`, whereClause, argIndex, argIndex+1)
+	args = append(args, filter.Limit, filter.Offset)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list reservations: %w", err)
+	}
+	defer rows.Close()
+
+	var reservations []*domain.Reservation
+	for rows.Next() {
+		reservation := &domain.Reservation{}
+		var reference, notes sql.NullString
+		err := rows.Scan(
+			&reservation.ID,
+			&reservation.ProductID,
+			&reservation.LocationID,
+			&reservation.Quantity,
+			&reservation.ConsumedQuantity,
+			&reservation.Status,
+			&reference,
+			&notes,
+			&reservation.ExpiresAt,
+			&reservation.CreatedBy,
+			&reservation.CreatedAt,
+			&reservation.UpdatedAt,
+			&reservation.ClosedAt,
+		)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan reservation: %w", err)
+		}
+		reservation.Reference = reference.String
+		reservation.Notes = notes.String
+		reservations = append(reservations, reservation)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating reservations: %w", err)
+	}
+
+	return reservations, total, nil
+}
+
+// Update saves the consumed quantity and status of a reservation
+func (r *reservationRepository) Update(ctx context.Context, reservation *domain.Reservation) error {
+	query := `
+		UPDATE stock_reservations
+		SET consumed_quantity = $2, status = $3, updated_at = $4, closed_at = $5
+		WHERE id = $1`
+
+	reservation.UpdatedAt = time.Now()
+
+	result, err := r.db.ExecContext(ctx, query,
+		reservation.ID,
+		reservation.ConsumedQuantity,
+		reservation.Status,
+		reservation.UpdatedAt,
+		reservation.ClosedAt,
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to update reservation: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// ExpireDue marks active reservations that are past their expiry as EXPIRED
+func (r *reservationRepository) ExpireDue(ctx context.Context) error {
+	query := `
+		UPDATE stock_reservations
+		SET status = 'EXPIRED', updated_at = NOW(), closed_at = expires_at
+		WHERE status = 'ACTIVE' AND expires_at IS NOT NULL AND expires_at <= NOW()`
+
+	if _, err := r.db.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("failed to expire reservations: %w", err)
+	}
+
+	return nil
+}
+
+// GetReservedQuantity returns the open reserved quantity of a product at a location.
+// The reservation given by excludeID, if any, is left out.
+func (r *reservationRepository) GetReservedQuantity(ctx context.Context, productID, locationID int, excludeID *int) (int, error) {
+	query := `
+		SELECT COALESCE(SUM(quantity - consumed_quantity), 0)
+		FROM stock_reservations
+		WHERE product_id = $1 AND location_id = $2 AND ` + activeReservationCondition + `
+		AND ($3::int IS NULL OR id <> $3)`
+
+	var reserved int
+	err := r.db.QueryRowContext(ctx, query, productID, locationID, excludeID).Scan(&reserved)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get reserved quantity: %w", err)
+	}
+
+	return reserved, nil
+}
+
+// GetReservedByProduct returns the open reserved quantity of a product keyed by location ID,
+// only at the locations of one warehouse when warehouseID is not nil
+func (r *reservationRepository) GetReservedByProduct(ctx context.Context, productID int, warehouseID *int) (map[int]int, error) {
+	query := `
+		SELECT location_id, SUM(quantity - consumed_quantity)
+		FROM stock_reservations
+		WHERE product_id = $1 AND ` + activeReservationCondition + `
+		AND ($2::INTEGER IS NULL OR location_id IN (SELECT id FROM locations WHERE warehouse_id = $2))
+		GROUP BY location_id`
+
+	return r.sumReserved(ctx, query, productID, warehouseID)
+}
+
+// GetWarehouseReservedByProduct returns the open reserved quantity of a product keyed by warehouse ID
+func (r *reservationRepository) GetWarehouseReservedByProduct(ctx context.Context, productID int) (map[int]int, error) {
+	query := `
+		SELECT l.warehouse_id, SUM(sr.quantity - sr.consumed_quantity)
+		FROM stock_reservations sr
+		JOIN locations l ON sr.location_id = l.id
+		WHERE sr.product_id = $1 AND ` + activeReservationCondition + `
+		GROUP BY l.warehouse_id`
+
+	return r.sumReserved(ctx, query, productID)
+}
+
+// GetReservedByLocation returns the open reserved quantity at a location keyed by product ID
+func (r *reservationRepository) GetReservedByLocation(ctx context.Context, locationID int) (map[int]int, error) {
+	query := `
+		SELECT product_id, SUM(quantity - consumed_quantity)
+		FROM stock_reservations
+		WHERE location_id = $1 AND ` + activeReservationCondition + `
+		GROUP BY product_id`
+
+	return r.sumReserved(ctx, query, locationID)
+}
+
+// GetReservedByWarehouse returns the open reserved quantity across the locations of a warehouse
+// keyed by product ID
+func (r *reservationRepository) GetReservedByWarehouse(ctx context.Context, warehouseID int) (map[int]int, error) {
+	query := `
+		SELECT product_id, SUM(quantity - consumed_quantity)
+		FROM stock_reservations
+		WHERE location_id IN (SELECT id FROM locations WHERE warehouse_id = $1) AND ` + activeReservationCondition + `
+		GROUP BY product_id`
+
+	return r.sumReserved(ctx, query, warehouseID)
+}
+
+func (r *reservationRepository) sumReserved(ctx context.Context, query string, args ...interface{}) (map[int]int, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reserved quantities: %w", err)
+	}
+	defer rows.Close()
+
+	reserved := make(map[int]int)
+	for rows.Next() {
+		var key, quantity int
+		if err := rows.Scan(&key, &quantity); err != nil {
+			return nil, fmt.Errorf("failed to scan reserved quantity: %w", err)
+		}
+		reserved[key] = quantity
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating reserved quantities: %w", err)
+	}
+
+	return reserved, nil
+}
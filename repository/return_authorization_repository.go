@@ -0,0 +1,315 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/edwinjordan/wmsTest_Golang/domain"
+)
+
+const returnAuthorizationColumns = `id, rma_number, sales_order_id, reference, customer_name, status, notes, created_by, created_at, updated_at, closed_at`
+
+type returnAuthorizationRepository struct {
+	db DBTX
+}
+
+func NewReturnAuthorizationRepository(db DBTX) ReturnAuthorizationRepository {
+	return &returnAuthorizationRepository{db: db}
+}
+
+func (r *returnAuthorizationRepository) Create(ctx context.Context, rma *domain.ReturnAuthorization) error {
+	query := `
+		INSERT INTO return_authorizations (rma_number, sales_order_id, reference, customer_name, status, notes, created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id`
+
+	now := time.Now()
+	rma.CreatedAt = now
+	rma.UpdatedAt = now
+
+	err := r.db.QueryRowContext(ctx, query,
+		rma.RMANumber,
+		rma.SalesOrderID,
+		rma.Reference,
+		rma.CustomerName,
+		rma.Status,
+		rma.Notes,
+		rma.CreatedBy,
+		rma.CreatedAt,
+		rma.UpdatedAt,
+	).Scan(&rma.ID)
+
+	if err != nil {
+		return fmt.Errorf("failed to create return authorization: %w", err)
+	}
+
+	return nil
+}
+
+func (r *returnAuthorizationRepository) GetByID(ctx context.Context, id int) (*domain.ReturnAuthorization, error) {
+	query := `SELECT ` + returnAuthorizationColumns + ` FROM return_authorizations WHERE id = $1`
+
+	return r.getOne(ctx, query, id)
+}
+
+// GetByIDForUpdate loads a return authorization and locks it until the surrounding transaction ends
+func (r *returnAuthorizationRepository) GetByIDForUpdate(ctx context.Context, id int) (*domain.ReturnAuthorization, error) {
+	query := `SELECT ` + returnAuthorizationColumns + ` FROM return_authorizations WHERE id = $1 FOR UPDATE`
+
+	return r.getOne(ctx, query, id)
+}
+
+func (r *returnAuthorizationRepository) GetByNumber(ctx context.Context, rmaNumber string) (*domain.ReturnAuthorization, error) {
+	query := `SELECT ` + returnAuthorizationColumns + ` FROM return_authorizations WHERE rma_number = $1`
+
+	return r.getOne(ctx, query, rmaNumber)
+}
+
+func (r *returnAuthorizationRepository) getOne(ctx context.Context, query string, arg interface{}) (*domain.ReturnAuthorization, error) {
+	rma := &domain.ReturnAuthorization{}
+	var reference, notes sql.NullString
+
+	err := r.db.QueryRowContext(ctx, query, arg).Scan(
+		&rma.ID,
+		&rma.RMANumber,
+		&rma.SalesOrderID,
+		&reference,
+		&rma.CustomerName,
+		&rma.Status,
+		&notes,
+		&rma.CreatedBy,
+		&rma.CreatedAt,
+		&rma.UpdatedAt,
+		&rma.ClosedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get return authorization: %w", err)
+	}
+
+	rma.Reference = reference.String
+	rma.Notes = notes.String
+
+	return rma, nil
+}
+
+// UpdateStatus saves the status and closing time of a return authorization
+func (r *returnAuthorizationRepository) UpdateStatus(ctx context.Context, rma *domain.ReturnAuthorization) error {
+	query := `UPDATE return_authorizations SET status = $2, closed_at = $3, updated_at = $4 WHERE id = $1`
+
+	rma.UpdatedAt = time.Now()
+
+	result, err := r.db.ExecContext(ctx, query, rma.ID, rma.Status, rma.ClosedAt, rma.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to update return authorization: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *returnAuthorizationRepository) List(ctx context.Context, filter *domain.ReturnAuthorizationFilter) ([]*domain.ReturnAuthorization, int, error) {
+	// Build WHERE conditions
+	var conditions []string
+	var args []interface{}
+	argIndex := 1
+
+	if filter.SalesOrderID != nil {
+		conditions = append(conditions, fmt.Sprintf("sales_order_id = $%d", argIndex))
+		args = append(args, *filter.SalesOrderID)
+		argIndex++
+	}
+
+	if filter.Status != nil {
+		conditions = append(conditions, fmt.Sprintf("status = $%d", argIndex))
+		args = append(args, *filter.Status)
+		argIndex++
+	}
+
+	whereClause := ""
+	if len(conditions) > 0 {
+		whereClause = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	// Count total records
+	countQuery := fmt.Sprintf(`SELECT COUNT(*) FROM return_authorizations %s`, whereClause)
+
+	var total int
+	err := r.db.QueryRowContext(ctx, countQuery, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count return authorizations: %w", err)
+	}
+
+	// Get paginated records
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM return_authorizations
+		%s
+		ORDER BY created_at DESC
+		LIMIT $%d OFFSET $%d`, returnAuthorizationColumns, whereClause, argIndex, argIndex+1)
+	args = append(args, filter.Limit, filter.Offset)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list return authorizations: %w", err)
+	}
+	defer rows.Close()
+
+	var rmas []*domain.ReturnAuthorization
+	for rows.Next() {
+		rma := &domain.ReturnAuthorization{}
+		var reference, notes sql.NullString
+		err := rows.Scan(
+			&rma.ID,
+			&rma.RMANumber,
+			&rma.SalesOrderID,
+			&reference,
+			&rma.CustomerName,
+			&rma.Status,
+			&notes,
+			&rma.CreatedBy,
+			&rma.CreatedAt,
+			&rma.UpdatedAt,
+			&rma.ClosedAt,
+		)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan return authorization: %w", err)
+		}
+		rma.Reference = reference.String
+		rma.Notes = notes.String
+		rmas = append(rmas, rma)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating return authorizations: %w", err)
+	}
+
+	return rmas, total, nil
+}
+
+func (r *returnAuthorizationRepository) CreateLine(ctx context.Context, line *domain.ReturnAuthorizationLine) error {
+	query := `
+		INSERT INTO return_authorization_lines (return_authorization_id, line_number, product_id, sales_order_line_id, return_reason, authorized_quantity)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id`
+
+	err := r.db.QueryRowContext(ctx, query,
+		line.ReturnAuthorizationID,
+		line.LineNumber,
+		line.ProductID,
+		line.SalesOrderLineID,
+		line.ReturnReason,
+		line.AuthorizedQuantity,
+	).Scan(&line.ID)
+
+	if err != nil {
+		return fmt.Errorf("failed to create return authorization line: %w", err)
+	}
+
+	return nil
+}
+
+// ListLines returns the lines of a return authorization in line number order
+func (r *returnAuthorizationRepository) ListLines(ctx context.Context, returnAuthorizationID int) ([]*domain.ReturnAuthorizationLine, error) {
+	query := `
+		SELECT ral.id, ral.return_authorization_id, ral.line_number, ral.product_id, ral.sales_order_line_id, ral.return_reason, ral.authorized_quantity, ral.restocked_quantity, ral.quarantined_quantity, ral.scrapped_quantity,
+		       p.id, p.sku, p.name, p.description, p.price, p.weight, p.dimensions, p.category, p.quantity, p.is_serialized, p.cost_method, p.base_unit, p.min_temperature, p.max_temperature, p.length_cm, p.width_cm, p.height_cm, p.is_active, p.created_at, p.updated_at
+		FROM return_authorization_lines ral
+		JOIN products p ON ral.product_id = p.id
+		WHERE ral.return_authorization_id = $1
+		ORDER BY ral.line_number`
+
+	rows, err := r.db.QueryContext(ctx, query, returnAuthorizationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list return authorization lines: %w", err)
+	}
+	defer rows.Close()
+
+	var lines []*domain.ReturnAuthorizationLine
+	for rows.Next() {
+		line := &domain.ReturnAuthorizationLine{}
+		product := &domain.Product{}
+		err := rows.Scan(
+			&line.ID, &line.ReturnAuthorizationID, &line.LineNumber, &line.ProductID, &line.SalesOrderLineID, &line.ReturnReason, &line.AuthorizedQuantity, &line.RestockedQuantity, &line.QuarantinedQuantity, &line.ScrappedQuantity,
+			&product.ID, &product.SKU, &product.Name, &product.Description, &product.Price, &product.Weight, &product.Dimensions, &product.Category, &product.Quantity, &product.IsSerialized, &product.CostMethod, &product.BaseUnit, &product.MinTemperature, &product.MaxTemperature, &product.Length, &product.Width, &product.Height, &product.IsActive, &product.CreatedAt, &product.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan return authorization line: %w", err)
+		}
+		line.Product = product
+		lines = append(lines, line)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating return authorization lines: %w", err)
+	}
+
+	return lines, nil
+}
+
+// AddReceivedQuantity adds returned goods to the quantity received under the given grade
+func (r *returnAuthorizationRepository) AddReceivedQuantity(ctx context.Context, lineID int, grade domain.ReturnGrade, quantity int) error {
+	var column string
+	switch grade {
+	case domain.ReturnRestock:
+		column = "restocked_quantity"
+	case domain.ReturnQuarantine:
+		column = "quarantined_quantity"
+	case domain.ReturnScrap:
+		column = "scrapped_quantity"
+	default:
+		return fmt.Errorf("%w: unknown return grade %s", domain.ErrInvalidInput, grade)
+	}
+
+	query := fmt.Sprintf(`UPDATE return_authorization_lines SET %[1]s = %[1]s + $2 WHERE id = $1`, column)
+
+	result, err := r.db.ExecContext(ctx, query, lineID, quantity)
+	if err != nil {
+		return fmt.Errorf("failed to update return authorization line: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// GetReturnedQuantity returns how much of a sales order line is already taken by return
+// authorizations: the authorized quantity of those still open and what was actually received
+// on those that were closed. Cancelled authorizations do not count.
+func (r *returnAuthorizationRepository) GetReturnedQuantity(ctx context.Context, salesOrderLineID int) (int, error) {
+	query := `
+		SELECT COALESCE(SUM(CASE WHEN ra.status = 'CLOSED'
+		                         THEN ral.restocked_quantity + ral.quarantined_quantity + ral.scrapped_quantity
+		                         ELSE ral.authorized_quantity END), 0)
+		FROM return_authorization_lines ral
+		JOIN return_authorizations ra ON ral.return_authorization_id = ra.id
+		WHERE ral.sales_order_line_id = $1 AND ra.status <> 'CANCELLED'`
+
+	var quantity int
+	if err := r.db.QueryRowContext(ctx, query, salesOrderLineID).Scan(&quantity); err != nil {
+		return 0, fmt.Errorf("failed to get returned quantity: %w", err)
+	}
+
+	return quantity, nil
+}
@@ -0,0 +1,452 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/edwinjordan/wmsTest_Golang/domain"
+)
+
+const salesOrderColumns = `id, so_number, warehouse_id, customer_name, ship_to, status, priority, carrier, carrier_cutoff, tracking_number, package_count, notes, wave_id, created_by, created_at, updated_at, allocated_at, picked_at, packed_at, shipped_at, cancelled_at`
+
+type salesOrderRepository struct {
+	db DBTX
+}
+
+func NewSalesOrderRepository(db DBTX) SalesOrderRepository {
+	return &salesOrderRepository{db: db}
+}
+
+func (r *salesOrderRepository) Create(ctx context.Context, order *domain.SalesOrder) error {
+	query := `
+		INSERT INTO sales_orders (so_number, warehouse_id, customer_name, ship_to, status, priority, carrier, carrier_cutoff, notes, created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		RETURNING id`
+
+	now := time.Now()
+	order.CreatedAt = now
+	order.UpdatedAt = now
+
+	err := r.db.QueryRowContext(ctx, query,
+		order.SONumber,
+		order.WarehouseID,
+		order.CustomerName,
+		order.ShipTo,
+		order.Status,
+		order.Priority,
+		order.Carrier,
+		order.CarrierCutoff,
+		order.Notes,
+		order.CreatedBy,
+		order.CreatedAt,
+		order.UpdatedAt,
+	).Scan(&order.ID)
+
+	if err != nil {
+		return fmt.Errorf("failed to create sales order: %w", err)
+	}
+
+	return nil
+}
+
+func (r *salesOrderRepository) GetByID(ctx context.Context, id int) (*domain.SalesOrder, error) {
+	query := `SELECT ` + salesOrderColumns + ` FROM sales_orders WHERE id = $1`
+
+	return r.getOne(ctx, query, id)
+}
+
+// GetByIDForUpdate loads a sales order and locks it until the surrounding transaction ends
+func (r *salesOrderRepository) GetByIDForUpdate(ctx context.Context, id int) (*domain.SalesOrder, error) {
+	query := `SELECT ` + salesOrderColumns + ` FROM sales_orders WHERE id = $1 FOR UPDATE`
+
+	return r.getOne(ctx, query, id)
+}
+
+func (r *salesOrderRepository) GetByNumber(ctx context.Context, soNumber string) (*domain.SalesOrder, error) {
+	query := `SELECT ` + salesOrderColumns + ` FROM sales_orders WHERE so_number = $1`
+
+	return r.getOne(ctx, query, soNumber)
+}
+
+func (r *salesOrderRepository) getOne(ctx context.Context, query string, arg interface{}) (*domain.SalesOrder, error) {
+	order := &domain.SalesOrder{}
+	var shipTo, carrier, trackingNumber, notes sql.NullString
+
+	err := r.db.QueryRowContext(ctx, query, arg).Scan(
+		&order.ID,
+		&order.SONumber,
+		&order.WarehouseID,
+		&order.CustomerName,
+		&shipTo,
+		&order.Status,
+		&order.Priority,
+		&carrier,
+		&order.CarrierCutoff,
+		&trackingNumber,
+		&order.PackageCount,
+		&notes,
+		&order.WaveID,
+		&order.CreatedBy,
+		&order.CreatedAt,
+		&order.UpdatedAt,
+		&order.AllocatedAt,
+		&order.PickedAt,
+		&order.PackedAt,
+		&order.ShippedAt,
+		&order.CancelledAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get sales order: %w", err)
+	}
+
+	order.ShipTo = shipTo.String
+	order.Carrier = carrier.String
+	order.TrackingNumber = trackingNumber.String
+	order.Notes = notes.String
+
+	return order, nil
+}
+
+// Update saves the status, wave, shipping details and transition times of a sales order
+func (r *salesOrderRepository) Update(ctx context.Context, order *domain.SalesOrder) error {
+	query := `
+		UPDATE sales_orders
+		SET status = $2, carrier = $3, tracking_number = $4, package_count = $5, wave_id = $6, allocated_at = $7,
+		    picked_at = $8, packed_at = $9, shipped_at = $10, cancelled_at = $11, updated_at = $12
+		WHERE id = $1`
+
+	order.UpdatedAt = time.Now()
+
+	result, err := r.db.ExecContext(ctx, query,
+		order.ID,
+		order.Status,
+		order.Carrier,
+		order.TrackingNumber,
+		order.PackageCount,
+		order.WaveID,
+		order.AllocatedAt,
+		order.PickedAt,
+		order.PackedAt,
+		order.ShippedAt,
+		order.CancelledAt,
+		order.UpdatedAt,
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to update sales order: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *salesOrderRepository) List(ctx context.Context, filter *domain.SalesOrderFilter) ([]*domain.SalesOrder, int, error) {
+	// Build WHERE conditions
+	var conditions []string
+	var args []interface{}
+	argIndex := 1
+
+	if filter.Status != nil {
+		conditions = append(conditions, fmt.Sprintf("status = $%d", argIndex))
+		args = append(args, *filter.Status)
+		argIndex++
+	}
+
+	if filter.WaveID != nil {
+		conditions = append(conditions, fmt.Sprintf("wave_id = $%d", argIndex))
+		args = append(args, *filter.WaveID)
+		argIndex++
+	}
+
+	if filter.WarehouseID != nil {
+		conditions = append(conditions, fmt.Sprintf("warehouse_id = $%d", argIndex))
+		args = append(args, *filter.WarehouseID)
+		argIndex++
+	}
+
+	whereClause := ""
+	if len(conditions) > 0 {
+		whereClause = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	// Count total records
+	countQuery := fmt.Sprintf(`SELECT COUNT(*) FROM sales_orders %s`, whereClause)
+
+	var total int
+	err := r.db.QueryRowContext(ctx, countQuery, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count sales orders: %w", err)
+	}
+
+	// Get paginated records
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM sales_orders
+		%s
+		ORDER BY created_at DESC
+		LIMIT $%d OFFSET $%d`, salesOrderColumns, whereClause, argIndex, argIndex+1)
+	args = append(args, filter.Limit, filter.Offset)
+
+	orders, err := r.list(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return orders, total, nil
+}
+
+// ListByWave returns the orders of a wave, oldest first
+func (r *salesOrderRepository) ListByWave(ctx context.Context, waveID int) ([]*domain.SalesOrder, error) {
+	query := `SELECT ` + salesOrderColumns + ` FROM sales_orders WHERE wave_id = $1 ORDER BY created_at, id`
+
+	return r.list(ctx, query, waveID)
+}
+
+// ListWaveCandidatesForUpdate returns the new orders that are not in a wave and match the request,
+// most urgent first, and locks them until the surrounding transaction ends
+func (r *salesOrderRepository) ListWaveCandidatesForUpdate(ctx context.Context, req *domain.BuildWavesRequest) ([]*domain.SalesOrder, error) {
+	conditions := []string{"status = 'NEW'", "wave_id IS NULL"}
+	var args []interface{}
+	argIndex := 1
+
+	if req.WarehouseID != nil {
+		conditions = append(conditions, fmt.Sprintf("warehouse_id = $%d", argIndex))
+		args = append(args, *req.WarehouseID)
+		argIndex++
+	}
+
+	if req.Carrier != "" {
+		conditions = append(conditions, fmt.Sprintf("carrier = $%d", argIndex))
+		args = append(args, req.Carrier)
+		argIndex++
+	}
+
+	if req.CutoffBefore != nil {
+		conditions = append(conditions, fmt.Sprintf("carrier_cutoff <= $%d", argIndex))
+		args = append(args, *req.CutoffBefore)
+		argIndex++
+	}
+
+	if req.MinPriority != nil {
+		conditions = append(conditions, fmt.Sprintf("priority >= $%d", argIndex))
+		args = append(args, *req.MinPriority)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM sales_orders
+		WHERE %s
+		ORDER BY priority DESC, carrier_cutoff NULLS LAST, created_at, id
+		FOR UPDATE`, salesOrderColumns, strings.Join(conditions, " AND "))
+
+	return r.list(ctx, query, args...)
+}
+
+func (r *salesOrderRepository) list(ctx context.Context, query string, args ...interface{}) ([]*domain.SalesOrder, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sales orders: %w", err)
+	}
+	defer rows.Close()
+
+	var orders []*domain.SalesOrder
+	for rows.Next() {
+		order := &domain.SalesOrder{}
+		var shipTo, carrier, trackingNumber, notes sql.NullString
+		err := rows.Scan(
+			&order.ID,
+			&order.SONumber,
+			&order.WarehouseID,
+			&order.CustomerName,
+			&shipTo,
+			&order.Status,
+			&order.Priority,
+			&carrier,
+			&order.CarrierCutoff,
+			&trackingNumber,
+			&order.PackageCount,
+			&notes,
+			&order.WaveID,
+			&order.CreatedBy,
+			&order.CreatedAt,
+			&order.UpdatedAt,
+			&order.AllocatedAt,
+			&order.PickedAt,
+			&order.PackedAt,
+			&order.ShippedAt,
+			&order.CancelledAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan sales order: %w", err)
+		}
+		order.ShipTo = shipTo.String
+		order.Carrier = carrier.String
+		order.TrackingNumber = trackingNumber.String
+		order.Notes = notes.String
+		orders = append(orders, order)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating sales orders: %w", err)
+	}
+
+	return orders, nil
+}
+
+func (r *salesOrderRepository) CreateLine(ctx context.Context, line *domain.SalesOrderLine) error {
+	query := `
+		INSERT INTO sales_order_lines (sales_order_id, line_number, product_id, ordered_quantity, shipped_quantity)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id`
+
+	err := r.db.QueryRowContext(ctx, query,
+		line.SalesOrderID,
+		line.LineNumber,
+		line.ProductID,
+		line.OrderedQuantity,
+		line.ShippedQuantity,
+	).Scan(&line.ID)
+
+	if err != nil {
+		return fmt.Errorf("failed to create sales order line: %w", err)
+	}
+
+	return nil
+}
+
+// ListLines returns the lines of a sales order in line number order
+func (r *salesOrderRepository) ListLines(ctx context.Context, salesOrderID int) ([]*domain.SalesOrderLine, error) {
+	query := `
+		SELECT sol.id, sol.sales_order_id, sol.line_number, sol.product_id, sol.ordered_quantity, sol.shipped_quantity,
+		       p.id, p.sku, p.name, p.description, p.price, p.weight, p.dimensions, p.category, p.quantity, p.is_serialized, p.cost_method, p.base_unit, p.min_temperature, p.max_temperature, p.length_cm, p.width_cm, p.height_cm, p.is_active, p.created_at, p.updated_at
+		FROM sales_order_lines sol
+		JOIN products p ON sol.product_id = p.id
+		WHERE sol.sales_order_id = $1
+		ORDER BY sol.line_number`
+
+	rows, err := r.db.QueryContext(ctx, query, salesOrderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sales order lines: %w", err)
+	}
+	defer rows.Close()
+
+	var lines []*domain.SalesOrderLine
+	for rows.Next() {
+		line := &domain.SalesOrderLine{}
+		product := &domain.Product{}
+		err := rows.Scan(
+			&line.ID, &line.SalesOrderID, &line.LineNumber, &line.ProductID, &line.OrderedQuantity, &line.ShippedQuantity,
+			&product.ID, &product.SKU, &product.Name, &product.Description, &product.Price, &product.Weight, &product.Dimensions, &product.Category, &product.Quantity, &product.IsSerialized, &product.CostMethod, &product.BaseUnit, &product.MinTemperature, &product.MaxTemperature, &product.Length, &product.Width, &product.Height, &product.IsActive, &product.CreatedAt, &product.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan sales order line: %w", err)
+		}
+		line.Product = product
+		lines = append(lines, line)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating sales order lines: %w", err)
+	}
+
+	return lines, nil
+}
+
+// AddShippedQuantity adds a shipment to the shipped quantity of a line
+func (r *salesOrderRepository) AddShippedQuantity(ctx context.Context, lineID int, quantity int) error {
+	query := `UPDATE sales_order_lines SET shipped_quantity = shipped_quantity + $2 WHERE id = $1`
+
+	result, err := r.db.ExecContext(ctx, query, lineID, quantity)
+	if err != nil {
+		return fmt.Errorf("failed to update sales order line: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *salesOrderRepository) CreateAllocation(ctx context.Context, allocation *domain.SalesOrderAllocation) error {
+	query := `
+		INSERT INTO sales_order_allocations (sales_order_id, sales_order_line_id, reservation_id, location_id, lot_number, quantity, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id`
+
+	allocation.CreatedAt = time.Now()
+
+	err := r.db.QueryRowContext(ctx, query,
+		allocation.SalesOrderID,
+		allocation.SalesOrderLineID,
+		allocation.ReservationID,
+		allocation.LocationID,
+		allocation.LotNumber,
+		allocation.Quantity,
+		allocation.CreatedAt,
+	).Scan(&allocation.ID)
+
+	if err != nil {
+		return fmt.Errorf("failed to create sales order allocation: %w", err)
+	}
+
+	return nil
+}
+
+// ListAllocations returns the allocations of a sales order with their locations
+func (r *salesOrderRepository) ListAllocations(ctx context.Context, salesOrderID int) ([]*domain.SalesOrderAllocation, error) {
+	query := `
+		SELECT soa.id, soa.sales_order_id, soa.sales_order_line_id, soa.reservation_id, soa.location_id, soa.lot_number, soa.quantity, soa.created_at,
+		       l.id, l.warehouse_id, l.code, l.name, l.zone, l.aisle, l.rack, l.shelf, l.capacity, l.temperature, l.max_weight, l.max_volume, l.is_active, l.created_at, l.updated_at
+		FROM sales_order_allocations soa
+		JOIN locations l ON soa.location_id = l.id
+		WHERE soa.sales_order_id = $1
+		ORDER BY soa.id`
+
+	rows, err := r.db.QueryContext(ctx, query, salesOrderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sales order allocations: %w", err)
+	}
+	defer rows.Close()
+
+	var allocations []*domain.SalesOrderAllocation
+	for rows.Next() {
+		allocation := &domain.SalesOrderAllocation{}
+		location := &domain.Location{}
+		err := rows.Scan(
+			&allocation.ID, &allocation.SalesOrderID, &allocation.SalesOrderLineID, &allocation.ReservationID, &allocation.LocationID, &allocation.LotNumber, &allocation.Quantity, &allocation.CreatedAt,
+			&location.ID, &location.WarehouseID, &location.Code, &location.Name, &location.Zone, &location.Aisle, &location.Rack, &location.Shelf, &location.Capacity, &location.Temperature, &location.MaxWeight, &location.MaxVolume, &location.IsActive, &location.CreatedAt, &location.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan sales order allocation: %w", err)
+		}
+		allocation.Location = location
+		allocations = append(allocations, allocation)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating sales order allocations: %w", err)
+	}
+
+	return allocations, nil
+}
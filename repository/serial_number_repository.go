@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/edwinjordan/wmsTest_Golang/domain"
+)
+
+type serialNumberRepository struct {
+	db DBTX
+}
+
+func NewSerialNumberRepository(db DBTX) SerialNumberRepository {
+	return &serialNumberRepository{db: db}
+}
+
+func (r *serialNumberRepository) Create(ctx context.Context, serial *domain.SerialNumber) error {
+	query := `
+		INSERT INTO serial_numbers (product_id, serial_number, location_id, lot_number, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id`
+
+	if serial.Status == "" {
+		serial.Status = domain.InventoryAvailable
+	}
+	now := time.Now()
+	serial.CreatedAt = now
+	serial.UpdatedAt = now
+
+	err := r.db.QueryRowContext(ctx, query,
+		serial.ProductID,
+		serial.SerialNumber,
+		serial.LocationID,
+		serial.LotNumber,
+		serial.Status,
+		serial.CreatedAt,
+		serial.UpdatedAt,
+	).Scan(&serial.ID)
+
+	if err != nil {
+		return fmt.Errorf("failed to create serial number: %w", err)
+	}
+
+	return nil
+}
+
+func (r *serialNumberRepository) GetByNumber(ctx context.Context, productID int, serialNumber string) (*domain.SerialNumber, error) {
+	query := `
+		SELECT id, product_id, serial_number, location_id, lot_number, status, created_at, updated_at
+		FROM serial_numbers
+		WHERE product_id = $1 AND serial_number = $2`
+
+	serial := &domain.SerialNumber{}
+	err := r.db.QueryRowContext(ctx, query, productID, serialNumber).Scan(
+		&serial.ID,
+		&serial.ProductID,
+		&serial.SerialNumber,
+		&serial.LocationID,
+		&serial.LotNumber,
+		&serial.Status,
+		&serial.CreatedAt,
+		&serial.UpdatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get serial number: %w", err)
+	}
+
+	return serial, nil
+}
+
+// ListByNumber returns the serial number records of every product using the given serial
+func (r *serialNumberRepository) ListByNumber(ctx context.Context, serialNumber string) ([]*domain.SerialNumber, error) {
+	query := `
+		SELECT sn.id, sn.product_id, sn.serial_number, sn.location_id, sn.lot_number, sn.status, sn.created_at, sn.updated_at,
+		       p.id, p.sku, p.name, p.description, p.price, p.weight, p.dimensions, p.category, p.quantity, p.is_serialized, p.cost_method, p.base_unit, p.min_temperature, p.max_temperature, p.length_cm, p.width_cm, p.height_cm, p.is_active, p.created_at, p.updated_at
+		FROM serial_numbers sn
+		JOIN products p ON sn.product_id = p.id
+		WHERE sn.serial_number = $1
+		ORDER BY p.sku`
+
+	rows, err := r.db.QueryContext(ctx, query, serialNumber)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list serial numbers: %w", err)
+	}
+	defer rows.Close()
+
+	var serials []*domain.SerialNumber
+	for rows.Next() {
+		serial := &domain.SerialNumber{}
+		product := &domain.Product{}
+		err := rows.Scan(
+			&serial.ID, &serial.ProductID, &serial.SerialNumber, &serial.LocationID, &serial.LotNumber, &serial.Status, &serial.CreatedAt, &serial.UpdatedAt,
+			&product.ID, &product.SKU, &product.Name, &product.Description, &product.Price, &product.Weight, &product.Dimensions, &product.Category, &product.Quantity, &product.IsSerialized, &product.CostMethod, &product.BaseUnit, &product.MinTemperature, &product.MaxTemperature, &product.Length, &product.Width, &product.Height, &product.IsActive, &product.CreatedAt, &product.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan serial number: %w", err)
+		}
+		serial.Product = product
+		serials = append(serials, serial)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating serial numbers: %w", err)
+	}
+
+	return serials, nil
+}
+
+// UpdateLocation records where a unit is now held and in which status; a nil location means it
+// has left the warehouse
+func (r *serialNumberRepository) UpdateLocation(ctx context.Context, serial *domain.SerialNumber) error {
+	query := `UPDATE serial_numbers SET location_id = $2, lot_number = $3, status = $4, updated_at = $5 WHERE id = $1`
+
+	serial.UpdatedAt = time.Now()
+
+	result, err := r.db.ExecContext(ctx, query, serial.ID, serial.LocationID, serial.LotNumber, serial.Status, serial.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to update serial number location: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// UpdateStatus moves every unit of a product lot at a location from one status to another
+func (r *serialNumberRepository) UpdateStatus(ctx context.Context, productID, locationID int, lotNumber string, from, to domain.InventoryStatus) error {
+	query := `
+		UPDATE serial_numbers
+		SET status = $5, updated_at = $6
+		WHERE product_id = $1 AND location_id = $2 AND lot_number = $3 AND status = $4`
+
+	if _, err := r.db.ExecContext(ctx, query, productID, locationID, lotNumber, from, to, time.Now()); err != nil {
+		return fmt.Errorf("failed to update serial number status: %w", err)
+	}
+
+	return nil
+}
+
+func (r *serialNumberRepository) AddMovement(ctx context.Context, movementID int, serialNumberID int) error {
+	query := `INSERT INTO stock_movement_serials (movement_id, serial_number_id) VALUES ($1, $2)`
+
+	if _, err := r.db.ExecContext(ctx, query, movementID, serialNumberID); err != nil {
+		return fmt.Errorf("failed to add stock movement serial: %w", err)
+	}
+
+	return nil
+}
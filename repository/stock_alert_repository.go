@@ -0,0 +1,217 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/edwinjordan/wmsTest_Golang/domain"
+)
+
+const stockAlertColumns = `id, rule_id, product_id, location_id, level, status, quantity, reorder_point, safety_stock, acknowledged_by, acknowledged_at, resolved_at, created_at, updated_at`
+
+type stockAlertRepository struct {
+	db DBTX
+}
+
+func NewStockAlertRepository(db DBTX) StockAlertRepository {
+	return &stockAlertRepository{db: db}
+}
+
+func (r *stockAlertRepository) Create(ctx context.Context, alert *domain.StockAlert) error {
+	query := `
+		INSERT INTO stock_alerts (rule_id, product_id, location_id, level, status, quantity, reorder_point, safety_stock, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id`
+
+	now := time.Now()
+	alert.CreatedAt = now
+	alert.UpdatedAt = now
+
+	err := r.db.QueryRowContext(ctx, query,
+		alert.RuleID,
+		alert.ProductID,
+		alert.LocationID,
+		alert.Level,
+		alert.Status,
+		alert.Quantity,
+		alert.ReorderPoint,
+		alert.SafetyStock,
+		alert.CreatedAt,
+		alert.UpdatedAt,
+	).Scan(&alert.ID)
+
+	if err != nil {
+		return fmt.Errorf("failed to create stock alert: %w", err)
+	}
+
+	return nil
+}
+
+func (r *stockAlertRepository) GetByID(ctx context.Context, id int) (*domain.StockAlert, error) {
+	query := `SELECT ` + stockAlertColumns + ` FROM stock_alerts WHERE id = $1`
+
+	return r.getOne(ctx, query, id)
+}
+
+// GetUnresolvedByRule returns the open or acknowledged alert of a rule
+func (r *stockAlertRepository) GetUnresolvedByRule(ctx context.Context, ruleID int) (*domain.StockAlert, error) {
+	query := `SELECT ` + stockAlertColumns + ` FROM stock_alerts WHERE rule_id = $1 AND status <> 'RESOLVED'`
+
+	return r.getOne(ctx, query, ruleID)
+}
+
+func (r *stockAlertRepository) getOne(ctx context.Context, query string, id int) (*domain.StockAlert, error) {
+	alert := &domain.StockAlert{}
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
+		&alert.ID,
+		&alert.RuleID,
+		&alert.ProductID,
+		&alert.LocationID,
+		&alert.Level,
+		&alert.Status,
+		&alert.Quantity,
+		&alert.ReorderPoint,
+		&alert.SafetyStock,
+		&alert.AcknowledgedBy,
+		&alert.AcknowledgedAt,
+		&alert.ResolvedAt,
+		&alert.CreatedAt,
+		&alert.UpdatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get stock alert: %w", err)
+	}
+
+	return alert, nil
+}
+
+// Update saves the evaluation and acknowledge state of an alert
+func (r *stockAlertRepository) Update(ctx context.Context, alert *domain.StockAlert) error {
+	query := `
+		UPDATE stock_alerts
+		SET level = $2, status = $3, quantity = $4, reorder_point = $5, safety_stock = $6,
+		    acknowledged_by = $7, acknowledged_at = $8, resolved_at = $9, updated_at = $10
+		WHERE id = $1`
+
+	alert.UpdatedAt = time.Now()
+
+	result, err := r.db.ExecContext(ctx, query,
+		alert.ID,
+		alert.Level,
+		alert.Status,
+		alert.Quantity,
+		alert.ReorderPoint,
+		alert.SafetyStock,
+		alert.AcknowledgedBy,
+		alert.AcknowledgedAt,
+		alert.ResolvedAt,
+		alert.UpdatedAt,
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to update stock alert: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *stockAlertRepository) List(ctx context.Context, filter *domain.StockAlertFilter) ([]*domain.StockAlert, int, error) {
+	// Build WHERE conditions
+	var conditions []string
+	var args []interface{}
+	argIndex := 1
+
+	if filter.ProductID != nil {
+		conditions = append(conditions, fmt.Sprintf("product_id = $%d", argIndex))
+		args = append(args, *filter.ProductID)
+		argIndex++
+	}
+
+	if filter.LocationID != nil {
+		conditions = append(conditions, fmt.Sprintf("location_id = $%d", argIndex))
+		args = append(args, *filter.LocationID)
+		argIndex++
+	}
+
+	if filter.Status != nil {
+		conditions = append(conditions, fmt.Sprintf("status = $%d", argIndex))
+		args = append(args, *filter.Status)
+		argIndex++
+	}
+
+	whereClause := ""
+	if len(conditions) > 0 {
+		whereClause = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	// Count total records
+	countQuery := fmt.Sprintf(`SELECT COUNT(*) FROM stock_alerts %s`, whereClause)
+
+	var total int
+	err := r.db.QueryRowContext(ctx, countQuery, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count stock alerts: %w", err)
+	}
+
+	// Get paginated records
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM stock_alerts
+		%s
+		ORDER BY created_at DESC
+		LIMIT $%d OFFSET $%d`, stockAlertColumns, whereClause, argIndex, argIndex+1)
+	args = append(args, filter.Limit, filter.Offset)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list stock alerts: %w", err)
+	}
+	defer rows.Close()
+
+	var alerts []*domain.StockAlert
+	for rows.Next() {
+		alert := &domain.StockAlert{}
+		err := rows.Scan(
+			&alert.ID,
+			&alert.RuleID,
+			&alert.ProductID,
+			&alert.LocationID,
+			&alert.Level,
+			&alert.Status,
+			&alert.Quantity,
+			&alert.ReorderPoint,
+			&alert.SafetyStock,
+			&alert.AcknowledgedBy,
+			&alert.AcknowledgedAt,
+			&alert.ResolvedAt,
+			&alert.CreatedAt,
+			&alert.UpdatedAt,
+		)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan stock alert: %w", err)
+		}
+		alerts = append(alerts, alert)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating stock alerts: %w", err)
+	}
+
+	return alerts, total, nil
+}
@@ -0,0 +1,372 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/edwinjordan/wmsTest_Golang/domain"
+	"github.com/lib/pq"
+)
+
+// pqCheckViolation is the PostgreSQL error code raised when a CHECK constraint fails
+const pqCheckViolation = "23514"
+
+type stockLevelRepository struct {
+	db DBTX
+}
+
+func NewStockLevelRepository(db DBTX) StockLevelRepository {
+	return &stockLevelRepository{db: db}
+}
+
+func (r *stockLevelRepository) Get(ctx context.Context, productID, locationID int, lotNumber string, status domain.InventoryStatus) (*domain.StockLevel, error) {
+	query := `
+		SELECT sl.id, sl.product_id, sl.location_id, sl.lot_number, sl.status, pl.expiry_date, sl.quantity, sl.created_at, sl.updated_at
+		FROM stock_levels sl
+		LEFT JOIN product_lots pl ON pl.product_id = sl.product_id AND pl.lot_number = sl.lot_number
+		WHERE sl.product_id = $1 AND sl.location_id = $2 AND sl.lot_number = $3 AND sl.status = $4`
+
+	level := &domain.StockLevel{}
+	err := r.db.QueryRowContext(ctx, query, productID, locationID, lotNumber, status).Scan(
+		&level.ID,
+		&level.ProductID,
+		&level.LocationID,
+		&level.LotNumber,
+		&level.Status,
+		&level.ExpiryDate,
+		&level.Quantity,
+		&level.CreatedAt,
+		&level.UpdatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get stock level: %w", err)
+	}
+
+	return level, nil
+}
+
+// GetQuantity returns the on-hand quantity of a product at a location across all lots
+func (r *stockLevelRepository) GetQuantity(ctx context.Context, productID, locationID int) (int, error) {
+	query := `SELECT COALESCE(SUM(quantity), 0) FROM stock_levels WHERE product_id = $1 AND location_id = $2`
+
+	var quantity int
+	err := r.db.QueryRowContext(ctx, query, productID, locationID).Scan(&quantity)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get stock quantity: %w", err)
+	}
+
+	return quantity, nil
+}
+
+// GetStatusQuantity returns the quantity of a product at a location across all lots that has the given status
+func (r *stockLevelRepository) GetStatusQuantity(ctx context.Context, productID, locationID int, status domain.InventoryStatus) (int, error) {
+	query := `SELECT COALESCE(SUM(quantity), 0) FROM stock_levels WHERE product_id = $1 AND location_id = $2 AND status = $3`
+
+	var quantity int
+	err := r.db.QueryRowContext(ctx, query, productID, locationID, status).Scan(&quantity)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get stock quantity: %w", err)
+	}
+
+	return quantity, nil
+}
+
+// AdjustQuantity adds delta (which may be negative) to the balance of a product lot with the given
+// status at a location, creating the balance row on first use. A balance can never drop below zero.
+func (r *stockLevelRepository) AdjustQuantity(ctx context.Context, productID, locationID int, lotNumber string, status domain.InventoryStatus, delta int) error {
+	query := `
+		INSERT INTO stock_levels (product_id, location_id, lot_number, status, quantity, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $6)
+		ON CONFLICT (product_id, location_id, lot_number, status)
+		DO UPDATE SET quantity = stock_levels.quantity + EXCLUDED.quantity, updated_at = EXCLUDED.updated_at`
+
+	_, err := r.db.ExecContext(ctx, query, productID, locationID, lotNumber, status, delta, time.Now())
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == pqCheckViolation {
+			return domain.ErrInsufficientStock
+		}
+		return fmt.Errorf("failed to adjust stock level: %w", err)
+	}
+
+	return nil
+}
+
+// ListLots returns the lots of a product with the given status held at a location in FEFO order:
+// earliest expiry first, lots without expiry last
+func (r *stockLevelRepository) ListLots(ctx context.Context, productID, locationID int, status domain.InventoryStatus) ([]*domain.StockLevel, error) {
+	query := `
+		SELECT sl.id, sl.product_id, sl.location_id, sl.lot_number, sl.status, pl.expiry_date, sl.quantity, sl.created_at, sl.updated_at
+		FROM stock_levels sl
+		LEFT JOIN product_lots pl ON pl.product_id = sl.product_id AND pl.lot_number = sl.lot_number
+		WHERE sl.product_id = $1 AND sl.location_id = $2 AND sl.status = $3 AND sl.quantity > 0
+		ORDER BY pl.expiry_date NULLS LAST, sl.created_at, sl.lot_number`
+
+	rows, err := r.db.QueryContext(ctx, query, productID, locationID, status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stock lots: %w", err)
+	}
+	defer rows.Close()
+
+	var levels []*domain.StockLevel
+	for rows.Next() {
+		level := &domain.StockLevel{}
+		err := rows.Scan(
+			&level.ID,
+			&level.ProductID,
+			&level.LocationID,
+			&level.LotNumber,
+			&level.Status,
+			&level.ExpiryDate,
+			&level.Quantity,
+			&level.CreatedAt,
+			&level.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan stock level: %w", err)
+		}
+		levels = append(levels, level)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating stock lots: %w", err)
+	}
+
+	return levels, nil
+}
+
+// ListByProduct returns the balances of a product, only those in one warehouse when warehouseID is set
+func (r *stockLevelRepository) ListByProduct(ctx context.Context, productID int, warehouseID *int) ([]*domain.StockLevel, error) {
+	query := `
+		SELECT sl.id, sl.product_id, sl.location_id, sl.lot_number, sl.status, pl.expiry_date, sl.quantity, sl.created_at, sl.updated_at,
+		       l.id, l.warehouse_id, l.code, l.name, l.zone, l.aisle, l.rack, l.shelf, l.capacity, l.temperature, l.max_weight, l.max_volume, l.is_active, l.created_at, l.updated_at
+		FROM stock_levels sl
+		JOIN locations l ON sl.location_id = l.id
+		LEFT JOIN product_lots pl ON pl.product_id = sl.product_id AND pl.lot_number = sl.lot_number
+		WHERE sl.product_id = $1 AND sl.quantity > 0 AND ($2::INTEGER IS NULL OR l.warehouse_id = $2)
+		ORDER BY l.warehouse_id, l.zone, l.aisle, l.rack, l.shelf, pl.expiry_date NULLS LAST, sl.lot_number, sl.status`
+
+	rows, err := r.db.QueryContext(ctx, query, productID, warehouseID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stock levels by product: %w", err)
+	}
+	defer rows.Close()
+
+	var levels []*domain.StockLevel
+	for rows.Next() {
+		level := &domain.StockLevel{}
+		location := &domain.Location{}
+		err := rows.Scan(
+			&level.ID, &level.ProductID, &level.LocationID, &level.LotNumber, &level.Status, &level.ExpiryDate, &level.Quantity, &level.CreatedAt, &level.UpdatedAt,
+			&location.ID, &location.WarehouseID, &location.Code, &location.Name, &location.Zone, &location.Aisle, &location.Rack, &location.Shelf, &location.Capacity, &location.Temperature, &location.MaxWeight, &location.MaxVolume, &location.IsActive, &location.CreatedAt, &location.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan stock level: %w", err)
+		}
+		level.Location = location
+		levels = append(levels, level)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating stock levels: %w", err)
+	}
+
+	return levels, nil
+}
+
+func (r *stockLevelRepository) ListByLocation(ctx context.Context, locationID int) ([]*domain.StockLevel, error) {
+	query := `
+		SELECT sl.id, sl.product_id, sl.location_id, sl.lot_number, sl.status, pl.expiry_date, sl.quantity, sl.created_at, sl.updated_at,
+		       p.id, p.sku, p.name, p.description, p.price, p.weight, p.dimensions, p.category, p.quantity, p.is_serialized, p.cost_method, p.base_unit, p.min_temperature, p.max_temperature, p.length_cm, p.width_cm, p.height_cm, p.is_active, p.created_at, p.updated_at
+		FROM stock_levels sl
+		JOIN products p ON sl.product_id = p.id
+		LEFT JOIN product_lots pl ON pl.product_id = sl.product_id AND pl.lot_number = sl.lot_number
+		WHERE sl.location_id = $1 AND sl.quantity > 0
+		ORDER BY p.sku, pl.expiry_date NULLS LAST, sl.lot_number, sl.status`
+
+	rows, err := r.db.QueryContext(ctx, query, locationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stock levels by location: %w", err)
+	}
+	defer rows.Close()
+
+	var levels []*domain.StockLevel
+	for rows.Next() {
+		level := &domain.StockLevel{}
+		product := &domain.Product{}
+		err := rows.Scan(
+			&level.ID, &level.ProductID, &level.LocationID, &level.LotNumber, &level.Status, &level.ExpiryDate, &level.Quantity, &level.CreatedAt, &level.UpdatedAt,
+			&product.ID, &product.SKU, &product.Name, &product.Description, &product.Price, &product.Weight, &product.Dimensions, &product.Category, &product.Quantity, &product.IsSerialized, &product.CostMethod, &product.BaseUnit, &product.MinTemperature, &product.MaxTemperature, &product.Length, &product.Width, &product.Height, &product.IsActive, &product.CreatedAt, &product.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan stock level: %w", err)
+		}
+		level.Product = product
+		levels = append(levels, level)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating stock levels: %w", err)
+	}
+
+	return levels, nil
+}
+
+// ListByWarehouse returns the balances held at the locations of a warehouse with their products
+func (r *stockLevelRepository) ListByWarehouse(ctx context.Context, warehouseID int) ([]*domain.StockLevel, error) {
+	query := `
+		SELECT sl.id, sl.product_id, sl.location_id, sl.lot_number, sl.status, pl.expiry_date, sl.quantity, sl.created_at, sl.updated_at,
+		       p.id, p.sku, p.name, p.description, p.price, p.weight, p.dimensions, p.category, p.quantity, p.is_serialized, p.cost_method, p.base_unit, p.min_temperature, p.max_temperature, p.length_cm, p.width_cm, p.height_cm, p.is_active, p.created_at, p.updated_at
+		FROM stock_levels sl
+		JOIN products p ON sl.product_id = p.id
+		JOIN locations l ON sl.location_id = l.id
+		LEFT JOIN product_lots pl ON pl.product_id = sl.product_id AND pl.lot_number = sl.lot_number
+		WHERE l.warehouse_id = $1 AND sl.quantity > 0
+		ORDER BY p.sku, l.code, pl.expiry_date NULLS LAST, sl.lot_number, sl.status`
+
+	rows, err := r.db.QueryContext(ctx, query, warehouseID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stock levels by warehouse: %w", err)
+	}
+	defer rows.Close()
+
+	var levels []*domain.StockLevel
+	for rows.Next() {
+		level := &domain.StockLevel{}
+		product := &domain.Product{}
+		err := rows.Scan(
+			&level.ID, &level.ProductID, &level.LocationID, &level.LotNumber, &level.Status, &level.ExpiryDate, &level.Quantity, &level.CreatedAt, &level.UpdatedAt,
+			&product.ID, &product.SKU, &product.Name, &product.Description, &product.Price, &product.Weight, &product.Dimensions, &product.Category, &product.Quantity, &product.IsSerialized, &product.CostMethod, &product.BaseUnit, &product.MinTemperature, &product.MaxTemperature, &product.Length, &product.Width, &product.Height, &product.IsActive, &product.CreatedAt, &product.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan stock level: %w", err)
+		}
+		level.Product = product
+		levels = append(levels, level)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating stock levels: %w", err)
+	}
+
+	return levels, nil
+}
+
+// GetLocationLoad returns the units, weight and volume currently stored at a location
+func (r *stockLevelRepository) GetLocationLoad(ctx context.Context, locationID int) (*domain.LocationLoad, error) {
+	query := `
+		SELECT COALESCE(SUM(sl.quantity), 0),
+		       COALESCE(SUM(sl.quantity * p.weight), 0),
+		       COALESCE(SUM(sl.quantity * p.length_cm * p.width_cm * p.height_cm), 0) / 1000000
+		FROM stock_levels sl
+		JOIN products p ON sl.product_id = p.id
+		WHERE sl.location_id = $1`
+
+	load := &domain.LocationLoad{}
+	err := r.db.QueryRowContext(ctx, query, locationID).Scan(&load.Quantity, &load.Weight, &load.Volume)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get location load: %w", err)
+	}
+
+	return load, nil
+}
+
+// ListLocationLoads returns the units, weight and volume stored at every location that holds stock
+func (r *stockLevelRepository) ListLocationLoads(ctx context.Context) (map[int]*domain.LocationLoad, error) {
+	query := `
+		SELECT sl.location_id,
+		       SUM(sl.quantity),
+		       SUM(sl.quantity * p.weight),
+		       SUM(sl.quantity * p.length_cm * p.width_cm * p.height_cm) / 1000000
+		FROM stock_levels sl
+		JOIN products p ON sl.product_id = p.id
+		GROUP BY sl.location_id`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list location loads: %w", err)
+	}
+	defer rows.Close()
+
+	loads := make(map[int]*domain.LocationLoad)
+	for rows.Next() {
+		var locationID int
+		load := &domain.LocationLoad{}
+		if err := rows.Scan(&locationID, &load.Quantity, &load.Weight, &load.Volume); err != nil {
+			return nil, fmt.Errorf("failed to scan location load: %w", err)
+		}
+		loads[locationID] = load
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating location loads: %w", err)
+	}
+
+	return loads, nil
+}
+
+// GetProductTotal returns the on-hand quantity of a product across all locations and lots
+func (r *stockLevelRepository) GetProductTotal(ctx context.Context, productID int) (int, error) {
+	query := `SELECT COALESCE(SUM(quantity), 0) FROM stock_levels WHERE product_id = $1`
+
+	var total int
+	err := r.db.QueryRowContext(ctx, query, productID).Scan(&total)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get product stock total: %w", err)
+	}
+
+	return total, nil
+}
+
+// ListByStatus returns the balances with the given status of a product, a location or a lot, with
+// the lots of each product and location in FEFO order. Nil filters match everything.
+func (r *stockLevelRepository) ListByStatus(ctx context.Context, productID, locationID *int, lotNumber *string, status domain.InventoryStatus) ([]*domain.StockLevel, error) {
+	query := `
+		SELECT sl.id, sl.product_id, sl.location_id, sl.lot_number, sl.status, pl.expiry_date, sl.quantity, sl.created_at, sl.updated_at
+		FROM stock_levels sl
+		LEFT JOIN product_lots pl ON pl.product_id = sl.product_id AND pl.lot_number = sl.lot_number
+		WHERE sl.status = $1 AND sl.quantity > 0
+		  AND ($2::INTEGER IS NULL OR sl.product_id = $2)
+		  AND ($3::INTEGER IS NULL OR sl.location_id = $3)
+		  AND ($4::VARCHAR IS NULL OR sl.lot_number = $4)
+		ORDER BY sl.product_id, sl.location_id, pl.expiry_date NULLS LAST, sl.created_at, sl.lot_number`
+
+	rows, err := r.db.QueryContext(ctx, query, status, productID, locationID, lotNumber)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stock levels by status: %w", err)
+	}
+	defer rows.Close()
+
+	var levels []*domain.StockLevel
+	for rows.Next() {
+		level := &domain.StockLevel{}
+		err := rows.Scan(
+			&level.ID,
+			&level.ProductID,
+			&level.LocationID,
+			&level.LotNumber,
+			&level.Status,
+			&level.ExpiryDate,
+			&level.Quantity,
+			&level.CreatedAt,
+			&level.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan stock level: %w", err)
+		}
+		levels = append(levels, level)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating stock levels: %w", err)
+	}
+
+	return levels, nil
+}
@@ -8,32 +8,51 @@ import (
 	"time"
 
 	"github.com/edwinjordan/wmsTest_Golang/domain"
+	"github.com/lib/pq"
 )
 
 type stockMovementRepository struct {
-	db *sql.DB
+	db DBTX
 }
 
-func NewStockMovementRepository(db *sql.DB) StockMovementRepository {
+func NewStockMovementRepository(db DBTX) StockMovementRepository {
 	return &stockMovementRepository{db: db}
 }
 
 func (r *stockMovementRepository) Create(ctx context.Context, movement *domain.StockMovement) error {
 	query := `
-		INSERT INTO stock_movements (product_id, location_id, user_id, type, quantity, reference, notes, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		INSERT INTO stock_movements (product_id, location_id, user_id, type, direction, quantity, reference, reason_code, notes, related_movement_id, reversal_of_id, reservation_id, purchase_order_line_id, sales_order_line_id, return_line_id, return_reason, return_grade, inventory_status, unit_cost, total_cost, uom, uom_quantity, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23)
 		RETURNING id`
 
 	movement.CreatedAt = time.Now()
+	if movement.InventoryStatus == "" {
+		movement.InventoryStatus = domain.InventoryAvailable
+	}
 
 	err := r.db.QueryRowContext(ctx, query,
 		movement.ProductID,
 		movement.LocationID,
 		movement.UserID,
 		movement.Type,
+		movement.Direction,
 		movement.Quantity,
 		movement.Reference,
+		movement.ReasonCode,
 		movement.Notes,
+		movement.RelatedMovementID,
+		movement.ReversalOfID,
+		movement.ReservationID,
+		movement.PurchaseOrderLineID,
+		movement.SalesOrderLineID,
+		movement.ReturnLineID,
+		movement.ReturnReason,
+		movement.ReturnGrade,
+		movement.InventoryStatus,
+		movement.UnitCost,
+		movement.TotalCost,
+		movement.UOM,
+		movement.UOMQuantity,
 		movement.CreatedAt,
 	).Scan(&movement.ID)
 
@@ -41,14 +60,61 @@ func (r *stockMovementRepository) Create(ctx context.Context, movement *domain.S
 		return fmt.Errorf("failed to create stock movement: %w", err)
 	}
 
+	for _, lot := range movement.Lots {
+		_, err := r.db.ExecContext(ctx,
+			`INSERT INTO stock_movement_lots (movement_id, lot_number, quantity) VALUES ($1, $2, $3)`,
+			movement.ID, lot.LotNumber, lot.Quantity,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to add stock movement lot: %w", err)
+		}
+	}
+
 	return nil
 }
 
+// SetRelatedMovement links a movement to its counterpart, e.g. the other leg of a transfer
+func (r *stockMovementRepository) SetRelatedMovement(ctx context.Context, id int, relatedID int) error {
+	query := `UPDATE stock_movements SET related_movement_id = $2 WHERE id = $1`
+
+	result, err := r.db.ExecContext(ctx, query, id, relatedID)
+	if err != nil {
+		return fmt.Errorf("failed to link stock movement: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// GetReversal returns the movement that reverses the given movement, or ErrNotFound if it has not been reversed
+func (r *stockMovementRepository) GetReversal(ctx context.Context, movementID int) (*domain.StockMovement, error) {
+	query := `SELECT id FROM stock_movements WHERE reversal_of_id = $1`
+
+	var id int
+	err := r.db.QueryRowContext(ctx, query, movementID).Scan(&id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get stock movement reversal: %w", err)
+	}
+
+	return r.GetByID(ctx, id)
+}
+
 func (r *stockMovementRepository) GetByID(ctx context.Context, id int) (*domain.StockMovement, error) {
 	query := `
-		SELECT sm.id, sm.product_id, sm.location_id, sm.user_id, sm.type, sm.quantity, sm.reference, sm.notes, sm.created_at,
+		SELECT sm.id, sm.product_id, sm.location_id, sm.user_id, sm.type, sm.direction, sm.quantity, sm.reference, sm.reason_code, sm.notes, sm.related_movement_id, sm.reversal_of_id, sm.reservation_id, sm.purchase_order_line_id, sm.sales_order_line_id, sm.return_line_id, sm.return_reason, sm.return_grade, sm.inventory_status, sm.unit_cost, sm.total_cost, sm.uom, sm.uom_quantity, sm.created_at,
 		       p.id, p.sku, p.name, p.description, p.price, p.weight, p.dimensions, p.category, p.is_active, p.created_at, p.updated_at,
-		       l.id, l.code, l.name, l.zone, l.aisle, l.rack, l.shelf, l.capacity, l.temperature, l.is_active, l.created_at, l.updated_at,
+		       l.id, l.warehouse_id, l.code, l.name, l.zone, l.aisle, l.rack, l.shelf, l.capacity, l.temperature, l.max_weight, l.max_volume, l.is_active, l.created_at, l.updated_at,
 		       u.id, u.username, u.email, u.password, u.api_key, u.is_active, u.created_at, u.updated_at
 		FROM stock_movements sm
 		JOIN products p ON sm.product_id = p.id
@@ -62,9 +128,9 @@ func (r *stockMovementRepository) GetByID(ctx context.Context, id int) (*domain.
 	user := &domain.User{}
 
 	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&movement.ID, &movement.ProductID, &movement.LocationID, &movement.UserID, &movement.Type, &movement.Quantity, &movement.Reference, &movement.Notes, &movement.CreatedAt,
+		&movement.ID, &movement.ProductID, &movement.LocationID, &movement.UserID, &movement.Type, &movement.Direction, &movement.Quantity, &movement.Reference, &movement.ReasonCode, &movement.Notes, &movement.RelatedMovementID, &movement.ReversalOfID, &movement.ReservationID, &movement.PurchaseOrderLineID, &movement.SalesOrderLineID, &movement.ReturnLineID, &movement.ReturnReason, &movement.ReturnGrade, &movement.InventoryStatus, &movement.UnitCost, &movement.TotalCost, &movement.UOM, &movement.UOMQuantity, &movement.CreatedAt,
 		&product.ID, &product.SKU, &product.Name, &product.Description, &product.Price, &product.Weight, &product.Dimensions, &product.Category, &product.IsActive, &product.CreatedAt, &product.UpdatedAt,
-		&location.ID, &location.Code, &location.Name, &location.Zone, &location.Aisle, &location.Rack, &location.Shelf, &location.Capacity, &location.Temperature, &location.IsActive, &location.CreatedAt, &location.UpdatedAt,
+		&location.ID, &location.WarehouseID, &location.Code, &location.Name, &location.Zone, &location.Aisle, &location.Rack, &location.Shelf, &location.Capacity, &location.Temperature, &location.MaxWeight, &location.MaxVolume, &location.IsActive, &location.CreatedAt, &location.UpdatedAt,
 		&user.ID, &user.Username, &user.Email, &user.Password, &user.APIKey, &user.IsActive, &user.CreatedAt, &user.UpdatedAt,
 	)
 
@@ -79,6 +145,10 @@ func (r *stockMovementRepository) GetByID(ctx context.Context, id int) (*domain.
 	movement.Location = location
 	movement.User = user
 
+	if err := r.loadLots(ctx, []*domain.StockMovement{movement}); err != nil {
+		return nil, err
+	}
+
 	return movement, nil
 }
 
@@ -100,6 +170,12 @@ func (r *stockMovementRepository) List(ctx context.Context, filter *domain.Stock
 		argIndex++
 	}
 
+	if filter.WarehouseID != nil {
+		conditions = append(conditions, fmt.Sprintf("sm.location_id IN (SELECT id FROM locations WHERE warehouse_id = $%d)", argIndex))
+		args = append(args, *filter.WarehouseID)
+		argIndex++
+	}
+
 	if filter.UserID != nil {
 		conditions = append(conditions, fmt.Sprintf("sm.user_id = $%d", argIndex))
 		args = append(args, *filter.UserID)
@@ -112,6 +188,42 @@ func (r *stockMovementRepository) List(ctx context.Context, filter *domain.Stock
 		argIndex++
 	}
 
+	if filter.ReasonCode != nil {
+		conditions = append(conditions, fmt.Sprintf("sm.reason_code = $%d", argIndex))
+		args = append(args, *filter.ReasonCode)
+		argIndex++
+	}
+
+	if filter.LotNumber != nil {
+		conditions = append(conditions, fmt.Sprintf("EXISTS (SELECT 1 FROM stock_movement_lots sml WHERE sml.movement_id = sm.id AND sml.lot_number = $%d)", argIndex))
+		args = append(args, *filter.LotNumber)
+		argIndex++
+	}
+
+	if filter.SerialNumber != nil {
+		conditions = append(conditions, fmt.Sprintf("EXISTS (SELECT 1 FROM stock_movement_serials sms JOIN serial_numbers sn ON sms.serial_number_id = sn.id WHERE sms.movement_id = sm.id AND sn.serial_number = $%d)", argIndex))
+		args = append(args, *filter.SerialNumber)
+		argIndex++
+	}
+
+	if filter.PurchaseOrderID != nil {
+		conditions = append(conditions, fmt.Sprintf("sm.purchase_order_line_id IN (SELECT id FROM purchase_order_lines WHERE purchase_order_id = $%d)", argIndex))
+		args = append(args, *filter.PurchaseOrderID)
+		argIndex++
+	}
+
+	if filter.SalesOrderID != nil {
+		conditions = append(conditions, fmt.Sprintf("sm.sales_order_line_id IN (SELECT id FROM sales_order_lines WHERE sales_order_id = $%d)", argIndex))
+		args = append(args, *filter.SalesOrderID)
+		argIndex++
+	}
+
+	if filter.ReturnAuthorizationID != nil {
+		conditions = append(conditions, fmt.Sprintf("sm.return_line_id IN (SELECT id FROM return_authorization_lines WHERE return_authorization_id = $%d)", argIndex))
+		args = append(args, *filter.ReturnAuthorizationID)
+		argIndex++
+	}
+
 	if filter.DateFrom != nil {
 		conditions = append(conditions, fmt.Sprintf("sm.created_at >= $%d", argIndex))
 		args = append(args, *filter.DateFrom)
@@ -147,9 +259,9 @@ func (r *stockMovementRepository) List(ctx context.Context, filter *domain.Stock
 
 	// Get paginated records
 	query := fmt.Sprintf(`
-		SELECT sm.id, sm.product_id, sm.location_id, sm.user_id, sm.type, sm.quantity, sm.reference, sm.notes, sm.created_at,
+		SELECT sm.id, sm.product_id, sm.location_id, sm.user_id, sm.type, sm.direction, sm.quantity, sm.reference, sm.reason_code, sm.notes, sm.related_movement_id, sm.reversal_of_id, sm.reservation_id, sm.purchase_order_line_id, sm.sales_order_line_id, sm.return_line_id, sm.return_reason, sm.return_grade, sm.inventory_status, sm.unit_cost, sm.total_cost, sm.uom, sm.uom_quantity, sm.created_at,
 		       p.id, p.sku, p.name, p.description, p.price, p.weight, p.dimensions, p.category, p.is_active, p.created_at, p.updated_at,p.quantity,
-		       l.id, l.code, l.name, l.zone, l.aisle, l.rack, l.shelf, l.capacity, l.temperature, l.is_active, l.created_at, l.updated_at,
+		       l.id, l.warehouse_id, l.code, l.name, l.zone, l.aisle, l.rack, l.shelf, l.capacity, l.temperature, l.max_weight, l.max_volume, l.is_active, l.created_at, l.updated_at,
 		       u.id, u.username, u.email, u.password, u.api_key, u.is_active, u.created_at, u.updated_at
 		FROM stock_movements sm
 		JOIN products p ON sm.product_id = p.id
@@ -172,9 +284,9 @@ func (r *stockMovementRepository) List(ctx context.Context, filter *domain.Stock
 		user := &domain.User{}
 
 		err := rows.Scan(
-			&movement.ID, &movement.ProductID, &movement.LocationID, &movement.UserID, &movement.Type, &movement.Quantity, &movement.Reference, &movement.Notes, &movement.CreatedAt,
+			&movement.ID, &movement.ProductID, &movement.LocationID, &movement.UserID, &movement.Type, &movement.Direction, &movement.Quantity, &movement.Reference, &movement.ReasonCode, &movement.Notes, &movement.RelatedMovementID, &movement.ReversalOfID, &movement.ReservationID, &movement.PurchaseOrderLineID, &movement.SalesOrderLineID, &movement.ReturnLineID, &movement.ReturnReason, &movement.ReturnGrade, &movement.InventoryStatus, &movement.UnitCost, &movement.TotalCost, &movement.UOM, &movement.UOMQuantity, &movement.CreatedAt,
 			&product.ID, &product.SKU, &product.Name, &product.Description, &product.Price, &product.Weight, &product.Dimensions, &product.Category, &product.IsActive, &product.CreatedAt, &product.UpdatedAt, &product.Quantity,
-			&location.ID, &location.Code, &location.Name, &location.Zone, &location.Aisle, &location.Rack, &location.Shelf, &location.Capacity, &location.Temperature, &location.IsActive, &location.CreatedAt, &location.UpdatedAt,
+			&location.ID, &location.WarehouseID, &location.Code, &location.Name, &location.Zone, &location.Aisle, &location.Rack, &location.Shelf, &location.Capacity, &location.Temperature, &location.MaxWeight, &location.MaxVolume, &location.IsActive, &location.CreatedAt, &location.UpdatedAt,
 			&user.ID, &user.Username, &user.Email, &user.Password, &user.APIKey, &user.IsActive, &user.CreatedAt, &user.UpdatedAt,
 		)
 		if err != nil {
@@ -191,9 +303,114 @@ func (r *stockMovementRepository) List(ctx context.Context, filter *domain.Stock
 		return nil, 0, fmt.Errorf("error iterating stock movements: %w", err)
 	}
 
+	if err := r.loadLots(ctx, movements); err != nil {
+		return nil, 0, err
+	}
+
 	return movements, total, nil
 }
 
+// loadLots fills in the lot breakdown, serial numbers and cost layers of the given movements
+func (r *stockMovementRepository) loadLots(ctx context.Context, movements []*domain.StockMovement) error {
+	if len(movements) == 0 {
+		return nil
+	}
+
+	byID := make(map[int]*domain.StockMovement, len(movements))
+	ids := make([]int64, 0, len(movements))
+	for _, movement := range movements {
+		byID[movement.ID] = movement
+		ids = append(ids, int64(movement.ID))
+	}
+
+	query := `
+		SELECT sml.movement_id, sml.lot_number, pl.expiry_date, sml.quantity
+		FROM stock_movement_lots sml
+		JOIN stock_movements sm ON sml.movement_id = sm.id
+		LEFT JOIN product_lots pl ON pl.product_id = sm.product_id AND pl.lot_number = sml.lot_number
+		WHERE sml.movement_id = ANY($1)
+		ORDER BY sml.movement_id, pl.expiry_date NULLS LAST, sml.lot_number`
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("failed to list stock movement lots: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var movementID int
+		lot := &domain.StockMovementLot{}
+		if err := rows.Scan(&movementID, &lot.LotNumber, &lot.ExpiryDate, &lot.Quantity); err != nil {
+			return fmt.Errorf("failed to scan stock movement lot: %w", err)
+		}
+		if movement, ok := byID[movementID]; ok {
+			movement.Lots = append(movement.Lots, lot)
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("error iterating stock movement lots: %w", err)
+	}
+
+	serialQuery := `
+		SELECT sms.movement_id, sn.serial_number
+		FROM stock_movement_serials sms
+		JOIN serial_numbers sn ON sms.serial_number_id = sn.id
+		WHERE sms.movement_id = ANY($1)
+		ORDER BY sms.movement_id, sn.serial_number`
+
+	serialRows, err := r.db.QueryContext(ctx, serialQuery, pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("failed to list stock movement serials: %w", err)
+	}
+	defer serialRows.Close()
+
+	for serialRows.Next() {
+		var movementID int
+		var serialNumber string
+		if err := serialRows.Scan(&movementID, &serialNumber); err != nil {
+			return fmt.Errorf("failed to scan stock movement serial: %w", err)
+		}
+		if movement, ok := byID[movementID]; ok {
+			movement.SerialNumbers = append(movement.SerialNumbers, serialNumber)
+		}
+	}
+
+	if err = serialRows.Err(); err != nil {
+		return fmt.Errorf("error iterating stock movement serials: %w", err)
+	}
+
+	costQuery := `
+		SELECT smc.movement_id, smc.cost_layer_id, smc.quantity, smc.unit_cost
+		FROM stock_movement_costs smc
+		JOIN cost_layers cl ON smc.cost_layer_id = cl.id
+		WHERE smc.movement_id = ANY($1)
+		ORDER BY smc.movement_id, cl.created_at, cl.id`
+
+	costRows, err := r.db.QueryContext(ctx, costQuery, pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("failed to list stock movement costs: %w", err)
+	}
+	defer costRows.Close()
+
+	for costRows.Next() {
+		var movementID int
+		cost := &domain.StockMovementCost{}
+		if err := costRows.Scan(&movementID, &cost.CostLayerID, &cost.Quantity, &cost.UnitCost); err != nil {
+			return fmt.Errorf("failed to scan stock movement cost: %w", err)
+		}
+		if movement, ok := byID[movementID]; ok {
+			movement.Costs = append(movement.Costs, cost)
+		}
+	}
+
+	if err = costRows.Err(); err != nil {
+		return fmt.Errorf("error iterating stock movement costs: %w", err)
+	}
+
+	return nil
+}
+
 func (r *stockMovementRepository) GetByProduct(ctx context.Context, productID int, limit, offset int) ([]*domain.StockMovement, int, error) {
 	filter := &domain.StockMovementFilter{
 		ProductID: &productID,
@@ -211,3 +428,140 @@ func (r *stockMovementRepository) GetByLocation(ctx context.Context, locationID
 	}
 	return r.List(ctx, filter)
 }
+
+// ListLevelDiscrepancies returns the stock levels of active products that differ from the sum of
+// their movements, per product, location and lot. productID limits the result to one product.
+func (r *stockMovementRepository) ListLevelDiscrepancies(ctx context.Context, productID *int) ([]*domain.StockDiscrepancy, error) {
+	query := `
+		SELECT p.id, p.sku, l.id, l.code, b.lot_number, b.stored, b.ledger
+		FROM (
+			SELECT product_id, location_id, lot_number, SUM(stored) AS stored, SUM(ledger) AS ledger
+			FROM (
+				SELECT product_id, location_id, lot_number, quantity AS stored, 0 AS ledger
+				FROM stock_levels
+				WHERE $1::INTEGER IS NULL OR product_id = $1
+				UNION ALL
+				SELECT sm.product_id, sm.location_id, sml.lot_number, 0, sm.direction * sml.quantity
+				FROM stock_movements sm
+				JOIN stock_movement_lots sml ON sml.movement_id = sm.id
+				WHERE $1::INTEGER IS NULL OR sm.product_id = $1
+			) balances
+			GROUP BY product_id, location_id, lot_number
+		) b
+		JOIN products p ON b.product_id = p.id
+		JOIN locations l ON b.location_id = l.id
+		WHERE p.is_active = true AND b.stored <> b.ledger
+		ORDER BY p.sku, l.code, b.lot_number`
+
+	rows, err := r.db.QueryContext(ctx, query, productID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stock level discrepancies: %w", err)
+	}
+	defer rows.Close()
+
+	var discrepancies []*domain.StockDiscrepancy
+	for rows.Next() {
+		d := &domain.StockDiscrepancy{}
+		err := rows.Scan(&d.ProductID, &d.SKU, &d.LocationID, &d.LocationCode, &d.LotNumber, &d.StoredQuantity, &d.LedgerQuantity)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan stock level discrepancy: %w", err)
+		}
+		discrepancies = append(discrepancies, d)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating stock level discrepancies: %w", err)
+	}
+
+	return discrepancies, nil
+}
+
+// ListProductDiscrepancies returns the active products whose stored quantity differs from the
+// sum of their movements. productID limits the result to one product.
+func (r *stockMovementRepository) ListProductDiscrepancies(ctx context.Context, productID *int) ([]*domain.StockDiscrepancy, error) {
+	query := `
+		SELECT p.id, p.sku, p.quantity, COALESCE(ledger.quantity, 0)
+		FROM products p
+		LEFT JOIN (
+			SELECT product_id, SUM(direction * quantity) AS quantity
+			FROM stock_movements
+			GROUP BY product_id
+		) ledger ON ledger.product_id = p.id
+		WHERE p.is_active = true AND p.quantity <> COALESCE(ledger.quantity, 0)
+		  AND ($1::INTEGER IS NULL OR p.id = $1)
+		ORDER BY p.sku`
+
+	rows, err := r.db.QueryContext(ctx, query, productID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list product discrepancies: %w", err)
+	}
+	defer rows.Close()
+
+	var discrepancies []*domain.StockDiscrepancy
+	for rows.Next() {
+		d := &domain.StockDiscrepancy{}
+		err := rows.Scan(&d.ProductID, &d.SKU, &d.StoredQuantity, &d.LedgerQuantity)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan product discrepancy: %w", err)
+		}
+		discrepancies = append(discrepancies, d)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating product discrepancies: %w", err)
+	}
+
+	return discrepancies, nil
+}
+
+// CountPutaways returns, per location, how many times stock of a product was received or
+// transferred into it since the given time. Reversed movements are left out.
+func (r *stockMovementRepository) CountPutaways(ctx context.Context, productID int, since time.Time) (map[int]int, error) {
+	query := `
+		SELECT sm.location_id, COUNT(*)
+		FROM stock_movements sm
+		WHERE sm.product_id = $1 AND sm.type IN ('IN', 'TRANSFER') AND sm.direction = 1 AND sm.created_at >= $2
+		  AND NOT EXISTS (SELECT 1 FROM stock_movements r WHERE r.reversal_of_id = sm.id)
+		GROUP BY sm.location_id`
+
+	rows, err := r.db.QueryContext(ctx, query, productID, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count putaways: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[int]int)
+	for rows.Next() {
+		var locationID, count int
+		if err := rows.Scan(&locationID, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan putaway count: %w", err)
+		}
+		counts[locationID] = count
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating putaway counts: %w", err)
+	}
+
+	return counts, nil
+}
+
+// GetShippedUnitCost returns the average cost per unit of what was shipped against a sales order
+// line, leaving out shipments that were reversed. It is nil when nothing costed was shipped.
+func (r *stockMovementRepository) GetShippedUnitCost(ctx context.Context, salesOrderLineID int) (*float64, error) {
+	query := `
+		SELECT SUM(sm.total_cost) / NULLIF(SUM(sm.quantity), 0)
+		FROM stock_movements sm
+		WHERE sm.sales_order_line_id = $1 AND sm.type = 'OUT' AND sm.total_cost IS NOT NULL
+		  AND NOT EXISTS (SELECT 1 FROM stock_movements r WHERE r.reversal_of_id = sm.id)`
+
+	var unitCost sql.NullFloat64
+	if err := r.db.QueryRowContext(ctx, query, salesOrderLineID).Scan(&unitCost); err != nil {
+		return nil, fmt.Errorf("failed to get shipped unit cost: %w", err)
+	}
+	if !unitCost.Valid {
+		return nil, nil
+	}
+
+	return &unitCost.Float64, nil
+}
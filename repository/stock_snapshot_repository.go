@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/edwinjordan/wmsTest_Golang/domain"
+)
+
+// ledgerBalances is the quantity of every product at every location at $3, replayed from the lines
+// of snapshot $1 taken at $2, or from the start of the ledger when both are NULL
+const ledgerBalances = `
+	SELECT product_id, location_id, SUM(quantity) AS quantity
+	FROM (
+		SELECT product_id, location_id, quantity
+		FROM stock_snapshot_lines
+		WHERE snapshot_id = $1
+		UNION ALL
+		SELECT product_id, location_id, direction * quantity
+		FROM stock_movements
+		WHERE ($2::TIMESTAMPTZ IS NULL OR created_at > $2) AND created_at <= $3
+	) ledger
+	GROUP BY product_id, location_id
+	HAVING SUM(quantity) <> 0`
+
+type stockSnapshotRepository struct {
+	db DBTX
+}
+
+func NewStockSnapshotRepository(db DBTX) StockSnapshotRepository {
+	return &stockSnapshotRepository{db: db}
+}
+
+// Create stores a snapshot at snapshot.TakenAt, replaying the movements since base (nil for the
+// whole ledger) on top of the lines of base
+func (r *stockSnapshotRepository) Create(ctx context.Context, snapshot *domain.StockSnapshot, base *domain.StockSnapshot) error {
+	query := `
+		INSERT INTO stock_snapshots (taken_at, created_at)
+		VALUES ($1, $2)
+		RETURNING id`
+
+	snapshot.CreatedAt = time.Now()
+
+	err := r.db.QueryRowContext(ctx, query, snapshot.TakenAt, snapshot.CreatedAt).Scan(&snapshot.ID)
+	if err != nil {
+		return fmt.Errorf("failed to create stock snapshot: %w", err)
+	}
+
+	baseID, baseTakenAt := snapshotArgs(base)
+	linesQuery := `
+		INSERT INTO stock_snapshot_lines (snapshot_id, product_id, location_id, quantity)
+		SELECT $4, product_id, location_id, quantity
+		FROM (` + ledgerBalances + `) balances`
+
+	_, err = r.db.ExecContext(ctx, linesQuery, baseID, baseTakenAt, snapshot.TakenAt, snapshot.ID)
+	if err != nil {
+		return fmt.Errorf("failed to create stock snapshot lines: %w", err)
+	}
+
+	return nil
+}
+
+// GetLatest returns the most recent snapshot taken at or before the given time
+func (r *stockSnapshotRepository) GetLatest(ctx context.Context, at time.Time) (*domain.StockSnapshot, error) {
+	query := `
+		SELECT id, taken_at, created_at
+		FROM stock_snapshots
+		WHERE taken_at <= $1
+		ORDER BY taken_at DESC
+		LIMIT 1`
+
+	snapshot := &domain.StockSnapshot{}
+	err := r.db.QueryRowContext(ctx, query, at).Scan(
+		&snapshot.ID,
+		&snapshot.TakenAt,
+		&snapshot.CreatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get stock snapshot: %w", err)
+	}
+
+	return snapshot, nil
+}
+
+// ListBalances returns the non-zero quantities per product and location at filter.At, replayed
+// from base (nil for the whole ledger)
+func (r *stockSnapshotRepository) ListBalances(ctx context.Context, base *domain.StockSnapshot, filter *domain.StockAsOfFilter) ([]*domain.StockAsOfLine, error) {
+	baseID, baseTakenAt := snapshotArgs(base)
+	args := []interface{}{baseID, baseTakenAt, filter.At}
+	argIndex := 4
+
+	var conditions []string
+	if filter.ProductID != nil {
+		conditions = append(conditions, fmt.Sprintf("b.product_id = $%d", argIndex))
+		args = append(args, *filter.ProductID)
+		argIndex++
+	}
+
+	if filter.LocationID != nil {
+		conditions = append(conditions, fmt.Sprintf("b.location_id = $%d", argIndex))
+		args = append(args, *filter.LocationID)
+		argIndex++
+	}
+
+	whereClause := ""
+	if len(conditions) > 0 {
+		whereClause = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	query := fmt.Sprintf(`
+		SELECT b.product_id, p.sku, p.name, b.location_id, l.code, b.quantity
+		FROM (%s) b
+		JOIN products p ON b.product_id = p.id
+		JOIN locations l ON b.location_id = l.id
+		%s
+		ORDER BY p.sku, l.code`, ledgerBalances, whereClause)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stock balances: %w", err)
+	}
+	defer rows.Close()
+
+	var lines []*domain.StockAsOfLine
+	for rows.Next() {
+		line := &domain.StockAsOfLine{}
+		err := rows.Scan(&line.ProductID, &line.SKU, &line.ProductName, &line.LocationID, &line.LocationCode, &line.Quantity)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan stock balance: %w", err)
+		}
+		lines = append(lines, line)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating stock balances: %w", err)
+	}
+
+	return lines, nil
+}
+
+func snapshotArgs(base *domain.StockSnapshot) (*int, *time.Time) {
+	if base == nil {
+		return nil, nil
+	}
+	return &base.ID, &base.TakenAt
+}
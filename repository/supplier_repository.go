@@ -0,0 +1,178 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/edwinjordan/wmsTest_Golang/domain"
+)
+
+const supplierColumns = `id, code, name, contact_name, email, phone, is_active, created_at, updated_at`
+
+type supplierRepository struct {
+	db DBTX
+}
+
+func NewSupplierRepository(db DBTX) SupplierRepository {
+	return &supplierRepository{db: db}
+}
+
+func (r *supplierRepository) Create(ctx context.Context, supplier *domain.Supplier) error {
+	query := `
+		INSERT INTO suppliers (code, name, contact_name, email, phone, is_active, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id`
+
+	now := time.Now()
+	supplier.IsActive = true
+	supplier.CreatedAt = now
+	supplier.UpdatedAt = now
+
+	err := r.db.QueryRowContext(ctx, query,
+		supplier.Code,
+		supplier.Name,
+		supplier.ContactName,
+		supplier.Email,
+		supplier.Phone,
+		supplier.IsActive,
+		supplier.CreatedAt,
+		supplier.UpdatedAt,
+	).Scan(&supplier.ID)
+
+	if err != nil {
+		return fmt.Errorf("failed to create supplier: %w", err)
+	}
+
+	return nil
+}
+
+func (r *supplierRepository) GetByID(ctx context.Context, id int) (*domain.Supplier, error) {
+	query := `SELECT ` + supplierColumns + ` FROM suppliers WHERE id = $1`
+
+	return r.getOne(ctx, query, id)
+}
+
+func (r *supplierRepository) GetByCode(ctx context.Context, code string) (*domain.Supplier, error) {
+	query := `SELECT ` + supplierColumns + ` FROM suppliers WHERE code = $1`
+
+	return r.getOne(ctx, query, code)
+}
+
+func (r *supplierRepository) getOne(ctx context.Context, query string, arg interface{}) (*domain.Supplier, error) {
+	supplier := &domain.Supplier{}
+	var contactName, email, phone sql.NullString
+
+	err := r.db.QueryRowContext(ctx, query, arg).Scan(
+		&supplier.ID,
+		&supplier.Code,
+		&supplier.Name,
+		&contactName,
+		&email,
+		&phone,
+		&supplier.IsActive,
+		&supplier.CreatedAt,
+		&supplier.UpdatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get supplier: %w", err)
+	}
+
+	supplier.ContactName = contactName.String
+	supplier.Email = email.String
+	supplier.Phone = phone.String
+
+	return supplier, nil
+}
+
+func (r *supplierRepository) Update(ctx context.Context, supplier *domain.Supplier) error {
+	query := `
+		UPDATE suppliers
+		SET name = $2, contact_name = $3, email = $4, phone = $5, is_active = $6, updated_at = $7
+		WHERE id = $1`
+
+	supplier.UpdatedAt = time.Now()
+
+	result, err := r.db.ExecContext(ctx, query,
+		supplier.ID,
+		supplier.Name,
+		supplier.ContactName,
+		supplier.Email,
+		supplier.Phone,
+		supplier.IsActive,
+		supplier.UpdatedAt,
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to update supplier: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *supplierRepository) List(ctx context.Context, limit, offset int) ([]*domain.Supplier, int, error) {
+	// Count total records
+	countQuery := `SELECT COUNT(*) FROM suppliers`
+	var total int
+	err := r.db.QueryRowContext(ctx, countQuery).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count suppliers: %w", err)
+	}
+
+	// Get paginated records
+	query := `
+		SELECT ` + supplierColumns + `
+		FROM suppliers
+		ORDER BY code
+		LIMIT $1 OFFSET $2`
+
+	rows, err := r.db.QueryContext(ctx, query, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list suppliers: %w", err)
+	}
+	defer rows.Close()
+
+	var suppliers []*domain.Supplier
+	for rows.Next() {
+		supplier := &domain.Supplier{}
+		var contactName, email, phone sql.NullString
+		err := rows.Scan(
+			&supplier.ID,
+			&supplier.Code,
+			&supplier.Name,
+			&contactName,
+			&email,
+			&phone,
+			&supplier.IsActive,
+			&supplier.CreatedAt,
+			&supplier.UpdatedAt,
+		)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan supplier: %w", err)
+		}
+		supplier.ContactName = contactName.String
+		supplier.Email = email.String
+		supplier.Phone = phone.String
+		suppliers = append(suppliers, supplier)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating suppliers: %w", err)
+	}
+
+	return suppliers, total, nil
+}
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// DBTX is the subset of *sql.DB and *sql.Tx used by the repositories, so the same
+// repository code can run directly against the pool or inside a transaction
+type DBTX interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// UnitOfWork runs a group of repository operations atomically
+type UnitOfWork interface {
+	// WithinTransaction calls fn with repositories bound to a single database transaction.
+	// The transaction is committed when fn returns nil and rolled back otherwise.
+	WithinTransaction(ctx context.Context, fn func(repos *Repositories) error) error
+}
+
+// NewRepositories builds every repository on top of the given connection or transaction
+func NewRepositories(db DBTX) *Repositories {
+	return &Repositories{
+		User:          NewUserRepository(db),
+		Product:       NewProductRepository(db),
+		ProductUnit:   NewProductUnitRepository(db),
+		Warehouse:     NewWarehouseRepository(db),
+		Location:      NewLocationRepository(db),
+		StockMovement: NewStockMovementRepository(db),
+		StockLevel:    NewStockLevelRepository(db),
+		StatusChange:  NewInventoryStatusChangeRepository(db),
+		StockSnapshot: NewStockSnapshotRepository(db),
+		Lot:           NewLotRepository(db),
+		SerialNumber:  NewSerialNumberRepository(db),
+		CostLayer:     NewCostLayerRepository(db),
+		ReorderRule:   NewReorderRuleRepository(db),
+		StockAlert:    NewStockAlertRepository(db),
+		Reservation:   NewReservationRepository(db),
+		Idempotency:   NewIdempotencyRepository(db),
+		CycleCount:    NewCycleCountRepository(db),
+		Supplier:      NewSupplierRepository(db),
+		PurchaseOrder: NewPurchaseOrderRepository(db),
+		SalesOrder:    NewSalesOrderRepository(db),
+		Wave:          NewWaveRepository(db),
+		Return:        NewReturnAuthorizationRepository(db),
+	}
+}
+
+type unitOfWork struct {
+	db *sql.DB
+}
+
+func NewUnitOfWork(db *sql.DB) UnitOfWork {
+	return &unitOfWork{db: db}
+}
+
+func (u *unitOfWork) WithinTransaction(ctx context.Context, fn func(repos *Repositories) error) error {
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err := fn(NewRepositories(tx)); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return fmt.Errorf("%w (rollback failed: %v)", err, rbErr)
+		}
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
@@ -7,13 +7,14 @@ import (
 	"time"
 
 	"github.com/edwinjordan/wmsTest_Golang/domain"
+	"github.com/lib/pq"
 )
 
 type userRepository struct {
-	db *sql.DB
+	db DBTX
 }
 
-func NewUserRepository(db *sql.DB) UserRepository {
+func NewUserRepository(db DBTX) UserRepository {
 	return &userRepository{db: db}
 }
 
@@ -46,7 +47,7 @@ func (r *userRepository) Create(ctx context.Context, user *domain.User) error {
 
 func (r *userRepository) GetByID(ctx context.Context, id int) (*domain.User, error) {
 	query := `
-		SELECT id, username, email, password, api_key, is_active, created_at, updated_at
+		SELECT id, username, email, password, api_key, is_active, permissions, created_at, updated_at
 		FROM users 
 		WHERE id = $1 AND is_active = true`
 
@@ -58,6 +59,7 @@ func (r *userRepository) GetByID(ctx context.Context, id int) (*domain.User, err
 		&user.Password,
 		&user.APIKey,
 		&user.IsActive,
+		pq.Array(&user.Permissions),
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
@@ -74,7 +76,7 @@ func (r *userRepository) GetByID(ctx context.Context, id int) (*domain.User, err
 
 func (r *userRepository) GetByUsername(ctx context.Context, username string) (*domain.User, error) {
 	query := `
-		SELECT id, username, email, password, api_key, is_active, created_at, updated_at
+		SELECT id, username, email, password, api_key, is_active, permissions, created_at, updated_at
 		FROM users 
 		WHERE username = $1 AND is_active = true`
 
@@ -86,6 +88,7 @@ func (r *userRepository) GetByUsername(ctx context.Context, username string) (*d
 		&user.Password,
 		&user.APIKey,
 		&user.IsActive,
+		pq.Array(&user.Permissions),
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
@@ -102,7 +105,7 @@ func (r *userRepository) GetByUsername(ctx context.Context, username string) (*d
 
 func (r *userRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
 	query := `
-		SELECT id, username, email, password, api_key, is_active, created_at, updated_at
+		SELECT id, username, email, password, api_key, is_active, permissions, created_at, updated_at
 		FROM users 
 		WHERE email = $1 AND is_active = true`
 
@@ -114,6 +117,7 @@ func (r *userRepository) GetByEmail(ctx context.Context, email string) (*domain.
 		&user.Password,
 		&user.APIKey,
 		&user.IsActive,
+		pq.Array(&user.Permissions),
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
@@ -130,7 +134,7 @@ func (r *userRepository) GetByEmail(ctx context.Context, email string) (*domain.
 
 func (r *userRepository) GetByAPIKey(ctx context.Context, apiKey string) (*domain.User, error) {
 	query := `
-		SELECT id, username, email, password, api_key, is_active, created_at, updated_at
+		SELECT id, username, email, password, api_key, is_active, permissions, created_at, updated_at
 		FROM users 
 		WHERE api_key = $1 AND is_active = true`
 
@@ -142,6 +146,7 @@ func (r *userRepository) GetByAPIKey(ctx context.Context, apiKey string) (*domai
 		&user.Password,
 		&user.APIKey,
 		&user.IsActive,
+		pq.Array(&user.Permissions),
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
@@ -221,7 +226,7 @@ func (r *userRepository) List(ctx context.Context, limit, offset int) ([]*domain
 
 	// Get paginated records
 	query := `
-		SELECT id, username, email, password, api_key, is_active, created_at, updated_at
+		SELECT id, username, email, password, api_key, is_active, permissions, created_at, updated_at
 		FROM users 
 		WHERE is_active = true
 		ORDER BY created_at DESC
@@ -243,6 +248,7 @@ func (r *userRepository) List(ctx context.Context, limit, offset int) ([]*domain
 			&user.Password,
 			&user.APIKey,
 			&user.IsActive,
+			pq.Array(&user.Permissions),
 			&user.CreatedAt,
 			&user.UpdatedAt,
 		)
@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/edwinjordan/wmsTest_Golang/domain"
+)
+
+const warehouseColumns = `id, code, name, address, is_active, created_at, updated_at`
+
+type warehouseRepository struct {
+	db DBTX
+}
+
+func NewWarehouseRepository(db DBTX) WarehouseRepository {
+	return &warehouseRepository{db: db}
+}
+
+func (r *warehouseRepository) Create(ctx context.Context, warehouse *domain.Warehouse) error {
+	query := `
+		INSERT INTO warehouses (code, name, address, is_active, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id`
+
+	now := time.Now()
+	warehouse.IsActive = true
+	warehouse.CreatedAt = now
+	warehouse.UpdatedAt = now
+
+	err := r.db.QueryRowContext(ctx, query,
+		warehouse.Code,
+		warehouse.Name,
+		warehouse.Address,
+		warehouse.IsActive,
+		warehouse.CreatedAt,
+		warehouse.UpdatedAt,
+	).Scan(&warehouse.ID)
+
+	if err != nil {
+		return fmt.Errorf("failed to create warehouse: %w", err)
+	}
+
+	return nil
+}
+
+func (r *warehouseRepository) GetByID(ctx context.Context, id int) (*domain.Warehouse, error) {
+	query := `SELECT ` + warehouseColumns + ` FROM warehouses WHERE id = $1`
+
+	return r.getOne(ctx, query, id)
+}
+
+func (r *warehouseRepository) GetByCode(ctx context.Context, code string) (*domain.Warehouse, error) {
+	query := `SELECT ` + warehouseColumns + ` FROM warehouses WHERE code = $1`
+
+	return r.getOne(ctx, query, code)
+}
+
+func (r *warehouseRepository) getOne(ctx context.Context, query string, arg interface{}) (*domain.Warehouse, error) {
+	warehouse := &domain.Warehouse{}
+	var address sql.NullString
+
+	err := r.db.QueryRowContext(ctx, query, arg).Scan(
+		&warehouse.ID,
+		&warehouse.Code,
+		&warehouse.Name,
+		&address,
+		&warehouse.IsActive,
+		&warehouse.CreatedAt,
+		&warehouse.UpdatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get warehouse: %w", err)
+	}
+
+	warehouse.Address = address.String
+
+	return warehouse, nil
+}
+
+func (r *warehouseRepository) Update(ctx context.Context, warehouse *domain.Warehouse) error {
+	query := `
+		UPDATE warehouses
+		SET name = $2, address = $3, is_active = $4, updated_at = $5
+		WHERE id = $1`
+
+	warehouse.UpdatedAt = time.Now()
+
+	result, err := r.db.ExecContext(ctx, query,
+		warehouse.ID,
+		warehouse.Name,
+		warehouse.Address,
+		warehouse.IsActive,
+		warehouse.UpdatedAt,
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to update warehouse: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *warehouseRepository) List(ctx context.Context, limit, offset int) ([]*domain.Warehouse, int, error) {
+	// Count total records
+	countQuery := `SELECT COUNT(*) FROM warehouses`
+	var total int
+	err := r.db.QueryRowContext(ctx, countQuery).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count warehouses: %w", err)
+	}
+
+	// Get paginated records
+	query := `
+		SELECT ` + warehouseColumns + `
+		FROM warehouses
+		ORDER BY code
+		LIMIT $1 OFFSET $2`
+
+	rows, err := r.db.QueryContext(ctx, query, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list warehouses: %w", err)
+	}
+	defer rows.Close()
+
+	var warehouses []*domain.Warehouse
+	for rows.Next() {
+		warehouse := &domain.Warehouse{}
+		var address sql.NullString
+		err := rows.Scan(
+			&warehouse.ID,
+			&warehouse.Code,
+			&warehouse.Name,
+			&address,
+			&warehouse.IsActive,
+			&warehouse.CreatedAt,
+			&warehouse.UpdatedAt,
+		)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan warehouse: %w", err)
+		}
+		warehouse.Address = address.String
+		warehouses = append(warehouses, warehouse)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating warehouses: %w", err)
+	}
+
+	return warehouses, total, nil
+}
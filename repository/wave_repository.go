@@ -0,0 +1,203 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/edwinjordan/wmsTest_Golang/domain"
+)
+
+const waveColumns = `id, warehouse_id, status, group_by, group_key, notes, created_by, created_at, updated_at, released_at, closed_at`
+
+type waveRepository struct {
+	db DBTX
+}
+
+func NewWaveRepository(db DBTX) WaveRepository {
+	return &waveRepository{db: db}
+}
+
+func (r *waveRepository) Create(ctx context.Context, wave *domain.Wave) error {
+	query := `
+		INSERT INTO waves (warehouse_id, status, group_by, group_key, notes, created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id`
+
+	now := time.Now()
+	wave.CreatedAt = now
+	wave.UpdatedAt = now
+
+	err := r.db.QueryRowContext(ctx, query,
+		wave.WarehouseID,
+		wave.Status,
+		wave.GroupBy,
+		wave.GroupKey,
+		wave.Notes,
+		wave.CreatedBy,
+		wave.CreatedAt,
+		wave.UpdatedAt,
+	).Scan(&wave.ID)
+
+	if err != nil {
+		return fmt.Errorf("failed to create wave: %w", err)
+	}
+
+	return nil
+}
+
+func (r *waveRepository) GetByID(ctx context.Context, id int) (*domain.Wave, error) {
+	query := `SELECT ` + waveColumns + ` FROM waves WHERE id = $1`
+
+	return r.getOne(ctx, query, id)
+}
+
+// GetByIDForUpdate loads a wave and locks it until the surrounding transaction ends
+func (r *waveRepository) GetByIDForUpdate(ctx context.Context, id int) (*domain.Wave, error) {
+	query := `SELECT ` + waveColumns + ` FROM waves WHERE id = $1 FOR UPDATE`
+
+	return r.getOne(ctx, query, id)
+}
+
+func (r *waveRepository) getOne(ctx context.Context, query string, id int) (*domain.Wave, error) {
+	wave := &domain.Wave{}
+	var notes sql.NullString
+
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
+		&wave.ID,
+		&wave.WarehouseID,
+		&wave.Status,
+		&wave.GroupBy,
+		&wave.GroupKey,
+		&notes,
+		&wave.CreatedBy,
+		&wave.CreatedAt,
+		&wave.UpdatedAt,
+		&wave.ReleasedAt,
+		&wave.ClosedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get wave: %w", err)
+	}
+
+	wave.Notes = notes.String
+
+	return wave, nil
+}
+
+// Update saves the status and transition times of a wave
+func (r *waveRepository) Update(ctx context.Context, wave *domain.Wave) error {
+	query := `
+		UPDATE waves
+		SET status = $2, released_at = $3, closed_at = $4, updated_at = $5
+		WHERE id = $1`
+
+	wave.UpdatedAt = time.Now()
+
+	result, err := r.db.ExecContext(ctx, query,
+		wave.ID,
+		wave.Status,
+		wave.ReleasedAt,
+		wave.ClosedAt,
+		wave.UpdatedAt,
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to update wave: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *waveRepository) List(ctx context.Context, filter *domain.WaveFilter) ([]*domain.Wave, int, error) {
+	// Build WHERE conditions
+	var conditions []string
+	var args []interface{}
+	argIndex := 1
+
+	if filter.Status != nil {
+		conditions = append(conditions, fmt.Sprintf("status = $%d", argIndex))
+		args = append(args, *filter.Status)
+		argIndex++
+	}
+
+	if filter.WarehouseID != nil {
+		conditions = append(conditions, fmt.Sprintf("warehouse_id = $%d", argIndex))
+		args = append(args, *filter.WarehouseID)
+		argIndex++
+	}
+
+	whereClause := ""
+	if len(conditions) > 0 {
+		whereClause = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	// Count total records
+	countQuery := fmt.Sprintf(`SELECT COUNT(*) FROM waves %s`, whereClause)
+
+	var total int
+	err := r.db.QueryRowContext(ctx, countQuery, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count waves: %w", err)
+	}
+
+	// Get paginated records
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM waves
+		%s
+		ORDER BY created_at DESC
+		LIMIT $%d OFFSET $%d`, waveColumns, whereClause, argIndex, argIndex+1)
+	args = append(args, filter.Limit, filter.Offset)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list waves: %w", err)
+	}
+	defer rows.Close()
+
+	var waves []*domain.Wave
+	for rows.Next() {
+		wave := &domain.Wave{}
+		var notes sql.NullString
+		err := rows.Scan(
+			&wave.ID,
+			&wave.WarehouseID,
+			&wave.Status,
+			&wave.GroupBy,
+			&wave.GroupKey,
+			&notes,
+			&wave.CreatedBy,
+			&wave.CreatedAt,
+			&wave.UpdatedAt,
+			&wave.ReleasedAt,
+			&wave.ClosedAt,
+		)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan wave: %w", err)
+		}
+		wave.Notes = notes.String
+		waves = append(waves, wave)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating waves: %w", err)
+	}
+
+	return waves, total, nil
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/edwinjordan/wmsTest_Golang/utils"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 // SeedUsers populates the users table with sample data
@@ -17,26 +18,29 @@ func SeedUsers(db *sql.DB) error {
 	}
 
 	// Define user data with API keys
+	// Admin and manager may ship stock that is on hold
+	overrides := []string{"OVERRIDE_INVENTORY_STATUS"}
 	users := []struct {
-		username string
-		email    string
-		apiKey   string
+		username    string
+		email       string
+		apiKey      string
+		permissions []string
 	}{
-		{"admin", "admin@wms.local", "wms-admin-" + uuid.New().String()[:8]},
-		{"manager", "manager@wms.local", "wms-manager-" + uuid.New().String()[:8]},
-		{"operator", "operator@wms.local", "wms-operator-" + uuid.New().String()[:8]},
-		{"viewer", "viewer@wms.local", "wms-viewer-" + uuid.New().String()[:8]},
-		{"alice", "alice@example.com", "wms-alice-" + uuid.New().String()[:8]},
-		{"bob", "bob@example.com", "wms-bob-" + uuid.New().String()[:8]},
+		{"admin", "admin@wms.local", "wms-admin-" + uuid.New().String()[:8], overrides},
+		{"manager", "manager@wms.local", "wms-manager-" + uuid.New().String()[:8], overrides},
+		{"operator", "operator@wms.local", "wms-operator-" + uuid.New().String()[:8], []string{}},
+		{"viewer", "viewer@wms.local", "wms-viewer-" + uuid.New().String()[:8], []string{}},
+		{"alice", "alice@example.com", "wms-alice-" + uuid.New().String()[:8], []string{}},
+		{"bob", "bob@example.com", "wms-bob-" + uuid.New().String()[:8], []string{}},
 	}
 
 	// Insert users with password hashes and API keys
 	for _, user := range users {
 		result, err := db.Exec(`
-			INSERT INTO users (username, email, password, api_key, is_active) 
-			VALUES ($1, $2, $3, $4, true)
+			INSERT INTO users (username, email, password, api_key, permissions, is_active) 
+			VALUES ($1, $2, $3, $4, $5, true)
 			ON CONFLICT (email) DO NOTHING;
-		`, user.username, user.email, password, user.apiKey)
+		`, user.username, user.email, password, user.apiKey, pq.Array(user.permissions))
 
 		if err != nil {
 			return fmt.Errorf("failed to insert user %s: %w", user.username, err)
@@ -0,0 +1,292 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/edwinjordan/wmsTest_Golang/domain"
+	"github.com/edwinjordan/wmsTest_Golang/repository"
+)
+
+type CycleCountService interface {
+	OpenCycleCount(ctx context.Context, req *domain.CreateCycleCountRequest, userID int) (*domain.CycleCount, error)
+	GetCycleCount(ctx context.Context, id int) (*domain.CycleCount, error)
+	ListCycleCounts(ctx context.Context, status *domain.CycleCountStatus, limit, offset int) ([]*domain.CycleCount, int, error)
+	SubmitCounts(ctx context.Context, id int, req *domain.SubmitCycleCountRequest, userID int) (*domain.CycleCount, error)
+	PostCycleCount(ctx context.Context, id int, userID int) (*domain.CycleCount, error)
+	CancelCycleCount(ctx context.Context, id int) (*domain.CycleCount, error)
+}
+
+type cycleCountService struct {
+	cycleCountRepo repository.CycleCountRepository
+	uow            repository.UnitOfWork
+}
+
+func NewCycleCountService(cycleCountRepo repository.CycleCountRepository, uow repository.UnitOfWork) CycleCountService {
+	return &cycleCountService{
+		cycleCountRepo: cycleCountRepo,
+		uow:            uow,
+	}
+}
+
+func (s *cycleCountService) OpenCycleCount(ctx context.Context, req *domain.CreateCycleCountRequest, userID int) (*domain.CycleCount, error) {
+	if len(req.LocationIDs) == 0 {
+		return nil, fmt.Errorf("%w: at least one location is required", domain.ErrInvalidInput)
+	}
+
+	count := &domain.CycleCount{
+		Status:    domain.CycleCountOpen,
+		Notes:     req.Notes,
+		CreatedBy: userID,
+	}
+
+	// Ignore duplicate location IDs
+	seen := make(map[int]bool)
+	for _, locationID := range req.LocationIDs {
+		if !seen[locationID] {
+			seen[locationID] = true
+			count.LocationIDs = append(count.LocationIDs, locationID)
+		}
+	}
+
+	err := s.uow.WithinTransaction(ctx, func(repos *repository.Repositories) error {
+		for _, locationID := range count.LocationIDs {
+			if _, err := repos.Location.GetByID(ctx, locationID); err != nil {
+				return fmt.Errorf("failed to get location: %w", err)
+			}
+		}
+
+		if err := repos.CycleCount.Create(ctx, count); err != nil {
+			return err
+		}
+
+		// Snapshot the expected quantities at the time the count is opened
+		for _, locationID := range count.LocationIDs {
+			levels, err := repos.StockLevel.ListByLocation(ctx, locationID)
+			if err != nil {
+				return fmt.Errorf("failed to get stock levels: %w", err)
+			}
+			// Lots are counted together, so one line per product
+			var lines []*domain.CycleCountLine
+			byProduct := make(map[int]*domain.CycleCountLine)
+			for _, level := range levels {
+				line, ok := byProduct[level.ProductID]
+				if !ok {
+					line = &domain.CycleCountLine{
+						CycleCountID: count.ID,
+						ProductID:    level.ProductID,
+						LocationID:   level.LocationID,
+					}
+					byProduct[level.ProductID] = line
+					lines = append(lines, line)
+				}
+				line.ExpectedQuantity += level.Quantity
+			}
+			for _, line := range lines {
+				if err := repos.CycleCount.CreateLine(ctx, line); err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetCycleCount(ctx, count.ID)
+}
+
+func (s *cycleCountService) GetCycleCount(ctx context.Context, id int) (*domain.CycleCount, error) {
+	count, err := s.cycleCountRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cycle count: %w", err)
+	}
+
+	lines, err := s.cycleCountRepo.ListLines(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cycle count lines: %w", err)
+	}
+
+	for _, line := range lines {
+		if line.CountedQuantity != nil {
+			variance := *line.CountedQuantity - line.ExpectedQuantity
+			line.Variance = &variance
+		}
+	}
+	count.Lines = lines
+
+	return count, nil
+}
+
+func (s *cycleCountService) ListCycleCounts(ctx context.Context, status *domain.CycleCountStatus, limit, offset int) ([]*domain.CycleCount, int, error) {
+	counts, total, err := s.cycleCountRepo.List(ctx, status, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list cycle counts: %w", err)
+	}
+
+	return counts, total, nil
+}
+
+func (s *cycleCountService) SubmitCounts(ctx context.Context, id int, req *domain.SubmitCycleCountRequest, userID int) (*domain.CycleCount, error) {
+	if len(req.Lines) == 0 {
+		return nil, fmt.Errorf("%w: at least one counted line is required", domain.ErrInvalidInput)
+	}
+
+	err := s.uow.WithinTransaction(ctx, func(repos *repository.Repositories) error {
+		count, err := repos.CycleCount.GetByIDForUpdate(ctx, id)
+		if err != nil {
+			return fmt.Errorf("failed to get cycle count: %w", err)
+		}
+		if count.Status != domain.CycleCountOpen {
+			return fmt.Errorf("%w: cycle count is %s", domain.ErrInvalidStatus, count.Status)
+		}
+
+		counted := make(map[int]bool)
+		for _, locationID := range count.LocationIDs {
+			counted[locationID] = true
+		}
+
+		now := time.Now()
+		for _, entry := range req.Lines {
+			if !counted[entry.LocationID] {
+				return fmt.Errorf("%w: location %d is not part of this count", domain.ErrInvalidInput, entry.LocationID)
+			}
+			if entry.CountedQuantity < 0 {
+				return fmt.Errorf("%w: counted quantity must not be negative", domain.ErrInvalidInput)
+			}
+			if _, err := repos.Product.GetByID(ctx, entry.ProductID); err != nil {
+				return fmt.Errorf("failed to get product: %w", err)
+			}
+
+			countedQuantity := entry.CountedQuantity
+			line := &domain.CycleCountLine{
+				CycleCountID:    id,
+				ProductID:       entry.ProductID,
+				LocationID:      entry.LocationID,
+				CountedQuantity: &countedQuantity,
+				CountedBy:       &userID,
+				CountedAt:       &now,
+			}
+			if err := repos.CycleCount.SaveCount(ctx, line); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetCycleCount(ctx, id)
+}
+
+// PostCycleCount writes an ADJUST movement for every line whose counted quantity differs from
+// the on-hand quantity at posting time, then closes the count
+func (s *cycleCountService) PostCycleCount(ctx context.Context, id int, userID int) (*domain.CycleCount, error) {
+	err := s.uow.WithinTransaction(ctx, func(repos *repository.Repositories) error {
+		count, err := repos.CycleCount.GetByIDForUpdate(ctx, id)
+		if err != nil {
+			return fmt.Errorf("failed to get cycle count: %w", err)
+		}
+		if count.Status != domain.CycleCountOpen {
+			return fmt.Errorf("%w: cycle count is %s", domain.ErrInvalidStatus, count.Status)
+		}
+
+		lines, err := repos.CycleCount.ListLines(ctx, id)
+		if err != nil {
+			return fmt.Errorf("failed to get cycle count lines: %w", err)
+		}
+
+		var productIDs []int
+		for _, line := range lines {
+			if line.CountedQuantity == nil {
+				return fmt.Errorf("%w: product %d at location %d has not been counted", domain.ErrInvalidInput, line.ProductID, line.LocationID)
+			}
+			productIDs = append(productIDs, line.ProductID)
+		}
+
+		if err := lockProducts(ctx, repos, productIDs...); err != nil {
+			return err
+		}
+		if err := lockLocations(ctx, repos, count.LocationIDs...); err != nil {
+			return err
+		}
+
+		for _, line := range lines {
+			onHand, err := getOnHand(ctx, repos.StockLevel, line.ProductID, line.LocationID)
+			if err != nil {
+				return err
+			}
+
+			delta := *line.CountedQuantity - onHand
+			if delta == 0 {
+				if err := repos.CycleCount.SetLineAdjustment(ctx, line.ID, 0, nil); err != nil {
+					return err
+				}
+				continue
+			}
+			if line.Product.IsSerialized {
+				return fmt.Errorf("%w: product %s is serialized and must be adjusted with serial numbers", domain.ErrInvalidInput, line.Product.SKU)
+			}
+
+			movement := &domain.StockMovement{
+				ProductID:  line.ProductID,
+				LocationID: line.LocationID,
+				UserID:     userID,
+				Type:       domain.StockADJUST,
+				Direction:  domain.DirectionIncrease,
+				Quantity:   delta,
+				Reference:  fmt.Sprintf("CC-%d", count.ID),
+				ReasonCode: domain.ReasonCountCorrection,
+				Notes:      "Cycle count adjustment",
+			}
+			if delta < 0 {
+				movement.Direction = domain.DirectionDecrease
+				movement.Quantity = -delta
+			}
+
+			if err := postStockMovement(ctx, repos, movement); err != nil {
+				return err
+			}
+			if err := repos.CycleCount.SetLineAdjustment(ctx, line.ID, delta, &movement.ID); err != nil {
+				return err
+			}
+		}
+
+		now := time.Now()
+		count.Status = domain.CycleCountPosted
+		count.PostedBy = &userID
+		count.PostedAt = &now
+
+		return repos.CycleCount.UpdateStatus(ctx, count)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetCycleCount(ctx, id)
+}
+
+func (s *cycleCountService) CancelCycleCount(ctx context.Context, id int) (*domain.CycleCount, error) {
+	err := s.uow.WithinTransaction(ctx, func(repos *repository.Repositories) error {
+		count, err := repos.CycleCount.GetByIDForUpdate(ctx, id)
+		if err != nil {
+			return fmt.Errorf("failed to get cycle count: %w", err)
+		}
+		if count.Status != domain.CycleCountOpen {
+			return fmt.Errorf("%w: cycle count is %s", domain.ErrInvalidStatus, count.Status)
+		}
+
+		count.Status = domain.CycleCountCancelled
+		return repos.CycleCount.UpdateStatus(ctx, count)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetCycleCount(ctx, id)
+}
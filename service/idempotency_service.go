@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/edwinjordan/wmsTest_Golang/domain"
+	"github.com/edwinjordan/wmsTest_Golang/repository"
+	"github.com/google/uuid"
+)
+
+const (
+	// idempotencyKeyTTL is how long a key is remembered; a key may be reused after it expires
+	idempotencyKeyTTL = 24 * time.Hour
+
+	// idempotencyLease is how long a request may hold a key without completing it. A key still in
+	// flight after that belonged to a request that died, and is released for a retry.
+	idempotencyLease = 2 * time.Minute
+
+	// idempotencyClaimAttempts bounds how often Begin tries to claim a key that keeps being
+	// released by the request holding it
+	idempotencyClaimAttempts = 3
+)
+
+type IdempotencyService interface {
+	// Begin claims a key for a request. It returns the stored record when the request is a retry
+	// whose response can be replayed, or the new in-flight claim (without a status code) when the
+	// caller should process the request and settle it with Complete or Abandon.
+	Begin(ctx context.Context, userID int, key, requestHash string) (*domain.IdempotencyRecord, error)
+	Complete(ctx context.Context, claim *domain.IdempotencyRecord, statusCode int, body []byte) error
+	Abandon(ctx context.Context, claim *domain.IdempotencyRecord) error
+}
+
+type idempotencyService struct {
+	idempotencyRepo repository.IdempotencyRepository
+}
+
+func NewIdempotencyService(idempotencyRepo repository.IdempotencyRepository) IdempotencyService {
+	return &idempotencyService{
+		idempotencyRepo: idempotencyRepo,
+	}
+}
+
+func (s *idempotencyService) Begin(ctx context.Context, userID int, key, requestHash string) (*domain.IdempotencyRecord, error) {
+	if err := s.idempotencyRepo.DeleteExpired(ctx, time.Now().Add(-idempotencyKeyTTL)); err != nil {
+		return nil, err
+	}
+
+	for attempt := 0; attempt < idempotencyClaimAttempts; attempt++ {
+		record := &domain.IdempotencyRecord{
+			UserID:      userID,
+			Key:         key,
+			RequestHash: requestHash,
+			ClaimToken:  uuid.New().String(),
+		}
+		created, err := s.idempotencyRepo.Create(ctx, record)
+		if err != nil {
+			return nil, err
+		}
+		if created {
+			return record, nil
+		}
+
+		existing, err := s.idempotencyRepo.Get(ctx, userID, key)
+		if err != nil {
+			if errors.Is(err, domain.ErrNotFound) {
+				// The original request failed and released the key in the meantime
+				continue
+			}
+			return nil, fmt.Errorf("failed to get idempotency key: %w", err)
+		}
+
+		if existing.StatusCode == nil && existing.CreatedAt.Before(time.Now().Add(-idempotencyLease)) {
+			if err := s.idempotencyRepo.DeleteStale(ctx, userID, key, time.Now().Add(-idempotencyLease)); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if existing.RequestHash != requestHash {
+			return nil, domain.ErrIdempotencyKeyReused
+		}
+		if existing.StatusCode == nil {
+			return nil, domain.ErrIdempotencyInProgress
+		}
+
+		return existing, nil
+	}
+
+	// Other requests keep claiming and releasing the key; let the client retry later
+	return nil, domain.ErrIdempotencyInProgress
+}
+
+// Complete stores the response for a claim. It fails with ErrIdempotencyClaimLost when the claim
+// outlived its lease and another request took the key over.
+func (s *idempotencyService) Complete(ctx context.Context, claim *domain.IdempotencyRecord, statusCode int, body []byte) error {
+	record := &domain.IdempotencyRecord{
+		UserID:       claim.UserID,
+		Key:          claim.Key,
+		ClaimToken:   claim.ClaimToken,
+		StatusCode:   &statusCode,
+		ResponseBody: body,
+	}
+
+	return s.idempotencyRepo.Complete(ctx, record)
+}
+
+// Abandon releases a key whose request failed without a definite outcome, so the client can retry.
+// A claim that lost the key leaves it to the request holding it now.
+func (s *idempotencyService) Abandon(ctx context.Context, claim *domain.IdempotencyRecord) error {
+	return s.idempotencyRepo.Delete(ctx, claim.UserID, claim.Key, claim.ClaimToken)
+}
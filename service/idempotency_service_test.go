@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/edwinjordan/wmsTest_Golang/domain"
+	"github.com/edwinjordan/wmsTest_Golang/repository"
+)
+
+// fakeIdempotencyRepo holds at most one record. Once it runs out of scripted Get results it
+// serves the stored record.
+type fakeIdempotencyRepo struct {
+	repository.IdempotencyRepository
+	record     *domain.IdempotencyRecord
+	getResults []error // Scripted Get errors, e.g. the key being released between Create and Get
+	creates    int
+	staleDrops int
+}
+
+func (r *fakeIdempotencyRepo) DeleteExpired(ctx context.Context, before time.Time) error {
+	return nil
+}
+
+func (r *fakeIdempotencyRepo) Create(ctx context.Context, record *domain.IdempotencyRecord) (bool, error) {
+	r.creates++
+	if r.record != nil {
+		return false, nil
+	}
+	record.CreatedAt = time.Now()
+	r.record = record
+	return true, nil
+}
+
+func (r *fakeIdempotencyRepo) Get(ctx context.Context, userID int, key string) (*domain.IdempotencyRecord, error) {
+	if len(r.getResults) > 0 {
+		err := r.getResults[0]
+		r.getResults = r.getResults[1:]
+		return nil, err
+	}
+	if r.record == nil {
+		return nil, domain.ErrNotFound
+	}
+	return r.record, nil
+}
+
+func (r *fakeIdempotencyRepo) Complete(ctx context.Context, record *domain.IdempotencyRecord) error {
+	if r.record == nil || r.record.ClaimToken != record.ClaimToken || r.record.StatusCode != nil {
+		return domain.ErrIdempotencyClaimLost
+	}
+	r.record.StatusCode = record.StatusCode
+	r.record.ResponseBody = record.ResponseBody
+	return nil
+}
+
+func (r *fakeIdempotencyRepo) Delete(ctx context.Context, userID int, key, claimToken string) error {
+	if r.record == nil || r.record.ClaimToken != claimToken || r.record.StatusCode != nil {
+		return domain.ErrIdempotencyClaimLost
+	}
+	r.record = nil
+	return nil
+}
+
+func (r *fakeIdempotencyRepo) DeleteStale(ctx context.Context, userID int, key string, before time.Time) error {
+	if r.record != nil && r.record.StatusCode == nil && r.record.CreatedAt.Before(before) {
+		r.record = nil
+		r.staleDrops++
+	}
+	return nil
+}
+
+func TestIdempotencyBegin(t *testing.T) {
+	status := 201
+	inFlight := func(age time.Duration) *domain.IdempotencyRecord {
+		return &domain.IdempotencyRecord{RequestHash: "hash", CreatedAt: time.Now().Add(-age)}
+	}
+
+	tests := []struct {
+		name           string
+		repo           *fakeIdempotencyRepo
+		hash           string
+		wantReplay     bool
+		wantErr        error
+		wantStaleDrops int
+	}{
+		{name: "new key is claimed", repo: &fakeIdempotencyRepo{}, hash: "hash"},
+		{
+			name:       "completed key is replayed",
+			repo:       &fakeIdempotencyRepo{record: &domain.IdempotencyRecord{RequestHash: "hash", StatusCode: &status, CreatedAt: time.Now()}},
+			hash:       "hash",
+			wantReplay: true,
+		},
+		{
+			name:    "key reused for another request",
+			repo:    &fakeIdempotencyRepo{record: &domain.IdempotencyRecord{RequestHash: "other", StatusCode: &status, CreatedAt: time.Now()}},
+			hash:    "hash",
+			wantErr: domain.ErrIdempotencyKeyReused,
+		},
+		{name: "request in flight", repo: &fakeIdempotencyRepo{record: inFlight(time.Second)}, hash: "hash", wantErr: domain.ErrIdempotencyInProgress},
+		{name: "request in flight past its lease is released", repo: &fakeIdempotencyRepo{record: inFlight(idempotencyLease + time.Second)}, hash: "hash", wantStaleDrops: 1},
+		{
+			name:    "key released and taken again on every attempt",
+			repo:    &fakeIdempotencyRepo{record: inFlight(time.Second), getResults: []error{domain.ErrNotFound, domain.ErrNotFound, domain.ErrNotFound}},
+			hash:    "hash",
+			wantErr: domain.ErrIdempotencyInProgress,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := NewIdempotencyService(tt.repo)
+
+			record, err := service.Begin(context.Background(), 7, "key-1", tt.hash)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Begin() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if replay := record.StatusCode != nil; replay != tt.wantReplay {
+				t.Errorf("Begin() replay = %v, want %v", replay, tt.wantReplay)
+			}
+			if !tt.wantReplay && (record.ClaimToken == "" || tt.repo.record != record) {
+				t.Errorf("Begin() did not return the new claim")
+			}
+			if tt.repo.staleDrops != tt.wantStaleDrops {
+				t.Errorf("stale records dropped = %d, want %d", tt.repo.staleDrops, tt.wantStaleDrops)
+			}
+			if tt.repo.creates > idempotencyClaimAttempts {
+				t.Errorf("claimed %d times, at most %d attempts are allowed", tt.repo.creates, idempotencyClaimAttempts)
+			}
+		})
+	}
+}
+
+func TestIdempotencyClaimLostAfterLease(t *testing.T) {
+	repo := &fakeIdempotencyRepo{}
+	service := NewIdempotencyService(repo)
+
+	first, err := service.Begin(context.Background(), 7, "key-1", "hash")
+	if err != nil {
+		t.Fatalf("Begin() error = %v", err)
+	}
+	first.CreatedAt = time.Now().Add(-idempotencyLease - time.Second)
+
+	second, err := service.Begin(context.Background(), 7, "key-1", "hash")
+	if err != nil {
+		t.Fatalf("Begin() after the lease error = %v", err)
+	}
+
+	if err := service.Complete(context.Background(), first, 201, nil); !errors.Is(err, domain.ErrIdempotencyClaimLost) {
+		t.Errorf("Complete() of the expired claim error = %v, want %v", err, domain.ErrIdempotencyClaimLost)
+	}
+	if err := service.Abandon(context.Background(), first); !errors.Is(err, domain.ErrIdempotencyClaimLost) {
+		t.Errorf("Abandon() of the expired claim error = %v, want %v", err, domain.ErrIdempotencyClaimLost)
+	}
+	if repo.record != second || repo.record.StatusCode != nil {
+		t.Fatalf("the new claim was settled by the expired one")
+	}
+
+	if err := service.Complete(context.Background(), second, 201, nil); err != nil {
+		t.Errorf("Complete() of the new claim error = %v", err)
+	}
+}
@@ -0,0 +1,313 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/edwinjordan/wmsTest_Golang/domain"
+	"github.com/edwinjordan/wmsTest_Golang/repository"
+)
+
+type InventoryStatusService interface {
+	ChangeInventoryStatus(ctx context.Context, req *domain.ChangeInventoryStatusRequest, userID int) (*domain.InventoryStatusChangeResult, error)
+	ListStatusChanges(ctx context.Context, filter *domain.InventoryStatusChangeFilter) ([]*domain.InventoryStatusChange, int, error)
+}
+
+type inventoryStatusService struct {
+	statusChangeRepo repository.InventoryStatusChangeRepository
+	uow              repository.UnitOfWork
+}
+
+func NewInventoryStatusService(statusChangeRepo repository.InventoryStatusChangeRepository, uow repository.UnitOfWork) InventoryStatusService {
+	return &inventoryStatusService{
+		statusChangeRepo: statusChangeRepo,
+		uow:              uow,
+	}
+}
+
+// ChangeInventoryStatus moves stock of a product, a location or a lot from one status to another
+// without moving it physically. Without a quantity all matching stock changes status; with one, the
+// lots are taken in FEFO order. Serial numbers follow the status of their units: part of the stock
+// of a serialized product can only change by naming the serials. Changing stock that is not
+// available needs the override permission, as it would otherwise let any user release held stock
+// for shipping. Holding available stock does not release its reservations: like a negative
+// adjustment, it leaves them short until they are released.
+func (s *inventoryStatusService) ChangeInventoryStatus(ctx context.Context, req *domain.ChangeInventoryStatusRequest, userID int) (*domain.InventoryStatusChangeResult, error) {
+	if req.ProductID == nil && req.LocationID == nil {
+		return nil, fmt.Errorf("%w: a product or a location is required", domain.ErrInvalidInput)
+	}
+	if req.LotNumber != nil && req.ProductID == nil {
+		return nil, fmt.Errorf("%w: a lot number needs a product", domain.ErrInvalidInput)
+	}
+	if req.FromStatus == "" {
+		req.FromStatus = domain.InventoryAvailable
+	}
+	if !req.FromStatus.IsValid() || !req.ToStatus.IsValid() {
+		return nil, fmt.Errorf("%w: status must be AVAILABLE, QUARANTINE, DAMAGED or ON_HOLD", domain.ErrInvalidInput)
+	}
+	if req.FromStatus == req.ToStatus {
+		return nil, fmt.Errorf("%w: stock is already %s", domain.ErrInvalidInput, req.ToStatus)
+	}
+	if req.Quantity != nil {
+		if *req.Quantity <= 0 {
+			return nil, fmt.Errorf("%w: quantity must be positive", domain.ErrInvalidInput)
+		}
+		if req.ProductID == nil {
+			return nil, fmt.Errorf("%w: a quantity needs a product", domain.ErrInvalidInput)
+		}
+	}
+	if len(req.SerialNumbers) > 0 {
+		if req.ProductID == nil {
+			return nil, fmt.Errorf("%w: serial numbers need a product", domain.ErrInvalidInput)
+		}
+		if req.Quantity != nil && *req.Quantity != len(req.SerialNumbers) {
+			return nil, fmt.Errorf("%w: quantity must match the number of serial numbers", domain.ErrInvalidInput)
+		}
+	}
+
+	result := &domain.InventoryStatusChangeResult{Changes: []*domain.InventoryStatusChange{}}
+	err := s.uow.WithinTransaction(ctx, func(repos *repository.Repositories) error {
+		if err := checkStatusRelease(ctx, repos, req.FromStatus, userID); err != nil {
+			return err
+		}
+
+		// Every stock posting locks its product first, so holding the product locks keeps the
+		// balances below from changing until the status change commits
+		if req.ProductID != nil {
+			if err := lockProducts(ctx, repos, *req.ProductID); err != nil {
+				return err
+			}
+		}
+		if req.LocationID != nil {
+			if _, err := repos.Location.GetByID(ctx, *req.LocationID); err != nil {
+				return fmt.Errorf("failed to get location: %w", err)
+			}
+		}
+
+		levels, err := repos.StockLevel.ListByStatus(ctx, req.ProductID, req.LocationID, req.LotNumber, req.FromStatus)
+		if err != nil {
+			return err
+		}
+		if req.ProductID == nil {
+			// A whole location: lock what it holds, then read the balances again under the locks.
+			// Products posted into the location in between are not locked and are left alone.
+			productIDs := distinctLevelProducts(levels)
+			if err := lockProducts(ctx, repos, productIDs...); err != nil {
+				return err
+			}
+			locked := make(map[int]bool, len(productIDs))
+			for _, productID := range productIDs {
+				locked[productID] = true
+			}
+			current, err := repos.StockLevel.ListByStatus(ctx, req.ProductID, req.LocationID, req.LotNumber, req.FromStatus)
+			if err != nil {
+				return err
+			}
+			levels = levels[:0]
+			for _, level := range current {
+				if locked[level.ProductID] {
+					levels = append(levels, level)
+				}
+			}
+		}
+
+		if len(req.SerialNumbers) > 0 {
+			return s.changeSerialStatus(ctx, repos, req, userID, result)
+		}
+
+		products := make(map[int]*domain.Product)
+		for _, productID := range distinctLevelProducts(levels) {
+			product, err := repos.Product.GetByID(ctx, productID)
+			if err != nil {
+				return fmt.Errorf("failed to get product: %w", err)
+			}
+			products[productID] = product
+		}
+
+		total := 0
+		for _, level := range levels {
+			total += level.Quantity
+		}
+		if total == 0 {
+			return fmt.Errorf("%w: no %s stock matches", domain.ErrInsufficientStock, req.FromStatus)
+		}
+		remaining := total
+		if req.Quantity != nil {
+			if *req.Quantity > total {
+				return fmt.Errorf("%w: only %d units are %s", domain.ErrInsufficientStock, total, req.FromStatus)
+			}
+			remaining = *req.Quantity
+		}
+
+		for _, level := range levels {
+			if remaining == 0 {
+				break
+			}
+			quantity := min(level.Quantity, remaining)
+
+			if product := products[level.ProductID]; product.IsSerialized {
+				// Without serial numbers there is no telling which units of the balance change
+				if quantity < level.Quantity {
+					return fmt.Errorf("%w: serial numbers are required to change part of the stock of serialized product %s", domain.ErrInvalidInput, product.SKU)
+				}
+				if err := repos.SerialNumber.UpdateStatus(ctx, level.ProductID, level.LocationID, level.LotNumber, req.FromStatus, req.ToStatus); err != nil {
+					return err
+				}
+			}
+
+			if err := recordStatusChange(ctx, repos, req, userID, level.ProductID, level.LocationID, level.LotNumber, quantity, result); err != nil {
+				return err
+			}
+			remaining -= quantity
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// changeSerialStatus changes the status of the named units of a serialized product, one change per
+// location and lot they are held in. The caller locks the product.
+func (s *inventoryStatusService) changeSerialStatus(ctx context.Context, repos *repository.Repositories, req *domain.ChangeInventoryStatusRequest, userID int, result *domain.InventoryStatusChangeResult) error {
+	product, err := repos.Product.GetByID(ctx, *req.ProductID)
+	if err != nil {
+		return fmt.Errorf("failed to get product: %w", err)
+	}
+	if !product.IsSerialized {
+		return fmt.Errorf("%w: product %s is not serialized", domain.ErrInvalidInput, product.SKU)
+	}
+
+	type balanceKey struct {
+		locationID int
+		lotNumber  string
+	}
+	var balances []balanceKey
+	counts := make(map[balanceKey]int)
+	seen := make(map[string]bool)
+	for _, serialNumber := range req.SerialNumbers {
+		if seen[serialNumber] {
+			return fmt.Errorf("%w: serial number %s is listed more than once", domain.ErrInvalidInput, serialNumber)
+		}
+		seen[serialNumber] = true
+
+		serial, err := repos.SerialNumber.GetByNumber(ctx, product.ID, serialNumber)
+		if err != nil {
+			if errors.Is(err, domain.ErrNotFound) {
+				return fmt.Errorf("%w: serial number %s is not known for %s", domain.ErrInvalidInput, serialNumber, product.SKU)
+			}
+			return err
+		}
+		if serial.LocationID == nil ||
+			(req.LocationID != nil && *serial.LocationID != *req.LocationID) ||
+			(req.LotNumber != nil && serial.LotNumber != *req.LotNumber) {
+			return fmt.Errorf("%w: serial number %s is not on hand at the given location and lot", domain.ErrInvalidInput, serialNumber)
+		}
+		if serial.Status != req.FromStatus {
+			return fmt.Errorf("%w: serial number %s is %s, not %s", domain.ErrInvalidInput, serialNumber, serial.Status, req.FromStatus)
+		}
+
+		serial.Status = req.ToStatus
+		if err := repos.SerialNumber.UpdateLocation(ctx, serial); err != nil {
+			return err
+		}
+
+		key := balanceKey{*serial.LocationID, serial.LotNumber}
+		if _, ok := counts[key]; !ok {
+			balances = append(balances, key)
+		}
+		counts[key]++
+	}
+
+	for _, key := range balances {
+		if err := recordStatusChange(ctx, repos, req, userID, product.ID, key.locationID, key.lotNumber, counts[key], result); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// recordStatusChange moves a quantity of a product lot at a location between the balances of two
+// statuses and records the change in the result
+func recordStatusChange(
+	ctx context.Context,
+	repos *repository.Repositories,
+	req *domain.ChangeInventoryStatusRequest,
+	userID int,
+	productID, locationID int,
+	lotNumber string,
+	quantity int,
+	result *domain.InventoryStatusChangeResult,
+) error {
+	if err := repos.StockLevel.AdjustQuantity(ctx, productID, locationID, lotNumber, req.FromStatus, -quantity); err != nil {
+		return fmt.Errorf("failed to update stock level: %w", err)
+	}
+	if err := repos.StockLevel.AdjustQuantity(ctx, productID, locationID, lotNumber, req.ToStatus, quantity); err != nil {
+		return fmt.Errorf("failed to update stock level: %w", err)
+	}
+
+	change := &domain.InventoryStatusChange{
+		ProductID:  productID,
+		LocationID: locationID,
+		LotNumber:  lotNumber,
+		FromStatus: req.FromStatus,
+		ToStatus:   req.ToStatus,
+		Quantity:   quantity,
+		Reference:  req.Reference,
+		Notes:      req.Notes,
+		UserID:     userID,
+	}
+	if err := repos.StatusChange.Create(ctx, change); err != nil {
+		return err
+	}
+	result.Changes = append(result.Changes, change)
+	result.Quantity += quantity
+
+	return nil
+}
+
+// distinctLevelProducts returns the IDs of the products held in the given balances
+func distinctLevelProducts(levels []*domain.StockLevel) []int {
+	var productIDs []int
+	seen := make(map[int]bool)
+	for _, level := range levels {
+		if !seen[level.ProductID] {
+			seen[level.ProductID] = true
+			productIDs = append(productIDs, level.ProductID)
+		}
+	}
+	return productIDs
+}
+
+// checkStatusRelease makes sure that quarantined, damaged or held stock is only changed by users
+// holding the override permission, like taking it out with an OUT movement
+func checkStatusRelease(ctx context.Context, repos *repository.Repositories, from domain.InventoryStatus, userID int) error {
+	if from == domain.InventoryAvailable {
+		return nil
+	}
+
+	user, err := repos.User.GetByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	if !user.HasPermission(domain.PermissionOverrideInventoryStatus) {
+		return fmt.Errorf("%w: changing %s stock needs the %s permission",
+			domain.ErrForbidden, from, domain.PermissionOverrideInventoryStatus)
+	}
+
+	return nil
+}
+
+func (s *inventoryStatusService) ListStatusChanges(ctx context.Context, filter *domain.InventoryStatusChangeFilter) ([]*domain.InventoryStatusChange, int, error) {
+	changes, total, err := s.statusChangeRepo.List(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list inventory status changes: %w", err)
+	}
+
+	return changes, total, nil
+}
@@ -11,31 +11,42 @@ import (
 type LocationService interface {
 	CreateLocation(ctx context.Context, req *domain.CreateLocationRequest) (*domain.Location, error)
 	GetLocationByID(ctx context.Context, id int) (*domain.Location, error)
-	GetLocationByCode(ctx context.Context, code string) (*domain.Location, error)
+	GetLocationByCode(ctx context.Context, warehouseID *int, code string) (*domain.Location, error)
 	UpdateLocation(ctx context.Context, id int, req *domain.UpdateLocationRequest) (*domain.Location, error)
 	DeleteLocation(ctx context.Context, id int) error
-	ListLocations(ctx context.Context, limit, offset int) ([]*domain.Location, int, error)
-	ListLocationsByZone(ctx context.Context, zone string, limit, offset int) ([]*domain.Location, int, error)
+	ListLocations(ctx context.Context, warehouseID *int, limit, offset int) ([]*domain.Location, int, error)
+	ListLocationsByZone(ctx context.Context, warehouseID *int, zone string, limit, offset int) ([]*domain.Location, int, error)
 }
 
 type locationService struct {
-	locationRepo repository.LocationRepository
+	locationRepo  repository.LocationRepository
+	warehouseRepo repository.WarehouseRepository
 }
 
-func NewLocationService(locationRepo repository.LocationRepository) LocationService {
+func NewLocationService(locationRepo repository.LocationRepository, warehouseRepo repository.WarehouseRepository) LocationService {
 	return &locationService{
-		locationRepo: locationRepo,
+		locationRepo:  locationRepo,
+		warehouseRepo: warehouseRepo,
 	}
 }
 
 func (s *locationService) CreateLocation(ctx context.Context, req *domain.CreateLocationRequest) (*domain.Location, error) {
-	// Check if code already exists
-	existingLocation, err := s.locationRepo.GetByCode(ctx, req.Code)
+	warehouse, err := getWarehouseOrDefault(ctx, s.warehouseRepo, req.WarehouseID)
+	if err != nil {
+		return nil, err
+	}
+	if !warehouse.IsActive {
+		return nil, fmt.Errorf("%w: warehouse %s is inactive", domain.ErrInvalidInput, warehouse.Code)
+	}
+
+	// Check if code already exists in the warehouse
+	existingLocation, err := s.locationRepo.GetByCode(ctx, warehouse.ID, req.Code)
 	if err == nil && existingLocation != nil {
 		return nil, domain.ErrDuplicateEntry
 	}
 
 	location := &domain.Location{
+		WarehouseID: warehouse.ID,
 		Code:        req.Code,
 		Name:        req.Name,
 		Zone:        req.Zone,
@@ -44,6 +55,8 @@ func (s *locationService) CreateLocation(ctx context.Context, req *domain.Create
 		Shelf:       req.Shelf,
 		Capacity:    req.Capacity,
 		Temperature: req.Temperature,
+		MaxWeight:   req.MaxWeight,
+		MaxVolume:   req.MaxVolume,
 	}
 
 	err = s.locationRepo.Create(ctx, location)
@@ -63,13 +76,29 @@ func (s *locationService) GetLocationByID(ctx context.Context, id int) (*domain.
 	return location, nil
 }
 
-func (s *locationService) GetLocationByCode(ctx context.Context, code string) (*domain.Location, error) {
-	location, err := s.locationRepo.GetByCode(ctx, code)
+// GetLocationByCode looks a location up by its code. Codes are unique per warehouse, so the
+// warehouse is only needed when several warehouses use the code.
+func (s *locationService) GetLocationByCode(ctx context.Context, warehouseID *int, code string) (*domain.Location, error) {
+	if warehouseID != nil {
+		location, err := s.locationRepo.GetByCode(ctx, *warehouseID, code)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get location by code: %w", err)
+		}
+		return location, nil
+	}
+
+	locations, err := s.locationRepo.ListByCode(ctx, code)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get location by code: %w", err)
 	}
+	switch len(locations) {
+	case 0:
+		return nil, domain.ErrNotFound
+	case 1:
+		return locations[0], nil
+	}
 
-	return location, nil
+	return nil, fmt.Errorf("%w: location code %s is used in %d warehouses, a warehouse ID is required", domain.ErrInvalidInput, code, len(locations))
 }
 
 func (s *locationService) UpdateLocation(ctx context.Context, id int, req *domain.UpdateLocationRequest) (*domain.Location, error) {
@@ -81,8 +110,8 @@ func (s *locationService) UpdateLocation(ctx context.Context, id int, req *domai
 
 	// Update fields if provided
 	if req.Code != nil {
-		// Check if new code already exists (excluding current location)
-		existingLocation, err := s.locationRepo.GetByCode(ctx, *req.Code)
+		// Check if new code already exists in the warehouse (excluding current location)
+		existingLocation, err := s.locationRepo.GetByCode(ctx, location.WarehouseID, *req.Code)
 		if err == nil && existingLocation != nil && existingLocation.ID != id {
 			return nil, domain.ErrDuplicateEntry
 		}
@@ -109,6 +138,12 @@ func (s *locationService) UpdateLocation(ctx context.Context, id int, req *domai
 	if req.Temperature != nil {
 		location.Temperature = req.Temperature
 	}
+	if req.MaxWeight != nil {
+		location.MaxWeight = req.MaxWeight
+	}
+	if req.MaxVolume != nil {
+		location.MaxVolume = req.MaxVolume
+	}
 	if req.IsActive != nil {
 		location.IsActive = *req.IsActive
 	}
@@ -130,8 +165,8 @@ func (s *locationService) DeleteLocation(ctx context.Context, id int) error {
 	return nil
 }
 
-func (s *locationService) ListLocations(ctx context.Context, limit, offset int) ([]*domain.Location, int, error) {
-	locations, total, err := s.locationRepo.List(ctx, limit, offset)
+func (s *locationService) ListLocations(ctx context.Context, warehouseID *int, limit, offset int) ([]*domain.Location, int, error) {
+	locations, total, err := s.locationRepo.List(ctx, warehouseID, limit, offset)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to list locations: %w", err)
 	}
@@ -139,8 +174,8 @@ func (s *locationService) ListLocations(ctx context.Context, limit, offset int)
 	return locations, total, nil
 }
 
-func (s *locationService) ListLocationsByZone(ctx context.Context, zone string, limit, offset int) ([]*domain.Location, int, error) {
-	locations, total, err := s.locationRepo.ListByZone(ctx, zone, limit, offset)
+func (s *locationService) ListLocationsByZone(ctx context.Context, warehouseID *int, zone string, limit, offset int) ([]*domain.Location, int, error) {
+	locations, total, err := s.locationRepo.ListByZone(ctx, warehouseID, zone, limit, offset)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to list locations by zone: %w", err)
 	}
@@ -0,0 +1,268 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/edwinjordan/wmsTest_Golang/domain"
+	"github.com/edwinjordan/wmsTest_Golang/repository"
+)
+
+// maxPickListLines bounds the size of a single pick list request
+const maxPickListLines = 500
+
+type PickListService interface {
+	GeneratePickList(ctx context.Context, req *domain.GeneratePickListRequest) (*domain.PickList, error)
+}
+
+type pickListService struct {
+	productRepo     repository.ProductRepository
+	productUnitRepo repository.ProductUnitRepository
+	stockLevelRepo  repository.StockLevelRepository
+	reservationRepo repository.ReservationRepository
+	warehouseRepo   repository.WarehouseRepository
+}
+
+func NewPickListService(
+	productRepo repository.ProductRepository,
+	productUnitRepo repository.ProductUnitRepository,
+	stockLevelRepo repository.StockLevelRepository,
+	reservationRepo repository.ReservationRepository,
+	warehouseRepo repository.WarehouseRepository,
+) PickListService {
+	return &pickListService{
+		productRepo:     productRepo,
+		productUnitRepo: productUnitRepo,
+		stockLevelRepo:  stockLevelRepo,
+		reservationRepo: reservationRepo,
+		warehouseRepo:   warehouseRepo,
+	}
+}
+
+// GeneratePickList allocates the requested lines from available stock of one warehouse and orders
+// the stops along the walking path. Nothing is reserved or moved; lines that cannot be allocated in
+// full are reported as shortages.
+func (s *pickListService) GeneratePickList(ctx context.Context, req *domain.GeneratePickListRequest) (*domain.PickList, error) {
+	if len(req.Lines) == 0 {
+		return nil, fmt.Errorf("%w: at least one line is required", domain.ErrInvalidInput)
+	}
+	if len(req.Lines) > maxPickListLines {
+		return nil, fmt.Errorf("%w: a pick list can have at most %d lines", domain.ErrInvalidInput, maxPickListLines)
+	}
+
+	warehouse, err := getWarehouseOrDefault(ctx, s.warehouseRepo, req.WarehouseID)
+	if err != nil {
+		return nil, err
+	}
+
+	pickList, err := allocatePickList(ctx, s.productRepo, s.productUnitRepo, s.stockLevelRepo, s.reservationRepo, warehouse.ID, req.Lines)
+	if err != nil {
+		return nil, err
+	}
+	pickList.Reference = strings.TrimSpace(req.Reference)
+
+	return pickList, nil
+}
+
+// pickCandidate is the pickable stock of a product at one location, lots in FEFO order
+type pickCandidate struct {
+	location  *domain.Location
+	lots      []*domain.StockLevel
+	available int
+}
+
+// allocatePickList allocates lines from the available stock of the active locations of a warehouse:
+// on-hand minus reserved, without expired lots. Locations holding the earliest expiring lot are
+// used first; otherwise a location that can fill the whole line is preferred, so the line takes one
+// stop. Remaining ties follow the walking path. Within a location lots are taken FEFO, the same way
+// stock OUT allocates them.
+func allocatePickList(
+	ctx context.Context,
+	productRepo repository.ProductRepository,
+	productUnitRepo repository.ProductUnitRepository,
+	stockLevelRepo repository.StockLevelRepository,
+	reservationRepo repository.ReservationRepository,
+	warehouseID int,
+	lines []*domain.PickListLineRequest,
+) (*domain.PickList, error) {
+	pickList := &domain.PickList{
+		WarehouseID: warehouseID,
+		GeneratedAt: time.Now(),
+		Complete:    true,
+		Stops:       []*domain.PickStop{},
+		Shortages:   []*domain.PickShortage{},
+	}
+
+	products := make(map[int]*domain.Product)
+	candidates := make(map[int][]*pickCandidate)
+	for i, line := range lines {
+		lineNumber := i + 1
+		if line.ProductID <= 0 {
+			return nil, fmt.Errorf("%w: line %d: valid product ID is required", domain.ErrInvalidInput, lineNumber)
+		}
+		if line.Quantity <= 0 {
+			return nil, fmt.Errorf("%w: line %d: quantity must be greater than 0", domain.ErrInvalidInput, lineNumber)
+		}
+
+		product, ok := products[line.ProductID]
+		if !ok {
+			var err error
+			product, err = productRepo.GetByID(ctx, line.ProductID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get product: %w", err)
+			}
+			if !product.IsActive {
+				return nil, fmt.Errorf("%w: line %d: product %s is inactive", domain.ErrInvalidInput, lineNumber, product.SKU)
+			}
+			units, err := productUnitRepo.ListByProduct(ctx, product.ID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get product units: %w", err)
+			}
+			product.Units = units
+			products[product.ID] = product
+
+			candidates[product.ID], err = listPickCandidates(ctx, stockLevelRepo, reservationRepo, warehouseID, product.ID)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		factor, err := product.UnitFactor(line.UOM)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+		quantity := line.Quantity * factor
+
+		// Candidates are shared by lines of the same product, so stock is never allocated twice
+		productCandidates := candidates[product.ID]
+		sort.SliceStable(productCandidates, func(i, j int) bool {
+			a, b := productCandidates[i], productCandidates[j]
+			if c := compareExpiry(earliestExpiry(a.lots), earliestExpiry(b.lots)); c != 0 {
+				return c < 0
+			}
+			if aFills, bFills := a.available >= quantity, b.available >= quantity; aFills != bFills {
+				return aFills
+			}
+			return domain.ComparePickPath(a.location, b.location) < 0
+		})
+
+		remaining := quantity
+		for _, candidate := range productCandidates {
+			if remaining == 0 {
+				break
+			}
+			for _, lot := range candidate.lots {
+				if remaining == 0 || candidate.available == 0 {
+					break
+				}
+				take := min(lot.Quantity, candidate.available, remaining)
+				if take == 0 {
+					continue
+				}
+				pickList.Stops = append(pickList.Stops, &domain.PickStop{
+					LineNumber:  lineNumber,
+					Location:    candidate.location,
+					ProductID:   product.ID,
+					SKU:         product.SKU,
+					ProductName: product.Name,
+					LotNumber:   lot.LotNumber,
+					ExpiryDate:  lot.ExpiryDate,
+					Quantity:    take,
+					Unit:        product.BaseUnit,
+				})
+				lot.Quantity -= take
+				candidate.available -= take
+				remaining -= take
+			}
+		}
+
+		pickList.TotalQuantity += quantity - remaining
+		if remaining > 0 {
+			pickList.Complete = false
+			pickList.Shortages = append(pickList.Shortages, &domain.PickShortage{
+				LineNumber:        lineNumber,
+				ProductID:         product.ID,
+				SKU:               product.SKU,
+				RequestedQuantity: quantity,
+				AllocatedQuantity: quantity - remaining,
+				ShortQuantity:     remaining,
+			})
+		}
+	}
+
+	domain.SequencePickStops(pickList.Stops)
+
+	return pickList, nil
+}
+
+// listPickCandidates returns the locations of a warehouse a product can be picked from with their
+// pickable quantity. Stock levels come back with the lots of each location in FEFO order.
+func listPickCandidates(ctx context.Context, stockLevelRepo repository.StockLevelRepository, reservationRepo repository.ReservationRepository, warehouseID, productID int) ([]*pickCandidate, error) {
+	levels, err := stockLevelRepo.ListByProduct(ctx, productID, &warehouseID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stock levels: %w", err)
+	}
+	reserved, err := reservationRepo.GetReservedByProduct(ctx, productID, &warehouseID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reserved stock: %w", err)
+	}
+
+	var candidates []*pickCandidate
+	byLocation := make(map[int]*pickCandidate)
+	onHand := make(map[int]int)
+	for _, level := range levels {
+		if !level.Location.IsActive {
+			continue
+		}
+		// Held stock cannot be picked, and reservations only ever cover available stock
+		if level.Status != domain.InventoryAvailable {
+			continue
+		}
+		onHand[level.LocationID] += level.Quantity
+		candidate, ok := byLocation[level.LocationID]
+		if !ok {
+			candidate = &pickCandidate{location: level.Location}
+			byLocation[level.LocationID] = candidate
+			candidates = append(candidates, candidate)
+		}
+		if isExpired(level.ExpiryDate) {
+			continue
+		}
+		candidate.lots = append(candidate.lots, level)
+		candidate.available += level.Quantity
+	}
+
+	for _, candidate := range candidates {
+		if free := onHand[candidate.location.ID] - reserved[candidate.location.ID]; free < candidate.available {
+			candidate.available = max(free, 0)
+		}
+	}
+
+	return candidates, nil
+}
+
+// earliestExpiry returns the expiry date of the first lot that expires, nil when none do
+func earliestExpiry(lots []*domain.StockLevel) *time.Time {
+	for _, lot := range lots {
+		if lot.Quantity > 0 && lot.ExpiryDate != nil {
+			return lot.ExpiryDate
+		}
+	}
+	return nil
+}
+
+// compareExpiry orders expiry dates earliest first, with lots that do not expire last
+func compareExpiry(a, b *time.Time) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return 1
+	case b == nil:
+		return -1
+	}
+	return a.Compare(*b)
+}
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/edwinjordan/wmsTest_Golang/domain"
+)
+
+func TestCompareExpiry(t *testing.T) {
+	early := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	late := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		a, b *time.Time
+		want int
+	}{
+		{"both without expiry", nil, nil, 0},
+		{"no expiry goes last", nil, &early, 1},
+		{"expiry goes first", &early, nil, -1},
+		{"earliest first", &early, &late, -1},
+		{"later last", &late, &early, 1},
+		{"same date", &early, &early, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := compareExpiry(tt.a, tt.b); got != tt.want {
+				t.Errorf("compareExpiry() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEarliestExpiry(t *testing.T) {
+	early := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	late := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		lots []*domain.StockLevel
+		want *time.Time
+	}{
+		{"no lots", nil, nil},
+		{"lots without expiry", []*domain.StockLevel{{Quantity: 5}}, nil},
+		{
+			name: "first lot with stock in FEFO order",
+			lots: []*domain.StockLevel{{Quantity: 5, ExpiryDate: &early}, {Quantity: 5, ExpiryDate: &late}},
+			want: &early,
+		},
+		{
+			name: "lots picked empty are skipped",
+			lots: []*domain.StockLevel{{Quantity: 0, ExpiryDate: &early}, {Quantity: 3, ExpiryDate: &late}},
+			want: &late,
+		},
+		{
+			name: "lots without expiry are skipped",
+			lots: []*domain.StockLevel{{Quantity: 4}, {Quantity: 3, ExpiryDate: &late}},
+			want: &late,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := earliestExpiry(tt.lots)
+			if (got == nil) != (tt.want == nil) || (got != nil && !got.Equal(*tt.want)) {
+				t.Errorf("earliestExpiry() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -19,12 +19,16 @@ type ProductService interface {
 }
 
 type productService struct {
-	productRepo repository.ProductRepository
+	productRepo     repository.ProductRepository
+	productUnitRepo repository.ProductUnitRepository
+	uow             repository.UnitOfWork
 }
 
-func NewProductService(productRepo repository.ProductRepository) ProductService {
+func NewProductService(productRepo repository.ProductRepository, productUnitRepo repository.ProductUnitRepository, uow repository.UnitOfWork) ProductService {
 	return &productService{
-		productRepo: productRepo,
+		productRepo:     productRepo,
+		productUnitRepo: productUnitRepo,
+		uow:             uow,
 	}
 }
 
@@ -35,20 +39,56 @@ func (s *productService) CreateProduct(ctx context.Context, req *domain.CreatePr
 		return nil, domain.ErrDuplicateEntry
 	}
 
+	if req.CostMethod == "" {
+		req.CostMethod = domain.CostFIFO
+	}
+	if !req.CostMethod.IsValid() {
+		return nil, fmt.Errorf("%w: cost method must be FIFO or AVERAGE", domain.ErrInvalidInput)
+	}
+
+	baseUnit := domain.NormalizeUOM(req.BaseUnit)
+	if baseUnit == "" {
+		baseUnit = domain.DefaultBaseUnit
+	}
+	units, err := validateUnits(baseUnit, req.Units)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateTemperatureRange(req.MinTemperature, req.MaxTemperature); err != nil {
+		return nil, err
+	}
+	length, width, height, err := domain.ParseDimensions(req.Dimensions)
+	if err != nil {
+		return nil, err
+	}
+
 	product := &domain.Product{
-		SKU:         req.SKU,
-		Name:        req.Name,
-		Description: req.Description,
-		Price:       req.Price,
-		Weight:      req.Weight,
-		Dimensions:  req.Dimensions,
-		Category:    req.Category,
-		Quantity:    req.Quantity,
+		SKU:            req.SKU,
+		Name:           req.Name,
+		Description:    req.Description,
+		Price:          req.Price,
+		Weight:         req.Weight,
+		Dimensions:     req.Dimensions,
+		Length:         length,
+		Width:          width,
+		Height:         height,
+		Category:       req.Category,
+		IsSerialized:   req.IsSerialized,
+		CostMethod:     req.CostMethod,
+		BaseUnit:       baseUnit,
+		Units:          units,
+		MinTemperature: req.MinTemperature,
+		MaxTemperature: req.MaxTemperature,
 	}
 
-	err = s.productRepo.Create(ctx, product)
+	err = s.uow.WithinTransaction(ctx, func(repos *repository.Repositories) error {
+		if err := repos.Product.Create(ctx, product); err != nil {
+			return fmt.Errorf("failed to create product: %w", err)
+		}
+		return repos.ProductUnit.Replace(ctx, product.ID, units)
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to create product: %w", err)
+		return nil, err
 	}
 
 	return product, nil
@@ -60,6 +100,10 @@ func (s *productService) GetProductByID(ctx context.Context, id int) (*domain.Pr
 		return nil, fmt.Errorf("failed to get product: %w", err)
 	}
 
+	if err := s.loadUnits(ctx, product); err != nil {
+		return nil, err
+	}
+
 	return product, nil
 }
 
@@ -69,22 +113,62 @@ func (s *productService) GetProductBySKU(ctx context.Context, sku string) (*doma
 		return nil, fmt.Errorf("failed to get product by SKU: %w", err)
 	}
 
+	if err := s.loadUnits(ctx, product); err != nil {
+		return nil, err
+	}
+
 	return product, nil
 }
 
 func (s *productService) UpdateProduct(ctx context.Context, id int, req *domain.UpdateProductRequest) (*domain.Product, error) {
-	// Get existing product
-	product, err := s.productRepo.GetByID(ctx, id)
+	var product *domain.Product
+	err := s.uow.WithinTransaction(ctx, func(repos *repository.Repositories) error {
+		// Every stock posting locks its product first, so the stock checked below stays put until
+		// the update commits
+		current, err := repos.Product.GetByIDForUpdate(ctx, id)
+		if err != nil {
+			return fmt.Errorf("failed to get product: %w", err)
+		}
+		units, err := repos.ProductUnit.ListByProduct(ctx, id)
+		if err != nil {
+			return fmt.Errorf("failed to get product units: %w", err)
+		}
+		current.Units = units
+
+		// The balances, not the stored product quantity, tell whether the product has stock
+		onHand, err := repos.StockLevel.GetProductTotal(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := applyProductUpdate(ctx, repos.Product, current, req, onHand); err != nil {
+			return err
+		}
+
+		if err := repos.Product.Update(ctx, current); err != nil {
+			return fmt.Errorf("failed to update product: %w", err)
+		}
+		if err := repos.ProductUnit.Replace(ctx, current.ID, current.Units); err != nil {
+			return err
+		}
+		product = current
+		return nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to get product: %w", err)
+		return nil, err
 	}
 
+	return product, nil
+}
+
+// applyProductUpdate applies the fields given in the request. Serial tracking, cost method and
+// base unit can only change while the product has no stock on hand.
+func applyProductUpdate(ctx context.Context, productRepo repository.ProductRepository, product *domain.Product, req *domain.UpdateProductRequest, onHand int) error {
 	// Update fields if provided
 	if req.SKU != nil {
 		// Check if new SKU already exists (excluding current product)
-		existingProduct, err := s.productRepo.GetBySKU(ctx, *req.SKU)
-		if err == nil && existingProduct != nil && existingProduct.ID != id {
-			return nil, domain.ErrDuplicateEntry
+		existingProduct, err := productRepo.GetBySKU(ctx, *req.SKU)
+		if err == nil && existingProduct != nil && existingProduct.ID != product.ID {
+			return domain.ErrDuplicateEntry
 		}
 		product.SKU = *req.SKU
 	}
@@ -101,7 +185,12 @@ func (s *productService) UpdateProduct(ctx context.Context, id int, req *domain.
 		product.Weight = *req.Weight
 	}
 	if req.Dimensions != nil {
+		length, width, height, err := domain.ParseDimensions(*req.Dimensions)
+		if err != nil {
+			return err
+		}
 		product.Dimensions = *req.Dimensions
+		product.Length, product.Width, product.Height = length, width, height
 	}
 	if req.Category != nil {
 		product.Category = *req.Category
@@ -110,16 +199,55 @@ func (s *productService) UpdateProduct(ctx context.Context, id int, req *domain.
 		product.IsActive = *req.IsActive
 	}
 
-	if req.Quantity != nil {
-		product.Quantity = *req.Quantity
+	if req.IsSerialized != nil && *req.IsSerialized != product.IsSerialized {
+		// Stock on hand would otherwise be left with no (or orphaned) serial numbers
+		if onHand != 0 {
+			return fmt.Errorf("%w: serial tracking can only be changed while the product has no stock", domain.ErrInvalidInput)
+		}
+		product.IsSerialized = *req.IsSerialized
+	}
+	if req.CostMethod != nil && *req.CostMethod != product.CostMethod {
+		if !req.CostMethod.IsValid() {
+			return fmt.Errorf("%w: cost method must be FIFO or AVERAGE", domain.ErrInvalidInput)
+		}
+		// Layers on hand were built for the old method
+		if onHand != 0 {
+			return fmt.Errorf("%w: cost method can only be changed while the product has no stock", domain.ErrInvalidInput)
+		}
+		product.CostMethod = *req.CostMethod
+	}
+	if req.BaseUnit != nil && domain.NormalizeUOM(*req.BaseUnit) != product.BaseUnit {
+		baseUnit := domain.NormalizeUOM(*req.BaseUnit)
+		if baseUnit == "" {
+			return fmt.Errorf("%w: base unit must not be empty", domain.ErrInvalidInput)
+		}
+		// Stored quantities are counted in the old base unit
+		if onHand != 0 {
+			return fmt.Errorf("%w: base unit can only be changed while the product has no stock", domain.ErrInvalidInput)
+		}
+		product.BaseUnit = baseUnit
+	}
+	if req.MinTemperature != nil {
+		product.MinTemperature = req.MinTemperature
+	}
+	if req.MaxTemperature != nil {
+		product.MaxTemperature = req.MaxTemperature
+	}
+	if err := validateTemperatureRange(product.MinTemperature, product.MaxTemperature); err != nil {
+		return err
 	}
 
-	err = s.productRepo.Update(ctx, product)
+	units := product.Units
+	if req.Units != nil {
+		units = req.Units
+	}
+	units, err := validateUnits(product.BaseUnit, units)
 	if err != nil {
-		return nil, fmt.Errorf("failed to update product: %w", err)
+		return err
 	}
+	product.Units = units
 
-	return product, nil
+	return nil
 }
 
 func (s *productService) DeleteProduct(ctx context.Context, id int) error {
@@ -148,3 +276,53 @@ func (s *productService) SearchProducts(ctx context.Context, query string, limit
 
 	return products, total, nil
 }
+
+func (s *productService) loadUnits(ctx context.Context, product *domain.Product) error {
+	units, err := s.productUnitRepo.ListByProduct(ctx, product.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get product units: %w", err)
+	}
+	product.Units = units
+
+	return nil
+}
+
+// validateUnits normalizes the pack sizes of a product and checks that every code is unique,
+// differs from the base unit and holds more than one base unit
+func validateUnits(baseUnit string, units []*domain.ProductUnit) ([]*domain.ProductUnit, error) {
+	if len(baseUnit) > 20 {
+		return nil, fmt.Errorf("%w: base unit must be at most 20 characters", domain.ErrInvalidInput)
+	}
+
+	seen := make(map[string]bool)
+	normalized := make([]*domain.ProductUnit, 0, len(units))
+	for _, unit := range units {
+		if unit == nil {
+			return nil, fmt.Errorf("%w: unit must not be empty", domain.ErrInvalidInput)
+		}
+		code := domain.NormalizeUOM(unit.Code)
+		if code == "" || len(code) > 20 {
+			return nil, fmt.Errorf("%w: unit code is required and must be at most 20 characters", domain.ErrInvalidInput)
+		}
+		if code == baseUnit {
+			return nil, fmt.Errorf("%w: unit %s is the base unit", domain.ErrInvalidInput, code)
+		}
+		if seen[code] {
+			return nil, fmt.Errorf("%w: unit %s is listed more than once", domain.ErrInvalidInput, code)
+		}
+		if unit.Factor <= 1 {
+			return nil, fmt.Errorf("%w: unit %s must hold more than one %s", domain.ErrInvalidInput, code, baseUnit)
+		}
+		seen[code] = true
+		normalized = append(normalized, &domain.ProductUnit{Code: code, Factor: unit.Factor})
+	}
+
+	return normalized, nil
+}
+
+func validateTemperatureRange(minTemperature, maxTemperature *float64) error {
+	if minTemperature != nil && maxTemperature != nil && *minTemperature > *maxTemperature {
+		return fmt.Errorf("%w: minimum temperature must not exceed the maximum temperature", domain.ErrInvalidInput)
+	}
+	return nil
+}
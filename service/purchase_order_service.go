@@ -0,0 +1,507 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/edwinjordan/wmsTest_Golang/domain"
+	"github.com/edwinjordan/wmsTest_Golang/repository"
+)
+
+// maxOverReceiptTolerance caps the percentage a purchase order line may be over-received
+const maxOverReceiptTolerance = 100
+
+type PurchaseOrderService interface {
+	CreateSupplier(ctx context.Context, req *domain.CreateSupplierRequest) (*domain.Supplier, error)
+	GetSupplier(ctx context.Context, id int) (*domain.Supplier, error)
+	UpdateSupplier(ctx context.Context, id int, req *domain.UpdateSupplierRequest) (*domain.Supplier, error)
+	ListSuppliers(ctx context.Context, limit, offset int) ([]*domain.Supplier, int, error)
+	CreatePurchaseOrder(ctx context.Context, req *domain.CreatePurchaseOrderRequest, userID int) (*domain.PurchaseOrder, error)
+	GetPurchaseOrder(ctx context.Context, id int) (*domain.PurchaseOrder, error)
+	ListPurchaseOrders(ctx context.Context, filter *domain.PurchaseOrderFilter) ([]*domain.PurchaseOrder, int, error)
+	ReceivePurchaseOrder(ctx context.Context, id int, req *domain.ReceivePurchaseOrderRequest, userID int) (*domain.PurchaseOrderReceipt, error)
+	ClosePurchaseOrder(ctx context.Context, id int) (*domain.PurchaseOrder, error)
+	CancelPurchaseOrder(ctx context.Context, id int) (*domain.PurchaseOrder, error)
+	ListReceipts(ctx context.Context, id int, limit, offset int) ([]*domain.StockMovement, int, error)
+}
+
+type purchaseOrderService struct {
+	supplierRepo      repository.SupplierRepository
+	purchaseOrderRepo repository.PurchaseOrderRepository
+	productRepo       repository.ProductRepository
+	productUnitRepo   repository.ProductUnitRepository
+	stockMovementRepo repository.StockMovementRepository
+	uow               repository.UnitOfWork
+}
+
+func NewPurchaseOrderService(
+	supplierRepo repository.SupplierRepository,
+	purchaseOrderRepo repository.PurchaseOrderRepository,
+	productRepo repository.ProductRepository,
+	productUnitRepo repository.ProductUnitRepository,
+	stockMovementRepo repository.StockMovementRepository,
+	uow repository.UnitOfWork,
+) PurchaseOrderService {
+	return &purchaseOrderService{
+		supplierRepo:      supplierRepo,
+		purchaseOrderRepo: purchaseOrderRepo,
+		productRepo:       productRepo,
+		productUnitRepo:   productUnitRepo,
+		stockMovementRepo: stockMovementRepo,
+		uow:               uow,
+	}
+}
+
+func (s *purchaseOrderService) CreateSupplier(ctx context.Context, req *domain.CreateSupplierRequest) (*domain.Supplier, error) {
+	code := strings.TrimSpace(req.Code)
+	if code == "" || strings.TrimSpace(req.Name) == "" {
+		return nil, fmt.Errorf("%w: supplier code and name are required", domain.ErrInvalidInput)
+	}
+
+	// Check if code already exists
+	existing, err := s.supplierRepo.GetByCode(ctx, code)
+	if err == nil && existing != nil {
+		return nil, domain.ErrDuplicateEntry
+	}
+
+	supplier := &domain.Supplier{
+		Code:        code,
+		Name:        req.Name,
+		ContactName: req.ContactName,
+		Email:       req.Email,
+		Phone:       req.Phone,
+	}
+
+	if err := s.supplierRepo.Create(ctx, supplier); err != nil {
+		return nil, fmt.Errorf("failed to create supplier: %w", err)
+	}
+
+	return supplier, nil
+}
+
+func (s *purchaseOrderService) GetSupplier(ctx context.Context, id int) (*domain.Supplier, error) {
+	supplier, err := s.supplierRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get supplier: %w", err)
+	}
+
+	return supplier, nil
+}
+
+func (s *purchaseOrderService) UpdateSupplier(ctx context.Context, id int, req *domain.UpdateSupplierRequest) (*domain.Supplier, error) {
+	supplier, err := s.supplierRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get supplier: %w", err)
+	}
+
+	// Update fields if provided
+	if req.Name != nil {
+		if strings.TrimSpace(*req.Name) == "" {
+			return nil, fmt.Errorf("%w: supplier name is required", domain.ErrInvalidInput)
+		}
+		supplier.Name = *req.Name
+	}
+	if req.ContactName != nil {
+		supplier.ContactName = *req.ContactName
+	}
+	if req.Email != nil {
+		supplier.Email = *req.Email
+	}
+	if req.Phone != nil {
+		supplier.Phone = *req.Phone
+	}
+	if req.IsActive != nil {
+		supplier.IsActive = *req.IsActive
+	}
+
+	if err := s.supplierRepo.Update(ctx, supplier); err != nil {
+		return nil, fmt.Errorf("failed to update supplier: %w", err)
+	}
+
+	return supplier, nil
+}
+
+func (s *purchaseOrderService) ListSuppliers(ctx context.Context, limit, offset int) ([]*domain.Supplier, int, error) {
+	suppliers, total, err := s.supplierRepo.List(ctx, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list suppliers: %w", err)
+	}
+
+	return suppliers, total, nil
+}
+
+// CreatePurchaseOrder records the expected quantities of an order placed with an active supplier.
+// Quantities and unit costs given in a pack size are stored per base unit.
+func (s *purchaseOrderService) CreatePurchaseOrder(ctx context.Context, req *domain.CreatePurchaseOrderRequest, userID int) (*domain.PurchaseOrder, error) {
+	poNumber := strings.TrimSpace(req.PONumber)
+	if poNumber == "" {
+		return nil, fmt.Errorf("%w: PO number is required", domain.ErrInvalidInput)
+	}
+	if len(req.Lines) == 0 {
+		return nil, fmt.Errorf("%w: at least one line is required", domain.ErrInvalidInput)
+	}
+	if req.OverReceiptTolerance < 0 || req.OverReceiptTolerance > maxOverReceiptTolerance {
+		return nil, fmt.Errorf("%w: over-receipt tolerance must be between 0 and %d percent", domain.ErrInvalidInput, maxOverReceiptTolerance)
+	}
+
+	existing, err := s.purchaseOrderRepo.GetByNumber(ctx, poNumber)
+	if err == nil && existing != nil {
+		return nil, domain.ErrDuplicateEntry
+	}
+
+	supplier, err := s.supplierRepo.GetByID(ctx, req.SupplierID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get supplier: %w", err)
+	}
+	if !supplier.IsActive {
+		return nil, fmt.Errorf("%w: supplier %s is inactive", domain.ErrInvalidInput, supplier.Code)
+	}
+
+	order := &domain.PurchaseOrder{
+		PONumber:             poNumber,
+		SupplierID:           supplier.ID,
+		Status:               domain.PurchaseOrderOpen,
+		OverReceiptTolerance: req.OverReceiptTolerance,
+		Notes:                req.Notes,
+		CreatedBy:            userID,
+		Supplier:             supplier,
+	}
+	if req.ExpectedDate != "" {
+		expected, err := time.Parse("2006-01-02", req.ExpectedDate)
+		if err != nil {
+			return nil, fmt.Errorf("%w: expected date must be in YYYY-MM-DD format", domain.ErrInvalidInput)
+		}
+		order.ExpectedDate = &expected
+	}
+
+	for i, lineReq := range req.Lines {
+		if lineReq.Quantity <= 0 {
+			return nil, fmt.Errorf("%w: line %d: quantity must be greater than 0", domain.ErrInvalidInput, i+1)
+		}
+		if lineReq.UnitCost != nil && *lineReq.UnitCost < 0 {
+			return nil, fmt.Errorf("%w: line %d: unit cost must not be negative", domain.ErrInvalidInput, i+1)
+		}
+
+		product, err := s.getProductWithUnits(ctx, lineReq.ProductID)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		if !product.IsActive {
+			return nil, fmt.Errorf("%w: line %d: product %s is inactive", domain.ErrInvalidInput, i+1, product.SKU)
+		}
+		factor, err := product.UnitFactor(lineReq.UOM)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+
+		line := &domain.PurchaseOrderLine{
+			LineNumber:      i + 1,
+			ProductID:       product.ID,
+			OrderedQuantity: lineReq.Quantity * factor,
+			Product:         product,
+		}
+		if lineReq.UnitCost != nil {
+			unitCost := *lineReq.UnitCost / float64(factor)
+			line.UnitCost = &unitCost
+		}
+		order.Lines = append(order.Lines, line)
+	}
+
+	err = s.uow.WithinTransaction(ctx, func(repos *repository.Repositories) error {
+		if err := repos.PurchaseOrder.Create(ctx, order); err != nil {
+			return err
+		}
+		for _, line := range order.Lines {
+			line.PurchaseOrderID = order.ID
+			if err := repos.PurchaseOrder.CreateLine(ctx, line); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, line := range order.Lines {
+		line.SetReceiptStatus(order.Status)
+	}
+
+	return order, nil
+}
+
+func (s *purchaseOrderService) GetPurchaseOrder(ctx context.Context, id int) (*domain.PurchaseOrder, error) {
+	order, err := s.purchaseOrderRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get purchase order: %w", err)
+	}
+
+	if err := loadPurchaseOrderRelations(ctx, s.supplierRepo, s.purchaseOrderRepo, order); err != nil {
+		return nil, err
+	}
+
+	return order, nil
+}
+
+func (s *purchaseOrderService) ListPurchaseOrders(ctx context.Context, filter *domain.PurchaseOrderFilter) ([]*domain.PurchaseOrder, int, error) {
+	orders, total, err := s.purchaseOrderRepo.List(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list purchase orders: %w", err)
+	}
+
+	return orders, total, nil
+}
+
+// ReceivePurchaseOrder posts an IN movement for every received line, at the line's unit cost, and
+// adds it to the received quantity. Lines may be received in part, and above the ordered
+// quantity within the order's over-receipt tolerance. The order closes by itself once every line
+// is received in full. The whole delivery succeeds or fails together; a failing line is reported
+// as a *BatchLineError.
+func (s *purchaseOrderService) ReceivePurchaseOrder(ctx context.Context, id int, req *domain.ReceivePurchaseOrderRequest, userID int) (*domain.PurchaseOrderReceipt, error) {
+	if len(req.Lines) == 0 {
+		return nil, fmt.Errorf("%w: at least one line is required", domain.ErrInvalidInput)
+	}
+
+	receipt := &domain.PurchaseOrderReceipt{Movements: []*domain.StockMovement{}}
+	err := s.uow.WithinTransaction(ctx, func(repos *repository.Repositories) error {
+		order, err := repos.PurchaseOrder.GetByIDForUpdate(ctx, id)
+		if err != nil {
+			return fmt.Errorf("failed to get purchase order: %w", err)
+		}
+		if !order.Status.IsReceivable() {
+			return fmt.Errorf("%w: purchase order is %s", domain.ErrInvalidStatus, order.Status)
+		}
+
+		lines, err := repos.PurchaseOrder.ListLines(ctx, order.ID)
+		if err != nil {
+			return err
+		}
+		byID := make(map[int]*domain.PurchaseOrderLine, len(lines))
+		for _, line := range lines {
+			byID[line.ID] = line
+		}
+
+		reference := strings.TrimSpace(req.Reference)
+		if reference == "" {
+			reference = order.PONumber
+		}
+
+		for i, lineReq := range req.Lines {
+			line, ok := byID[lineReq.LineID]
+			if !ok {
+				return &BatchLineError{Line: i + 1, Err: fmt.Errorf("%w: line %d is not on purchase order %s", domain.ErrInvalidInput, lineReq.LineID, order.PONumber)}
+			}
+			movement, err := receiptMovement(ctx, repos, order, line, req, lineReq, reference, userID)
+			if err != nil {
+				return &BatchLineError{Line: i + 1, Err: err}
+			}
+
+			if err := postStockMovement(ctx, repos, movement); err != nil {
+				return &BatchLineError{Line: i + 1, Err: err}
+			}
+			if err := repos.PurchaseOrder.AddReceivedQuantity(ctx, line.ID, movement.Quantity); err != nil {
+				return err
+			}
+			line.ReceivedQuantity += movement.Quantity
+			receipt.Movements = append(receipt.Movements, movement)
+		}
+
+		order.Status = domain.PurchaseOrderPartiallyReceived
+		if fullyReceived(lines) {
+			now := time.Now()
+			order.Status = domain.PurchaseOrderClosed
+			order.ClosedAt = &now
+		}
+		if err := repos.PurchaseOrder.UpdateStatus(ctx, order); err != nil {
+			return err
+		}
+
+		order.Lines = lines
+		for _, line := range order.Lines {
+			line.SetReceiptStatus(order.Status)
+		}
+		receipt.PurchaseOrder = order
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return receipt, nil
+}
+
+// receiptMovement builds the IN movement for goods received against a purchase order line,
+// converting a pack size quantity to base units and checking the over-receipt tolerance
+func receiptMovement(
+	ctx context.Context,
+	repos *repository.Repositories,
+	order *domain.PurchaseOrder,
+	line *domain.PurchaseOrderLine,
+	req *domain.ReceivePurchaseOrderRequest,
+	lineReq *domain.ReceivePurchaseOrderLineRequest,
+	reference string,
+	userID int,
+) (*domain.StockMovement, error) {
+	if lineReq.Quantity <= 0 {
+		return nil, fmt.Errorf("%w: quantity must be greater than 0", domain.ErrInvalidInput)
+	}
+	locationID := lineReq.LocationID
+	if locationID == 0 {
+		locationID = req.LocationID
+	}
+	if locationID <= 0 {
+		return nil, fmt.Errorf("%w: valid location ID is required", domain.ErrInvalidInput)
+	}
+
+	units, err := repos.ProductUnit.ListByProduct(ctx, line.ProductID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product units: %w", err)
+	}
+	line.Product.Units = units
+	factor, err := line.Product.UnitFactor(lineReq.UOM)
+	if err != nil {
+		return nil, err
+	}
+	quantity := lineReq.Quantity * factor
+
+	if maxReceivable := line.MaxReceivable(order.OverReceiptTolerance); line.ReceivedQuantity+quantity > maxReceivable {
+		return nil, fmt.Errorf("%w: receiving %d would bring line %d to %d, more than the %d allowed for %d ordered",
+			domain.ErrInvalidInput, quantity, line.LineNumber, line.ReceivedQuantity+quantity, maxReceivable, line.OrderedQuantity)
+	}
+
+	lots, err := requestedLots(domain.DirectionIncrease, lineReq.LotNumber, lineReq.ExpiryDate, quantity)
+	if err != nil {
+		return nil, err
+	}
+
+	lineID := line.ID
+	movement := &domain.StockMovement{
+		ProductID:           line.ProductID,
+		LocationID:          locationID,
+		UserID:              userID,
+		Type:                domain.StockIN,
+		Direction:           domain.DirectionIncrease,
+		Quantity:            quantity,
+		Reference:           reference,
+		Notes:               req.Notes,
+		PurchaseOrderLineID: &lineID,
+		Lots:                lots,
+		SerialNumbers:       lineReq.SerialNumbers,
+		UnitCost:            line.UnitCost,
+	}
+	if factor != 1 {
+		requested := lineReq.Quantity
+		movement.UOM = domain.NormalizeUOM(lineReq.UOM)
+		movement.UOMQuantity = &requested
+	}
+
+	return movement, nil
+}
+
+// fullyReceived reports whether every line has received at least its ordered quantity
+func fullyReceived(lines []*domain.PurchaseOrderLine) bool {
+	for _, line := range lines {
+		if line.ReceivedQuantity < line.OrderedQuantity {
+			return false
+		}
+	}
+	return true
+}
+
+// ClosePurchaseOrder closes an order that will not be delivered in full; lines received short stay short
+func (s *purchaseOrderService) ClosePurchaseOrder(ctx context.Context, id int) (*domain.PurchaseOrder, error) {
+	return s.finish(ctx, id, domain.PurchaseOrderClosed)
+}
+
+// CancelPurchaseOrder cancels an order nothing has been received against yet
+func (s *purchaseOrderService) CancelPurchaseOrder(ctx context.Context, id int) (*domain.PurchaseOrder, error) {
+	return s.finish(ctx, id, domain.PurchaseOrderCancelled)
+}
+
+func (s *purchaseOrderService) finish(ctx context.Context, id int, status domain.PurchaseOrderStatus) (*domain.PurchaseOrder, error) {
+	var order *domain.PurchaseOrder
+	err := s.uow.WithinTransaction(ctx, func(repos *repository.Repositories) error {
+		var err error
+		order, err = repos.PurchaseOrder.GetByIDForUpdate(ctx, id)
+		if err != nil {
+			return fmt.Errorf("failed to get purchase order: %w", err)
+		}
+		if !order.Status.IsReceivable() {
+			return fmt.Errorf("%w: purchase order is %s", domain.ErrInvalidStatus, order.Status)
+		}
+		if status == domain.PurchaseOrderCancelled && order.Status != domain.PurchaseOrderOpen {
+			return fmt.Errorf("%w: goods have been received against this purchase order, close it instead", domain.ErrInvalidStatus)
+		}
+
+		now := time.Now()
+		order.Status = status
+		order.ClosedAt = &now
+		if err := repos.PurchaseOrder.UpdateStatus(ctx, order); err != nil {
+			return err
+		}
+
+		return loadPurchaseOrderRelations(ctx, repos.Supplier, repos.PurchaseOrder, order)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return order, nil
+}
+
+// ListReceipts returns the movements posted against the lines of a purchase order, newest first
+func (s *purchaseOrderService) ListReceipts(ctx context.Context, id int, limit, offset int) ([]*domain.StockMovement, int, error) {
+	if _, err := s.purchaseOrderRepo.GetByID(ctx, id); err != nil {
+		return nil, 0, fmt.Errorf("failed to get purchase order: %w", err)
+	}
+
+	movements, total, err := s.stockMovementRepo.List(ctx, &domain.StockMovementFilter{
+		PurchaseOrderID: &id,
+		Limit:           limit,
+		Offset:          offset,
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get purchase order receipts: %w", err)
+	}
+
+	return movements, total, nil
+}
+
+// loadPurchaseOrderRelations populates the supplier and lines of an order
+func loadPurchaseOrderRelations(ctx context.Context, supplierRepo repository.SupplierRepository, purchaseOrderRepo repository.PurchaseOrderRepository, order *domain.PurchaseOrder) error {
+	supplier, err := supplierRepo.GetByID(ctx, order.SupplierID)
+	if err != nil {
+		return fmt.Errorf("failed to get supplier: %w", err)
+	}
+	order.Supplier = supplier
+
+	lines, err := purchaseOrderRepo.ListLines(ctx, order.ID)
+	if err != nil {
+		return err
+	}
+	for _, line := range lines {
+		line.SetReceiptStatus(order.Status)
+	}
+	order.Lines = lines
+
+	return nil
+}
+
+// getProductWithUnits loads a product together with its pack sizes
+func (s *purchaseOrderService) getProductWithUnits(ctx context.Context, productID int) (*domain.Product, error) {
+	product, err := s.productRepo.GetByID(ctx, productID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product: %w", err)
+	}
+
+	units, err := s.productUnitRepo.ListByProduct(ctx, productID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product units: %w", err)
+	}
+	product.Units = units
+
+	return product, nil
+}
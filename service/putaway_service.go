@@ -0,0 +1,202 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/edwinjordan/wmsTest_Golang/domain"
+	"github.com/edwinjordan/wmsTest_Golang/repository"
+)
+
+const (
+	// defaultPutawayLimit and maxPutawayLimit bound the number of suggested locations
+	defaultPutawayLimit = 10
+	maxPutawayLimit     = 50
+
+	// putawayHistoryWindow is how far back the ledger is searched for earlier putaways of a product
+	putawayHistoryWindow = 90 * 24 * time.Hour
+
+	// Score weights of the putaway ranking
+	putawayScoreConsolidation = 40.0 // Location already holds the product
+	putawayScoreZone          = 30.0 // Location is in the preferred zone
+	putawayScoreFit           = 20.0 // Scaled by how much of the free space the putaway fills
+	putawayScoreHistory       = 10.0 // Product was put away here recently
+	putawayScoreControlled    = 25.0 // Penalty for using temperature-controlled space without need
+)
+
+type PutawayService interface {
+	SuggestLocations(ctx context.Context, req *domain.PutawayRequest) (*domain.PutawayPlan, error)
+}
+
+type putawayService struct {
+	productRepo       repository.ProductRepository
+	productUnitRepo   repository.ProductUnitRepository
+	locationRepo      repository.LocationRepository
+	stockLevelRepo    repository.StockLevelRepository
+	stockMovementRepo repository.StockMovementRepository
+	warehouseRepo     repository.WarehouseRepository
+}
+
+func NewPutawayService(
+	productRepo repository.ProductRepository,
+	productUnitRepo repository.ProductUnitRepository,
+	locationRepo repository.LocationRepository,
+	stockLevelRepo repository.StockLevelRepository,
+	stockMovementRepo repository.StockMovementRepository,
+	warehouseRepo repository.WarehouseRepository,
+) PutawayService {
+	return &putawayService{
+		productRepo:       productRepo,
+		productUnitRepo:   productUnitRepo,
+		locationRepo:      locationRepo,
+		stockLevelRepo:    stockLevelRepo,
+		stockMovementRepo: stockMovementRepo,
+		warehouseRepo:     warehouseRepo,
+	}
+}
+
+// SuggestLocations ranks the active locations of a warehouse that can take at least part of a
+// quantity of a product. Locations at the wrong temperature or without free space are left out.
+// Locations that take the whole quantity come first, then the ranking favours locations that
+// already hold the product, the preferred zone, a tight fit and locations the product was recently
+// put away in, and keeps temperature-controlled space for products that need it.
+func (s *putawayService) SuggestLocations(ctx context.Context, req *domain.PutawayRequest) (*domain.PutawayPlan, error) {
+	if req.Quantity <= 0 {
+		return nil, fmt.Errorf("%w: quantity must be greater than 0", domain.ErrInvalidInput)
+	}
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultPutawayLimit
+	}
+	if limit > maxPutawayLimit {
+		limit = maxPutawayLimit
+	}
+
+	product, err := s.productRepo.GetByID(ctx, req.ProductID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product: %w", err)
+	}
+	units, err := s.productUnitRepo.ListByProduct(ctx, product.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product units: %w", err)
+	}
+	product.Units = units
+
+	factor, err := product.UnitFactor(req.UOM)
+	if err != nil {
+		return nil, err
+	}
+	quantity := req.Quantity * factor
+
+	warehouse, err := getWarehouseOrDefault(ctx, s.warehouseRepo, req.WarehouseID)
+	if err != nil {
+		return nil, err
+	}
+
+	locations, err := s.locationRepo.ListActive(ctx)
+	if err != nil {
+		return nil, err
+	}
+	loads, err := s.stockLevelRepo.ListLocationLoads(ctx)
+	if err != nil {
+		return nil, err
+	}
+	levels, err := s.stockLevelRepo.ListByProduct(ctx, product.ID, &warehouse.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stock levels: %w", err)
+	}
+	onHand := make(map[int]int)
+	for _, level := range levels {
+		onHand[level.LocationID] += level.Quantity
+	}
+	putaways, err := s.stockMovementRepo.CountPutaways(ctx, product.ID, time.Now().Add(-putawayHistoryWindow))
+	if err != nil {
+		return nil, err
+	}
+
+	zone := strings.TrimSpace(req.Zone)
+	suggestions := []*domain.PutawaySuggestion{}
+	for _, location := range locations {
+		if location.WarehouseID != warehouse.ID || !product.CanBeStoredAt(location) {
+			continue
+		}
+		load := loads[location.ID]
+		if load == nil {
+			load = &domain.LocationLoad{}
+		}
+		free := location.FreeUnits(load, product)
+		if free == 0 {
+			continue
+		}
+
+		suggestion := &domain.PutawaySuggestion{
+			Location:          location,
+			AcceptsQuantity:   min(free, quantity),
+			FitsAll:           free >= quantity,
+			OnHandQuantity:    onHand[location.ID],
+			RemainingCapacity: location.Capacity - load.Quantity,
+			Reasons:           []string{},
+		}
+		if location.MaxWeight != nil {
+			remaining := *location.MaxWeight - load.Weight
+			suggestion.RemainingWeight = &remaining
+		}
+		if location.MaxVolume != nil {
+			remaining := *location.MaxVolume - load.Volume
+			suggestion.RemainingVolume = &remaining
+		}
+
+		if suggestion.OnHandQuantity > 0 {
+			suggestion.Score += putawayScoreConsolidation
+			suggestion.Reasons = append(suggestion.Reasons, fmt.Sprintf("consolidates with %d units already stored", suggestion.OnHandQuantity))
+		}
+		if zone != "" && strings.EqualFold(location.Zone, zone) {
+			suggestion.Score += putawayScoreZone
+			suggestion.Reasons = append(suggestion.Reasons, "in preferred zone "+location.Zone)
+		}
+		fill := float64(suggestion.AcceptsQuantity) / float64(free)
+		suggestion.Score += putawayScoreFit * fill
+		if suggestion.FitsAll {
+			suggestion.Reasons = append(suggestion.Reasons, fmt.Sprintf("fits all units, using %.0f%% of the free space", fill*100))
+		} else {
+			suggestion.Reasons = append(suggestion.Reasons, fmt.Sprintf("fits %d of %d units", suggestion.AcceptsQuantity, quantity))
+		}
+		if putaways[location.ID] > 0 {
+			suggestion.Score += putawayScoreHistory
+			suggestion.Reasons = append(suggestion.Reasons, "product was put away here recently")
+		}
+		if product.HasTemperatureRequirement() {
+			suggestion.Reasons = append(suggestion.Reasons, "temperature within "+product.TemperatureRange())
+		} else if location.Temperature != nil {
+			suggestion.Score -= putawayScoreControlled
+			suggestion.Reasons = append(suggestion.Reasons, "uses temperature-controlled space")
+		}
+
+		suggestions = append(suggestions, suggestion)
+	}
+
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		if suggestions[i].FitsAll != suggestions[j].FitsAll {
+			return suggestions[i].FitsAll
+		}
+		if suggestions[i].Score != suggestions[j].Score {
+			return suggestions[i].Score > suggestions[j].Score
+		}
+		return suggestions[i].Location.Code < suggestions[j].Location.Code
+	})
+	if len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+	for i, suggestion := range suggestions {
+		suggestion.Rank = i + 1
+	}
+
+	return &domain.PutawayPlan{
+		Product:     product,
+		Quantity:    quantity,
+		Suggestions: suggestions,
+	}, nil
+}
@@ -0,0 +1,243 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/edwinjordan/wmsTest_Golang/domain"
+	"github.com/edwinjordan/wmsTest_Golang/repository"
+)
+
+type ReorderService interface {
+	SetReorderRule(ctx context.Context, productID int, req *domain.SetReorderRuleRequest) (*domain.ReorderRule, error)
+	ListReorderRules(ctx context.Context, productID int) ([]*domain.ReorderRule, error)
+	DeleteReorderRule(ctx context.Context, productID, ruleID int) error
+	ListLowStock(ctx context.Context) ([]*domain.LowStockItem, error)
+	ListStockAlerts(ctx context.Context, filter *domain.StockAlertFilter) ([]*domain.StockAlert, int, error)
+	AcknowledgeStockAlert(ctx context.Context, id int, userID int) (*domain.StockAlert, error)
+}
+
+type reorderService struct {
+	reorderRuleRepo repository.ReorderRuleRepository
+	stockAlertRepo  repository.StockAlertRepository
+	uow             repository.UnitOfWork
+}
+
+func NewReorderService(reorderRuleRepo repository.ReorderRuleRepository, stockAlertRepo repository.StockAlertRepository, uow repository.UnitOfWork) ReorderService {
+	return &reorderService{
+		reorderRuleRepo: reorderRuleRepo,
+		stockAlertRepo:  stockAlertRepo,
+		uow:             uow,
+	}
+}
+
+// SetReorderRule creates or replaces the rule of a product at a location, or across all locations
+// when no location is given, and evaluates it against the current stock straight away
+func (s *reorderService) SetReorderRule(ctx context.Context, productID int, req *domain.SetReorderRuleRequest) (*domain.ReorderRule, error) {
+	if req.ReorderPoint < 0 || req.SafetyStock < 0 {
+		return nil, fmt.Errorf("%w: reorder point and safety stock must not be negative", domain.ErrInvalidInput)
+	}
+	if req.SafetyStock > req.ReorderPoint {
+		return nil, fmt.Errorf("%w: safety stock must not exceed the reorder point", domain.ErrInvalidInput)
+	}
+	if req.ReorderQuantity <= 0 {
+		return nil, fmt.Errorf("%w: reorder quantity must be greater than 0", domain.ErrInvalidInput)
+	}
+
+	var rule *domain.ReorderRule
+	err := s.uow.WithinTransaction(ctx, func(repos *repository.Repositories) error {
+		// Same lock as stock postings, so the rule cannot be evaluated twice at once
+		if _, err := repos.Product.GetByIDForUpdate(ctx, productID); err != nil {
+			return fmt.Errorf("failed to get product: %w", err)
+		}
+		if req.LocationID != nil {
+			if _, err := repos.Location.GetByID(ctx, *req.LocationID); err != nil {
+				return fmt.Errorf("failed to get location: %w", err)
+			}
+		}
+
+		existing, err := repos.ReorderRule.Get(ctx, productID, req.LocationID)
+		if err != nil && !errors.Is(err, domain.ErrNotFound) {
+			return err
+		}
+
+		if existing != nil {
+			rule = existing
+			rule.ReorderPoint = req.ReorderPoint
+			rule.SafetyStock = req.SafetyStock
+			rule.ReorderQuantity = req.ReorderQuantity
+			if err := repos.ReorderRule.Update(ctx, rule); err != nil {
+				return err
+			}
+		} else {
+			rule = &domain.ReorderRule{
+				ProductID:       productID,
+				LocationID:      req.LocationID,
+				ReorderPoint:    req.ReorderPoint,
+				SafetyStock:     req.SafetyStock,
+				ReorderQuantity: req.ReorderQuantity,
+			}
+			if err := repos.ReorderRule.Create(ctx, rule); err != nil {
+				return err
+			}
+		}
+
+		return evaluateReorderRule(ctx, repos, rule)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return rule, nil
+}
+
+func (s *reorderService) ListReorderRules(ctx context.Context, productID int) ([]*domain.ReorderRule, error) {
+	rules, err := s.reorderRuleRepo.ListByProduct(ctx, productID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reorder rules: %w", err)
+	}
+	if rules == nil {
+		rules = []*domain.ReorderRule{}
+	}
+
+	return rules, nil
+}
+
+func (s *reorderService) DeleteReorderRule(ctx context.Context, productID, ruleID int) error {
+	rule, err := s.reorderRuleRepo.GetByID(ctx, ruleID)
+	if err != nil {
+		return fmt.Errorf("failed to get reorder rule: %w", err)
+	}
+	if rule.ProductID != productID {
+		return domain.ErrNotFound
+	}
+
+	return s.reorderRuleRepo.Delete(ctx, ruleID)
+}
+
+// ListLowStock returns every rule whose product is currently at or below its reorder point
+func (s *reorderService) ListLowStock(ctx context.Context) ([]*domain.LowStockItem, error) {
+	items, err := s.reorderRuleRepo.ListLowStock(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get low stock: %w", err)
+	}
+
+	lowStock := make([]*domain.LowStockItem, 0, len(items))
+	for _, item := range items {
+		rule := &domain.ReorderRule{ReorderPoint: item.ReorderPoint, SafetyStock: item.SafetyStock}
+		item.Level = rule.AlertLevel(item.OnHandQuantity)
+		lowStock = append(lowStock, item)
+	}
+
+	return lowStock, nil
+}
+
+func (s *reorderService) ListStockAlerts(ctx context.Context, filter *domain.StockAlertFilter) ([]*domain.StockAlert, int, error) {
+	alerts, total, err := s.stockAlertRepo.List(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get stock alerts: %w", err)
+	}
+
+	return alerts, total, nil
+}
+
+// AcknowledgeStockAlert records that someone is dealing with an open alert. The alert stays
+// unresolved until stock rises above the reorder point again.
+func (s *reorderService) AcknowledgeStockAlert(ctx context.Context, id int, userID int) (*domain.StockAlert, error) {
+	alert, err := s.stockAlertRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stock alert: %w", err)
+	}
+	if alert.Status != domain.StockAlertOpen {
+		return nil, fmt.Errorf("%w: stock alert is %s", domain.ErrInvalidStatus, alert.Status)
+	}
+
+	now := time.Now()
+	alert.Status = domain.StockAlertAcknowledged
+	alert.AcknowledgedBy = &userID
+	alert.AcknowledgedAt = &now
+
+	if err := s.stockAlertRepo.Update(ctx, alert); err != nil {
+		return nil, err
+	}
+
+	return alert, nil
+}
+
+// evaluateReorderRules checks the rules a movement may have triggered: the rule at its location
+// and the rule covering all locations. It runs inside the posting transaction, so alerts always
+// match the stock they were raised for.
+func evaluateReorderRules(ctx context.Context, repos *repository.Repositories, movement *domain.StockMovement) error {
+	rules, err := repos.ReorderRule.ListForLocation(ctx, movement.ProductID, movement.LocationID)
+	if err != nil {
+		return err
+	}
+
+	for _, rule := range rules {
+		if err := evaluateReorderRule(ctx, repos, rule); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// evaluateReorderRule raises, updates or resolves the alert of a rule for the current on-hand stock.
+// An acknowledged alert is reopened when the stock drops further to the safety stock.
+func evaluateReorderRule(ctx context.Context, repos *repository.Repositories, rule *domain.ReorderRule) error {
+	var quantity int
+	var err error
+	if rule.LocationID != nil {
+		quantity, err = repos.StockLevel.GetQuantity(ctx, rule.ProductID, *rule.LocationID)
+	} else {
+		quantity, err = repos.StockLevel.GetProductTotal(ctx, rule.ProductID)
+	}
+	if err != nil {
+		return err
+	}
+
+	alert, err := repos.StockAlert.GetUnresolvedByRule(ctx, rule.ID)
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
+		return err
+	}
+
+	level := rule.AlertLevel(quantity)
+	if level == "" {
+		if alert == nil {
+			return nil
+		}
+		now := time.Now()
+		alert.Status = domain.StockAlertResolved
+		alert.Quantity = quantity
+		alert.ResolvedAt = &now
+		return repos.StockAlert.Update(ctx, alert)
+	}
+
+	if alert == nil {
+		alert = &domain.StockAlert{
+			RuleID:       rule.ID,
+			ProductID:    rule.ProductID,
+			LocationID:   rule.LocationID,
+			Level:        level,
+			Status:       domain.StockAlertOpen,
+			Quantity:     quantity,
+			ReorderPoint: rule.ReorderPoint,
+			SafetyStock:  rule.SafetyStock,
+		}
+		return repos.StockAlert.Create(ctx, alert)
+	}
+
+	if level == domain.AlertCritical && alert.Level != domain.AlertCritical && alert.Status == domain.StockAlertAcknowledged {
+		alert.Status = domain.StockAlertOpen
+		alert.AcknowledgedBy = nil
+		alert.AcknowledgedAt = nil
+	}
+	alert.Level = level
+	alert.Quantity = quantity
+	alert.ReorderPoint = rule.ReorderPoint
+	alert.SafetyStock = rule.SafetyStock
+
+	return repos.StockAlert.Update(ctx, alert)
+}
@@ -0,0 +1,251 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/edwinjordan/wmsTest_Golang/domain"
+	"github.com/edwinjordan/wmsTest_Golang/repository"
+)
+
+type ReservationService interface {
+	CreateReservation(ctx context.Context, req *domain.CreateReservationRequest, userID int) (*domain.Reservation, error)
+	GetReservation(ctx context.Context, id int) (*domain.Reservation, error)
+	ListReservations(ctx context.Context, filter *domain.ReservationFilter) ([]*domain.Reservation, int, error)
+	ReleaseReservation(ctx context.Context, id int) (*domain.Reservation, error)
+	ConsumeReservation(ctx context.Context, id int, req *domain.ConsumeReservationRequest, userID int) (*domain.StockMovement, error)
+}
+
+type reservationService struct {
+	reservationRepo repository.ReservationRepository
+	uow             repository.UnitOfWork
+}
+
+func NewReservationService(reservationRepo repository.ReservationRepository, uow repository.UnitOfWork) ReservationService {
+	return &reservationService{
+		reservationRepo: reservationRepo,
+		uow:             uow,
+	}
+}
+
+// CreateReservation reserves stock at a location. Only stock that is on hand and not already
+// reserved can be reserved.
+func (s *reservationService) CreateReservation(ctx context.Context, req *domain.CreateReservationRequest, userID int) (*domain.Reservation, error) {
+	if req.Quantity <= 0 {
+		return nil, fmt.Errorf("%w: quantity must be positive", domain.ErrInvalidInput)
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, fmt.Errorf("%w: expiry must be in the future", domain.ErrInvalidInput)
+	}
+
+	reservation := &domain.Reservation{
+		ProductID:  req.ProductID,
+		LocationID: req.LocationID,
+		Quantity:   req.Quantity,
+		Status:     domain.ReservationActive,
+		Reference:  req.Reference,
+		Notes:      req.Notes,
+		ExpiresAt:  req.ExpiresAt,
+		CreatedBy:  userID,
+	}
+
+	err := s.uow.WithinTransaction(ctx, func(repos *repository.Repositories) error {
+		// Same lock order as stock postings, so reservations and movements for a product are serialized
+		product, err := repos.Product.GetByIDForUpdate(ctx, req.ProductID)
+		if err != nil {
+			return fmt.Errorf("failed to get product: %w", err)
+		}
+		location, err := repos.Location.GetByIDForUpdate(ctx, req.LocationID)
+		if err != nil {
+			return fmt.Errorf("failed to get location: %w", err)
+		}
+
+		availability, err := getAvailability(ctx, repos, req.ProductID, req.LocationID, nil)
+		if err != nil {
+			return err
+		}
+		if availability.AvailableQuantity < req.Quantity {
+			return domain.ErrInsufficientStock
+		}
+
+		if err := repos.Reservation.Create(ctx, reservation); err != nil {
+			return err
+		}
+
+		reservation.Product = product
+		reservation.Location = location
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return reservation, nil
+}
+
+func (s *reservationService) GetReservation(ctx context.Context, id int) (*domain.Reservation, error) {
+	if err := s.reservationRepo.ExpireDue(ctx); err != nil {
+		return nil, err
+	}
+
+	reservation, err := s.reservationRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reservation: %w", err)
+	}
+
+	return reservation, nil
+}
+
+func (s *reservationService) ListReservations(ctx context.Context, filter *domain.ReservationFilter) ([]*domain.Reservation, int, error) {
+	if err := s.reservationRepo.ExpireDue(ctx); err != nil {
+		return nil, 0, err
+	}
+
+	reservations, total, err := s.reservationRepo.List(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list reservations: %w", err)
+	}
+
+	return reservations, total, nil
+}
+
+// ReleaseReservation gives the open quantity of a reservation back to available stock
+func (s *reservationService) ReleaseReservation(ctx context.Context, id int) (*domain.Reservation, error) {
+	var reservation *domain.Reservation
+	err := s.uow.WithinTransaction(ctx, func(repos *repository.Repositories) error {
+		if err := repos.Reservation.ExpireDue(ctx); err != nil {
+			return err
+		}
+
+		var err error
+		reservation, err = repos.Reservation.GetByIDForUpdate(ctx, id)
+		if err != nil {
+			return fmt.Errorf("failed to get reservation: %w", err)
+		}
+		if reservation.Status != domain.ReservationActive {
+			return fmt.Errorf("%w: reservation is %s", domain.ErrInvalidStatus, reservation.Status)
+		}
+
+		now := time.Now()
+		reservation.Status = domain.ReservationReleased
+		reservation.ClosedAt = &now
+
+		return repos.Reservation.Update(ctx, reservation)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return reservation, nil
+}
+
+// ConsumeReservation ships reserved stock with an OUT movement linked to the reservation.
+// The reservation is closed once its whole quantity has been consumed.
+func (s *reservationService) ConsumeReservation(ctx context.Context, id int, req *domain.ConsumeReservationRequest, userID int) (*domain.StockMovement, error) {
+	if req.Quantity < 0 {
+		return nil, fmt.Errorf("%w: quantity must not be negative", domain.ErrInvalidInput)
+	}
+
+	// The reservation is read up front only to find which product and location to lock
+	existing, err := s.reservationRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reservation: %w", err)
+	}
+
+	var movement *domain.StockMovement
+	err = s.uow.WithinTransaction(ctx, func(repos *repository.Repositories) error {
+		if _, err := repos.Product.GetByIDForUpdate(ctx, existing.ProductID); err != nil {
+			return fmt.Errorf("failed to get product: %w", err)
+		}
+		if _, err := repos.Location.GetByIDForUpdate(ctx, existing.LocationID); err != nil {
+			return fmt.Errorf("failed to get location: %w", err)
+		}
+		if err := repos.Reservation.ExpireDue(ctx); err != nil {
+			return err
+		}
+
+		reservation, err := repos.Reservation.GetByIDForUpdate(ctx, id)
+		if err != nil {
+			return fmt.Errorf("failed to get reservation: %w", err)
+		}
+		if reservation.Status != domain.ReservationActive {
+			return fmt.Errorf("%w: reservation is %s", domain.ErrInvalidStatus, reservation.Status)
+		}
+
+		quantity := req.Quantity
+		if quantity == 0 {
+			quantity = reservation.OpenQuantity()
+		}
+		if quantity > reservation.OpenQuantity() {
+			return fmt.Errorf("%w: only %d units remain on this reservation", domain.ErrInvalidInput, reservation.OpenQuantity())
+		}
+
+		reference := req.Reference
+		if reference == "" {
+			reference = reservation.Reference
+		}
+
+		reservationID := reservation.ID
+		movement = &domain.StockMovement{
+			ProductID:     reservation.ProductID,
+			LocationID:    reservation.LocationID,
+			UserID:        userID,
+			Type:          domain.StockOUT,
+			Direction:     domain.DirectionDecrease,
+			Quantity:      quantity,
+			Reference:     reference,
+			Notes:         req.Notes,
+			ReservationID: &reservationID,
+			SerialNumbers: req.SerialNumbers,
+		}
+		if req.LotNumber != "" {
+			movement.Lots = []*domain.StockMovementLot{{LotNumber: req.LotNumber, Quantity: quantity}}
+		}
+
+		if err := postStockMovement(ctx, repos, movement); err != nil {
+			return err
+		}
+
+		reservation.ConsumedQuantity += quantity
+		if reservation.OpenQuantity() == 0 {
+			now := time.Now()
+			reservation.Status = domain.ReservationConsumed
+			reservation.ClosedAt = &now
+		}
+
+		return repos.Reservation.Update(ctx, reservation)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return movement, nil
+}
+
+// getAvailability returns the on-hand, held, reserved and available quantity of a product at a
+// location, leaving out the reservation given by excludeID. Held stock is not available to reserve.
+func getAvailability(ctx context.Context, repos *repository.Repositories, productID, locationID int, excludeID *int) (*domain.StockAvailability, error) {
+	onHand, err := getOnHand(ctx, repos.StockLevel, productID, locationID)
+	if err != nil {
+		return nil, err
+	}
+	usable, err := repos.StockLevel.GetStatusQuantity(ctx, productID, locationID, domain.InventoryAvailable)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stock level: %w", err)
+	}
+
+	reserved, err := repos.Reservation.GetReservedQuantity(ctx, productID, locationID, excludeID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.StockAvailability{
+		ProductID:         productID,
+		LocationID:        locationID,
+		OnHandQuantity:    onHand,
+		HeldQuantity:      onHand - usable,
+		ReservedQuantity:  reserved,
+		AvailableQuantity: usable - reserved,
+	}, nil
+}
@@ -0,0 +1,488 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/edwinjordan/wmsTest_Golang/domain"
+	"github.com/edwinjordan/wmsTest_Golang/repository"
+)
+
+type ReturnService interface {
+	CreateReturnAuthorization(ctx context.Context, req *domain.CreateReturnAuthorizationRequest, userID int) (*domain.ReturnAuthorization, error)
+	GetReturnAuthorization(ctx context.Context, id int) (*domain.ReturnAuthorization, error)
+	ListReturnAuthorizations(ctx context.Context, filter *domain.ReturnAuthorizationFilter) ([]*domain.ReturnAuthorization, int, error)
+	ReceiveReturn(ctx context.Context, id int, req *domain.ReceiveReturnRequest, userID int) (*domain.ReturnReceipt, error)
+	CloseReturnAuthorization(ctx context.Context, id int) (*domain.ReturnAuthorization, error)
+	CancelReturnAuthorization(ctx context.Context, id int) (*domain.ReturnAuthorization, error)
+	ListMovements(ctx context.Context, id int, limit, offset int) ([]*domain.StockMovement, int, error)
+}
+
+type returnService struct {
+	returnRepo        repository.ReturnAuthorizationRepository
+	stockMovementRepo repository.StockMovementRepository
+	uow               repository.UnitOfWork
+}
+
+func NewReturnService(
+	returnRepo repository.ReturnAuthorizationRepository,
+	stockMovementRepo repository.StockMovementRepository,
+	uow repository.UnitOfWork,
+) ReturnService {
+	return &returnService{
+		returnRepo:        returnRepo,
+		stockMovementRepo: stockMovementRepo,
+		uow:               uow,
+	}
+}
+
+// CreateReturnAuthorization authorizes a customer to send goods back, either against the lines of
+// a shipped sales order or against a free reference such as an invoice number. Against a sales
+// order, a line may not be authorized for more than was shipped and not yet returned.
+func (s *returnService) CreateReturnAuthorization(ctx context.Context, req *domain.CreateReturnAuthorizationRequest, userID int) (*domain.ReturnAuthorization, error) {
+	rmaNumber := strings.TrimSpace(req.RMANumber)
+	if rmaNumber == "" {
+		return nil, fmt.Errorf("%w: RMA number is required", domain.ErrInvalidInput)
+	}
+	reference := strings.TrimSpace(req.Reference)
+	if req.SalesOrderID == nil && reference == "" {
+		return nil, fmt.Errorf("%w: a sales order or a reference is required", domain.ErrInvalidInput)
+	}
+	if len(req.Lines) == 0 {
+		return nil, fmt.Errorf("%w: at least one line is required", domain.ErrInvalidInput)
+	}
+
+	existing, err := s.returnRepo.GetByNumber(ctx, rmaNumber)
+	if err == nil && existing != nil {
+		return nil, domain.ErrDuplicateEntry
+	}
+
+	rma := &domain.ReturnAuthorization{
+		RMANumber:    rmaNumber,
+		SalesOrderID: req.SalesOrderID,
+		Reference:    reference,
+		CustomerName: strings.TrimSpace(req.CustomerName),
+		Status:       domain.ReturnAuthorizationOpen,
+		Notes:        req.Notes,
+		CreatedBy:    userID,
+	}
+
+	err = s.uow.WithinTransaction(ctx, func(repos *repository.Repositories) error {
+		// Locking the order keeps two authorizations from returning the same shipped units
+		var soLines map[int]*domain.SalesOrderLine
+		if req.SalesOrderID != nil {
+			order, err := repos.SalesOrder.GetByIDForUpdate(ctx, *req.SalesOrderID)
+			if err != nil {
+				return fmt.Errorf("failed to get sales order: %w", err)
+			}
+			if order.Status != domain.SalesOrderShipped {
+				return fmt.Errorf("%w: sales order %s is %s, only shipped orders can be returned", domain.ErrInvalidStatus, order.SONumber, order.Status)
+			}
+			if rma.CustomerName == "" {
+				rma.CustomerName = order.CustomerName
+			}
+			if rma.Reference == "" {
+				rma.Reference = order.SONumber
+			}
+
+			lines, err := repos.SalesOrder.ListLines(ctx, order.ID)
+			if err != nil {
+				return err
+			}
+			soLines = make(map[int]*domain.SalesOrderLine, len(lines))
+			for _, line := range lines {
+				soLines[line.ID] = line
+			}
+		}
+		if rma.CustomerName == "" {
+			return fmt.Errorf("%w: customer name is required", domain.ErrInvalidInput)
+		}
+
+		for i, lineReq := range req.Lines {
+			line, err := returnLine(ctx, repos, rma, soLines, lineReq)
+			if err != nil {
+				return fmt.Errorf("line %d: %w", i+1, err)
+			}
+			line.LineNumber = i + 1
+			rma.Lines = append(rma.Lines, line)
+		}
+
+		if err := repos.Return.Create(ctx, rma); err != nil {
+			return err
+		}
+		for _, line := range rma.Lines {
+			line.ReturnAuthorizationID = rma.ID
+			if err := repos.Return.CreateLine(ctx, line); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return rma, nil
+}
+
+// returnLine builds an authorized line, converting a pack size quantity to base units and, for
+// lines of a sales order, checking the quantity against what is still returnable
+func returnLine(
+	ctx context.Context,
+	repos *repository.Repositories,
+	rma *domain.ReturnAuthorization,
+	soLines map[int]*domain.SalesOrderLine,
+	lineReq *domain.CreateReturnAuthorizationLineRequest,
+) (*domain.ReturnAuthorizationLine, error) {
+	if lineReq.Quantity <= 0 {
+		return nil, fmt.Errorf("%w: quantity must be greater than 0", domain.ErrInvalidInput)
+	}
+	if !lineReq.ReturnReason.IsValid() {
+		return nil, fmt.Errorf("%w: unknown return reason %q", domain.ErrInvalidInput, lineReq.ReturnReason)
+	}
+
+	line := &domain.ReturnAuthorizationLine{
+		ProductID:        lineReq.ProductID,
+		SalesOrderLineID: lineReq.SalesOrderLineID,
+		ReturnReason:     lineReq.ReturnReason,
+	}
+
+	var soLine *domain.SalesOrderLine
+	if rma.SalesOrderID != nil {
+		if lineReq.SalesOrderLineID == nil {
+			return nil, fmt.Errorf("%w: sales order line ID is required", domain.ErrInvalidInput)
+		}
+		var ok bool
+		soLine, ok = soLines[*lineReq.SalesOrderLineID]
+		if !ok {
+			return nil, fmt.Errorf("%w: sales order line %d is not on the sales order", domain.ErrInvalidInput, *lineReq.SalesOrderLineID)
+		}
+		if lineReq.ProductID != 0 && lineReq.ProductID != soLine.ProductID {
+			return nil, fmt.Errorf("%w: sales order line %d is for product %d", domain.ErrInvalidInput, soLine.ID, soLine.ProductID)
+		}
+		line.ProductID = soLine.ProductID
+	} else if lineReq.SalesOrderLineID != nil {
+		return nil, fmt.Errorf("%w: sales order lines can only be returned against their sales order", domain.ErrInvalidInput)
+	}
+
+	product, err := repos.Product.GetByID(ctx, line.ProductID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product: %w", err)
+	}
+	units, err := repos.ProductUnit.ListByProduct(ctx, product.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product units: %w", err)
+	}
+	product.Units = units
+	factor, err := product.UnitFactor(lineReq.UOM)
+	if err != nil {
+		return nil, err
+	}
+	line.AuthorizedQuantity = lineReq.Quantity * factor
+	line.Product = product
+
+	if soLine != nil {
+		returned, err := repos.Return.GetReturnedQuantity(ctx, soLine.ID)
+		if err != nil {
+			return nil, err
+		}
+		if returnable := soLine.ShippedQuantity - returned; line.AuthorizedQuantity > returnable {
+			return nil, fmt.Errorf("%w: %d authorized for sales order line %d, only %d of the %d shipped can still be returned",
+				domain.ErrInvalidInput, line.AuthorizedQuantity, soLine.LineNumber, max(returnable, 0), soLine.ShippedQuantity)
+		}
+	}
+
+	return line, nil
+}
+
+func (s *returnService) GetReturnAuthorization(ctx context.Context, id int) (*domain.ReturnAuthorization, error) {
+	rma, err := s.returnRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get return authorization: %w", err)
+	}
+
+	lines, err := s.returnRepo.ListLines(ctx, rma.ID)
+	if err != nil {
+		return nil, err
+	}
+	rma.Lines = lines
+
+	return rma, nil
+}
+
+func (s *returnService) ListReturnAuthorizations(ctx context.Context, filter *domain.ReturnAuthorizationFilter) ([]*domain.ReturnAuthorization, int, error) {
+	rmas, total, err := s.returnRepo.List(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list return authorizations: %w", err)
+	}
+
+	return rmas, total, nil
+}
+
+// ReceiveReturn books returned goods in against the lines of an RMA according to their grade.
+// Restocked and quarantined goods are an IN at the given location; scrapped goods are an IN
+// followed straight away by an ADJUST write-off with reason SCRAP, so the receipt and the loss
+// both show in the ledger. Returns of a shipped line come back at the cost they shipped at. The
+// RMA closes by itself once every line is received in full. The whole receipt succeeds or fails
+// together; a failing line is reported as a *BatchLineError.
+func (s *returnService) ReceiveReturn(ctx context.Context, id int, req *domain.ReceiveReturnRequest, userID int) (*domain.ReturnReceipt, error) {
+	if len(req.Lines) == 0 {
+		return nil, fmt.Errorf("%w: at least one line is required", domain.ErrInvalidInput)
+	}
+
+	receipt := &domain.ReturnReceipt{Movements: []*domain.StockMovement{}}
+	err := s.uow.WithinTransaction(ctx, func(repos *repository.Repositories) error {
+		rma, err := repos.Return.GetByIDForUpdate(ctx, id)
+		if err != nil {
+			return fmt.Errorf("failed to get return authorization: %w", err)
+		}
+		if !rma.Status.IsReceivable() {
+			return fmt.Errorf("%w: return authorization is %s", domain.ErrInvalidStatus, rma.Status)
+		}
+
+		lines, err := repos.Return.ListLines(ctx, rma.ID)
+		if err != nil {
+			return err
+		}
+		byID := make(map[int]*domain.ReturnAuthorizationLine, len(lines))
+		for _, line := range lines {
+			byID[line.ID] = line
+		}
+
+		for i, lineReq := range req.Lines {
+			line, ok := byID[lineReq.LineID]
+			if !ok {
+				return &BatchLineError{Line: i + 1, Err: fmt.Errorf("%w: line %d is not on return authorization %s", domain.ErrInvalidInput, lineReq.LineID, rma.RMANumber)}
+			}
+			movements, err := receiveReturnLine(ctx, repos, rma, line, req, lineReq, userID)
+			if err != nil {
+				return &BatchLineError{Line: i + 1, Err: err}
+			}
+			receipt.Movements = append(receipt.Movements, movements...)
+		}
+
+		rma.Status = domain.ReturnAuthorizationPartiallyReceived
+		if fullyReturned(lines) {
+			now := time.Now()
+			rma.Status = domain.ReturnAuthorizationClosed
+			rma.ClosedAt = &now
+		}
+		if err := repos.Return.UpdateStatus(ctx, rma); err != nil {
+			return err
+		}
+
+		rma.Lines = lines
+		receipt.ReturnAuthorization = rma
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return receipt, nil
+}
+
+// receiveReturnLine posts the movements for goods received against one RMA line and adds them to
+// the quantity received under their grade
+func receiveReturnLine(
+	ctx context.Context,
+	repos *repository.Repositories,
+	rma *domain.ReturnAuthorization,
+	line *domain.ReturnAuthorizationLine,
+	req *domain.ReceiveReturnRequest,
+	lineReq *domain.ReceiveReturnLineRequest,
+	userID int,
+) ([]*domain.StockMovement, error) {
+	if lineReq.Quantity <= 0 {
+		return nil, fmt.Errorf("%w: quantity must be greater than 0", domain.ErrInvalidInput)
+	}
+	if !lineReq.Grade.IsValid() {
+		return nil, fmt.Errorf("%w: grade must be RESTOCK, QUARANTINE or SCRAP", domain.ErrInvalidInput)
+	}
+	locationID := lineReq.LocationID
+	if locationID == 0 {
+		locationID = req.LocationID
+	}
+	if locationID <= 0 {
+		return nil, fmt.Errorf("%w: valid location ID is required", domain.ErrInvalidInput)
+	}
+
+	units, err := repos.ProductUnit.ListByProduct(ctx, line.ProductID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product units: %w", err)
+	}
+	line.Product.Units = units
+	factor, err := line.Product.UnitFactor(lineReq.UOM)
+	if err != nil {
+		return nil, err
+	}
+	quantity := lineReq.Quantity * factor
+
+	if received := line.ReceivedQuantity(); received+quantity > line.AuthorizedQuantity {
+		return nil, fmt.Errorf("%w: receiving %d would bring line %d to %d, more than the %d authorized",
+			domain.ErrInvalidInput, quantity, line.LineNumber, received+quantity, line.AuthorizedQuantity)
+	}
+
+	lots, err := requestedLots(domain.DirectionIncrease, lineReq.LotNumber, lineReq.ExpiryDate, quantity)
+	if err != nil {
+		return nil, err
+	}
+
+	// Goods shipped on a sales order come back at the cost they left at
+	var unitCost *float64
+	if line.SalesOrderLineID != nil {
+		unitCost, err = repos.StockMovement.GetShippedUnitCost(ctx, *line.SalesOrderLineID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Quarantined goods wait for QA and scrapped goods are damaged; neither can be sold meanwhile
+	status := domain.InventoryAvailable
+	switch lineReq.Grade {
+	case domain.ReturnQuarantine:
+		status = domain.InventoryQuarantine
+	case domain.ReturnScrap:
+		status = domain.InventoryDamaged
+	}
+
+	lineID := line.ID
+	in := &domain.StockMovement{
+		ProductID:       line.ProductID,
+		LocationID:      locationID,
+		UserID:          userID,
+		Type:            domain.StockIN,
+		Direction:       domain.DirectionIncrease,
+		Quantity:        quantity,
+		Reference:       rma.RMANumber,
+		Notes:           req.Notes,
+		ReturnLineID:    &lineID,
+		ReturnReason:    line.ReturnReason,
+		ReturnGrade:     lineReq.Grade,
+		Lots:            lots,
+		SerialNumbers:   lineReq.SerialNumbers,
+		UnitCost:        unitCost,
+		InventoryStatus: status,
+	}
+	if factor != 1 {
+		requested := lineReq.Quantity
+		in.UOM = domain.NormalizeUOM(lineReq.UOM)
+		in.UOMQuantity = &requested
+	}
+	if err := postStockMovement(ctx, repos, in); err != nil {
+		return nil, err
+	}
+	movements := []*domain.StockMovement{in}
+
+	if lineReq.Grade == domain.ReturnScrap {
+		scrap := &domain.StockMovement{
+			ProductID:     line.ProductID,
+			LocationID:    locationID,
+			UserID:        userID,
+			Type:          domain.StockADJUST,
+			Direction:     domain.DirectionDecrease,
+			Quantity:      quantity,
+			Reference:     rma.RMANumber,
+			ReasonCode:    domain.ReasonScrap,
+			Notes:         req.Notes,
+			ReturnLineID:  &lineID,
+			ReturnReason:  line.ReturnReason,
+			ReturnGrade:   lineReq.Grade,
+			Lots:          copyLots(in.Lots),
+			SerialNumbers: lineReq.SerialNumbers,
+			// Written off from the damaged stock the receipt just added
+			InventoryStatus: status,
+		}
+		if err := postStockMovement(ctx, repos, scrap); err != nil {
+			return nil, err
+		}
+		movements = append(movements, scrap)
+	}
+
+	if err := repos.Return.AddReceivedQuantity(ctx, line.ID, lineReq.Grade, quantity); err != nil {
+		return nil, err
+	}
+	switch lineReq.Grade {
+	case domain.ReturnRestock:
+		line.RestockedQuantity += quantity
+	case domain.ReturnQuarantine:
+		line.QuarantinedQuantity += quantity
+	case domain.ReturnScrap:
+		line.ScrappedQuantity += quantity
+	}
+
+	return movements, nil
+}
+
+// fullyReturned reports whether every line has received its authorized quantity
+func fullyReturned(lines []*domain.ReturnAuthorizationLine) bool {
+	for _, line := range lines {
+		if line.ReceivedQuantity() < line.AuthorizedQuantity {
+			return false
+		}
+	}
+	return true
+}
+
+// CloseReturnAuthorization closes an RMA the rest of the goods will not come back against
+func (s *returnService) CloseReturnAuthorization(ctx context.Context, id int) (*domain.ReturnAuthorization, error) {
+	return s.finish(ctx, id, domain.ReturnAuthorizationClosed)
+}
+
+// CancelReturnAuthorization cancels an RMA nothing has been received against yet
+func (s *returnService) CancelReturnAuthorization(ctx context.Context, id int) (*domain.ReturnAuthorization, error) {
+	return s.finish(ctx, id, domain.ReturnAuthorizationCancelled)
+}
+
+func (s *returnService) finish(ctx context.Context, id int, status domain.ReturnAuthorizationStatus) (*domain.ReturnAuthorization, error) {
+	var rma *domain.ReturnAuthorization
+	err := s.uow.WithinTransaction(ctx, func(repos *repository.Repositories) error {
+		var err error
+		rma, err = repos.Return.GetByIDForUpdate(ctx, id)
+		if err != nil {
+			return fmt.Errorf("failed to get return authorization: %w", err)
+		}
+		if !rma.Status.IsReceivable() {
+			return fmt.Errorf("%w: return authorization is %s", domain.ErrInvalidStatus, rma.Status)
+		}
+		if status == domain.ReturnAuthorizationCancelled && rma.Status != domain.ReturnAuthorizationOpen {
+			return fmt.Errorf("%w: goods have been received against this return authorization, close it instead", domain.ErrInvalidStatus)
+		}
+
+		now := time.Now()
+		rma.Status = status
+		rma.ClosedAt = &now
+		if err := repos.Return.UpdateStatus(ctx, rma); err != nil {
+			return err
+		}
+
+		rma.Lines, err = repos.Return.ListLines(ctx, rma.ID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return rma, nil
+}
+
+// ListMovements returns the movements posted against the lines of an RMA, newest first
+func (s *returnService) ListMovements(ctx context.Context, id int, limit, offset int) ([]*domain.StockMovement, int, error) {
+	if _, err := s.returnRepo.GetByID(ctx, id); err != nil {
+		return nil, 0, fmt.Errorf("failed to get return authorization: %w", err)
+	}
+
+	movements, total, err := s.stockMovementRepo.List(ctx, &domain.StockMovementFilter{
+		ReturnAuthorizationID: &id,
+		Limit:                 limit,
+		Offset:                offset,
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get return movements: %w", err)
+	}
+
+	return movements, total, nil
+}
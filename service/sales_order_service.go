@@ -0,0 +1,658 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/edwinjordan/wmsTest_Golang/domain"
+	"github.com/edwinjordan/wmsTest_Golang/repository"
+)
+
+type SalesOrderService interface {
+	CreateSalesOrder(ctx context.Context, req *domain.CreateSalesOrderRequest, userID int) (*domain.SalesOrder, error)
+	GetSalesOrder(ctx context.Context, id int) (*domain.SalesOrder, error)
+	ListSalesOrders(ctx context.Context, filter *domain.SalesOrderFilter) ([]*domain.SalesOrder, int, error)
+	AllocateSalesOrder(ctx context.Context, id int, userID int) (*domain.SalesOrder, error)
+	PickSalesOrder(ctx context.Context, id int) (*domain.SalesOrder, error)
+	PackSalesOrder(ctx context.Context, id int, req *domain.PackSalesOrderRequest) (*domain.SalesOrder, error)
+	ShipSalesOrder(ctx context.Context, id int, req *domain.ShipSalesOrderRequest, userID int) (*domain.SalesOrderShipment, error)
+	CancelSalesOrder(ctx context.Context, id int) (*domain.SalesOrder, error)
+	GetPickList(ctx context.Context, id int) (*domain.PickList, error)
+	ListMovements(ctx context.Context, id int, limit, offset int) ([]*domain.StockMovement, int, error)
+}
+
+type salesOrderService struct {
+	salesOrderRepo    repository.SalesOrderRepository
+	productRepo       repository.ProductRepository
+	productUnitRepo   repository.ProductUnitRepository
+	stockMovementRepo repository.StockMovementRepository
+	warehouseRepo     repository.WarehouseRepository
+	uow               repository.UnitOfWork
+}
+
+func NewSalesOrderService(
+	salesOrderRepo repository.SalesOrderRepository,
+	productRepo repository.ProductRepository,
+	productUnitRepo repository.ProductUnitRepository,
+	stockMovementRepo repository.StockMovementRepository,
+	warehouseRepo repository.WarehouseRepository,
+	uow repository.UnitOfWork,
+) SalesOrderService {
+	return &salesOrderService{
+		salesOrderRepo:    salesOrderRepo,
+		productRepo:       productRepo,
+		productUnitRepo:   productUnitRepo,
+		stockMovementRepo: stockMovementRepo,
+		warehouseRepo:     warehouseRepo,
+		uow:               uow,
+	}
+}
+
+func (s *salesOrderService) CreateSalesOrder(ctx context.Context, req *domain.CreateSalesOrderRequest, userID int) (*domain.SalesOrder, error) {
+	soNumber := strings.TrimSpace(req.SONumber)
+	if soNumber == "" || strings.TrimSpace(req.CustomerName) == "" {
+		return nil, fmt.Errorf("%w: SO number and customer name are required", domain.ErrInvalidInput)
+	}
+	if len(req.Lines) == 0 {
+		return nil, fmt.Errorf("%w: at least one line is required", domain.ErrInvalidInput)
+	}
+
+	existing, err := s.salesOrderRepo.GetByNumber(ctx, soNumber)
+	if err == nil && existing != nil {
+		return nil, domain.ErrDuplicateEntry
+	}
+
+	warehouse, err := getWarehouseOrDefault(ctx, s.warehouseRepo, req.WarehouseID)
+	if err != nil {
+		return nil, err
+	}
+	if !warehouse.IsActive {
+		return nil, fmt.Errorf("%w: warehouse %s is inactive", domain.ErrInvalidInput, warehouse.Code)
+	}
+
+	order := &domain.SalesOrder{
+		SONumber:      soNumber,
+		WarehouseID:   warehouse.ID,
+		CustomerName:  req.CustomerName,
+		ShipTo:        req.ShipTo,
+		Status:        domain.SalesOrderNew,
+		Priority:      req.Priority,
+		Carrier:       strings.TrimSpace(req.Carrier),
+		CarrierCutoff: req.CarrierCutoff,
+		Notes:         req.Notes,
+		CreatedBy:     userID,
+	}
+
+	for i, lineReq := range req.Lines {
+		if lineReq.Quantity <= 0 {
+			return nil, fmt.Errorf("%w: line %d: quantity must be greater than 0", domain.ErrInvalidInput, i+1)
+		}
+
+		product, err := s.getProductWithUnits(ctx, lineReq.ProductID)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		if !product.IsActive {
+			return nil, fmt.Errorf("%w: line %d: product %s is inactive", domain.ErrInvalidInput, i+1, product.SKU)
+		}
+		factor, err := product.UnitFactor(lineReq.UOM)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+
+		order.Lines = append(order.Lines, &domain.SalesOrderLine{
+			LineNumber:      i + 1,
+			ProductID:       product.ID,
+			OrderedQuantity: lineReq.Quantity * factor,
+			Product:         product,
+		})
+	}
+
+	err = s.uow.WithinTransaction(ctx, func(repos *repository.Repositories) error {
+		if err := repos.SalesOrder.Create(ctx, order); err != nil {
+			return err
+		}
+		for _, line := range order.Lines {
+			line.SalesOrderID = order.ID
+			if err := repos.SalesOrder.CreateLine(ctx, line); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return order, nil
+}
+
+func (s *salesOrderService) GetSalesOrder(ctx context.Context, id int) (*domain.SalesOrder, error) {
+	order, err := s.salesOrderRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sales order: %w", err)
+	}
+
+	if err := loadSalesOrderRelations(ctx, s.salesOrderRepo, order); err != nil {
+		return nil, err
+	}
+
+	return order, nil
+}
+
+func (s *salesOrderService) ListSalesOrders(ctx context.Context, filter *domain.SalesOrderFilter) ([]*domain.SalesOrder, int, error) {
+	orders, total, err := s.salesOrderRepo.List(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list sales orders: %w", err)
+	}
+
+	return orders, total, nil
+}
+
+// AllocateSalesOrder reserves the stock to pick for every line of a new order, choosing locations
+// and lots the same way pick lists do. Each line gets one reservation per location it is picked
+// from, referenced by the SO number. The order is allocated in full or not at all.
+func (s *salesOrderService) AllocateSalesOrder(ctx context.Context, id int, userID int) (*domain.SalesOrder, error) {
+	var order *domain.SalesOrder
+	err := s.uow.WithinTransaction(ctx, func(repos *repository.Repositories) error {
+		var err error
+		order, err = repos.SalesOrder.GetByIDForUpdate(ctx, id)
+		if err != nil {
+			return fmt.Errorf("failed to get sales order: %w", err)
+		}
+		if order.Status != domain.SalesOrderNew {
+			return fmt.Errorf("%w: sales order is %s", domain.ErrInvalidStatus, order.Status)
+		}
+
+		lines, err := repos.SalesOrder.ListLines(ctx, order.ID)
+		if err != nil {
+			return err
+		}
+
+		// Same lock as stock postings, so nothing else reserves or ships the stock being allocated
+		if err := lockProducts(ctx, repos, distinctLineProducts(lines)...); err != nil {
+			return err
+		}
+		if err := repos.Reservation.ExpireDue(ctx); err != nil {
+			return err
+		}
+
+		if err := allocateSalesOrder(ctx, repos, order, lines, userID); err != nil {
+			return err
+		}
+
+		return loadSalesOrderRelations(ctx, repos.SalesOrder, order)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return order, nil
+}
+
+// allocateSalesOrder reserves stock for the lines of a new order from its warehouse and marks it
+// allocated. The caller locks the order and its products. Nothing is written when a line cannot be
+// allocated in full; the error then wraps domain.ErrInsufficientStock.
+func allocateSalesOrder(ctx context.Context, repos *repository.Repositories, order *domain.SalesOrder, lines []*domain.SalesOrderLine, userID int) error {
+	pickLines := make([]*domain.PickListLineRequest, len(lines))
+	for i, line := range lines {
+		pickLines[i] = &domain.PickListLineRequest{ProductID: line.ProductID, Quantity: line.OrderedQuantity}
+	}
+	pickList, err := allocatePickList(ctx, repos.Product, repos.ProductUnit, repos.StockLevel, repos.Reservation, order.WarehouseID, pickLines)
+	if err != nil {
+		return err
+	}
+	if !pickList.Complete {
+		shortages := make([]string, len(pickList.Shortages))
+		for i, shortage := range pickList.Shortages {
+			shortages[i] = fmt.Sprintf("line %d (%s) is short %d of %d",
+				lines[shortage.LineNumber-1].LineNumber, shortage.SKU, shortage.ShortQuantity, shortage.RequestedQuantity)
+		}
+		return fmt.Errorf("%w: %s", domain.ErrInsufficientStock, strings.Join(shortages, "; "))
+	}
+
+	// One reservation per line and location, one allocation per lot picked there
+	type reservationKey struct{ lineNumber, locationID int }
+	reservations := make(map[reservationKey]*domain.Reservation)
+	var keys []reservationKey
+	for _, stop := range pickList.Stops {
+		key := reservationKey{stop.LineNumber, stop.Location.ID}
+		reservation, ok := reservations[key]
+		if !ok {
+			reservation = &domain.Reservation{
+				ProductID:  stop.ProductID,
+				LocationID: stop.Location.ID,
+				Status:     domain.ReservationActive,
+				Reference:  order.SONumber,
+				CreatedBy:  userID,
+			}
+			reservations[key] = reservation
+			keys = append(keys, key)
+		}
+		reservation.Quantity += stop.Quantity
+	}
+	for _, key := range keys {
+		if err := repos.Reservation.Create(ctx, reservations[key]); err != nil {
+			return err
+		}
+	}
+
+	for _, stop := range pickList.Stops {
+		allocation := &domain.SalesOrderAllocation{
+			SalesOrderID:     order.ID,
+			SalesOrderLineID: lines[stop.LineNumber-1].ID,
+			ReservationID:    reservations[reservationKey{stop.LineNumber, stop.Location.ID}].ID,
+			LocationID:       stop.Location.ID,
+			LotNumber:        stop.LotNumber,
+			Quantity:         stop.Quantity,
+		}
+		if err := repos.SalesOrder.CreateAllocation(ctx, allocation); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	order.Status = domain.SalesOrderAllocated
+	order.AllocatedAt = &now
+	return repos.SalesOrder.Update(ctx, order)
+}
+
+// PickSalesOrder records that the allocated stock has been picked
+func (s *salesOrderService) PickSalesOrder(ctx context.Context, id int) (*domain.SalesOrder, error) {
+	return s.advance(ctx, id, domain.SalesOrderAllocated, func(order *domain.SalesOrder, now time.Time) {
+		order.Status = domain.SalesOrderPicked
+		order.PickedAt = &now
+	})
+}
+
+// PackSalesOrder records that the picked stock has been packed, optionally with the number of packages
+func (s *salesOrderService) PackSalesOrder(ctx context.Context, id int, req *domain.PackSalesOrderRequest) (*domain.SalesOrder, error) {
+	if req.PackageCount != nil && *req.PackageCount <= 0 {
+		return nil, fmt.Errorf("%w: package count must be greater than 0", domain.ErrInvalidInput)
+	}
+
+	return s.advance(ctx, id, domain.SalesOrderPicked, func(order *domain.SalesOrder, now time.Time) {
+		order.Status = domain.SalesOrderPacked
+		order.PackedAt = &now
+		order.PackageCount = req.PackageCount
+	})
+}
+
+// advance moves an order on from the given status; apply sets the new status and its timestamp
+func (s *salesOrderService) advance(ctx context.Context, id int, from domain.SalesOrderStatus, apply func(order *domain.SalesOrder, now time.Time)) (*domain.SalesOrder, error) {
+	var order *domain.SalesOrder
+	err := s.uow.WithinTransaction(ctx, func(repos *repository.Repositories) error {
+		var err error
+		order, err = repos.SalesOrder.GetByIDForUpdate(ctx, id)
+		if err != nil {
+			return fmt.Errorf("failed to get sales order: %w", err)
+		}
+		if order.Status != from {
+			return fmt.Errorf("%w: sales order is %s, expected %s", domain.ErrInvalidStatus, order.Status, from)
+		}
+
+		apply(order, time.Now())
+		if err := repos.SalesOrder.Update(ctx, order); err != nil {
+			return err
+		}
+
+		return loadSalesOrderRelations(ctx, repos.SalesOrder, order)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return order, nil
+}
+
+// ShipSalesOrder posts an OUT movement for every allocation of a packed order, consuming its
+// reservation, and adds the quantity to the shipped quantity of the line. Serialized products
+// need the serial numbers of each line; they are matched to allocations by location and lot.
+// The whole shipment succeeds or fails together.
+func (s *salesOrderService) ShipSalesOrder(ctx context.Context, id int, req *domain.ShipSalesOrderRequest, userID int) (*domain.SalesOrderShipment, error) {
+	shipment := &domain.SalesOrderShipment{Movements: []*domain.StockMovement{}}
+	err := s.uow.WithinTransaction(ctx, func(repos *repository.Repositories) error {
+		order, err := repos.SalesOrder.GetByIDForUpdate(ctx, id)
+		if err != nil {
+			return fmt.Errorf("failed to get sales order: %w", err)
+		}
+		if order.Status != domain.SalesOrderPacked {
+			return fmt.Errorf("%w: sales order is %s, expected %s", domain.ErrInvalidStatus, order.Status, domain.SalesOrderPacked)
+		}
+
+		lines, err := repos.SalesOrder.ListLines(ctx, order.ID)
+		if err != nil {
+			return err
+		}
+		allocations, err := repos.SalesOrder.ListAllocations(ctx, order.ID)
+		if err != nil {
+			return err
+		}
+
+		var locationIDs []int
+		seen := make(map[int]bool)
+		for _, allocation := range allocations {
+			if !seen[allocation.LocationID] {
+				seen[allocation.LocationID] = true
+				locationIDs = append(locationIDs, allocation.LocationID)
+			}
+		}
+		if err := lockProducts(ctx, repos, distinctLineProducts(lines)...); err != nil {
+			return err
+		}
+		if err := lockLocations(ctx, repos, locationIDs...); err != nil {
+			return err
+		}
+
+		serials, err := assignShipmentSerials(ctx, repos, lines, allocations, req.Lines)
+		if err != nil {
+			return err
+		}
+
+		linesByID := make(map[int]*domain.SalesOrderLine, len(lines))
+		for _, line := range lines {
+			linesByID[line.ID] = line
+		}
+
+		for _, allocation := range allocations {
+			line := linesByID[allocation.SalesOrderLineID]
+
+			reservation, err := repos.Reservation.GetByIDForUpdate(ctx, allocation.ReservationID)
+			if err != nil {
+				return fmt.Errorf("failed to get reservation: %w", err)
+			}
+			if reservation.Status != domain.ReservationActive {
+				return fmt.Errorf("%w: line %d: reservation %d is %s", domain.ErrInvalidStatus, line.LineNumber, reservation.ID, reservation.Status)
+			}
+
+			reservationID := reservation.ID
+			lineID := line.ID
+			movement := &domain.StockMovement{
+				ProductID:        line.ProductID,
+				LocationID:       allocation.LocationID,
+				UserID:           userID,
+				Type:             domain.StockOUT,
+				Direction:        domain.DirectionDecrease,
+				Quantity:         allocation.Quantity,
+				Reference:        order.SONumber,
+				Notes:            req.Notes,
+				ReservationID:    &reservationID,
+				SalesOrderLineID: &lineID,
+				Lots:             []*domain.StockMovementLot{{LotNumber: allocation.LotNumber, Quantity: allocation.Quantity}},
+				SerialNumbers:    serials[allocation.ID],
+			}
+			if err := postStockMovement(ctx, repos, movement); err != nil {
+				return fmt.Errorf("line %d: %w", line.LineNumber, err)
+			}
+
+			reservation.ConsumedQuantity += allocation.Quantity
+			if reservation.OpenQuantity() == 0 {
+				now := time.Now()
+				reservation.Status = domain.ReservationConsumed
+				reservation.ClosedAt = &now
+			}
+			if err := repos.Reservation.Update(ctx, reservation); err != nil {
+				return err
+			}
+
+			if err := repos.SalesOrder.AddShippedQuantity(ctx, line.ID, allocation.Quantity); err != nil {
+				return err
+			}
+			shipment.Movements = append(shipment.Movements, movement)
+		}
+
+		now := time.Now()
+		order.Status = domain.SalesOrderShipped
+		order.ShippedAt = &now
+		if carrier := strings.TrimSpace(req.Carrier); carrier != "" {
+			order.Carrier = carrier
+		}
+		order.TrackingNumber = strings.TrimSpace(req.TrackingNumber)
+		if err := repos.SalesOrder.Update(ctx, order); err != nil {
+			return err
+		}
+
+		if err := loadSalesOrderRelations(ctx, repos.SalesOrder, order); err != nil {
+			return err
+		}
+		shipment.SalesOrder = order
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return shipment, nil
+}
+
+// assignShipmentSerials splits the serial numbers given for each line across the line's
+// allocations, by the location and lot each unit is stored in. It returns the serial numbers
+// to ship per allocation ID.
+func assignShipmentSerials(
+	ctx context.Context,
+	repos *repository.Repositories,
+	lines []*domain.SalesOrderLine,
+	allocations []*domain.SalesOrderAllocation,
+	lineReqs []*domain.ShipSalesOrderLineRequest,
+) (map[int][]string, error) {
+	onOrder := make(map[int]bool, len(lines))
+	for _, line := range lines {
+		onOrder[line.ID] = true
+	}
+	requested := make(map[int][]string, len(lineReqs))
+	for _, lineReq := range lineReqs {
+		if !onOrder[lineReq.LineID] {
+			return nil, fmt.Errorf("%w: line %d is not on this sales order", domain.ErrInvalidInput, lineReq.LineID)
+		}
+		requested[lineReq.LineID] = append(requested[lineReq.LineID], lineReq.SerialNumbers...)
+	}
+
+	assigned := make(map[int][]string)
+	for _, line := range lines {
+		serialNumbers := requested[line.ID]
+		if !line.Product.IsSerialized {
+			if len(serialNumbers) > 0 {
+				return nil, fmt.Errorf("%w: line %d: product %s is not serialized", domain.ErrInvalidInput, line.LineNumber, line.Product.SKU)
+			}
+			continue
+		}
+
+		var lineAllocations []*domain.SalesOrderAllocation
+		allocated := 0
+		for _, allocation := range allocations {
+			if allocation.SalesOrderLineID == line.ID {
+				lineAllocations = append(lineAllocations, allocation)
+				allocated += allocation.Quantity
+			}
+		}
+		if len(serialNumbers) != allocated {
+			return nil, fmt.Errorf("%w: line %d: %d serial numbers are required, got %d", domain.ErrInvalidInput, line.LineNumber, allocated, len(serialNumbers))
+		}
+
+		for _, serialNumber := range serialNumbers {
+			serial, err := repos.SerialNumber.GetByNumber(ctx, line.ProductID, serialNumber)
+			if err != nil {
+				return nil, fmt.Errorf("%w: line %d: serial number %s is not known for %s", domain.ErrInvalidInput, line.LineNumber, serialNumber, line.Product.SKU)
+			}
+
+			var match *domain.SalesOrderAllocation
+			for _, allocation := range lineAllocations {
+				if serial.LocationID != nil && *serial.LocationID == allocation.LocationID && serial.LotNumber == allocation.LotNumber &&
+					serial.Status == domain.InventoryAvailable && len(assigned[allocation.ID]) < allocation.Quantity {
+					match = allocation
+					break
+				}
+			}
+			if match == nil {
+				return nil, fmt.Errorf("%w: line %d: serial number %s is not available at an allocated location and lot", domain.ErrInvalidInput, line.LineNumber, serialNumber)
+			}
+			assigned[match.ID] = append(assigned[match.ID], serialNumber)
+		}
+	}
+
+	return assigned, nil
+}
+
+// CancelSalesOrder cancels an order that has not shipped and releases its reservations
+func (s *salesOrderService) CancelSalesOrder(ctx context.Context, id int) (*domain.SalesOrder, error) {
+	var order *domain.SalesOrder
+	err := s.uow.WithinTransaction(ctx, func(repos *repository.Repositories) error {
+		var err error
+		order, err = repos.SalesOrder.GetByIDForUpdate(ctx, id)
+		if err != nil {
+			return fmt.Errorf("failed to get sales order: %w", err)
+		}
+		if order.Status == domain.SalesOrderShipped || order.Status == domain.SalesOrderCancelled {
+			return fmt.Errorf("%w: sales order is %s", domain.ErrInvalidStatus, order.Status)
+		}
+
+		allocations, err := repos.SalesOrder.ListAllocations(ctx, order.ID)
+		if err != nil {
+			return err
+		}
+		released := make(map[int]bool)
+		for _, allocation := range allocations {
+			if released[allocation.ReservationID] {
+				continue
+			}
+			released[allocation.ReservationID] = true
+
+			reservation, err := repos.Reservation.GetByIDForUpdate(ctx, allocation.ReservationID)
+			if err != nil {
+				return fmt.Errorf("failed to get reservation: %w", err)
+			}
+			if reservation.Status != domain.ReservationActive {
+				continue
+			}
+			now := time.Now()
+			reservation.Status = domain.ReservationReleased
+			reservation.ClosedAt = &now
+			if err := repos.Reservation.Update(ctx, reservation); err != nil {
+				return err
+			}
+		}
+
+		now := time.Now()
+		order.Status = domain.SalesOrderCancelled
+		order.CancelledAt = &now
+		if err := repos.SalesOrder.Update(ctx, order); err != nil {
+			return err
+		}
+
+		return loadSalesOrderRelations(ctx, repos.SalesOrder, order)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return order, nil
+}
+
+// GetPickList returns the allocations of an order as stops along the walking path
+func (s *salesOrderService) GetPickList(ctx context.Context, id int) (*domain.PickList, error) {
+	order, err := s.GetSalesOrder(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(order.Allocations) == 0 {
+		return nil, fmt.Errorf("%w: sales order %s has not been allocated", domain.ErrInvalidStatus, order.SONumber)
+	}
+
+	linesByID := make(map[int]*domain.SalesOrderLine, len(order.Lines))
+	for _, line := range order.Lines {
+		linesByID[line.ID] = line
+	}
+
+	pickList := &domain.PickList{
+		Reference:   order.SONumber,
+		WarehouseID: order.WarehouseID,
+		GeneratedAt: time.Now(),
+		Complete:    true,
+		Stops:       []*domain.PickStop{},
+		Shortages:   []*domain.PickShortage{},
+	}
+	for _, allocation := range order.Allocations {
+		line := linesByID[allocation.SalesOrderLineID]
+		pickList.Stops = append(pickList.Stops, &domain.PickStop{
+			LineNumber:  line.LineNumber,
+			Location:    allocation.Location,
+			ProductID:   line.ProductID,
+			SKU:         line.Product.SKU,
+			ProductName: line.Product.Name,
+			LotNumber:   allocation.LotNumber,
+			Quantity:    allocation.Quantity,
+			Unit:        line.Product.BaseUnit,
+		})
+		pickList.TotalQuantity += allocation.Quantity
+	}
+	domain.SequencePickStops(pickList.Stops)
+
+	return pickList, nil
+}
+
+// ListMovements returns the movements shipped against the lines of a sales order, newest first
+func (s *salesOrderService) ListMovements(ctx context.Context, id int, limit, offset int) ([]*domain.StockMovement, int, error) {
+	if _, err := s.salesOrderRepo.GetByID(ctx, id); err != nil {
+		return nil, 0, fmt.Errorf("failed to get sales order: %w", err)
+	}
+
+	movements, total, err := s.stockMovementRepo.List(ctx, &domain.StockMovementFilter{
+		SalesOrderID: &id,
+		Limit:        limit,
+		Offset:       offset,
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get sales order movements: %w", err)
+	}
+
+	return movements, total, nil
+}
+
+// loadSalesOrderRelations populates the lines and allocations of an order
+func loadSalesOrderRelations(ctx context.Context, salesOrderRepo repository.SalesOrderRepository, order *domain.SalesOrder) error {
+	lines, err := salesOrderRepo.ListLines(ctx, order.ID)
+	if err != nil {
+		return err
+	}
+	order.Lines = lines
+
+	allocations, err := salesOrderRepo.ListAllocations(ctx, order.ID)
+	if err != nil {
+		return err
+	}
+	order.Allocations = allocations
+
+	return nil
+}
+
+// distinctLineProducts returns the IDs of the products on the given lines, each once
+func distinctLineProducts(lines []*domain.SalesOrderLine) []int {
+	var productIDs []int
+	seen := make(map[int]bool)
+	for _, line := range lines {
+		if !seen[line.ProductID] {
+			seen[line.ProductID] = true
+			productIDs = append(productIDs, line.ProductID)
+		}
+	}
+	return productIDs
+}
+
+// getProductWithUnits loads a product together with its pack sizes
+func (s *salesOrderService) getProductWithUnits(ctx context.Context, productID int) (*domain.Product, error) {
+	product, err := s.productRepo.GetByID(ctx, productID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product: %w", err)
+	}
+
+	units, err := s.productUnitRepo.ListByProduct(ctx, productID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product units: %w", err)
+	}
+	product.Units = units
+
+	return product, nil
+}
@@ -0,0 +1,199 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/edwinjordan/wmsTest_Golang/domain"
+	"github.com/edwinjordan/wmsTest_Golang/repository"
+)
+
+// costMovement works out the cost of a movement before it is recorded. Increases are valued at
+// the costs already set on the movement (a transfer carries the costs of its outbound leg, a
+// reversal of a decrease the costs it issued), at the unit cost received, or else at the current
+// average cost. Decreases take the open cost layers at the location oldest first; an AVERAGE
+// product only has one open layer per location, so this issues it at its moving average.
+// It returns the layers a decrease takes from, with their remaining quantities already reduced.
+func costMovement(ctx context.Context, repos *repository.Repositories, product *domain.Product, movement *domain.StockMovement) ([]*domain.CostLayer, error) {
+	if movement.Direction == domain.DirectionIncrease {
+		return nil, costIncrease(ctx, repos, product, movement)
+	}
+
+	layers, err := repos.CostLayer.ListOpen(ctx, movement.ProductID, movement.LocationID)
+	if err != nil {
+		return nil, err
+	}
+
+	var costs []*domain.StockMovementCost
+	var taken []*domain.CostLayer
+	remaining := movement.Quantity
+	for _, layer := range layers {
+		if remaining == 0 {
+			break
+		}
+
+		quantity := layer.RemainingQuantity
+		if quantity > remaining {
+			quantity = remaining
+		}
+		layer.RemainingQuantity -= quantity
+		costs = append(costs, &domain.StockMovementCost{
+			CostLayerID: layer.ID,
+			Quantity:    quantity,
+			UnitCost:    layer.UnitCost,
+		})
+		taken = append(taken, layer)
+		remaining -= quantity
+	}
+
+	// Every unit on hand is received through a costed increase, so this means the books are out of step
+	if remaining > 0 {
+		return nil, fmt.Errorf("cost layers of product %s at location %d do not cover %d units", product.SKU, movement.LocationID, movement.Quantity)
+	}
+
+	movement.Costs = costs
+	setMovementCost(movement)
+
+	return taken, nil
+}
+
+func costIncrease(ctx context.Context, repos *repository.Repositories, product *domain.Product, movement *domain.StockMovement) error {
+	costs := copyCosts(movement.Costs)
+	if len(costs) == 0 {
+		unitCost := movement.UnitCost
+		if unitCost == nil {
+			defaultCost, err := defaultUnitCost(ctx, repos, product, movement.LocationID)
+			if err != nil {
+				return err
+			}
+			unitCost = &defaultCost
+		}
+		costs = []*domain.StockMovementCost{{Quantity: movement.Quantity, UnitCost: *unitCost}}
+	}
+
+	quantity := 0
+	value := 0.0
+	for _, cost := range costs {
+		quantity += cost.Quantity
+		value += float64(cost.Quantity) * cost.UnitCost
+	}
+	if quantity != movement.Quantity {
+		return fmt.Errorf("%w: cost quantities must add up to the movement quantity", domain.ErrInvalidInput)
+	}
+
+	// An AVERAGE product folds everything it receives into one layer per location
+	if product.CostMethod == domain.CostAverage && len(costs) > 1 {
+		costs = []*domain.StockMovementCost{{Quantity: quantity, UnitCost: value / float64(quantity)}}
+	}
+
+	movement.Costs = costs
+	setMovementCost(movement)
+
+	return nil
+}
+
+// defaultUnitCost values stock received without a cost at the average cost of the product at the
+// location, then across all locations, and finally at the product price
+func defaultUnitCost(ctx context.Context, repos *repository.Repositories, product *domain.Product, locationID int) (float64, error) {
+	cost, err := repos.CostLayer.GetAverageCost(ctx, product.ID, &locationID)
+	if err != nil {
+		return 0, err
+	}
+	if cost != nil {
+		return *cost, nil
+	}
+
+	cost, err = repos.CostLayer.GetAverageCost(ctx, product.ID, nil)
+	if err != nil {
+		return 0, err
+	}
+	if cost != nil {
+		return *cost, nil
+	}
+
+	return product.Price, nil
+}
+
+// setMovementCost fills in the total cost of a movement and its average cost per unit
+func setMovementCost(movement *domain.StockMovement) {
+	total := 0.0
+	for _, cost := range movement.Costs {
+		total += float64(cost.Quantity) * cost.UnitCost
+	}
+	unitCost := total / float64(movement.Quantity)
+
+	movement.TotalCost = &total
+	movement.UnitCost = &unitCost
+}
+
+// recordCosts writes the cost layers of a posted movement: increases open a new layer per cost
+// (or, for AVERAGE products, fold into the open layer at the location) and decreases save the
+// layers costMovement took from. Every layer touched is linked to the movement.
+func recordCosts(ctx context.Context, repos *repository.Repositories, product *domain.Product, movement *domain.StockMovement, taken []*domain.CostLayer) error {
+	if movement.Direction == domain.DirectionDecrease {
+		for _, layer := range taken {
+			if err := repos.CostLayer.Update(ctx, layer); err != nil {
+				return err
+			}
+		}
+		return addMovementCosts(ctx, repos, movement)
+	}
+
+	if product.CostMethod == domain.CostAverage {
+		open, err := repos.CostLayer.ListOpen(ctx, movement.ProductID, movement.LocationID)
+		if err != nil {
+			return err
+		}
+		if len(open) > 0 {
+			layer := open[0]
+			cost := movement.Costs[0]
+			remaining := layer.RemainingQuantity + cost.Quantity
+			layer.UnitCost = (float64(layer.RemainingQuantity)*layer.UnitCost + float64(cost.Quantity)*cost.UnitCost) / float64(remaining)
+			layer.Quantity += cost.Quantity
+			layer.RemainingQuantity = remaining
+			if err := repos.CostLayer.Update(ctx, layer); err != nil {
+				return err
+			}
+			cost.CostLayerID = layer.ID
+			return addMovementCosts(ctx, repos, movement)
+		}
+	}
+
+	for _, cost := range movement.Costs {
+		movementID := movement.ID
+		layer := &domain.CostLayer{
+			ProductID:         movement.ProductID,
+			LocationID:        movement.LocationID,
+			MovementID:        &movementID,
+			UnitCost:          cost.UnitCost,
+			Quantity:          cost.Quantity,
+			RemainingQuantity: cost.Quantity,
+		}
+		if err := repos.CostLayer.Create(ctx, layer); err != nil {
+			return err
+		}
+		cost.CostLayerID = layer.ID
+	}
+
+	return addMovementCosts(ctx, repos, movement)
+}
+
+func addMovementCosts(ctx context.Context, repos *repository.Repositories, movement *domain.StockMovement) error {
+	for _, cost := range movement.Costs {
+		if err := repos.CostLayer.AddMovementCost(ctx, movement.ID, cost); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func copyCosts(costs []*domain.StockMovementCost) []*domain.StockMovementCost {
+	copied := make([]*domain.StockMovementCost, 0, len(costs))
+	for _, cost := range costs {
+		copied = append(copied, &domain.StockMovementCost{
+			Quantity: cost.Quantity,
+			UnitCost: cost.UnitCost,
+		})
+	}
+	return copied
+}
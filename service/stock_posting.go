@@ -0,0 +1,417 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/edwinjordan/wmsTest_Golang/domain"
+	"github.com/edwinjordan/wmsTest_Golang/repository"
+)
+
+// postStockMovement validates a movement against the current balances and records it together
+// with the stock level, cost layer and product quantity updates. It must run inside a unit of work: the
+// product and location rows stay locked until the transaction ends, which serializes concurrent
+// postings for the same product or location. Locks are always taken product first, then location.
+func postStockMovement(ctx context.Context, repos *repository.Repositories, movement *domain.StockMovement) error {
+	// Validate product exists and is active
+	product, err := repos.Product.GetByIDForUpdate(ctx, movement.ProductID)
+	if err != nil {
+		return fmt.Errorf("failed to get product: %w", err)
+	}
+
+	// Validate location exists and is active
+	location, err := repos.Location.GetByIDForUpdate(ctx, movement.LocationID)
+	if err != nil {
+		return fmt.Errorf("failed to get location: %w", err)
+	}
+
+	delta := movement.SignedQuantity()
+	if delta == 0 {
+		return fmt.Errorf("%w: movement direction is required", domain.ErrInvalidInput)
+	}
+
+	// Every movement posts to one inventory status bucket, available unless stated otherwise
+	if movement.InventoryStatus == "" {
+		movement.InventoryStatus = domain.InventoryAvailable
+	}
+	if !movement.InventoryStatus.IsValid() {
+		return fmt.Errorf("%w: inventory status must be AVAILABLE, QUARANTINE, DAMAGED or ON_HOLD", domain.ErrInvalidInput)
+	}
+
+	// Business Rule 1: Stock OUT tidak boleh melebihi stok tersedia di lokasi
+	if delta < 0 {
+		onHand, err := repos.StockLevel.GetStatusQuantity(ctx, movement.ProductID, movement.LocationID, movement.InventoryStatus)
+		if err != nil {
+			return fmt.Errorf("failed to get stock level: %w", err)
+		}
+		if onHand < -delta {
+			return domain.ErrInsufficientStock
+		}
+
+		// Reserved stock is promised elsewhere, so shipping and transfers may only take what is
+		// still available. Adjustments and reversals record what physically happened and are exempt.
+		// Reservations only ever hold available stock.
+		if (movement.Type == domain.StockOUT || movement.Type == domain.StockTRANSFER) && movement.InventoryStatus == domain.InventoryAvailable {
+			reserved, err := repos.Reservation.GetReservedQuantity(ctx, movement.ProductID, movement.LocationID, movement.ReservationID)
+			if err != nil {
+				return err
+			}
+			if onHand-reserved < -delta {
+				return domain.ErrInsufficientStock
+			}
+		}
+	}
+
+	// Business Rule 2: Stock IN tidak boleh melebihi kapasitas lokasi (unit, berat dan volume).
+	// Positive adjustments record stock that is already physically there, so they are exempt.
+	if delta > 0 && movement.Type != domain.StockADJUST {
+		load, err := repos.StockLevel.GetLocationLoad(ctx, movement.LocationID)
+		if err != nil {
+			return fmt.Errorf("failed to get location stock: %w", err)
+		}
+		if err := location.CheckCapacity(load, product, delta); err != nil {
+			return err
+		}
+	}
+
+	// Business Rule 6: Stock IN dan transfer hanya boleh masuk ke lokasi dengan suhu yang sesuai untuk produk.
+	// Adjustments and reversals record stock that is already there, so they are exempt.
+	if delta > 0 && (movement.Type == domain.StockIN || movement.Type == domain.StockTRANSFER) && !product.CanBeStoredAt(location) {
+		locationTemperature := "not temperature controlled"
+		if location.Temperature != nil {
+			locationTemperature = fmt.Sprintf("kept at %.1f°C", *location.Temperature)
+		}
+		return fmt.Errorf("%w: %s must be stored at %s, location %s is %s",
+			domain.ErrTemperatureRange, product.SKU, product.TemperatureRange(), location.Code, locationTemperature)
+	}
+
+	serials, err := assignSerials(ctx, repos, product, movement)
+	if err != nil {
+		return err
+	}
+
+	if err := assignLots(ctx, repos, movement); err != nil {
+		return err
+	}
+
+	costLayers, err := costMovement(ctx, repos, product, movement)
+	if err != nil {
+		return err
+	}
+
+	if err := repos.StockMovement.Create(ctx, movement); err != nil {
+		return fmt.Errorf("failed to create stock movement: %w", err)
+	}
+
+	// Keep the per-location, per-lot balances in step with the movement
+	for _, lot := range movement.Lots {
+		if err := repos.StockLevel.AdjustQuantity(ctx, movement.ProductID, movement.LocationID, lot.LotNumber, movement.InventoryStatus, lot.Quantity*movement.Direction); err != nil {
+			return fmt.Errorf("failed to update stock level: %w", err)
+		}
+	}
+
+	if err := moveSerials(ctx, repos, movement, serials); err != nil {
+		return err
+	}
+
+	if err := recordCosts(ctx, repos, product, movement, costLayers); err != nil {
+		return err
+	}
+
+	// Business Rule 3: Quantity produk auto-update saat ada pergerakan stok
+	if err := repos.Product.AdjustQuantity(ctx, movement.ProductID, delta); err != nil {
+		return fmt.Errorf("failed to update product quantity: %w", err)
+	}
+	product.Quantity += delta
+
+	// Raise or resolve low-stock alerts for the new balance
+	if err := evaluateReorderRules(ctx, repos, movement); err != nil {
+		return fmt.Errorf("failed to evaluate reorder rules: %w", err)
+	}
+
+	// Populate movement with related data
+	movement.Product = product
+	movement.Location = location
+
+	return nil
+}
+
+// assignLots works out which lots a movement touches. Increases go to the lots named on the
+// movement (registering new lots on first receipt) or to the untracked lot. Decreases take the
+// named lots, or are allocated FEFO across the lots held at the location; automatic allocation
+// for OUT movements never picks expired stock.
+func assignLots(ctx context.Context, repos *repository.Repositories, movement *domain.StockMovement) error {
+	if len(movement.Lots) == 0 {
+		if movement.Direction == domain.DirectionIncrease {
+			movement.Lots = []*domain.StockMovementLot{{Quantity: movement.Quantity}}
+			return nil
+		}
+		return allocateLotsFEFO(ctx, repos, movement)
+	}
+
+	total := 0
+	for _, lot := range movement.Lots {
+		if lot.Quantity <= 0 {
+			return fmt.Errorf("%w: lot quantity must be positive", domain.ErrInvalidInput)
+		}
+		total += lot.Quantity
+
+		if movement.Direction == domain.DirectionIncrease {
+			if err := ensureLot(ctx, repos, movement.ProductID, lot); err != nil {
+				return err
+			}
+			continue
+		}
+
+		level, err := repos.StockLevel.Get(ctx, movement.ProductID, movement.LocationID, lot.LotNumber, movement.InventoryStatus)
+		if err != nil {
+			if errors.Is(err, domain.ErrNotFound) {
+				return domain.ErrInsufficientStock
+			}
+			return fmt.Errorf("failed to get stock level: %w", err)
+		}
+		if level.Quantity < lot.Quantity {
+			return domain.ErrInsufficientStock
+		}
+		if movement.Type == domain.StockOUT && isExpired(level.ExpiryDate) {
+			return fmt.Errorf("%w: lot %s has expired", domain.ErrInvalidInput, lot.LotNumber)
+		}
+		lot.ExpiryDate = level.ExpiryDate
+	}
+
+	if total != movement.Quantity {
+		return fmt.Errorf("%w: lot quantities must add up to the movement quantity", domain.ErrInvalidInput)
+	}
+
+	return nil
+}
+
+// allocateLotsFEFO splits a decrease across the lots at the location that are in the movement's
+// status, earliest expiry first
+func allocateLotsFEFO(ctx context.Context, repos *repository.Repositories, movement *domain.StockMovement) error {
+	levels, err := repos.StockLevel.ListLots(ctx, movement.ProductID, movement.LocationID, movement.InventoryStatus)
+	if err != nil {
+		return err
+	}
+
+	remaining := movement.Quantity
+	for _, level := range levels {
+		if remaining == 0 {
+			break
+		}
+		if movement.Type == domain.StockOUT && isExpired(level.ExpiryDate) {
+			continue
+		}
+
+		quantity := level.Quantity
+		if quantity > remaining {
+			quantity = remaining
+		}
+		movement.Lots = append(movement.Lots, &domain.StockMovementLot{
+			LotNumber:  level.LotNumber,
+			ExpiryDate: level.ExpiryDate,
+			Quantity:   quantity,
+		})
+		remaining -= quantity
+	}
+
+	if remaining > 0 {
+		movement.Lots = nil
+		return domain.ErrInsufficientStock
+	}
+
+	return nil
+}
+
+// ensureLot registers a lot on its first receipt. Later receipts of the same lot must not
+// contradict the expiry date recorded for it.
+func ensureLot(ctx context.Context, repos *repository.Repositories, productID int, movementLot *domain.StockMovementLot) error {
+	if movementLot.LotNumber == "" {
+		if movementLot.ExpiryDate != nil {
+			return fmt.Errorf("%w: an expiry date requires a lot number", domain.ErrInvalidInput)
+		}
+		return nil
+	}
+
+	lot, err := repos.Lot.GetByNumber(ctx, productID, movementLot.LotNumber)
+	if err != nil {
+		if !errors.Is(err, domain.ErrNotFound) {
+			return fmt.Errorf("failed to get lot: %w", err)
+		}
+		lot = &domain.Lot{
+			ProductID:  productID,
+			LotNumber:  movementLot.LotNumber,
+			ExpiryDate: movementLot.ExpiryDate,
+		}
+		return repos.Lot.Create(ctx, lot)
+	}
+
+	if movementLot.ExpiryDate != nil && (lot.ExpiryDate == nil || !lot.ExpiryDate.Equal(*movementLot.ExpiryDate)) {
+		return fmt.Errorf("%w: expiry date does not match lot %s", domain.ErrInvalidInput, lot.LotNumber)
+	}
+	movementLot.ExpiryDate = lot.ExpiryDate
+
+	return nil
+}
+
+// isExpired reports whether a lot with the given expiry date is past its expiry today
+func isExpired(expiryDate *time.Time) bool {
+	if expiryDate == nil {
+		return false
+	}
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	return expiryDate.Before(today)
+}
+
+// assignSerials validates the serial numbers of a movement. Serialized products must name one
+// serial per unit; a unit can only leave a location it is on hand at, in the status the movement
+// takes stock from, and can only arrive when it is not on hand anywhere. Decreases take their lot
+// breakdown from the units being moved.
+func assignSerials(ctx context.Context, repos *repository.Repositories, product *domain.Product, movement *domain.StockMovement) ([]*domain.SerialNumber, error) {
+	if !product.IsSerialized {
+		if len(movement.SerialNumbers) > 0 {
+			return nil, fmt.Errorf("%w: product %s is not serialized", domain.ErrInvalidInput, product.SKU)
+		}
+		return nil, nil
+	}
+
+	if len(movement.SerialNumbers) != movement.Quantity {
+		return nil, fmt.Errorf("%w: product %s requires one serial number per unit", domain.ErrInvalidInput, product.SKU)
+	}
+
+	seen := make(map[string]bool)
+	serials := make([]*domain.SerialNumber, 0, len(movement.SerialNumbers))
+	for _, serialNumber := range movement.SerialNumbers {
+		if serialNumber == "" {
+			return nil, fmt.Errorf("%w: serial numbers must not be empty", domain.ErrInvalidInput)
+		}
+		if seen[serialNumber] {
+			return nil, fmt.Errorf("%w: serial number %s is listed more than once", domain.ErrInvalidInput, serialNumber)
+		}
+		seen[serialNumber] = true
+
+		serial, err := repos.SerialNumber.GetByNumber(ctx, product.ID, serialNumber)
+		if err != nil && !errors.Is(err, domain.ErrNotFound) {
+			return nil, err
+		}
+
+		if movement.Direction == domain.DirectionDecrease {
+			if serial == nil || serial.LocationID == nil || *serial.LocationID != movement.LocationID {
+				return nil, fmt.Errorf("%w: serial number %s is not on hand at this location", domain.ErrInvalidInput, serialNumber)
+			}
+			if serial.Status != movement.InventoryStatus {
+				return nil, fmt.Errorf("%w: serial number %s is %s, not %s", domain.ErrInvalidInput, serialNumber, serial.Status, movement.InventoryStatus)
+			}
+		} else {
+			if serial != nil && serial.LocationID != nil {
+				return nil, fmt.Errorf("%w: serial number %s is already on hand", domain.ErrInvalidInput, serialNumber)
+			}
+			if serial == nil {
+				serial = &domain.SerialNumber{
+					ProductID:    product.ID,
+					SerialNumber: serialNumber,
+				}
+			}
+		}
+
+		serials = append(serials, serial)
+	}
+
+	if movement.Direction == domain.DirectionDecrease {
+		named := ""
+		if len(movement.Lots) == 1 {
+			named = movement.Lots[0].LotNumber
+		}
+
+		var lots []*domain.StockMovementLot
+		byLot := make(map[string]*domain.StockMovementLot)
+		for _, serial := range serials {
+			if len(movement.Lots) > 0 && serial.LotNumber != named {
+				return nil, fmt.Errorf("%w: serial number %s is not in lot %s", domain.ErrInvalidInput, serial.SerialNumber, named)
+			}
+			lot, ok := byLot[serial.LotNumber]
+			if !ok {
+				lot = &domain.StockMovementLot{LotNumber: serial.LotNumber}
+				byLot[serial.LotNumber] = lot
+				lots = append(lots, lot)
+			}
+			lot.Quantity++
+		}
+		movement.Lots = lots
+	}
+
+	return serials, nil
+}
+
+// moveSerials records the new whereabouts of the units moved by a posted movement
+func moveSerials(ctx context.Context, repos *repository.Repositories, movement *domain.StockMovement, serials []*domain.SerialNumber) error {
+	for _, serial := range serials {
+		if movement.Direction == domain.DirectionDecrease {
+			serial.LocationID = nil
+		} else {
+			locationID := movement.LocationID
+			serial.LocationID = &locationID
+			serial.Status = movement.InventoryStatus
+			if len(movement.Lots) == 1 {
+				serial.LotNumber = movement.Lots[0].LotNumber
+			}
+		}
+
+		if serial.ID == 0 {
+			if err := repos.SerialNumber.Create(ctx, serial); err != nil {
+				return err
+			}
+		} else if err := repos.SerialNumber.UpdateLocation(ctx, serial); err != nil {
+			return err
+		}
+
+		if err := repos.SerialNumber.AddMovement(ctx, movement.ID, serial.ID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// lockProducts locks the given products in ascending ID order, see lockLocations
+func lockProducts(ctx context.Context, repos *repository.Repositories, productIDs ...int) error {
+	ids := append([]int(nil), productIDs...)
+	sort.Ints(ids)
+
+	for _, id := range ids {
+		if _, err := repos.Product.GetByIDForUpdate(ctx, id); err != nil {
+			return fmt.Errorf("failed to get product: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// lockLocations locks the given locations in ascending ID order. Callers that post movements
+// against several products or locations in one transaction take these locks up front (all
+// products before any location) so that two transactions never wait on each other in opposite order.
+func lockLocations(ctx context.Context, repos *repository.Repositories, locationIDs ...int) error {
+	ids := append([]int(nil), locationIDs...)
+	sort.Ints(ids)
+
+	for _, id := range ids {
+		if _, err := repos.Location.GetByIDForUpdate(ctx, id); err != nil {
+			return fmt.Errorf("failed to get location: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// getOnHand returns the quantity of a product stored at a location across all of its lots
+func getOnHand(ctx context.Context, stockLevelRepo repository.StockLevelRepository, productID, locationID int) (int, error) {
+	quantity, err := stockLevelRepo.GetQuantity(ctx, productID, locationID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get stock level: %w", err)
+	}
+
+	return quantity, nil
+}
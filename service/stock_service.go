@@ -2,146 +2,564 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
+	"time"
 
 	"github.com/edwinjordan/wmsTest_Golang/domain"
 	"github.com/edwinjordan/wmsTest_Golang/repository"
 )
 
+const (
+	// serialHistoryLimit caps the number of movements returned for a single serial number
+	serialHistoryLimit = 1000
+
+	// maxBatchSize caps the number of lines in one stock movement batch
+	maxBatchSize = 1000
+
+	// stockSnapshotSettleTime keeps snapshots clear of movements that were stamped but not yet committed
+	stockSnapshotSettleTime = time.Minute
+)
+
+// BatchLineError reports which line of an all-or-nothing batch caused it to be rolled back
+type BatchLineError struct {
+	Line int
+	Err  error
+}
+
+func (e *BatchLineError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+func (e *BatchLineError) Unwrap() error {
+	return e.Err
+}
+
 type StockService interface {
 	ProcessStockMovement(ctx context.Context, req *domain.CreateStockMovementRequest, userID int) (*domain.StockMovement, error)
+	ProcessStockMovementBatch(ctx context.Context, req *domain.CreateStockMovementBatchRequest, userID int) (*domain.StockMovementBatchResult, error)
+	TransferStock(ctx context.Context, req *domain.CreateStockTransferRequest, userID int) (*domain.StockTransfer, error)
+	AdjustStock(ctx context.Context, req *domain.CreateStockAdjustmentRequest, userID int) (*domain.StockMovement, error)
+	ReverseStockMovement(ctx context.Context, id int, req *domain.ReverseStockMovementRequest, userID int) ([]*domain.StockMovement, error)
 	GetStockMovements(ctx context.Context, filter *domain.StockMovementFilter) ([]*domain.StockMovement, int, error)
 	GetStockMovementByID(ctx context.Context, id int) (*domain.StockMovement, error)
+	GetStockSummary(ctx context.Context, productID int, uom string, warehouseID *int) (*domain.StockSummary, error)
+	GetStockByLocation(ctx context.Context, locationID int) (*domain.LocationStock, error)
+	GetStockByWarehouse(ctx context.Context, warehouseID int) (*domain.WarehouseStockSummary, error)
+	GetSerialHistory(ctx context.Context, serialNumber string) ([]*domain.SerialHistory, error)
+	GetInventoryValuation(ctx context.Context, filter *domain.ValuationFilter) (*domain.ValuationReport, error)
+	GetStockAsOf(ctx context.Context, filter *domain.StockAsOfFilter) (*domain.StockAsOf, error)
+	TakeStockSnapshot(ctx context.Context, at time.Time) (*domain.StockSnapshot, error)
+	ReconcileStock(ctx context.Context, fix bool, userID int) (*domain.StockReconciliation, error)
 }
 
 type stockService struct {
 	stockMovementRepo repository.StockMovementRepository
 	productRepo       repository.ProductRepository
+	productUnitRepo   repository.ProductUnitRepository
 	locationRepo      repository.LocationRepository
+	warehouseRepo     repository.WarehouseRepository
+	stockLevelRepo    repository.StockLevelRepository
+	stockSnapshotRepo repository.StockSnapshotRepository
+	serialNumberRepo  repository.SerialNumberRepository
+	reservationRepo   repository.ReservationRepository
+	costLayerRepo     repository.CostLayerRepository
+	uow               repository.UnitOfWork
 }
 
 func NewStockService(
 	stockMovementRepo repository.StockMovementRepository,
 	productRepo repository.ProductRepository,
+	productUnitRepo repository.ProductUnitRepository,
 	locationRepo repository.LocationRepository,
+	warehouseRepo repository.WarehouseRepository,
+	stockLevelRepo repository.StockLevelRepository,
+	stockSnapshotRepo repository.StockSnapshotRepository,
+	serialNumberRepo repository.SerialNumberRepository,
+	reservationRepo repository.ReservationRepository,
+	costLayerRepo repository.CostLayerRepository,
+	uow repository.UnitOfWork,
 ) StockService {
 	return &stockService{
 		stockMovementRepo: stockMovementRepo,
 		productRepo:       productRepo,
+		productUnitRepo:   productUnitRepo,
 		locationRepo:      locationRepo,
+		warehouseRepo:     warehouseRepo,
+		stockLevelRepo:    stockLevelRepo,
+		stockSnapshotRepo: stockSnapshotRepo,
+		serialNumberRepo:  serialNumberRepo,
+		reservationRepo:   reservationRepo,
+		costLayerRepo:     costLayerRepo,
+		uow:               uow,
 	}
 }
 
 func (s *stockService) ProcessStockMovement(ctx context.Context, req *domain.CreateStockMovementRequest, userID int) (*domain.StockMovement, error) {
-	// Validate product exists and is active
-	product, err := s.productRepo.GetByID(ctx, req.ProductID)
+	movement, err := newStockMovement(req, userID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get product: %w", err)
+		return nil, err
 	}
-	if !product.IsActive {
-		return nil, fmt.Errorf("product is not active")
+	if err := s.convertToBaseUnits(ctx, req.UOM, movement); err != nil {
+		return nil, err
 	}
 
-	// Validate location exists and is active
-	location, err := s.locationRepo.GetByID(ctx, req.LocationID)
+	// Validation, movement insert and balance updates succeed or fail together
+	err = s.uow.WithinTransaction(ctx, func(repos *repository.Repositories) error {
+		if err := checkStatusOverride(ctx, repos, movement); err != nil {
+			return err
+		}
+		return postStockMovement(ctx, repos, movement)
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to get location: %w", err)
+		return nil, err
+	}
+
+	return movement, nil
+}
+
+// checkStatusOverride makes sure that stock out of quarantine, damaged or on hold stock is only
+// shipped by users holding the override permission
+func checkStatusOverride(ctx context.Context, repos *repository.Repositories, movement *domain.StockMovement) error {
+	if movement.Type != domain.StockOUT || movement.InventoryStatus == "" || movement.InventoryStatus == domain.InventoryAvailable {
+		return nil
+	}
+
+	user, err := repos.User.GetByID(ctx, movement.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
 	}
-	if !location.IsActive {
-		return nil, fmt.Errorf("location is not active")
+	if !user.HasPermission(domain.PermissionOverrideInventoryStatus) {
+		return fmt.Errorf("%w: taking %s stock out needs the %s permission",
+			domain.ErrForbidden, movement.InventoryStatus, domain.PermissionOverrideInventoryStatus)
 	}
 
-	// Get current stock for validation from product quantity
-	currentQuantity := product.Quantity
+	return nil
+}
 
-	// Debug logging - check request type
-	fmt.Printf("DEBUG: Request Type='%s', StockIN='%s', StockOUT='%s'\n", req.Type, domain.StockIN, domain.StockOUT)
+// ProcessStockMovementBatch posts a list of IN/OUT movements in request order, so every line is
+// checked against the balances left by the lines before it. In ALL_OR_NOTHING mode the batch runs
+// in one transaction and the first failing line rolls everything back; the returned error is a
+// *BatchLineError. In BEST_EFFORT mode every line is posted in its own transaction.
+func (s *stockService) ProcessStockMovementBatch(ctx context.Context, req *domain.CreateStockMovementBatchRequest, userID int) (*domain.StockMovementBatchResult, error) {
+	if req.Mode == "" {
+		req.Mode = domain.BatchAllOrNothing
+	}
+	if req.Mode != domain.BatchAllOrNothing && req.Mode != domain.BatchBestEffort {
+		return nil, fmt.Errorf("%w: mode must be ALL_OR_NOTHING or BEST_EFFORT", domain.ErrInvalidInput)
+	}
+	if len(req.Movements) == 0 {
+		return nil, fmt.Errorf("%w: at least one movement is required", domain.ErrInvalidInput)
+	}
+	if len(req.Movements) > maxBatchSize {
+		return nil, fmt.Errorf("%w: a batch can hold at most %d movements", domain.ErrInvalidInput, maxBatchSize)
+	}
 
-	// Business Rule 1: Stock OUT tidak boleh melebihi stok tersedia
-	if req.Type == domain.StockOUT {
-		fmt.Printf("DEBUG: Processing Stock OUT validation\n")
-		if currentQuantity < req.Quantity {
-			return nil, domain.ErrInsufficientStock
-		}
+	result := &domain.StockMovementBatchResult{Mode: req.Mode}
+	for i := range req.Movements {
+		result.Lines = append(result.Lines, &domain.StockMovementBatchLine{Line: i + 1})
 	}
 
-	// Business Rule 2: Stock IN tidak boleh melebihi kapasitas lokasi
-	if req.Type == domain.StockIN {
-		fmt.Printf("DEBUG: Processing Stock IN validation\n")
-		// Get all stock movements for this location to calculate current occupancy
-		filter := &domain.StockMovementFilter{
-			LocationID: &req.LocationID,
-			Limit:      1000, // Get all movements for this location
-			Offset:     0,
+	if req.Mode == domain.BatchBestEffort {
+		for i := range req.Movements {
+			line := result.Lines[i]
+			line.Movement, line.Err = s.ProcessStockMovement(ctx, &req.Movements[i], userID)
+			if line.Err != nil {
+				line.Movement = nil
+				result.Failed++
+				continue
+			}
+			line.Success = true
+			result.Succeeded++
 		}
+		return result, nil
+	}
 
-		movements, _, err := s.stockMovementRepo.List(ctx, filter)
+	movements := make([]*domain.StockMovement, len(req.Movements))
+	for i := range req.Movements {
+		movement, err := newStockMovement(&req.Movements[i], userID)
 		if err != nil {
-			return nil, fmt.Errorf("failed to get location stock movements: %w", err)
+			return nil, &BatchLineError{Line: i + 1, Err: err}
+		}
+		if err := s.convertToBaseUnits(ctx, req.Movements[i].UOM, movement); err != nil {
+			return nil, &BatchLineError{Line: i + 1, Err: err}
+		}
+		movements[i] = movement
+	}
+
+	err := s.uow.WithinTransaction(ctx, func(repos *repository.Repositories) error {
+		// Take every lock up front in the usual order, so a large batch cannot deadlock with
+		// single postings touching the same products and locations
+		if err := lockBatch(ctx, repos, movements); err != nil {
+			return err
 		}
 
-		// Calculate current total quantity in location by summing all movements
-		currentLocationQuantity := 0
-		for _, movement := range movements {
-			if movement.Type == domain.StockIN {
-				currentLocationQuantity += movement.Quantity
-			} else {
-				currentLocationQuantity -= movement.Quantity
+		for i, movement := range movements {
+			if err := checkStatusOverride(ctx, repos, movement); err != nil {
+				return &BatchLineError{Line: i + 1, Err: err}
+			}
+			if err := postStockMovement(ctx, repos, movement); err != nil {
+				return &BatchLineError{Line: i + 1, Err: err}
 			}
 		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
 
-		// Check if adding new quantity would exceed location capacity
-		newTotalQuantity := currentLocationQuantity + req.Quantity
+	for i, movement := range movements {
+		result.Lines[i].Success = true
+		result.Lines[i].Movement = movement
+	}
+	result.Succeeded = len(movements)
 
-		// Debug logging (remove in production)
-		fmt.Printf("DEBUG: Location ID=%d, Current quantity=%d, Adding=%d, Total will be=%d, Capacity=%d\n",
-			req.LocationID, currentLocationQuantity, req.Quantity, newTotalQuantity, location.Capacity)
+	return result, nil
+}
+
+// lockBatch locks the products and then the locations of a batch, reporting a missing row
+// against the first line that refers to it
+func lockBatch(ctx context.Context, repos *repository.Repositories, movements []*domain.StockMovement) error {
+	productLines := make(map[int]int)
+	locationLines := make(map[int]int)
+	var productIDs, locationIDs []int
+	for i, movement := range movements {
+		if _, ok := productLines[movement.ProductID]; !ok {
+			productLines[movement.ProductID] = i + 1
+			productIDs = append(productIDs, movement.ProductID)
+		}
+		if _, ok := locationLines[movement.LocationID]; !ok {
+			locationLines[movement.LocationID] = i + 1
+			locationIDs = append(locationIDs, movement.LocationID)
+		}
+	}
+	sort.Ints(productIDs)
+	sort.Ints(locationIDs)
 
-		if newTotalQuantity > location.Capacity {
-			fmt.Printf("DEBUG: CAPACITY EXCEEDED! Returning ErrExceedsCapacity\n")
-			return nil, domain.ErrExceedsCapacity
-		} else {
-			fmt.Printf("DEBUG: Capacity OK - continuing with movement creation\n")
+	for _, id := range productIDs {
+		if _, err := repos.Product.GetByIDForUpdate(ctx, id); err != nil {
+			return &BatchLineError{Line: productLines[id], Err: fmt.Errorf("failed to get product: %w", err)}
+		}
+	}
+	for _, id := range locationIDs {
+		if _, err := repos.Location.GetByIDForUpdate(ctx, id); err != nil {
+			return &BatchLineError{Line: locationLines[id], Err: fmt.Errorf("failed to get location: %w", err)}
+		}
+	}
+
+	return nil
+}
+
+// newStockMovement validates an IN/OUT request and builds the movement it describes
+func newStockMovement(req *domain.CreateStockMovementRequest, userID int) (*domain.StockMovement, error) {
+	if req.ProductID <= 0 || req.LocationID <= 0 {
+		return nil, fmt.Errorf("%w: valid product and location IDs are required", domain.ErrInvalidInput)
+	}
+	if req.Type != domain.StockIN && req.Type != domain.StockOUT {
+		return nil, fmt.Errorf("%w: type must be IN or OUT", domain.ErrInvalidInput)
+	}
+	if req.Quantity <= 0 {
+		return nil, fmt.Errorf("%w: quantity must be greater than 0", domain.ErrInvalidInput)
+	}
+	if req.UnitCost != nil {
+		if req.Type != domain.StockIN {
+			return nil, fmt.Errorf("%w: unit cost can only be given when receiving stock", domain.ErrInvalidInput)
+		}
+		if *req.UnitCost < 0 {
+			return nil, fmt.Errorf("%w: unit cost must not be negative", domain.ErrInvalidInput)
 		}
 	}
 
-	// Create stock movement record
 	movement := &domain.StockMovement{
-		ProductID:  req.ProductID,
-		LocationID: req.LocationID,
-		UserID:     userID,
-		Type:       req.Type,
-		Quantity:   req.Quantity,
-		Reference:  req.Reference,
-		Notes:      req.Notes,
+		ProductID:       req.ProductID,
+		LocationID:      req.LocationID,
+		UserID:          userID,
+		Type:            req.Type,
+		Direction:       domain.DirectionForType(req.Type),
+		Quantity:        req.Quantity,
+		Reference:       req.Reference,
+		Notes:           req.Notes,
+		SerialNumbers:   req.SerialNumbers,
+		UnitCost:        req.UnitCost,
+		InventoryStatus: req.InventoryStatus,
 	}
 
-	err = s.stockMovementRepo.Create(ctx, movement)
+	lots, err := requestedLots(movement.Direction, req.LotNumber, req.ExpiryDate, req.Quantity)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create stock movement: %w", err)
+		return nil, err
+	}
+	movement.Lots = lots
+
+	return movement, nil
+}
+
+func (s *stockService) TransferStock(ctx context.Context, req *domain.CreateStockTransferRequest, userID int) (*domain.StockTransfer, error) {
+	if req.FromLocationID == req.ToLocationID {
+		return nil, fmt.Errorf("%w: source and destination locations must differ", domain.ErrInvalidInput)
+	}
+
+	outbound := &domain.StockMovement{
+		ProductID:       req.ProductID,
+		LocationID:      req.FromLocationID,
+		UserID:          userID,
+		Type:            domain.StockTRANSFER,
+		Direction:       domain.DirectionDecrease,
+		Quantity:        req.Quantity,
+		Reference:       req.Reference,
+		Notes:           req.Notes,
+		SerialNumbers:   req.SerialNumbers,
+		InventoryStatus: req.InventoryStatus,
+	}
+	if req.LotNumber != "" {
+		outbound.Lots = []*domain.StockMovementLot{{LotNumber: req.LotNumber, Quantity: req.Quantity}}
+	}
+	inbound := &domain.StockMovement{
+		ProductID:       req.ProductID,
+		LocationID:      req.ToLocationID,
+		UserID:          userID,
+		Type:            domain.StockTRANSFER,
+		Direction:       domain.DirectionIncrease,
+		Quantity:        req.Quantity,
+		Reference:       req.Reference,
+		Notes:           req.Notes,
+		SerialNumbers:   req.SerialNumbers,
+		InventoryStatus: req.InventoryStatus,
+	}
+	if err := s.convertToBaseUnits(ctx, req.UOM, outbound, inbound); err != nil {
+		return nil, err
+	}
+
+	err := s.uow.WithinTransaction(ctx, func(repos *repository.Repositories) error {
+		if _, err := repos.Product.GetByIDForUpdate(ctx, req.ProductID); err != nil {
+			return fmt.Errorf("failed to get product: %w", err)
+		}
+		if err := lockLocations(ctx, repos, req.FromLocationID, req.ToLocationID); err != nil {
+			return err
+		}
+
+		// Source availability is checked by the outbound leg, destination capacity by the inbound leg
+		if err := postStockMovement(ctx, repos, outbound); err != nil {
+			return err
+		}
+
+		// The destination receives exactly the lots taken from the source, at the cost they left it
+		inbound.Lots = copyLots(outbound.Lots)
+		inbound.Costs = copyCosts(outbound.Costs)
+		inbound.RelatedMovementID = &outbound.ID
+		if err := postStockMovement(ctx, repos, inbound); err != nil {
+			return err
+		}
+
+		outbound.RelatedMovementID = &inbound.ID
+		return repos.StockMovement.SetRelatedMovement(ctx, outbound.ID, inbound.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.StockTransfer{
+		Outbound: outbound,
+		Inbound:  inbound,
+	}, nil
+}
+
+func (s *stockService) AdjustStock(ctx context.Context, req *domain.CreateStockAdjustmentRequest, userID int) (*domain.StockMovement, error) {
+	if !req.ReasonCode.IsValid() {
+		return nil, fmt.Errorf("%w: unknown reason code %q", domain.ErrInvalidInput, req.ReasonCode)
+	}
+	if req.ReasonCode == domain.ReasonReconciliation {
+		return nil, fmt.Errorf("%w: reason code %s is reserved for the reconcile command", domain.ErrInvalidInput, req.ReasonCode)
+	}
+	if req.Quantity == 0 {
+		return nil, fmt.Errorf("%w: adjustment quantity must not be zero", domain.ErrInvalidInput)
 	}
 
-	// Business Rule 3: Quantity produk auto-update saat ada pergerakan stok
-	var newQuantity int
-	if req.Type == domain.StockIN {
-		newQuantity = currentQuantity + req.Quantity
-	} else {
-		newQuantity = currentQuantity - req.Quantity
+	movement := &domain.StockMovement{
+		ProductID:       req.ProductID,
+		LocationID:      req.LocationID,
+		UserID:          userID,
+		Type:            domain.StockADJUST,
+		Direction:       domain.DirectionIncrease,
+		Quantity:        req.Quantity,
+		Reference:       req.Reference,
+		ReasonCode:      req.ReasonCode,
+		Notes:           req.Notes,
+		SerialNumbers:   req.SerialNumbers,
+		InventoryStatus: req.InventoryStatus,
+	}
+	if req.Quantity < 0 {
+		movement.Direction = domain.DirectionDecrease
+		movement.Quantity = -req.Quantity
 	}
 
-	// Update product quantity
-	err = s.productRepo.UpdateQuantity(ctx, req.ProductID, newQuantity)
+	lots, err := requestedLots(movement.Direction, req.LotNumber, req.ExpiryDate, movement.Quantity)
 	if err != nil {
-		return nil, fmt.Errorf("failed to update product quantity: %w", err)
+		return nil, err
+	}
+	movement.Lots = lots
+	if err := s.convertToBaseUnits(ctx, req.UOM, movement); err != nil {
+		return nil, err
 	}
 
-	// Populate movement with related data
-	movement.Product = product
-	movement.Location = location
+	err = s.uow.WithinTransaction(ctx, func(repos *repository.Repositories) error {
+		return postStockMovement(ctx, repos, movement)
+	})
+	if err != nil {
+		return nil, err
+	}
 
 	return movement, nil
 }
 
+// ReverseStockMovement posts a compensating REVERSAL movement for a movement. Both legs of a
+// transfer are always reversed together. The reversal goes through the same availability and
+// capacity rules as any other movement.
+func (s *stockService) ReverseStockMovement(ctx context.Context, id int, req *domain.ReverseStockMovementRequest, userID int) ([]*domain.StockMovement, error) {
+	original, err := s.stockMovementRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stock movement: %w", err)
+	}
+	if original.Type == domain.StockREVERSAL {
+		return nil, fmt.Errorf("%w: a reversal cannot be reversed", domain.ErrInvalidInput)
+	}
+	// Reconciliation adjustments never moved stock, so there is nothing to give back
+	if original.ReasonCode == domain.ReasonReconciliation {
+		return nil, fmt.Errorf("%w: a reconciliation adjustment cannot be reversed", domain.ErrInvalidInput)
+	}
+
+	originals := []*domain.StockMovement{original}
+	if original.Type == domain.StockTRANSFER && original.RelatedMovementID != nil {
+		related, err := s.stockMovementRepo.GetByID(ctx, *original.RelatedMovementID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get related stock movement: %w", err)
+		}
+		originals = append(originals, related)
+	}
+
+	// Post the legs that take stock away first, so a reversed transfer frees its destination
+	// before the source is refilled
+	sort.SliceStable(originals, func(i, j int) bool {
+		return originals[i].Direction > originals[j].Direction
+	})
+
+	notes := req.Notes
+	if notes == "" {
+		notes = fmt.Sprintf("Reversal of movement #%d", original.ID)
+	}
+
+	var reversals []*domain.StockMovement
+	err = s.uow.WithinTransaction(ctx, func(repos *repository.Repositories) error {
+		// Every posting for the product waits on this lock, so the double reversal check below cannot race
+		if _, err := repos.Product.GetByIDForUpdate(ctx, original.ProductID); err != nil {
+			return fmt.Errorf("failed to get product: %w", err)
+		}
+
+		var locationIDs []int
+		for _, m := range originals {
+			locationIDs = append(locationIDs, m.LocationID)
+		}
+		if err := lockLocations(ctx, repos, locationIDs...); err != nil {
+			return err
+		}
+
+		for _, m := range originals {
+			_, err := repos.StockMovement.GetReversal(ctx, m.ID)
+			if err == nil {
+				return fmt.Errorf("%w: movement %d has already been reversed", domain.ErrInvalidStatus, m.ID)
+			}
+			if !errors.Is(err, domain.ErrNotFound) {
+				return err
+			}
+		}
+
+		for _, m := range originals {
+			reversalOfID := m.ID
+			reversal := &domain.StockMovement{
+				ProductID:     m.ProductID,
+				LocationID:    m.LocationID,
+				UserID:        userID,
+				Type:          domain.StockREVERSAL,
+				Direction:     -m.Direction,
+				Quantity:      m.Quantity,
+				Reference:     m.Reference,
+				ReasonCode:    m.ReasonCode,
+				Notes:         notes,
+				ReversalOfID:  &reversalOfID,
+				UOM:           m.UOM,
+				UOMQuantity:   m.UOMQuantity,
+				Lots:          copyLots(m.Lots),
+				SerialNumbers: m.SerialNumbers,
+				// The stock comes back to, or leaves, the status bucket the original touched
+				InventoryStatus: m.InventoryStatus,
+			}
+			if len(reversals) > 0 {
+				reversal.RelatedMovementID = &reversals[0].ID
+			}
+			// Stock taken away comes back at the cost it was issued at; stock that was
+			// received leaves again through the usual cost method
+			if reversal.Direction == domain.DirectionIncrease {
+				reversal.Costs = copyCosts(m.Costs)
+			}
+
+			if err := postStockMovement(ctx, repos, reversal); err != nil {
+				return err
+			}
+			reversals = append(reversals, reversal)
+		}
+
+		// Link the reversal legs of a transfer to each other
+		if len(reversals) == 2 {
+			reversals[0].RelatedMovementID = &reversals[1].ID
+			return repos.StockMovement.SetRelatedMovement(ctx, reversals[0].ID, reversals[1].ID)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return reversals, nil
+}
+
+// requestedLots turns the lot fields of a request into the lot breakdown of a movement.
+// No lot means untracked stock on increases and FEFO allocation on decreases.
+func requestedLots(direction int, lotNumber, expiryDate string, quantity int) ([]*domain.StockMovementLot, error) {
+	if expiryDate != "" && direction != domain.DirectionIncrease {
+		return nil, fmt.Errorf("%w: expiry date can only be given when receiving stock", domain.ErrInvalidInput)
+	}
+	if lotNumber == "" && expiryDate == "" {
+		return nil, nil
+	}
+
+	lot := &domain.StockMovementLot{
+		LotNumber: lotNumber,
+		Quantity:  quantity,
+	}
+	if expiryDate != "" {
+		expiry, err := time.Parse("2006-01-02", expiryDate)
+		if err != nil {
+			return nil, fmt.Errorf("%w: expiry date must be in YYYY-MM-DD format", domain.ErrInvalidInput)
+		}
+		lot.ExpiryDate = &expiry
+	}
+
+	return []*domain.StockMovementLot{lot}, nil
+}
+
+func copyLots(lots []*domain.StockMovementLot) []*domain.StockMovementLot {
+	copied := make([]*domain.StockMovementLot, 0, len(lots))
+	for _, lot := range lots {
+		copied = append(copied, &domain.StockMovementLot{
+			LotNumber:  lot.LotNumber,
+			ExpiryDate: lot.ExpiryDate,
+			Quantity:   lot.Quantity,
+		})
+	}
+	return copied
+}
+
 func (s *stockService) GetStockMovements(ctx context.Context, filter *domain.StockMovementFilter) ([]*domain.StockMovement, int, error) {
 	movements, total, err := s.stockMovementRepo.List(ctx, filter)
 	if err != nil {
@@ -159,3 +577,492 @@ func (s *stockService) GetStockMovementByID(ctx context.Context, id int) (*domai
 
 	return movement, nil
 }
+
+// GetStockSummary returns the stock of a product per location and per warehouse, only in one
+// warehouse when warehouseID is not nil. The totals are also given in the base unit and every pack
+// size of the product, or only in uom when it is not empty.
+func (s *stockService) GetStockSummary(ctx context.Context, productID int, uom string, warehouseID *int) (*domain.StockSummary, error) {
+	product, err := s.getProductWithUnits(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	units := append([]*domain.ProductUnit{{Code: product.BaseUnit, Factor: 1}}, product.Units...)
+	if uom != "" {
+		factor, err := product.UnitFactor(uom)
+		if err != nil {
+			return nil, err
+		}
+		units = []*domain.ProductUnit{{Code: domain.NormalizeUOM(uom), Factor: factor}}
+	}
+
+	if warehouseID != nil {
+		if _, err := s.warehouseRepo.GetByID(ctx, *warehouseID); err != nil {
+			return nil, fmt.Errorf("failed to get warehouse: %w", err)
+		}
+	}
+
+	levels, err := s.stockLevelRepo.ListByProduct(ctx, productID, warehouseID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stock levels: %w", err)
+	}
+
+	reserved, err := s.reservationRepo.GetReservedByProduct(ctx, productID, warehouseID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reserved stock: %w", err)
+	}
+
+	warehouseReserved, err := s.reservationRepo.GetWarehouseReservedByProduct(ctx, productID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reserved stock: %w", err)
+	}
+
+	summary := &domain.StockSummary{
+		Product:      product,
+		Locations:    []*domain.StockLevel{},
+		Availability: []*domain.StockAvailability{},
+		Warehouses:   []*domain.WarehouseStock{},
+	}
+	byLocation := make(map[int]*domain.StockAvailability)
+	byWarehouse := make(map[int]*domain.WarehouseStock)
+	for _, level := range levels {
+		stock, ok := byWarehouse[level.Location.WarehouseID]
+		if !ok {
+			stock = &domain.WarehouseStock{WarehouseID: level.Location.WarehouseID}
+			byWarehouse[level.Location.WarehouseID] = stock
+			summary.Warehouses = append(summary.Warehouses, stock)
+		}
+		stock.TotalQuantity += level.Quantity
+		if level.Status != domain.InventoryAvailable {
+			stock.HeldQuantity += level.Quantity
+		}
+
+		summary.TotalQuantity += level.Quantity
+		summary.Locations = append(summary.Locations, level)
+
+		availability, ok := byLocation[level.LocationID]
+		if !ok {
+			availability = &domain.StockAvailability{ProductID: productID, LocationID: level.LocationID}
+			byLocation[level.LocationID] = availability
+			summary.Availability = append(summary.Availability, availability)
+		}
+		availability.OnHandQuantity += level.Quantity
+		if level.Status != domain.InventoryAvailable {
+			availability.HeldQuantity += level.Quantity
+			summary.HeldQuantity += level.Quantity
+		}
+	}
+	for locationID, quantity := range reserved {
+		if _, ok := byLocation[locationID]; !ok {
+			// Reserved stock that has since left the location, e.g. after a negative adjustment
+			availability := &domain.StockAvailability{ProductID: productID, LocationID: locationID}
+			byLocation[locationID] = availability
+			summary.Availability = append(summary.Availability, availability)
+		}
+		byLocation[locationID].ReservedQuantity = quantity
+		summary.ReservedQuantity += quantity
+	}
+	for _, availability := range summary.Availability {
+		availability.AvailableQuantity = availability.OnHandQuantity - availability.HeldQuantity - availability.ReservedQuantity
+	}
+	summary.AvailableQuantity = summary.TotalQuantity - summary.HeldQuantity - summary.ReservedQuantity
+
+	for id, quantity := range warehouseReserved {
+		if warehouseID != nil && id != *warehouseID {
+			continue
+		}
+		if _, ok := byWarehouse[id]; !ok {
+			stock := &domain.WarehouseStock{WarehouseID: id}
+			byWarehouse[id] = stock
+			summary.Warehouses = append(summary.Warehouses, stock)
+		}
+		byWarehouse[id].ReservedQuantity = quantity
+	}
+	for _, stock := range summary.Warehouses {
+		warehouse, err := s.warehouseRepo.GetByID(ctx, stock.WarehouseID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get warehouse: %w", err)
+		}
+		stock.WarehouseCode = warehouse.Code
+		stock.AvailableQuantity = stock.TotalQuantity - stock.HeldQuantity - stock.ReservedQuantity
+	}
+	sort.Slice(summary.Warehouses, func(i, j int) bool {
+		return summary.Warehouses[i].WarehouseCode < summary.Warehouses[j].WarehouseCode
+	})
+
+	for _, unit := range units {
+		factor := float64(unit.Factor)
+		summary.Units = append(summary.Units, &domain.UnitQuantity{
+			UOM:               unit.Code,
+			Factor:            unit.Factor,
+			TotalQuantity:     float64(summary.TotalQuantity) / factor,
+			HeldQuantity:      float64(summary.HeldQuantity) / factor,
+			ReservedQuantity:  float64(summary.ReservedQuantity) / factor,
+			AvailableQuantity: float64(summary.AvailableQuantity) / factor,
+		})
+	}
+
+	return summary, nil
+}
+
+func (s *stockService) GetStockByLocation(ctx context.Context, locationID int) (*domain.LocationStock, error) {
+	location, err := s.locationRepo.GetByID(ctx, locationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get location: %w", err)
+	}
+
+	levels, err := s.stockLevelRepo.ListByLocation(ctx, locationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stock levels: %w", err)
+	}
+
+	reserved, err := s.reservationRepo.GetReservedByLocation(ctx, locationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reserved stock: %w", err)
+	}
+
+	stock := &domain.LocationStock{
+		Location:     location,
+		Items:        []*domain.StockLevel{},
+		Availability: []*domain.StockAvailability{},
+	}
+	byProduct := make(map[int]*domain.StockAvailability)
+	load := &domain.LocationLoad{}
+	for _, level := range levels {
+		stock.TotalQuantity += level.Quantity
+		stock.Items = append(stock.Items, level)
+		if level.Product != nil {
+			load.Weight += float64(level.Quantity) * level.Product.Weight
+			load.Volume += float64(level.Quantity) * level.Product.Volume()
+		}
+
+		availability, ok := byProduct[level.ProductID]
+		if !ok {
+			availability = &domain.StockAvailability{ProductID: level.ProductID, LocationID: locationID}
+			byProduct[level.ProductID] = availability
+			stock.Availability = append(stock.Availability, availability)
+		}
+		availability.OnHandQuantity += level.Quantity
+		if level.Status != domain.InventoryAvailable {
+			availability.HeldQuantity += level.Quantity
+			stock.HeldQuantity += level.Quantity
+		}
+	}
+	for productID, quantity := range reserved {
+		if _, ok := byProduct[productID]; !ok {
+			availability := &domain.StockAvailability{ProductID: productID, LocationID: locationID}
+			byProduct[productID] = availability
+			stock.Availability = append(stock.Availability, availability)
+		}
+		byProduct[productID].ReservedQuantity = quantity
+		stock.ReservedQuantity += quantity
+	}
+	for _, availability := range stock.Availability {
+		availability.AvailableQuantity = availability.OnHandQuantity - availability.HeldQuantity - availability.ReservedQuantity
+	}
+	stock.AvailableQuantity = stock.TotalQuantity - stock.HeldQuantity - stock.ReservedQuantity
+	stock.RemainingCapacity = location.Capacity - stock.TotalQuantity
+	if location.MaxWeight != nil {
+		remaining := *location.MaxWeight - load.Weight
+		stock.RemainingWeight = &remaining
+	}
+	if location.MaxVolume != nil {
+		remaining := *location.MaxVolume - load.Volume
+		stock.RemainingVolume = &remaining
+	}
+
+	return stock, nil
+}
+
+// GetStockByWarehouse returns the stock held across the locations of a warehouse, in total and per product
+func (s *stockService) GetStockByWarehouse(ctx context.Context, warehouseID int) (*domain.WarehouseStockSummary, error) {
+	warehouse, err := s.warehouseRepo.GetByID(ctx, warehouseID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get warehouse: %w", err)
+	}
+
+	levels, err := s.stockLevelRepo.ListByWarehouse(ctx, warehouseID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stock levels: %w", err)
+	}
+
+	reserved, err := s.reservationRepo.GetReservedByWarehouse(ctx, warehouseID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reserved stock: %w", err)
+	}
+
+	stock := &domain.WarehouseStockSummary{
+		Warehouse: warehouse,
+		Products:  []*domain.WarehouseProductStock{},
+	}
+	byProduct := make(map[int]*domain.WarehouseProductStock)
+	for _, level := range levels {
+		product, ok := byProduct[level.ProductID]
+		if !ok {
+			product = &domain.WarehouseProductStock{Product: level.Product}
+			byProduct[level.ProductID] = product
+			stock.Products = append(stock.Products, product)
+		}
+		product.TotalQuantity += level.Quantity
+		stock.TotalQuantity += level.Quantity
+		if level.Status != domain.InventoryAvailable {
+			product.HeldQuantity += level.Quantity
+			stock.HeldQuantity += level.Quantity
+		}
+	}
+	for productID, quantity := range reserved {
+		product, ok := byProduct[productID]
+		if !ok {
+			// Reserved stock that has since left the warehouse, e.g. after a negative adjustment
+			p, err := s.productRepo.GetByID(ctx, productID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get product: %w", err)
+			}
+			product = &domain.WarehouseProductStock{Product: p}
+			byProduct[productID] = product
+			stock.Products = append(stock.Products, product)
+		}
+		product.ReservedQuantity = quantity
+		stock.ReservedQuantity += quantity
+	}
+	for _, product := range stock.Products {
+		product.AvailableQuantity = product.TotalQuantity - product.HeldQuantity - product.ReservedQuantity
+	}
+	stock.AvailableQuantity = stock.TotalQuantity - stock.HeldQuantity - stock.ReservedQuantity
+
+	return stock, nil
+}
+
+// GetSerialHistory returns where a serial number currently is and every movement that moved it.
+// Serial numbers are unique per product, so the same serial may belong to several products.
+func (s *stockService) GetSerialHistory(ctx context.Context, serialNumber string) ([]*domain.SerialHistory, error) {
+	serials, err := s.serialNumberRepo.ListByNumber(ctx, serialNumber)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get serial number: %w", err)
+	}
+	if len(serials) == 0 {
+		return nil, domain.ErrNotFound
+	}
+
+	histories := make([]*domain.SerialHistory, 0, len(serials))
+	for _, serial := range serials {
+		if serial.LocationID != nil {
+			location, err := s.locationRepo.GetByID(ctx, *serial.LocationID)
+			if err != nil && !errors.Is(err, domain.ErrNotFound) {
+				return nil, fmt.Errorf("failed to get location: %w", err)
+			}
+			serial.Location = location
+		}
+
+		productID := serial.ProductID
+		filter := &domain.StockMovementFilter{
+			ProductID:    &productID,
+			SerialNumber: &serialNumber,
+			Limit:        serialHistoryLimit,
+		}
+		movements, _, err := s.stockMovementRepo.List(ctx, filter)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get serial number movements: %w", err)
+		}
+		if movements == nil {
+			movements = []*domain.StockMovement{}
+		}
+
+		histories = append(histories, &domain.SerialHistory{
+			Serial:    serial,
+			Movements: movements,
+		})
+	}
+
+	return histories, nil
+}
+
+// GetInventoryValuation values the stock on hand at the cost of its open cost layers, grouped by
+// product, category or location, together with the cost of goods issued by OUT movements in the
+// requested period
+func (s *stockService) GetInventoryValuation(ctx context.Context, filter *domain.ValuationFilter) (*domain.ValuationReport, error) {
+	if filter.GroupBy == "" {
+		filter.GroupBy = domain.ValuationByProduct
+	}
+	if !filter.GroupBy.IsValid() {
+		return nil, fmt.Errorf("%w: group_by must be PRODUCT, CATEGORY or LOCATION", domain.ErrInvalidInput)
+	}
+
+	lines, err := s.costLayerRepo.ListValuation(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get inventory valuation: %w", err)
+	}
+	sort.Slice(lines, func(i, j int) bool {
+		return lines[i].Key < lines[j].Key
+	})
+
+	report := &domain.ValuationReport{
+		GroupBy:  filter.GroupBy,
+		DateFrom: filter.DateFrom,
+		DateTo:   filter.DateTo,
+		Lines:    []*domain.ValuationLine{},
+	}
+	for _, line := range lines {
+		if line.Quantity > 0 {
+			line.AverageUnitCost = line.Value / float64(line.Quantity)
+		}
+		report.TotalQuantity += line.Quantity
+		report.TotalValue += line.Value
+		report.TotalCostOfGoodsIssued += line.CostOfGoodsIssued
+		report.Lines = append(report.Lines, line)
+	}
+
+	return report, nil
+}
+
+// GetStockAsOf rebuilds the on-hand quantity of every product at every location at a point in time
+// by replaying the movement ledger from the latest snapshot taken before it
+func (s *stockService) GetStockAsOf(ctx context.Context, filter *domain.StockAsOfFilter) (*domain.StockAsOf, error) {
+	if filter.At.After(time.Now()) {
+		return nil, fmt.Errorf("%w: point in time must not be in the future", domain.ErrInvalidInput)
+	}
+
+	snapshot, err := s.stockSnapshotRepo.GetLatest(ctx, filter.At)
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
+		return nil, err
+	}
+
+	lines, err := s.stockSnapshotRepo.ListBalances(ctx, snapshot, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stock as of %s: %w", filter.At.Format(time.RFC3339), err)
+	}
+
+	stock := &domain.StockAsOf{
+		At:       filter.At,
+		Snapshot: snapshot,
+		Items:    []*domain.StockAsOfLine{},
+	}
+	for _, line := range lines {
+		stock.TotalQuantity += line.Quantity
+		stock.Items = append(stock.Items, line)
+	}
+
+	return stock, nil
+}
+
+// TakeStockSnapshot stores the on-hand quantities at the given time, built on the latest earlier
+// snapshot, so that point-in-time queries only replay the movements since then
+func (s *stockService) TakeStockSnapshot(ctx context.Context, at time.Time) (*domain.StockSnapshot, error) {
+	if at.After(time.Now().Add(-stockSnapshotSettleTime)) {
+		return nil, fmt.Errorf("%w: snapshots can only be taken at least %s in the past", domain.ErrInvalidInput, stockSnapshotSettleTime)
+	}
+
+	snapshot := &domain.StockSnapshot{TakenAt: at}
+	err := s.uow.WithinTransaction(ctx, func(repos *repository.Repositories) error {
+		base, err := repos.StockSnapshot.GetLatest(ctx, at)
+		if err != nil && !errors.Is(err, domain.ErrNotFound) {
+			return err
+		}
+		if base != nil && base.TakenAt.Equal(at) {
+			return domain.ErrDuplicateEntry
+		}
+
+		return repos.StockSnapshot.Create(ctx, snapshot, base)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return snapshot, nil
+}
+
+// ReconcileStock compares the stored stock levels and product quantities with the movement ledger.
+// In fix mode the stored stock levels are taken as the truth: the ledger receives an ADJUST movement
+// for every difference, without moving stock, cost layers or serial numbers, and each product's
+// quantity is reset to its ledger total. Every product is fixed in its own transaction.
+func (s *stockService) ReconcileStock(ctx context.Context, fix bool, userID int) (*domain.StockReconciliation, error) {
+	levels, err := s.stockMovementRepo.ListLevelDiscrepancies(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	products, err := s.stockMovementRepo.ListProductDiscrepancies(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &domain.StockReconciliation{
+		Locations: levels,
+		Products:  products,
+	}
+	if report.Locations == nil {
+		report.Locations = []*domain.StockDiscrepancy{}
+	}
+	if report.Products == nil {
+		report.Products = []*domain.StockDiscrepancy{}
+	}
+	if !fix {
+		return report, nil
+	}
+
+	var productIDs []int
+	seen := make(map[int]bool)
+	for _, d := range append(levels, products...) {
+		if !seen[d.ProductID] {
+			seen[d.ProductID] = true
+			productIDs = append(productIDs, d.ProductID)
+		}
+	}
+	sort.Ints(productIDs)
+
+	for _, productID := range productIDs {
+		var adjustments []*domain.StockMovement
+		err := s.uow.WithinTransaction(ctx, func(repos *repository.Repositories) error {
+			// Postings lock the product first, so the discrepancies cannot change underneath us
+			if _, err := repos.Product.GetByIDForUpdate(ctx, productID); err != nil {
+				return fmt.Errorf("failed to get product: %w", err)
+			}
+
+			adjustments = nil
+			levels, err := repos.StockMovement.ListLevelDiscrepancies(ctx, &productID)
+			if err != nil {
+				return err
+			}
+			for _, d := range levels {
+				adjustment := &domain.StockMovement{
+					ProductID:  d.ProductID,
+					LocationID: *d.LocationID,
+					UserID:     userID,
+					Type:       domain.StockADJUST,
+					Direction:  domain.DirectionIncrease,
+					Quantity:   d.Difference(),
+					ReasonCode: domain.ReasonReconciliation,
+					Notes:      fmt.Sprintf("Ledger reconciliation: stored %d, ledger %d", d.StoredQuantity, d.LedgerQuantity),
+				}
+				if adjustment.Quantity < 0 {
+					adjustment.Direction = domain.DirectionDecrease
+					adjustment.Quantity = -adjustment.Quantity
+				}
+				adjustment.Lots = []*domain.StockMovementLot{{LotNumber: d.LotNumber, Quantity: adjustment.Quantity}}
+
+				if err := repos.StockMovement.Create(ctx, adjustment); err != nil {
+					return err
+				}
+				adjustments = append(adjustments, adjustment)
+			}
+
+			// The ledger now matches the stock levels, so the product quantity follows the ledger
+			products, err := repos.StockMovement.ListProductDiscrepancies(ctx, &productID)
+			if err != nil {
+				return err
+			}
+			for _, d := range products {
+				if err := repos.Product.UpdateQuantity(ctx, d.ProductID, d.LedgerQuantity); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to reconcile product %d: %w", productID, err)
+		}
+		report.Adjustments = append(report.Adjustments, adjustments...)
+	}
+	report.Fixed = true
+
+	return report, nil
+}
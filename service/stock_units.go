@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/edwinjordan/wmsTest_Golang/domain"
+)
+
+// getProductWithUnits loads a product together with its pack sizes
+func (s *stockService) getProductWithUnits(ctx context.Context, productID int) (*domain.Product, error) {
+	product, err := s.productRepo.GetByID(ctx, productID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product: %w", err)
+	}
+
+	units, err := s.productUnitRepo.ListByProduct(ctx, productID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product units: %w", err)
+	}
+	product.Units = units
+
+	return product, nil
+}
+
+// convertToBaseUnits turns movements requested in a pack size of their product into base units,
+// so stock, capacity and cost math never sees anything else. The requested unit and quantity are
+// kept on the movement. Unit costs are given per requested unit and become per base unit.
+func (s *stockService) convertToBaseUnits(ctx context.Context, uom string, movements ...*domain.StockMovement) error {
+	code := domain.NormalizeUOM(uom)
+	if code == "" || len(movements) == 0 {
+		return nil
+	}
+
+	product, err := s.getProductWithUnits(ctx, movements[0].ProductID)
+	if err != nil {
+		return err
+	}
+	factor, err := product.UnitFactor(code)
+	if err != nil {
+		return err
+	}
+	if factor == 1 {
+		return nil
+	}
+
+	for _, movement := range movements {
+		requested := movement.Quantity
+		movement.UOM = code
+		movement.UOMQuantity = &requested
+		movement.Quantity = requested * factor
+		for _, lot := range movement.Lots {
+			lot.Quantity *= factor
+		}
+		if movement.UnitCost != nil {
+			unitCost := *movement.UnitCost / float64(factor)
+			movement.UnitCost = &unitCost
+		}
+	}
+
+	return nil
+}
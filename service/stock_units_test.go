@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/edwinjordan/wmsTest_Golang/domain"
+	"github.com/edwinjordan/wmsTest_Golang/repository"
+)
+
+// unitsProductRepo serves a single product; other methods are not used by the unit conversion
+type unitsProductRepo struct {
+	repository.ProductRepository
+	product *domain.Product
+}
+
+func (r *unitsProductRepo) GetByID(ctx context.Context, id int) (*domain.Product, error) {
+	if id != r.product.ID {
+		return nil, domain.ErrNotFound
+	}
+	product := *r.product
+	return &product, nil
+}
+
+type unitsProductUnitRepo struct {
+	repository.ProductUnitRepository
+	units []*domain.ProductUnit
+}
+
+func (r *unitsProductUnitRepo) ListByProduct(ctx context.Context, productID int) ([]*domain.ProductUnit, error) {
+	return r.units, nil
+}
+
+func TestConvertToBaseUnits(t *testing.T) {
+	service := &stockService{
+		productRepo: &unitsProductRepo{product: &domain.Product{ID: 1, SKU: "MOUSE-001", BaseUnit: "EA"}},
+		productUnitRepo: &unitsProductUnitRepo{units: []*domain.ProductUnit{
+			{Code: "BOX", Factor: 12},
+			{Code: "CASE", Factor: 144},
+		}},
+	}
+	unitCost := func(cost float64) *float64 { return &cost }
+
+	tests := []struct {
+		name         string
+		uom          string
+		quantity     int
+		lots         []int
+		unitCost     *float64
+		wantQuantity int
+		wantLots     []int
+		wantUnitCost *float64
+		wantUOM      string
+		wantErr      error
+	}{
+		{name: "no unit keeps base units", uom: "", quantity: 5, wantQuantity: 5},
+		{name: "base unit keeps base units", uom: "ea", quantity: 5, wantQuantity: 5},
+		{name: "pack size", uom: "BOX", quantity: 2, wantQuantity: 24, wantUOM: "BOX"},
+		{name: "unit codes are normalized", uom: " case ", quantity: 1, wantQuantity: 144, wantUOM: "CASE"},
+		{name: "lots are converted", uom: "BOX", quantity: 3, lots: []int{1, 2}, wantQuantity: 36, wantLots: []int{12, 24}, wantUOM: "BOX"},
+		{name: "unit cost becomes per base unit", uom: "BOX", quantity: 1, unitCost: unitCost(60), wantQuantity: 12, wantUnitCost: unitCost(5), wantUOM: "BOX"},
+		{name: "unknown unit", uom: "PALLET", quantity: 1, wantErr: domain.ErrInvalidInput},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			movement := &domain.StockMovement{ProductID: 1, Quantity: tt.quantity, UnitCost: tt.unitCost}
+			for _, quantity := range tt.lots {
+				movement.Lots = append(movement.Lots, &domain.StockMovementLot{Quantity: quantity})
+			}
+
+			err := service.convertToBaseUnits(context.Background(), tt.uom, movement)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("convertToBaseUnits() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("convertToBaseUnits() error = %v", err)
+			}
+
+			if movement.Quantity != tt.wantQuantity {
+				t.Errorf("quantity = %d, want %d", movement.Quantity, tt.wantQuantity)
+			}
+			if movement.UOM != tt.wantUOM {
+				t.Errorf("uom = %q, want %q", movement.UOM, tt.wantUOM)
+			}
+			if tt.wantUOM != "" && (movement.UOMQuantity == nil || *movement.UOMQuantity != tt.quantity) {
+				t.Errorf("uom quantity = %v, want %d", movement.UOMQuantity, tt.quantity)
+			}
+			for i, want := range tt.wantLots {
+				if movement.Lots[i].Quantity != want {
+					t.Errorf("lot %d quantity = %d, want %d", i, movement.Lots[i].Quantity, want)
+				}
+			}
+			if tt.wantUnitCost != nil && (movement.UnitCost == nil || *movement.UnitCost != *tt.wantUnitCost) {
+				t.Errorf("unit cost = %v, want %v", movement.UnitCost, *tt.wantUnitCost)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/edwinjordan/wmsTest_Golang/domain"
+	"github.com/edwinjordan/wmsTest_Golang/repository"
+)
+
+type WarehouseService interface {
+	CreateWarehouse(ctx context.Context, req *domain.CreateWarehouseRequest) (*domain.Warehouse, error)
+	GetWarehouse(ctx context.Context, id int) (*domain.Warehouse, error)
+	UpdateWarehouse(ctx context.Context, id int, req *domain.UpdateWarehouseRequest) (*domain.Warehouse, error)
+	ListWarehouses(ctx context.Context, limit, offset int) ([]*domain.Warehouse, int, error)
+}
+
+type warehouseService struct {
+	warehouseRepo repository.WarehouseRepository
+	locationRepo  repository.LocationRepository
+}
+
+func NewWarehouseService(warehouseRepo repository.WarehouseRepository, locationRepo repository.LocationRepository) WarehouseService {
+	return &warehouseService{
+		warehouseRepo: warehouseRepo,
+		locationRepo:  locationRepo,
+	}
+}
+
+func (s *warehouseService) CreateWarehouse(ctx context.Context, req *domain.CreateWarehouseRequest) (*domain.Warehouse, error) {
+	code := strings.TrimSpace(req.Code)
+	if code == "" || strings.TrimSpace(req.Name) == "" {
+		return nil, fmt.Errorf("%w: warehouse code and name are required", domain.ErrInvalidInput)
+	}
+
+	// Check if code already exists
+	existing, err := s.warehouseRepo.GetByCode(ctx, code)
+	if err == nil && existing != nil {
+		return nil, domain.ErrDuplicateEntry
+	}
+
+	warehouse := &domain.Warehouse{
+		Code:    code,
+		Name:    req.Name,
+		Address: req.Address,
+	}
+
+	if err := s.warehouseRepo.Create(ctx, warehouse); err != nil {
+		return nil, fmt.Errorf("failed to create warehouse: %w", err)
+	}
+
+	return warehouse, nil
+}
+
+func (s *warehouseService) GetWarehouse(ctx context.Context, id int) (*domain.Warehouse, error) {
+	warehouse, err := s.warehouseRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get warehouse: %w", err)
+	}
+
+	return warehouse, nil
+}
+
+// UpdateWarehouse changes the details of a warehouse. A warehouse can only be deactivated once none
+// of its locations are active any more.
+func (s *warehouseService) UpdateWarehouse(ctx context.Context, id int, req *domain.UpdateWarehouseRequest) (*domain.Warehouse, error) {
+	warehouse, err := s.warehouseRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get warehouse: %w", err)
+	}
+
+	// Update fields if provided
+	if req.Name != nil {
+		if strings.TrimSpace(*req.Name) == "" {
+			return nil, fmt.Errorf("%w: warehouse name is required", domain.ErrInvalidInput)
+		}
+		warehouse.Name = *req.Name
+	}
+	if req.Address != nil {
+		warehouse.Address = *req.Address
+	}
+	if req.IsActive != nil {
+		if warehouse.IsActive && !*req.IsActive {
+			_, locations, err := s.locationRepo.List(ctx, &warehouse.ID, 1, 0)
+			if err != nil {
+				return nil, err
+			}
+			if locations > 0 {
+				return nil, fmt.Errorf("%w: warehouse %s still has %d active locations", domain.ErrInvalidStatus, warehouse.Code, locations)
+			}
+		}
+		warehouse.IsActive = *req.IsActive
+	}
+
+	if err := s.warehouseRepo.Update(ctx, warehouse); err != nil {
+		return nil, fmt.Errorf("failed to update warehouse: %w", err)
+	}
+
+	return warehouse, nil
+}
+
+// getWarehouseOrDefault returns the warehouse with the given ID, or the default warehouse when no
+// ID is given
+func getWarehouseOrDefault(ctx context.Context, warehouseRepo repository.WarehouseRepository, id *int) (*domain.Warehouse, error) {
+	var warehouse *domain.Warehouse
+	var err error
+	if id != nil {
+		warehouse, err = warehouseRepo.GetByID(ctx, *id)
+	} else {
+		warehouse, err = warehouseRepo.GetByCode(ctx, domain.DefaultWarehouseCode)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get warehouse: %w", err)
+	}
+
+	return warehouse, nil
+}
+
+func (s *warehouseService) ListWarehouses(ctx context.Context, limit, offset int) ([]*domain.Warehouse, int, error) {
+	warehouses, total, err := s.warehouseRepo.List(ctx, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list warehouses: %w", err)
+	}
+
+	return warehouses, total, nil
+}
@@ -0,0 +1,364 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/edwinjordan/wmsTest_Golang/domain"
+	"github.com/edwinjordan/wmsTest_Golang/repository"
+)
+
+const (
+	// defaultWaveSize is the number of orders per wave when the request does not set one
+	defaultWaveSize = 20
+	// maxWaveSize bounds the number of orders in a single wave
+	maxWaveSize = 200
+)
+
+type WaveService interface {
+	BuildWaves(ctx context.Context, req *domain.BuildWavesRequest, userID int) (*domain.WaveBuild, error)
+	GetWave(ctx context.Context, id int) (*domain.Wave, error)
+	ListWaves(ctx context.Context, filter *domain.WaveFilter) ([]*domain.Wave, int, error)
+	ReleaseWave(ctx context.Context, id int) (*domain.Wave, error)
+	CloseWave(ctx context.Context, id int) (*domain.Wave, error)
+	GetPickList(ctx context.Context, id int) (*domain.PickList, error)
+}
+
+type waveService struct {
+	waveRepo       repository.WaveRepository
+	salesOrderRepo repository.SalesOrderRepository
+	uow            repository.UnitOfWork
+}
+
+func NewWaveService(waveRepo repository.WaveRepository, salesOrderRepo repository.SalesOrderRepository, uow repository.UnitOfWork) WaveService {
+	return &waveService{
+		waveRepo:       waveRepo,
+		salesOrderRepo: salesOrderRepo,
+		uow:            uow,
+	}
+}
+
+// BuildWaves allocates the new orders that are not in a wave yet, most urgent first, and groups
+// them into planned waves of at most MaxOrders orders. A wave only holds orders of one warehouse.
+// Orders that cannot be allocated in full are skipped and stay new, so the stock goes to the orders
+// that can ship complete.
+func (s *waveService) BuildWaves(ctx context.Context, req *domain.BuildWavesRequest, userID int) (*domain.WaveBuild, error) {
+	if !req.GroupBy.IsValid() {
+		return nil, fmt.Errorf("%w: group by must be CARRIER_CUTOFF, ZONE or PRIORITY", domain.ErrInvalidInput)
+	}
+	waveSize := req.MaxOrders
+	if waveSize == 0 {
+		waveSize = defaultWaveSize
+	}
+	if waveSize < 0 || waveSize > maxWaveSize {
+		return nil, fmt.Errorf("%w: max orders must be between 1 and %d", domain.ErrInvalidInput, maxWaveSize)
+	}
+
+	build := &domain.WaveBuild{Waves: []*domain.Wave{}, Skipped: []*domain.WaveSkippedOrder{}}
+	err := s.uow.WithinTransaction(ctx, func(repos *repository.Repositories) error {
+		if req.WarehouseID != nil {
+			if _, err := repos.Warehouse.GetByID(ctx, *req.WarehouseID); err != nil {
+				return fmt.Errorf("failed to get warehouse: %w", err)
+			}
+		}
+
+		orders, err := repos.SalesOrder.ListWaveCandidatesForUpdate(ctx, req)
+		if err != nil {
+			return err
+		}
+
+		orderLines := make(map[int][]*domain.SalesOrderLine, len(orders))
+		var allLines []*domain.SalesOrderLine
+		for _, order := range orders {
+			lines, err := repos.SalesOrder.ListLines(ctx, order.ID)
+			if err != nil {
+				return err
+			}
+			orderLines[order.ID] = lines
+			allLines = append(allLines, lines...)
+		}
+
+		// Same lock as stock postings, so nothing else reserves or ships the stock being allocated
+		if err := lockProducts(ctx, repos, distinctLineProducts(allLines)...); err != nil {
+			return err
+		}
+		if err := repos.Reservation.ExpireDue(ctx); err != nil {
+			return err
+		}
+
+		// Groups keep the order of their most urgent order
+		type groupKey struct {
+			warehouseID int
+			key         string
+		}
+		var keys []groupKey
+		groups := make(map[groupKey][]*domain.SalesOrder)
+		for _, order := range orders {
+			err := allocateSalesOrder(ctx, repos, order, orderLines[order.ID], userID)
+			if errors.Is(err, domain.ErrInsufficientStock) || errors.Is(err, domain.ErrInvalidInput) {
+				build.Skipped = append(build.Skipped, &domain.WaveSkippedOrder{
+					SalesOrderID: order.ID,
+					SONumber:     order.SONumber,
+					Reason:       err.Error(),
+				})
+				continue
+			}
+			if err != nil {
+				return err
+			}
+
+			value, err := waveGroupKey(ctx, repos, req.GroupBy, order)
+			if err != nil {
+				return err
+			}
+			key := groupKey{order.WarehouseID, value}
+			if _, ok := groups[key]; !ok {
+				keys = append(keys, key)
+			}
+			groups[key] = append(groups[key], order)
+		}
+
+		for _, key := range keys {
+			group := groups[key]
+			for start := 0; start < len(group); start += waveSize {
+				wave := &domain.Wave{
+					WarehouseID: key.warehouseID,
+					Status:      domain.WavePlanned,
+					GroupBy:     req.GroupBy,
+					GroupKey:    key.key,
+					Notes:       req.Notes,
+					CreatedBy:   userID,
+					Orders:      group[start:min(start+waveSize, len(group))],
+				}
+				if err := repos.Wave.Create(ctx, wave); err != nil {
+					return err
+				}
+				for _, order := range wave.Orders {
+					waveID := wave.ID
+					order.WaveID = &waveID
+					if err := repos.SalesOrder.Update(ctx, order); err != nil {
+						return err
+					}
+				}
+				build.Waves = append(build.Waves, wave)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return build, nil
+}
+
+// waveGroupKey returns the value an allocated order is grouped on. The zone of an order is the
+// zone its allocations take the most units from.
+func waveGroupKey(ctx context.Context, repos *repository.Repositories, groupBy domain.WaveGrouping, order *domain.SalesOrder) (string, error) {
+	switch groupBy {
+	case domain.WaveByPriority:
+		return strconv.Itoa(order.Priority), nil
+	case domain.WaveByCarrierCutoff:
+		cutoff := "no cutoff"
+		if order.CarrierCutoff != nil {
+			cutoff = order.CarrierCutoff.Format("2006-01-02 15:04")
+		}
+		carrier := order.Carrier
+		if carrier == "" {
+			carrier = "no carrier"
+		}
+		return carrier + " " + cutoff, nil
+	}
+
+	allocations, err := repos.SalesOrder.ListAllocations(ctx, order.ID)
+	if err != nil {
+		return "", err
+	}
+	units := make(map[string]int)
+	zone := ""
+	for _, allocation := range allocations {
+		units[allocation.Location.Zone] += allocation.Quantity
+	}
+	for candidate, quantity := range units {
+		if quantity > units[zone] || (quantity == units[zone] && candidate < zone) {
+			zone = candidate
+		}
+	}
+
+	return zone, nil
+}
+
+func (s *waveService) GetWave(ctx context.Context, id int) (*domain.Wave, error) {
+	wave, err := s.waveRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wave: %w", err)
+	}
+
+	orders, err := s.salesOrderRepo.ListByWave(ctx, wave.ID)
+	if err != nil {
+		return nil, err
+	}
+	wave.Orders = orders
+
+	return wave, nil
+}
+
+func (s *waveService) ListWaves(ctx context.Context, filter *domain.WaveFilter) ([]*domain.Wave, int, error) {
+	waves, total, err := s.waveRepo.List(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list waves: %w", err)
+	}
+
+	return waves, total, nil
+}
+
+// ReleaseWave hands a planned wave to the floor
+func (s *waveService) ReleaseWave(ctx context.Context, id int) (*domain.Wave, error) {
+	var wave *domain.Wave
+	err := s.uow.WithinTransaction(ctx, func(repos *repository.Repositories) error {
+		var err error
+		wave, err = repos.Wave.GetByIDForUpdate(ctx, id)
+		if err != nil {
+			return fmt.Errorf("failed to get wave: %w", err)
+		}
+		if wave.Status != domain.WavePlanned {
+			return fmt.Errorf("%w: wave is %s, expected %s", domain.ErrInvalidStatus, wave.Status, domain.WavePlanned)
+		}
+
+		now := time.Now()
+		wave.Status = domain.WaveReleased
+		wave.ReleasedAt = &now
+		if err := repos.Wave.Update(ctx, wave); err != nil {
+			return err
+		}
+
+		wave.Orders, err = repos.SalesOrder.ListByWave(ctx, wave.ID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return wave, nil
+}
+
+// CloseWave records that a released wave has been picked: its orders that are still allocated
+// move on to picked, ready to be packed and shipped one by one
+func (s *waveService) CloseWave(ctx context.Context, id int) (*domain.Wave, error) {
+	var wave *domain.Wave
+	err := s.uow.WithinTransaction(ctx, func(repos *repository.Repositories) error {
+		var err error
+		wave, err = repos.Wave.GetByIDForUpdate(ctx, id)
+		if err != nil {
+			return fmt.Errorf("failed to get wave: %w", err)
+		}
+		if wave.Status != domain.WaveReleased {
+			return fmt.Errorf("%w: wave is %s, expected %s", domain.ErrInvalidStatus, wave.Status, domain.WaveReleased)
+		}
+
+		orders, err := repos.SalesOrder.ListByWave(ctx, wave.ID)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		for i := range orders {
+			order, err := repos.SalesOrder.GetByIDForUpdate(ctx, orders[i].ID)
+			if err != nil {
+				return fmt.Errorf("failed to get sales order: %w", err)
+			}
+			orders[i] = order
+			if order.Status != domain.SalesOrderAllocated {
+				continue
+			}
+			order.Status = domain.SalesOrderPicked
+			order.PickedAt = &now
+			if err := repos.SalesOrder.Update(ctx, order); err != nil {
+				return err
+			}
+		}
+
+		wave.Status = domain.WaveClosed
+		wave.ClosedAt = &now
+		if err := repos.Wave.Update(ctx, wave); err != nil {
+			return err
+		}
+		wave.Orders = orders
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return wave, nil
+}
+
+// GetPickList consolidates the allocations of the wave's orders: stock of a product taken from
+// the same location and lot for several orders is one stop, split per order line in Orders.
+// Cancelled orders are left out.
+func (s *waveService) GetPickList(ctx context.Context, id int) (*domain.PickList, error) {
+	wave, err := s.GetWave(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	pickList := &domain.PickList{
+		Reference:   fmt.Sprintf("WAVE-%d %s", wave.ID, wave.GroupKey),
+		WarehouseID: wave.WarehouseID,
+		GeneratedAt: time.Now(),
+		Complete:    true,
+		Stops:       []*domain.PickStop{},
+		Shortages:   []*domain.PickShortage{},
+	}
+
+	type stopKey struct {
+		locationID, productID int
+		lotNumber             string
+	}
+	stops := make(map[stopKey]*domain.PickStop)
+	for _, order := range wave.Orders {
+		if order.Status == domain.SalesOrderCancelled {
+			continue
+		}
+		if err := loadSalesOrderRelations(ctx, s.salesOrderRepo, order); err != nil {
+			return nil, err
+		}
+		linesByID := make(map[int]*domain.SalesOrderLine, len(order.Lines))
+		for _, line := range order.Lines {
+			linesByID[line.ID] = line
+		}
+
+		for _, allocation := range order.Allocations {
+			line := linesByID[allocation.SalesOrderLineID]
+			key := stopKey{allocation.LocationID, line.ProductID, allocation.LotNumber}
+			stop, ok := stops[key]
+			if !ok {
+				stop = &domain.PickStop{
+					Location:    allocation.Location,
+					ProductID:   line.ProductID,
+					SKU:         line.Product.SKU,
+					ProductName: line.Product.Name,
+					LotNumber:   allocation.LotNumber,
+					Unit:        line.Product.BaseUnit,
+				}
+				stops[key] = stop
+				pickList.Stops = append(pickList.Stops, stop)
+			}
+			stop.Quantity += allocation.Quantity
+			stop.Orders = append(stop.Orders, &domain.PickStopOrder{
+				SalesOrderID: order.ID,
+				SONumber:     order.SONumber,
+				LineNumber:   line.LineNumber,
+				Quantity:     allocation.Quantity,
+			})
+			pickList.TotalQuantity += allocation.Quantity
+		}
+	}
+	domain.SequencePickStops(pickList.Stops)
+
+	return pickList, nil
+}